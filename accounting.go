@@ -0,0 +1,238 @@
+package wfs
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Traffic is the number of bytes read and written.
+type Traffic struct {
+	BytesRead    int64
+	BytesWritten int64
+}
+
+// accounting is the traffic per prefix shared by an AccountingFS and the
+// filesystems returned by its Sub.
+type accounting struct {
+	mutex    sync.Mutex
+	prefixes []string
+	traffic  map[string]*Traffic
+}
+
+// prefix returns the longest prefix that contains the named file or "".
+func (a *accounting) prefix(name string) string {
+	found, foundLen := "", -1
+	for _, prefix := range a.prefixes {
+		// NOTE: "." contains every file, so it is the shortest prefix.
+		n := len(prefix)
+		if prefix == "." {
+			n = 0
+		}
+		if n <= foundLen {
+			continue
+		}
+		if prefix == "." || name == prefix || strings.HasPrefix(name, prefix+"/") {
+			found, foundLen = prefix, n
+		}
+	}
+	return found
+}
+
+func (a *accounting) add(name string, read, written int64) {
+	if read == 0 && written == 0 {
+		return
+	}
+	prefix := a.prefix(name)
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	t, ok := a.traffic[prefix]
+	if !ok {
+		t = &Traffic{}
+		a.traffic[prefix] = t
+	}
+	t.BytesRead += read
+	t.BytesWritten += written
+}
+
+func (a *accounting) snapshot(reset bool) map[string]Traffic {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	snapshot := make(map[string]Traffic, len(a.traffic))
+	for prefix, t := range a.traffic {
+		snapshot[prefix] = *t
+	}
+	if reset {
+		a.traffic = map[string]*Traffic{}
+	}
+	return snapshot
+}
+
+// AccountingFS is a filesystem that aggregates the bytes read and written per
+// path prefix such as the directory of a tenant for usage-based billing and
+// capacity planning. The bytes are counted by Read and Write of the files
+// returned by Open and CreateFile, ReadFile, WriteFile and OpenRange.
+type AccountingFS struct {
+	*FSDelegator
+	fsys fs.FS
+	dir  string
+	acct *accounting
+}
+
+var (
+	_ fs.ReadFileFS = (*AccountingFS)(nil)
+	_ fs.SubFS      = (*AccountingFS)(nil)
+	_ WriteFileFS   = (*AccountingFS)(nil)
+	_ RangeReaderFS = (*AccountingFS)(nil)
+)
+
+// NewAccountingFS returns an AccountingFS that aggregates the traffic per
+// prefix. The traffic of a file is counted for the longest prefix that
+// contains the file and the traffic of files outside of all prefixes is
+// counted for "".
+func NewAccountingFS(fsys fs.FS, prefixes []string) *AccountingFS {
+	cleaned := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		cleaned[i] = path.Clean(prefix)
+	}
+	return &AccountingFS{
+		FSDelegator: DelegateFS(fsys),
+		fsys:        fsys,
+		dir:         ".",
+		acct: &accounting{
+			prefixes: cleaned,
+			traffic:  map[string]*Traffic{},
+		},
+	}
+}
+
+// Snapshot returns the traffic per prefix since the creation or the last
+// Reset. The filesystems returned by Sub share the traffic with fsys.
+func (fsys *AccountingFS) Snapshot() map[string]Traffic {
+	return fsys.acct.snapshot(false)
+}
+
+// Reset returns the traffic per prefix like Snapshot and starts a new period
+// of aggregation.
+func (fsys *AccountingFS) Reset() map[string]Traffic {
+	return fsys.acct.snapshot(true)
+}
+
+func (fsys *AccountingFS) add(name string, read, written int64) {
+	fsys.acct.add(path.Join(fsys.dir, name), read, written)
+}
+
+// Open opens the named file that counts the bytes read. The opened file
+// implements the optional interfaces of the file such as io.ReaderAt.
+func (fsys *AccountingFS) Open(name string) (fs.File, error) {
+	f, err := fsys.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	d := DelegateFile(f)
+	d.ReadFunc = func(p []byte) (int, error) {
+		n, err := f.Read(p)
+		fsys.add(name, int64(n), 0)
+		return n, err
+	}
+	if readAt := d.ReadAtFunc; readAt != nil {
+		d.ReadAtFunc = func(p []byte, off int64) (int, error) {
+			n, err := readAt(p, off)
+			fsys.add(name, int64(n), 0)
+			return n, err
+		}
+	}
+	return d.File(), nil
+}
+
+// ReadFile reads the named file and returns its contents.
+func (fsys *AccountingFS) ReadFile(name string) ([]byte, error) {
+	p, err := fs.ReadFile(fsys.fsys, name)
+	fsys.add(name, int64(len(p)), 0)
+	return p, err
+}
+
+// OpenRange opens the named file and returns a reader of the range that counts
+// the bytes read.
+func (fsys *AccountingFS) OpenRange(name string, off, length int64) (io.ReadCloser, error) {
+	r, err := OpenRange(fsys.fsys, name, off, length)
+	if err != nil {
+		return nil, err
+	}
+	return &readCloser{
+		Reader: &accountingReader{r: r, count: func(n int64) {
+			fsys.add(name, n, 0)
+		}},
+		Closer: r,
+	}, nil
+}
+
+// CreateFile creates the named file that counts the bytes written. The
+// created file implements the optional interfaces of the file such as
+// AbortWriterFile and WriterAtFile.
+func (fsys *AccountingFS) CreateFile(name string, mode fs.FileMode) (WriterFile, error) {
+	f, err := CreateFile(fsys.fsys, name, mode)
+	if err != nil {
+		return nil, err
+	}
+	d := DelegateFile(f)
+	d.WriteFunc = func(p []byte) (int, error) {
+		n, err := f.Write(p)
+		fsys.add(name, 0, int64(n))
+		return n, err
+	}
+	if writeAt := d.WriteAtFunc; writeAt != nil {
+		d.WriteAtFunc = func(p []byte, off int64) (int, error) {
+			n, err := writeAt(p, off)
+			fsys.add(name, 0, int64(n))
+			return n, err
+		}
+	}
+	if readFrom := d.ReadFromFunc; readFrom != nil {
+		d.ReadFromFunc = func(r io.Reader) (int64, error) {
+			n, err := readFrom(r)
+			fsys.add(name, 0, n)
+			return n, err
+		}
+	}
+	return d.File().(WriterFile), nil
+}
+
+// WriteFile writes the specified bytes to the named file.
+func (fsys *AccountingFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	n, err := WriteFile(fsys.fsys, name, p, mode)
+	fsys.add(name, 0, int64(n))
+	return n, err
+}
+
+// Sub returns an AccountingFS corresponding to the subtree rooted at dir that
+// shares the traffic with fsys. The prefixes are matched against the paths
+// from the root of fsys.
+func (fsys *AccountingFS) Sub(dir string) (fs.FS, error) {
+	sub, err := fs.Sub(fsys.fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &AccountingFS{
+		FSDelegator: DelegateFS(sub),
+		fsys:        sub,
+		dir:         path.Join(fsys.dir, dir),
+		acct:        fsys.acct,
+	}, nil
+}
+
+type accountingReader struct {
+	r     io.Reader
+	count func(n int64)
+}
+
+func (r *accountingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.count(int64(n))
+	return n, err
+}
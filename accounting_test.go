@@ -0,0 +1,134 @@
+package wfs
+
+import (
+	"io"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAccountingFS(t *testing.T) {
+	m := fstest.MapFS{
+		"tenants/a/x.txt":    {Data: []byte("12345")},
+		"tenants/a/in/y.txt": {Data: []byte("12")},
+		"tenants/b/z.txt":    {Data: []byte("123")},
+		"public/index.html":  {Data: []byte("1234567")},
+	}
+	fsys := NewAccountingFS(newMapWriteFS(m), []string{"tenants/a", "tenants/a/in", "tenants/b/"})
+
+	if err := fstest.TestFS(fsys, "tenants/a/x.txt", "tenants/b/z.txt", "public/index.html"); err != nil {
+		t.Fatal(err)
+	}
+	fsys.Reset()
+
+	if _, err := fs.ReadFile(fsys, "tenants/a/x.txt"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fsys.Open("tenants/a/in/y.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	r, err := fsys.OpenRange("public/index.html", 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(r); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if _, err := fsys.WriteFile("tenants/b/new.txt", []byte("1234"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	w, err := fsys.CreateFile("tenants/b/w.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("123456")); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	sub, err := fs.Sub(fsys, "tenants/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.ReadFile(sub, "z.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Traffic{
+		"tenants/a":    {BytesRead: 5},
+		"tenants/a/in": {BytesRead: 2},
+		"tenants/b":    {BytesRead: 3, BytesWritten: 10},
+		"":             {BytesRead: 3},
+	}
+	if got := fsys.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+	if got := fsys.Reset(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+	if got := fsys.Snapshot(); len(got) != 0 {
+		t.Errorf("unexpected %v; want empty", got)
+	}
+}
+
+func TestAccountingFS_PrefixOrder(t *testing.T) {
+	m := fstest.MapFS{
+		"a/x.txt": {Data: []byte("12345")},
+		"b.txt":   {Data: []byte("12")},
+	}
+	for _, prefixes := range [][]string{{".", "a"}, {"a", "."}} {
+		fsys := NewAccountingFS(m, prefixes)
+		for _, name := range []string{"a/x.txt", "b.txt"} {
+			if _, err := fs.ReadFile(fsys, name); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want := map[string]Traffic{
+			"a": {BytesRead: 5},
+			".": {BytesRead: 2},
+		}
+		if got := fsys.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: unexpected %v; want %v", prefixes, got, want)
+		}
+	}
+}
+
+func TestAccountingFS_FileInterfaces(t *testing.T) {
+	m := fstest.MapFS{"a.txt": {Data: []byte("original")}}
+	fsys := NewAccountingFS(newAbortMapWriteFS(m), nil)
+
+	w, err := fsys.CreateFile("a.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteAt(w, []byte("new"), 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := Abort(fsys, "a.txt", w); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(m["a.txt"].Data); got != "original" {
+		t.Errorf("unexpected %q; want %q", got, "original")
+	}
+
+	f, err := fsys.Open("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	p := make([]byte, 4)
+	if _, err := f.(io.ReaderAt).ReadAt(p, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Traffic{"": {BytesRead: 4, BytesWritten: 3}}
+	if got := fsys.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,314 @@
+package wfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+)
+
+// Access is a set of permissions granted by an ACLRule.
+type Access int
+
+const (
+	// AccessDeny grants nothing.
+	AccessDeny Access = 0
+	// AccessRead grants Open, ReadDir, ReadFile, Glob, Stat and Sub.
+	AccessRead Access = 1
+	// AccessWrite grants MkdirAll, CreateFile, WriteFile, RemoveFile and
+	// RemoveAll.
+	AccessWrite Access = 2
+	// AccessReadWrite grants both AccessRead and AccessWrite.
+	AccessReadWrite = AccessRead | AccessWrite
+)
+
+// ACLRule is a rule of ACLFS. Pattern is matched by path.Match against a path
+// and each of its parent directories, so a rule of a directory applies to the
+// subtree. A rule with an empty Principal applies to all principals.
+type ACLRule struct {
+	Principal string
+	Pattern   string
+	Access    Access
+}
+
+// ACLFS is a filesystem that authorizes operations of a principal by rules
+// before delegating them to the wrapped filesystem. The first matching rule
+// of a path is used and a path that matches no rule is denied. A denied
+// operation returns a fs.PathError of fs.ErrPermission. ReadDir and Glob omit
+// the entries that are not readable.
+type ACLFS struct {
+	fsys      fs.FS
+	rules     []ACLRule
+	principal string
+	dir       string
+}
+
+var (
+	_ fs.GlobFS     = (*ACLFS)(nil)
+	_ fs.ReadDirFS  = (*ACLFS)(nil)
+	_ fs.ReadFileFS = (*ACLFS)(nil)
+	_ fs.StatFS     = (*ACLFS)(nil)
+	_ fs.SubFS      = (*ACLFS)(nil)
+	_ WriteFileFS   = (*ACLFS)(nil)
+	_ RemoveFileFS  = (*ACLFS)(nil)
+)
+
+// NewACLFS returns an ACLFS of the anonymous principal that is the empty
+// string. Use For to authorize another principal.
+func NewACLFS(fsys fs.FS, rules []ACLRule) (*ACLFS, error) {
+	for _, r := range rules {
+		if _, err := path.Match(r.Pattern, ""); err != nil {
+			return nil, err
+		}
+	}
+	return &ACLFS{fsys: fsys, rules: rules, dir: "."}, nil
+}
+
+// FS returns fsys as a filesystem that implements WriteFileFS and
+// RemoveFileFS only if the wrapped filesystem implements them. The rules
+// still apply to the returned filesystem.
+func (fsys *ACLFS) FS() fs.FS {
+	return narrowFS(fsys, fsys.fsys)
+}
+
+// For returns an ACLFS that authorizes operations of the principal with the
+// same rules.
+func (fsys *ACLFS) For(principal string) *ACLFS {
+	return &ACLFS{
+		fsys:      fsys.fsys,
+		rules:     fsys.rules,
+		principal: principal,
+		dir:       fsys.dir,
+	}
+}
+
+// ForContext returns an ACLFS that authorizes operations of the principal
+// carried by ctx using WithPrincipal. If ctx carries no principal the
+// returned ACLFS authorizes the anonymous principal.
+func (fsys *ACLFS) ForContext(ctx context.Context) *ACLFS {
+	id, _ := PrincipalFrom(ctx)
+	return fsys.For(id)
+}
+
+// Access returns the access of the principal of fsys to the named path.
+func (fsys *ACLFS) Access(name string) Access {
+	name = path.Join(fsys.dir, name)
+	for _, r := range fsys.rules {
+		if r.Principal != "" && r.Principal != fsys.principal {
+			continue
+		}
+		for p := name; ; p = path.Dir(p) {
+			if ok, _ := path.Match(r.Pattern, p); ok {
+				return r.Access
+			}
+			if p == "." {
+				break
+			}
+		}
+	}
+	return AccessDeny
+}
+
+func (fsys *ACLFS) check(op Op, name string, access Access) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
+	}
+	if fsys.Access(name)&access != access {
+		return &fs.PathError{Op: string(op), Path: name, Err: fs.ErrPermission}
+	}
+	return nil
+}
+
+// Open opens the named file. The opened file is read-only and implements
+// io.Seeker and io.ReaderAt if the file of the wrapped filesystem implements
+// them. The ReadDir of the opened directory returns the readable entries.
+func (fsys *ACLFS) Open(name string) (fs.File, error) {
+	if err := fsys.check(OpOpen, name, AccessRead); err != nil {
+		return nil, err
+	}
+	f, err := fsys.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	rd, ok := f.(fs.ReadDirFile)
+	if !ok || !info.IsDir() {
+		return readOnlyFile(f), nil
+	}
+	d := DelegateFile(f)
+	d.WriteFunc, d.WriteAtFunc, d.ReadFromFunc, d.SyncFunc = nil, nil, nil, nil
+	d.TruncateFunc, d.PunchHoleFunc, d.AbortFunc = nil, nil, nil
+	d.ReadDirFunc = func(n int) ([]fs.DirEntry, error) {
+		for {
+			entries, err := rd.ReadDir(n)
+			readable := fsys.readable(name, entries)
+			// NOTE: ReadDir(n > 0) must not return no entries without an error.
+			if n <= 0 || len(readable) > 0 || err != nil {
+				return readable, err
+			}
+		}
+	}
+	return d.File(), nil
+}
+
+// aclFile hides the methods of a file other than fs.File so that a file
+// opened by ACLFS cannot be written.
+type aclFile struct {
+	fs.File
+}
+
+// readOnlyFile returns f as an aclFile that also implements io.Seeker and
+// io.ReaderAt if f implements them.
+func readOnlyFile(f fs.File) fs.File {
+	s, isSeeker := f.(io.Seeker)
+	ra, isReaderAt := f.(io.ReaderAt)
+	switch {
+	case isSeeker && isReaderAt:
+		return &struct {
+			aclFile
+			io.Seeker
+			io.ReaderAt
+		}{aclFile{File: f}, s, ra}
+	case isSeeker:
+		return &struct {
+			aclFile
+			io.Seeker
+		}{aclFile{File: f}, s}
+	case isReaderAt:
+		return &struct {
+			aclFile
+			io.ReaderAt
+		}{aclFile{File: f}, ra}
+	}
+	return &aclFile{File: f}
+}
+
+// readable returns the entries of dir that are readable.
+func (fsys *ACLFS) readable(dir string, entries []fs.DirEntry) []fs.DirEntry {
+	var readable []fs.DirEntry
+	for _, e := range entries {
+		if fsys.Access(path.Join(dir, e.Name()))&AccessRead != 0 {
+			readable = append(readable, e)
+		}
+	}
+	return readable
+}
+
+// ReadDir reads the named directory and returns the readable entries.
+func (fsys *ACLFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	if err := fsys.check(OpReadDir, dir, AccessRead); err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(fsys.fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return fsys.readable(dir, entries), nil
+}
+
+// ReadFile reads the named file and returns its contents.
+func (fsys *ACLFS) ReadFile(name string) ([]byte, error) {
+	if err := fsys.check(OpReadFile, name, AccessRead); err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(fsys.fsys, name)
+}
+
+// Glob returns the readable names of all files matching pattern.
+func (fsys *ACLFS) Glob(pattern string) ([]string, error) {
+	names, err := Glob(fsys.fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	var readable []string
+	for _, name := range names {
+		if fsys.Access(name)&AccessRead != 0 {
+			readable = append(readable, name)
+		}
+	}
+	return readable, nil
+}
+
+// Stat returns a FileInfo describing the named file.
+func (fsys *ACLFS) Stat(name string) (fs.FileInfo, error) {
+	if err := fsys.check(OpStat, name, AccessRead); err != nil {
+		return nil, err
+	}
+	return fs.Stat(fsys.fsys, name)
+}
+
+// Sub returns an ACLFS corresponding to the subtree rooted at dir. The rules
+// are still matched against the paths from the root of fsys.
+func (fsys *ACLFS) Sub(dir string) (fs.FS, error) {
+	if err := fsys.check(OpSub, dir, AccessRead); err != nil {
+		return nil, err
+	}
+	sub, err := fs.Sub(fsys.fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &ACLFS{
+		fsys:      sub,
+		rules:     fsys.rules,
+		principal: fsys.principal,
+		dir:       path.Join(fsys.dir, dir),
+	}, nil
+}
+
+// MkdirAll creates the named directory.
+func (fsys *ACLFS) MkdirAll(dir string, mode fs.FileMode) error {
+	if err := fsys.check(OpMkdirAll, dir, AccessWrite); err != nil {
+		return err
+	}
+	return MkdirAll(fsys.fsys, dir, mode)
+}
+
+// CreateFile creates the named file.
+func (fsys *ACLFS) CreateFile(name string, mode fs.FileMode) (WriterFile, error) {
+	if err := fsys.check(OpCreateFile, name, AccessWrite); err != nil {
+		return nil, err
+	}
+	return CreateFile(fsys.fsys, name, mode)
+}
+
+// WriteFile writes the specified bytes to the named file.
+func (fsys *ACLFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	if err := fsys.check(OpWriteFile, name, AccessWrite); err != nil {
+		return 0, err
+	}
+	return WriteFile(fsys.fsys, name, p, mode)
+}
+
+// RemoveFile removes the named file.
+func (fsys *ACLFS) RemoveFile(name string) error {
+	if err := fsys.check(OpRemoveFile, name, AccessWrite); err != nil {
+		return err
+	}
+	return RemoveFile(fsys.fsys, name)
+}
+
+// RemoveAll removes path and any children it contains. Nothing is removed
+// unless all of path and its children are writable.
+func (fsys *ACLFS) RemoveAll(path string) error {
+	if err := fsys.check(OpRemoveAll, path, AccessWrite); err != nil {
+		return err
+	}
+	err := fs.WalkDir(fsys.fsys, path, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		return fsys.check(OpRemoveAll, name, AccessWrite)
+	})
+	if err != nil {
+		return err
+	}
+	return RemoveAll(fsys.fsys, path)
+}
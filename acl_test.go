@@ -0,0 +1,182 @@
+package wfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newACLFSTest(t *testing.T) *ACLFS {
+	m := fstest.MapFS{
+		"public/a.txt":       {Data: []byte("a")},
+		"public/secret.txt":  {Data: []byte("secret")},
+		"home/alice/a.txt":   {Data: []byte("alice")},
+		"home/bob/b.txt":     {Data: []byte("bob")},
+		"private/config.txt": {Data: []byte("config")},
+	}
+	fsys, err := NewACLFS(newMapWriteFS(m), []ACLRule{
+		{Pattern: "public/secret.txt", Access: AccessDeny},
+		{Pattern: "public", Access: AccessRead},
+		{Principal: "alice", Pattern: "home/alice", Access: AccessReadWrite},
+		{Principal: "alice", Pattern: ".", Access: AccessRead},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fsys
+}
+
+func TestACLFS(t *testing.T) {
+	fsys := newACLFSTest(t)
+
+	if _, err := fsys.ReadFile("public/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"public/secret.txt", "home/alice/a.txt", "private/config.txt"} {
+		if _, err := fsys.ReadFile(name); !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("%s: unexpected %v; want %v", name, err, fs.ErrPermission)
+		}
+	}
+	if _, err := fsys.WriteFile("public/b.txt", nil, fs.ModePerm); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrPermission)
+	}
+
+	entries, err := fsys.ReadDir("public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "a.txt" {
+		t.Errorf("unexpected %v", entries)
+	}
+
+	got, err := fsys.Glob("public/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"public/a.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+}
+
+func TestACLFS_For(t *testing.T) {
+	fsys := newACLFSTest(t).For("alice")
+
+	if _, err := fsys.ReadFile("home/bob/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("home/alice/new.txt", []byte("new"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("home/bob/new.txt", []byte("new"), fs.ModePerm); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrPermission)
+	}
+	if _, err := fsys.ReadFile("public/secret.txt"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrPermission)
+	}
+
+	sub, err := fsys.Sub("public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.ReadFile(sub, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.ReadFile(sub, "secret.txt"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrPermission)
+	}
+}
+
+func TestACLFS_ForContext(t *testing.T) {
+	fsys := newACLFSTest(t)
+	ctx := WithPrincipal(context.Background(), "alice")
+	if _, err := fsys.ForContext(ctx).ReadFile("home/alice/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.ForContext(context.Background()).ReadFile("home/alice/a.txt"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrPermission)
+	}
+}
+
+func TestACLFS_Open(t *testing.T) {
+	fsys := newACLFSTest(t)
+
+	f, err := fsys.Open("public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	d, ok := f.(fs.ReadDirFile)
+	if !ok {
+		t.Fatalf("unexpected %T; want fs.ReadDirFile", f)
+	}
+	entries, err := d.ReadDir(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "a.txt" {
+		t.Errorf("unexpected %v", entries)
+	}
+
+	f, err = fsys.Open("public/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, ok := f.(io.Writer); ok {
+		t.Errorf("unexpected %T; want a read-only file", f)
+	}
+	if _, ok := f.(io.Seeker); !ok {
+		t.Errorf("unexpected %T; want an io.Seeker", f)
+	}
+	p := make([]byte, 1)
+	if _, err := f.(io.ReaderAt).ReadAt(p, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestACLFS_RemoveAll(t *testing.T) {
+	m := fstest.MapFS{
+		"home/alice/a.txt":    {Data: []byte("a")},
+		"home/alice/keep.txt": {Data: []byte("keep")},
+	}
+	mfs := newMapWriteFS(m)
+	mfs.RemoveAllFunc = func(dir string) error {
+		for name := range m {
+			if name == dir || strings.HasPrefix(name, dir+"/") {
+				delete(m, name)
+			}
+		}
+		return nil
+	}
+	fsys, err := NewACLFS(mfs, []ACLRule{
+		{Pattern: "home/alice/keep.txt", Access: AccessRead},
+		{Pattern: "home/alice", Access: AccessReadWrite},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fsys.RemoveAll("home/alice"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrPermission)
+	}
+	if len(m) != 2 {
+		t.Errorf("unexpected %v; want no removal", m)
+	}
+	if err := fsys.RemoveAll("home/alice/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.RemoveAll("home/alice/not-found"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewACLFS_ErrBadPattern(t *testing.T) {
+	if _, err := NewACLFS(fstest.MapFS{}, []ACLRule{{Pattern: "["}}); err == nil {
+		t.Errorf("no error")
+	}
+}
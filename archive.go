@@ -0,0 +1,148 @@
+package wfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"io"
+	"io/fs"
+	"path"
+	"time"
+)
+
+// ArchiveOption is an option for WriteZip and WriteTar.
+type ArchiveOption func(o *archiveOptions)
+
+type archiveOptions struct {
+	deterministic bool
+}
+
+// WithDeterministic makes archives built from the same tree byte-identical.
+// Modification times are fixed, modes are normalized to 0755 for directories
+// and 0644 for files and owners are cleared. Entries are always written in
+// lexical order of fs.WalkDir.
+func WithDeterministic() ArchiveOption {
+	return func(o *archiveOptions) {
+		o.deterministic = true
+	}
+}
+
+var (
+	// NOTE: The MS-DOS time of zip cannot represent times before 1980.
+	deterministicZipTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	deterministicTarTime = time.Unix(0, 0)
+)
+
+func newArchiveOptions(opts []ArchiveOption) *archiveOptions {
+	o := &archiveOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *archiveOptions) mode(info fs.FileInfo) fs.FileMode {
+	if !o.deterministic {
+		return info.Mode()
+	}
+	if info.IsDir() {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+
+// walkArchive calls fn with the name relative to root of each directory and
+// regular file in the tree rooted at root. The root itself is skipped.
+func walkArchive(fsys fs.FS, root string, fn func(name string, info fs.FileInfo) error) error {
+	return fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == root || !(d.IsDir() || d.Type().IsRegular()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel := name
+		if root != "." {
+			rel = name[len(root)+1:]
+		}
+		return fn(rel, info)
+	})
+}
+
+func copyArchiveFile(w io.Writer, fsys fs.FS, name string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// WriteZip writes the directories and files of the tree rooted at root on
+// fsys to w as a zip archive.
+func WriteZip(w io.Writer, fsys fs.FS, root string, opts ...ArchiveOption) error {
+	o := newArchiveOptions(opts)
+	zw := zip.NewWriter(w)
+	err := walkArchive(fsys, root, func(name string, info fs.FileInfo) error {
+		h := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: info.ModTime()}
+		if o.deterministic {
+			h.Modified = deterministicZipTime
+		}
+		h.SetMode(o.mode(info))
+		if info.IsDir() {
+			h.Name += "/"
+			h.Method = zip.Store
+			_, err := zw.CreateHeader(h)
+			return err
+		}
+		fw, err := zw.CreateHeader(h)
+		if err != nil {
+			return err
+		}
+		return copyArchiveFile(fw, fsys, path.Join(root, name))
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// WriteTar writes the directories and files of the tree rooted at root on
+// fsys to w as a tar archive.
+func WriteTar(w io.Writer, fsys fs.FS, root string, opts ...ArchiveOption) error {
+	o := newArchiveOptions(opts)
+	tw := tar.NewWriter(w)
+	err := walkArchive(fsys, root, func(name string, info fs.FileInfo) error {
+		h, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		h.Name = name
+		h.Mode = int64(o.mode(info).Perm())
+		if o.deterministic {
+			h.ModTime = deterministicTarTime
+			h.AccessTime = time.Time{}
+			h.ChangeTime = time.Time{}
+			h.Uid, h.Gid = 0, 0
+			h.Uname, h.Gname = "", ""
+			h.Format = tar.FormatPAX
+		}
+		if info.IsDir() {
+			h.Name += "/"
+			return tw.WriteHeader(h)
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		return copyArchiveFile(tw, fsys, path.Join(root, name))
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
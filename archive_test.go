@@ -0,0 +1,95 @@
+package wfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func newArchiveFSTest(modTime time.Time) fstest.MapFS {
+	return fstest.MapFS{
+		"root/a.txt":     {Data: []byte("a"), Mode: 0600, ModTime: modTime},
+		"root/dir/b.txt": {Data: []byte("b"), Mode: 0700, ModTime: modTime},
+		"other.txt":      {Data: []byte("other"), ModTime: modTime},
+	}
+}
+
+func TestWriteZip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteZip(&buf, newArchiveFSTest(time.Now()), "root"); err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	want := "a.txt,dir/,dir/b.txt"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("unexpected %s; want %s", got, want)
+	}
+}
+
+func TestWriteTar(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTar(&buf, newArchiveFSTest(time.Now()), "."); err != nil {
+		t.Fatal(err)
+	}
+	r := tar.NewReader(&buf)
+	var names []string
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, h.Name)
+	}
+	want := "other.txt,root/,root/a.txt,root/dir/,root/dir/b.txt"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("unexpected %s; want %s", got, want)
+	}
+}
+
+func TestWithDeterministic(t *testing.T) {
+	writers := map[string]func(w io.Writer, fsys fstest.MapFS, opts ...ArchiveOption) error{
+		"zip": func(w io.Writer, fsys fstest.MapFS, opts ...ArchiveOption) error {
+			return WriteZip(w, fsys, "root", opts...)
+		},
+		"tar": func(w io.Writer, fsys fstest.MapFS, opts ...ArchiveOption) error {
+			return WriteTar(w, fsys, "root", opts...)
+		},
+	}
+	for name, write := range writers {
+		var buf1, buf2 bytes.Buffer
+		if err := write(&buf1, newArchiveFSTest(time.Unix(1, 0)), WithDeterministic()); err != nil {
+			t.Fatal(err)
+		}
+		fsys := newArchiveFSTest(time.Unix(2, 0))
+		fsys["root/a.txt"].Mode = 0666
+		if err := write(&buf2, fsys, WithDeterministic()); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf1.Bytes(), buf2.Bytes()) {
+			t.Errorf("%s: archives are not identical", name)
+		}
+
+		buf1.Reset()
+		if err := write(&buf1, newArchiveFSTest(time.Unix(1, 0))); err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(buf1.Bytes(), buf2.Bytes()) {
+			t.Errorf("%s: archives without WithDeterministic are identical", name)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package wfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrUnknownArchiveFormat "unknown archive format"
+var ErrUnknownArchiveFormat = errors.New("unknown archive format")
+
+// ArchiveFormat is the format of an archive opened by OpenArchive.
+type ArchiveFormat string
+
+const (
+	// ArchiveAuto detects the format by the magic bytes.
+	ArchiveAuto ArchiveFormat = ""
+	// ArchiveZip is the zip format.
+	ArchiveZip ArchiveFormat = "zip"
+	// ArchiveTar is the tar format.
+	ArchiveTar ArchiveFormat = "tar"
+	// ArchiveTarGz is the gzip compressed tar format.
+	ArchiveTarGz ArchiveFormat = "tar.gz"
+)
+
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	zipEmpty  = []byte("PK\x05\x06")
+	gzipMagic = []byte("\x1f\x8b")
+	tarMagic  = []byte("ustar")
+)
+
+// tarMagicOffset is the offset of the magic of a POSIX tar header.
+const tarMagicOffset = 257
+
+// detectArchiveFormat detects the format of the archive read by br.
+func detectArchiveFormat(br *bufio.Reader) (ArchiveFormat, error) {
+	head, err := br.Peek(tarMagicOffset + len(tarMagic))
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	switch {
+	case bytes.HasPrefix(head, zipMagic), bytes.HasPrefix(head, zipEmpty):
+		return ArchiveZip, nil
+	case bytes.HasPrefix(head, gzipMagic):
+		return ArchiveTarGz, nil
+	case len(head) > tarMagicOffset && bytes.HasPrefix(head[tarMagicOffset:], tarMagic):
+		return ArchiveTar, nil
+	}
+	return "", ErrUnknownArchiveFormat
+}
+
+// OpenArchive reads the archive of the specified format from r and returns the
+// tree of the archive as a read-only filesystem. If format is ArchiveAuto the
+// format is detected by the magic bytes. The archive is read into memory so
+// the returned filesystem can be used as the source of CopyFS:
+//
+//	src, err := wfs.OpenArchive(resp.Body, wfs.ArchiveAuto)
+//	if err != nil {
+//	  return err
+//	}
+//	return wfs.CopyFS(dest, src, ".")
+func OpenArchive(r io.Reader, format ArchiveFormat) (fs.FS, error) {
+	br := bufio.NewReaderSize(r, 512)
+	if format == ArchiveAuto {
+		var err error
+		if format, err = detectArchiveFormat(br); err != nil {
+			return nil, err
+		}
+	}
+	switch format {
+	case ArchiveZip:
+		p, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		return zip.NewReader(bytes.NewReader(p), int64(len(p)))
+	case ArchiveTar:
+		return readTarFS(br)
+	case ArchiveTarGz:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return readTarFS(zr)
+	}
+	return nil, ErrUnknownArchiveFormat
+}
+
+// archiveEntry is a directory or file of archiveFS.
+type archiveEntry struct {
+	name     string
+	mode     fs.FileMode
+	modTime  time.Time
+	data     []byte
+	children []*archiveEntry
+}
+
+var (
+	_ fs.FileInfo = (*archiveEntry)(nil)
+	_ fs.DirEntry = (*archiveEntry)(nil)
+)
+
+func (e *archiveEntry) Name() string               { return e.name }
+func (e *archiveEntry) Size() int64                { return int64(len(e.data)) }
+func (e *archiveEntry) Mode() fs.FileMode          { return e.mode }
+func (e *archiveEntry) ModTime() time.Time         { return e.modTime }
+func (e *archiveEntry) IsDir() bool                { return e.mode.IsDir() }
+func (e *archiveEntry) Sys() interface{}           { return nil }
+func (e *archiveEntry) Type() fs.FileMode          { return e.mode.Type() }
+func (e *archiveEntry) Info() (fs.FileInfo, error) { return e, nil }
+
+// archiveFS is a read-only filesystem of the entries read from an archive.
+type archiveFS struct {
+	entries map[string]*archiveEntry
+}
+
+// readTarFS reads the directories and regular files of the tar archive read
+// by r. Names that escape the root such as "../a" are rejected.
+func readTarFS(r io.Reader) (*archiveFS, error) {
+	fsys := &archiveFS{
+		entries: map[string]*archiveEntry{
+			".": {name: ".", mode: fs.ModeDir | 0755},
+		},
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "/"))
+		if !fs.ValidPath(name) {
+			return nil, &fs.PathError{Op: "OpenArchive", Path: hdr.Name, Err: fs.ErrInvalid}
+		}
+		info := hdr.FileInfo()
+		switch {
+		case info.IsDir():
+			e, err := fsys.mkdirAll(name)
+			if err != nil {
+				return nil, err
+			}
+			e.mode = info.Mode()
+			e.modTime = info.ModTime()
+		case info.Mode().IsRegular():
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			if err := fsys.add(name, &archiveEntry{
+				name:    path.Base(name),
+				mode:    info.Mode(),
+				modTime: info.ModTime(),
+				data:    data,
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, e := range fsys.entries {
+		sort.Slice(e.children, func(i, j int) bool {
+			return e.children[i].name < e.children[j].name
+		})
+	}
+	return fsys, nil
+}
+
+// mkdirAll returns the named directory and creates it and its parents if
+// they do not exist.
+func (fsys *archiveFS) mkdirAll(dir string) (*archiveEntry, error) {
+	if e, ok := fsys.entries[dir]; ok {
+		if !e.IsDir() {
+			return nil, &fs.PathError{Op: "OpenArchive", Path: dir, Err: fs.ErrExist}
+		}
+		return e, nil
+	}
+	parent, err := fsys.mkdirAll(path.Dir(dir))
+	if err != nil {
+		return nil, err
+	}
+	e := &archiveEntry{name: path.Base(dir), mode: fs.ModeDir | 0755}
+	parent.children = append(parent.children, e)
+	fsys.entries[dir] = e
+	return e, nil
+}
+
+// add adds the named file. A later entry of the same name replaces the
+// earlier one like extracting the archive.
+func (fsys *archiveFS) add(name string, e *archiveEntry) error {
+	parent, err := fsys.mkdirAll(path.Dir(name))
+	if err != nil {
+		return err
+	}
+	if old, ok := fsys.entries[name]; ok {
+		if old.IsDir() {
+			return &fs.PathError{Op: "OpenArchive", Path: name, Err: fs.ErrExist}
+		}
+		*old = *e
+		return nil
+	}
+	parent.children = append(parent.children, e)
+	fsys.entries[name] = e
+	return nil
+}
+
+// Open opens the named file.
+func (fsys *archiveFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: string(OpOpen), Path: name, Err: fs.ErrInvalid}
+	}
+	e, ok := fsys.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: string(OpOpen), Path: name, Err: fs.ErrNotExist}
+	}
+	return &archiveFile{entry: e, path: name, r: bytes.NewReader(e.data)}, nil
+}
+
+// archiveFile is an opened entry of archiveFS.
+type archiveFile struct {
+	entry    *archiveEntry
+	path     string
+	r        *bytes.Reader
+	dirIndex int
+}
+
+func (f *archiveFile) Stat() (fs.FileInfo, error) {
+	return f.entry, nil
+}
+
+func (f *archiveFile) Read(p []byte) (int, error) {
+	if f.entry.IsDir() {
+		return 0, &fs.PathError{Op: "Read", Path: f.path, Err: fs.ErrInvalid}
+	}
+	return f.r.Read(p)
+}
+
+func (f *archiveFile) Close() error {
+	return nil
+}
+
+func (f *archiveFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !f.entry.IsDir() {
+		return nil, &fs.PathError{Op: string(OpReadDir), Path: f.path, Err: fs.ErrInvalid}
+	}
+	rest := f.entry.children[f.dirIndex:]
+	if n > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n <= 0 || n > len(rest) {
+		n = len(rest)
+	}
+	entries := make([]fs.DirEntry, n)
+	for i, e := range rest[:n] {
+		entries[i] = e
+	}
+	f.dirIndex += n
+	return entries, nil
+}
@@ -0,0 +1,83 @@
+package wfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestOpenArchive(t *testing.T) {
+	src := newArchiveFSTest(time.Now())
+
+	var zipBuf, tarBuf, tgzBuf bytes.Buffer
+	if err := WriteZip(&zipBuf, src, "root"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTar(&tarBuf, src, "root"); err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(&tgzBuf)
+	if _, err := zw.Write(tarBuf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name   string
+		data   []byte
+		format ArchiveFormat
+	}{
+		{name: "zip", data: zipBuf.Bytes(), format: ArchiveAuto},
+		{name: "tar", data: tarBuf.Bytes(), format: ArchiveAuto},
+		{name: "tar.gz", data: tgzBuf.Bytes(), format: ArchiveAuto},
+		{name: "tar explicit", data: tarBuf.Bytes(), format: ArchiveTar},
+	}
+	for _, tc := range testCases {
+		fsys, err := OpenArchive(bytes.NewReader(tc.data), tc.format)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if err := fstest.TestFS(fsys, "a.txt", "dir/b.txt"); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+
+		m := fstest.MapFS{}
+		if err := CopyFS(newMapWriteFS(m), fsys, "."); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		for name, want := range map[string]string{"a.txt": "a", "dir/b.txt": "b"} {
+			if f, ok := m[name]; !ok || string(f.Data) != want {
+				t.Errorf("%s: unexpected %s; want %s", tc.name, name, want)
+			}
+		}
+	}
+}
+
+func TestOpenArchive_Errors(t *testing.T) {
+	if _, err := OpenArchive(strings.NewReader("plain text"), ArchiveAuto); !errors.Is(err, ErrUnknownArchiveFormat) {
+		t.Errorf("unexpected %v; want %v", err, ErrUnknownArchiveFormat)
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "../evil.txt", Mode: 0644, Size: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenArchive(&buf, ArchiveAuto); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+}
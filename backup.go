@@ -0,0 +1,224 @@
+package wfs
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+)
+
+// backupSnapshotsDir is the directory of snapshots written by Backup.
+const backupSnapshotsDir = "snapshots"
+
+// ManifestEntry is a file recorded in a Manifest.
+type ManifestEntry struct {
+	Name    string      `json:"name"`
+	Size    int64       `json:"size"`
+	Mode    fs.FileMode `json:"mode"`
+	ModTime time.Time   `json:"modTime"`
+	// Hash is the hash of the file formatted as "<algo>:<hex>".
+	Hash string `json:"hash"`
+	// Snapshot is the ID of the snapshot that stores the data of the file.
+	Snapshot string `json:"snapshot"`
+}
+
+// Manifest is a snapshot written by Backup. The tree of a backup is:
+//
+//	snapshots/<id>/manifest.json
+//	snapshots/<id>/files/<name>
+//
+// A snapshot stores only the files changed from the parent snapshot and its
+// manifest refers to the snapshots that store the unchanged files.
+type Manifest struct {
+	ID      string          `json:"id"`
+	Parent  string          `json:"parent,omitempty"`
+	Created time.Time       `json:"created"`
+	Files   []ManifestEntry `json:"files"`
+}
+
+func manifestPath(id string) string {
+	return path.Join(backupSnapshotsDir, id, "manifest.json")
+}
+
+func snapshotFilePath(id, name string) string {
+	return path.Join(backupSnapshotsDir, id, "files", name)
+}
+
+// LoadManifest reads the manifest of the specified snapshot ID from the backup
+// filesystem.
+func LoadManifest(backup fs.FS, id string) (*Manifest, error) {
+	p, err := fs.ReadFile(backup, manifestPath(id))
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(p, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func newManifestID(created time.Time) string {
+	return created.Format("20060102T150405.000000000Z")
+}
+
+// manifestEntry returns the ManifestEntry of the regular file.
+func manifestEntry(fsys fs.FS, name string, d fs.DirEntry, snapshot string) (ManifestEntry, error) {
+	info, err := d.Info()
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	algo, sum, err := fileHash(fsys, name, info)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	return ManifestEntry{
+		Name:     name,
+		Size:     info.Size(),
+		Mode:     info.Mode(),
+		ModTime:  info.ModTime(),
+		Hash:     algo + ":" + hex.EncodeToString(sum),
+		Snapshot: snapshot,
+	}, nil
+}
+
+// NewManifest returns a manifest of the regular files of the tree of fsys
+// without writing the files. The Snapshot of each entry is the ID of the
+// returned manifest. Use VerifyManifest to detect changes of the tree later.
+func NewManifest(fsys fs.FS) (*Manifest, error) {
+	created := time.Now().UTC()
+	m := &Manifest{
+		ID:      newManifestID(created),
+		Created: created,
+	}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		e, err := manifestEntry(fsys, name, d, m.ID)
+		if err != nil {
+			return err
+		}
+		m.Files = append(m.Files, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// VerifyManifest returns the sorted names of the regular files of the tree of
+// fsys that differ from the manifest. The names contain the files that are
+// added, removed or changed in size or hash since the manifest was made. An
+// empty result means that the tree has not drifted.
+func VerifyManifest(fsys fs.FS, m *Manifest) ([]string, error) {
+	current, err := NewManifest(fsys)
+	if err != nil {
+		return nil, err
+	}
+	entries := map[string]ManifestEntry{}
+	for _, e := range m.Files {
+		entries[e.Name] = e
+	}
+	var names []string
+	for _, e := range current.Files {
+		me, ok := entries[e.Name]
+		if !ok || me.Size != e.Size || me.Hash != e.Hash {
+			names = append(names, e.Name)
+		}
+		delete(entries, e.Name)
+	}
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Backup writes a snapshot of the tree of src to dest and returns its
+// manifest. If prev is not nil only the files whose hashes differ from prev
+// are written and the unchanged files refer to the snapshots of prev.
+func Backup(dest WriteFileFS, src fs.FS, prev *Manifest) (*Manifest, error) {
+	created := time.Now().UTC()
+	m := &Manifest{
+		ID:      newManifestID(created),
+		Created: created,
+	}
+	prevEntries := map[string]ManifestEntry{}
+	if prev != nil {
+		m.Parent = prev.ID
+		for _, e := range prev.Files {
+			prevEntries[e.Name] = e
+		}
+	}
+
+	err := fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		e, err := manifestEntry(src, name, d, m.ID)
+		if err != nil {
+			return err
+		}
+		if pe, ok := prevEntries[name]; ok && pe.Hash == e.Hash && pe.Size == e.Size {
+			e.Snapshot = pe.Snapshot
+		} else if err := copyFileTo(dest, snapshotFilePath(m.ID, name), src, name, 0644); err != nil {
+			return err
+		}
+		m.Files = append(m.Files, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := dest.MkdirAll(path.Dir(manifestPath(m.ID)), fs.ModePerm); err != nil {
+		return nil, err
+	}
+	if _, err := dest.WriteFile(manifestPath(m.ID), p, 0644); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Restore writes the files of the manifest from the backup filesystem to
+// dest with the recorded modes.
+func Restore(dest WriteFileFS, backup fs.FS, m *Manifest) error {
+	for _, e := range m.Files {
+		src := snapshotFilePath(e.Snapshot, e.Name)
+		if err := copyFileTo(dest, e.Name, backup, src, e.Mode.Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFileTo copies the file srcName on src to the file name on dest.
+func copyFileTo(dest WriteFileFS, name string, src fs.FS, srcName string, mode fs.FileMode) error {
+	srcFile, err := src.Open(srcName)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	if err := dest.MkdirAll(path.Dir(name), fs.ModePerm); err != nil {
+		return err
+	}
+	f, err := dest.CreateFile(name, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, srcFile); err != nil {
+		Abort(dest, name, f)
+		return err
+	}
+	return f.Close()
+}
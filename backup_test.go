@@ -0,0 +1,96 @@
+package wfs
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestBackup(t *testing.T) {
+	src := fstest.MapFS{
+		"a.txt":     {Data: []byte("a"), Mode: 0600},
+		"dir/b.txt": {Data: []byte("b"), Mode: 0644},
+	}
+	backup := fstest.MapFS{}
+	dest := newMapWriteFS(backup)
+
+	m1, err := Backup(dest, src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src["dir/b.txt"] = &fstest.MapFile{Data: []byte("b2"), Mode: 0644}
+	m2, err := Backup(dest, src, m1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m2.Parent != m1.ID {
+		t.Errorf("unexpected parent %s; want %s", m2.Parent, m1.ID)
+	}
+
+	var written []string
+	err = fs.WalkDir(backup, snapshotFilePath(m2.ID, "."), func(name string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			written = append(written, name)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{snapshotFilePath(m2.ID, "dir/b.txt")}
+	if !reflect.DeepEqual(written, want) {
+		t.Errorf("unexpected %v; want %v", written, want)
+	}
+
+	loaded, err := LoadManifest(backup, m2.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := fstest.MapFS{}
+	if err := Restore(newMapWriteFS(restored), backup, loaded); err != nil {
+		t.Fatal(err)
+	}
+	for name, f := range src {
+		got, ok := restored[name]
+		if !ok {
+			t.Fatalf("not restored %s", name)
+		}
+		if string(got.Data) != string(f.Data) || got.Mode != f.Mode {
+			t.Errorf("unexpected %s %s %v; want %s %v", name, got.Data, got.Mode, f.Data, f.Mode)
+		}
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	src := fstest.MapFS{
+		"a.txt":     {Data: []byte("a")},
+		"dir/b.txt": {Data: []byte("b")},
+		"dir/c.txt": {Data: []byte("c")},
+	}
+	m, err := NewManifest(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Files) != 3 || m.Files[0].Snapshot != m.ID {
+		t.Errorf("unexpected %v", m.Files)
+	}
+	names, err := VerifyManifest(src, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 0 {
+		t.Errorf("unexpected %v; want no names", names)
+	}
+
+	src["a.txt"] = &fstest.MapFile{Data: []byte("A")}
+	src["new.txt"] = &fstest.MapFile{Data: []byte("new")}
+	delete(src, "dir/c.txt")
+	names, err = VerifyManifest(src, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.txt", "dir/c.txt", "new.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("unexpected %v; want %v", names, want)
+	}
+}
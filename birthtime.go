@@ -0,0 +1,22 @@
+package wfs
+
+import (
+	"io/fs"
+	"time"
+)
+
+// BirthTimeInfo is the interface implemented by a fs.FileInfo that provides
+// the creation time of the file. BirthTime returns false if the creation time
+// is not available on the platform or the filesystem.
+type BirthTimeInfo interface {
+	BirthTime() (time.Time, bool)
+}
+
+// BirthTime returns the creation time of the file described by info. If info
+// does not implement BirthTimeInfo BirthTime returns false.
+func BirthTime(info fs.FileInfo) (time.Time, bool) {
+	if info, ok := info.(BirthTimeInfo); ok {
+		return info.BirthTime()
+	}
+	return time.Time{}, false
+}
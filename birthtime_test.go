@@ -0,0 +1,33 @@
+package wfs
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type birthTimeInfoTest struct {
+	*FileInfoDelegator
+	birth time.Time
+}
+
+func (info *birthTimeInfoTest) BirthTime() (time.Time, bool) {
+	return info.birth, true
+}
+
+func TestBirthTime(t *testing.T) {
+	want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	info := &birthTimeInfoTest{FileInfoDelegator: &FileInfoDelegator{}, birth: want}
+	if got, ok := BirthTime(info); !ok || !got.Equal(want) {
+		t.Errorf("unexpected %v %v; want %v true", got, ok, want)
+	}
+
+	m := fstest.MapFS{"a.txt": {}}
+	mapInfo, err := m.Stat("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := BirthTime(mapInfo); ok || !got.IsZero() {
+		t.Errorf("unexpected %v %v; want zero false", got, ok)
+	}
+}
@@ -0,0 +1,217 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// BreakerFS is a filesystem that works as a circuit breaker of the wrapped
+// filesystem. After threshold consecutive failures BreakerFS fails fast with
+// ErrUnavailable for the cool-down period. After the period one operation is
+// passed through as a probe; the breaker is reset if it succeeds and trips
+// again if it fails. Errors of missing or invalid files such as
+// fs.ErrNotExist are not counted as failures.
+type BreakerFS struct {
+	fsys fs.FS
+	b    *breaker
+}
+
+var (
+	_ fs.GlobFS     = (*BreakerFS)(nil)
+	_ fs.ReadDirFS  = (*BreakerFS)(nil)
+	_ fs.ReadFileFS = (*BreakerFS)(nil)
+	_ fs.StatFS     = (*BreakerFS)(nil)
+	_ fs.SubFS      = (*BreakerFS)(nil)
+	_ WriteFileFS   = (*BreakerFS)(nil)
+	_ RemoveFileFS  = (*BreakerFS)(nil)
+)
+
+// NewBreakerFS returns a BreakerFS that trips after threshold consecutive
+// failures and fails fast for cooldown. A non-positive threshold disables the
+// breaker.
+func NewBreakerFS(fsys fs.FS, threshold int, cooldown time.Duration) *BreakerFS {
+	return &BreakerFS{
+		fsys: fsys,
+		b: &breaker{
+			threshold: threshold,
+			cooldown:  cooldown,
+			now:       time.Now,
+		},
+	}
+}
+
+// FS returns fsys as a filesystem that implements WriteFileFS and
+// RemoveFileFS only if the wrapped filesystem implements them.
+func (fsys *BreakerFS) FS() fs.FS {
+	return narrowFS(fsys, fsys.fsys)
+}
+
+// breaker holds the state of BreakerFS that is shared with sub filesystems.
+type breaker struct {
+	mutex     sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+// allow reports whether an operation can be passed through.
+func (b *breaker) allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return true
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// done records the result of an operation.
+func (b *breaker) done(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.probing = false
+	if !isBackendError(err) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+func isBackendError(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, target := range []error{fs.ErrNotExist, fs.ErrExist, fs.ErrPermission, fs.ErrInvalid, ErrNotImplemented} {
+		if errors.Is(err, target) {
+			return false
+		}
+	}
+	return true
+}
+
+func (fsys *BreakerFS) call(op Op, name string, fn func() error) error {
+	if !fsys.b.allow() {
+		return &fs.PathError{Op: string(op), Path: name, Err: ErrUnavailable}
+	}
+	err := fn()
+	fsys.b.done(err)
+	return err
+}
+
+// Open opens the named file.
+func (fsys *BreakerFS) Open(name string) (fs.File, error) {
+	var f fs.File
+	err := fsys.call(OpOpen, name, func() (err error) {
+		f, err = fsys.fsys.Open(name)
+		return
+	})
+	return f, err
+}
+
+// ReadDir reads the named directory.
+func (fsys *BreakerFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	err := fsys.call(OpReadDir, dir, func() (err error) {
+		entries, err = fs.ReadDir(fsys.fsys, dir)
+		return
+	})
+	return entries, err
+}
+
+// ReadFile reads the named file and returns its contents.
+func (fsys *BreakerFS) ReadFile(name string) ([]byte, error) {
+	var p []byte
+	err := fsys.call(OpReadFile, name, func() (err error) {
+		p, err = fs.ReadFile(fsys.fsys, name)
+		return
+	})
+	return p, err
+}
+
+// Glob returns the names of all files matching pattern.
+func (fsys *BreakerFS) Glob(pattern string) ([]string, error) {
+	var names []string
+	err := fsys.call(OpGlob, pattern, func() (err error) {
+		names, err = Glob(fsys.fsys, pattern)
+		return
+	})
+	return names, err
+}
+
+// Stat returns a FileInfo describing the named file.
+func (fsys *BreakerFS) Stat(name string) (fs.FileInfo, error) {
+	var info fs.FileInfo
+	err := fsys.call(OpStat, name, func() (err error) {
+		info, err = fs.Stat(fsys.fsys, name)
+		return
+	})
+	return info, err
+}
+
+// Sub returns a BreakerFS corresponding to the subtree rooted at dir. The
+// returned BreakerFS shares the state of the breaker with fsys.
+func (fsys *BreakerFS) Sub(dir string) (fs.FS, error) {
+	var sub fs.FS
+	err := fsys.call(OpSub, dir, func() (err error) {
+		sub, err = fs.Sub(fsys.fsys, dir)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &BreakerFS{fsys: sub, b: fsys.b}, nil
+}
+
+// MkdirAll creates the named directory.
+func (fsys *BreakerFS) MkdirAll(dir string, mode fs.FileMode) error {
+	return fsys.call(OpMkdirAll, dir, func() error {
+		return MkdirAll(fsys.fsys, dir, mode)
+	})
+}
+
+// CreateFile creates the named file.
+func (fsys *BreakerFS) CreateFile(name string, mode fs.FileMode) (WriterFile, error) {
+	var f WriterFile
+	err := fsys.call(OpCreateFile, name, func() (err error) {
+		f, err = CreateFile(fsys.fsys, name, mode)
+		return
+	})
+	return f, err
+}
+
+// WriteFile writes the specified bytes to the named file.
+func (fsys *BreakerFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	var n int
+	err := fsys.call(OpWriteFile, name, func() (err error) {
+		n, err = WriteFile(fsys.fsys, name, p, mode)
+		return
+	})
+	return n, err
+}
+
+// RemoveFile removes the named file.
+func (fsys *BreakerFS) RemoveFile(name string) error {
+	return fsys.call(OpRemoveFile, name, func() error {
+		return RemoveFile(fsys.fsys, name)
+	})
+}
+
+// RemoveAll removes path and any children it contains.
+func (fsys *BreakerFS) RemoveAll(path string) error {
+	return fsys.call(OpRemoveAll, path, func() error {
+		return RemoveAll(fsys.fsys, path)
+	})
+}
@@ -0,0 +1,85 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestBreakerFS(t *testing.T) {
+	errBackend := errors.New("backend")
+	var calls int
+	var backendErr error
+	d := &FSDelegator{
+		StatFunc: func(name string) (fs.FileInfo, error) {
+			calls++
+			if backendErr != nil {
+				return nil, backendErr
+			}
+			return nil, &fs.PathError{Op: "Stat", Path: name, Err: fs.ErrNotExist}
+		},
+	}
+	now := time.Now()
+	fsys := NewBreakerFS(d, 2, time.Minute)
+	fsys.b.now = func() time.Time { return now }
+
+	// NOTE: fs.ErrNotExist is not a failure.
+	for i := 0; i < 3; i++ {
+		if _, err := fsys.Stat("test.txt"); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("unexpected %v; want %v", err, fs.ErrNotExist)
+		}
+	}
+
+	backendErr = errBackend
+	for i := 0; i < 2; i++ {
+		if _, err := fsys.Stat("test.txt"); !errors.Is(err, errBackend) {
+			t.Fatalf("unexpected %v; want %v", err, errBackend)
+		}
+	}
+	calls = 0
+	if _, err := fsys.Stat("test.txt"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("unexpected %v; want %v", err, ErrUnavailable)
+	}
+	if calls != 0 {
+		t.Errorf("unexpected %d calls; want 0", calls)
+	}
+
+	// NOTE: A failed probe trips the breaker again.
+	now = now.Add(time.Minute)
+	if _, err := fsys.Stat("test.txt"); !errors.Is(err, errBackend) {
+		t.Errorf("unexpected %v; want %v", err, errBackend)
+	}
+	if _, err := fsys.Stat("test.txt"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("unexpected %v; want %v", err, ErrUnavailable)
+	}
+
+	// NOTE: A succeeded probe resets the breaker.
+	now = now.Add(time.Minute)
+	backendErr = nil
+	for i := 0; i < 2; i++ {
+		if _, err := fsys.Stat("test.txt"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+		}
+	}
+}
+
+func TestBreakerFS_Sub(t *testing.T) {
+	d := &FSDelegator{
+		SubFunc: func(dir string) (fs.FS, error) {
+			return &FSDelegator{}, nil
+		},
+		ReadFileFunc: func(name string) ([]byte, error) {
+			return nil, errors.New("backend")
+		},
+	}
+	fsys := NewBreakerFS(d, 1, time.Minute)
+	sub, err := fsys.Sub("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys.ReadFile("test.txt")
+	if _, err := fs.Stat(sub, "test.txt"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("unexpected %v; want %v", err, ErrUnavailable)
+	}
+}
@@ -0,0 +1,95 @@
+package wfs
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+)
+
+// BufferedFS is a filesystem that buffers writes to files created by
+// CreateFile, so small writes are batched into larger writes of the wrapped
+// filesystem. Files returned by CreateFile implement SyncWriterFile and Sync
+// or Close flushes the buffer. They also implement AbortWriterFile and Abort
+// drops the buffer.
+type BufferedFS struct {
+	*FSDelegator
+	fsys    fs.FS
+	bufSize int
+}
+
+var _ WriteFileFS = (*BufferedFS)(nil)
+
+// NewBufferedFS returns a BufferedFS that buffers bufSize bytes per file. If
+// bufSize is not positive the default size of bufio is used.
+func NewBufferedFS(fsys fs.FS, bufSize int) *BufferedFS {
+	return &BufferedFS{
+		FSDelegator: DelegateFS(fsys),
+		fsys:        fsys,
+		bufSize:     bufSize,
+	}
+}
+
+// FS returns fsys as a filesystem that implements WriteFileFS and
+// RemoveFileFS only if the wrapped filesystem implements them.
+func (fsys *BufferedFS) FS() fs.FS {
+	return narrowFS(fsys, fsys.fsys)
+}
+
+// CreateFile creates the named file and returns a buffered file.
+func (fsys *BufferedFS) CreateFile(name string, mode fs.FileMode) (WriterFile, error) {
+	f, err := CreateFile(fsys.fsys, name, mode)
+	if err != nil {
+		return nil, err
+	}
+	return &bufferedFile{
+		WriterFile: f,
+		w:          bufio.NewWriterSize(f, fsys.bufSize),
+		fsys:       fsys.fsys,
+		name:       name,
+	}, nil
+}
+
+// bufferedFile is a WriterFile that buffers writes.
+type bufferedFile struct {
+	WriterFile
+	w    *bufio.Writer
+	fsys fs.FS
+	name string
+}
+
+var (
+	_ SyncWriterFile  = (*bufferedFile)(nil)
+	_ AbortWriterFile = (*bufferedFile)(nil)
+)
+
+// Write writes p to the buffer.
+func (f *bufferedFile) Write(p []byte) (int, error) {
+	return f.w.Write(p)
+}
+
+// Sync flushes the buffer and calls Sync of the wrapped file if the file
+// implements SyncWriterFile.
+func (f *bufferedFile) Sync() error {
+	if err := f.w.Flush(); err != nil {
+		return err
+	}
+	if sf, ok := f.WriterFile.(SyncWriterFile); ok {
+		return sf.Sync()
+	}
+	return nil
+}
+
+// Close flushes the buffer and closes the wrapped file.
+func (f *bufferedFile) Close() error {
+	if err := f.w.Flush(); err != nil {
+		f.WriterFile.Close()
+		return err
+	}
+	return f.WriterFile.Close()
+}
+
+// Abort drops the buffer and discards the wrapped file by Abort.
+func (f *bufferedFile) Abort() error {
+	f.w.Reset(io.Discard)
+	return Abort(f.fsys, f.name, f.WriterFile)
+}
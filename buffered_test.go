@@ -0,0 +1,92 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+)
+
+func TestBufferedFS(t *testing.T) {
+	var writes []string
+	d := &FSDelegator{
+		CreateFileFunc: func(name string, mode fs.FileMode) (WriterFile, error) {
+			return &FileDelegator{
+				WriteFunc: func(p []byte) (int, error) {
+					writes = append(writes, string(p))
+					return len(p), nil
+				},
+			}, nil
+		},
+	}
+	fsys := NewBufferedFS(d, 16)
+
+	f, err := fsys.CreateFile("test.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"a", "b", "c"} {
+		if _, err := f.Write([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(writes) != 0 {
+		t.Errorf("unexpected writes %v before Sync", writes)
+	}
+	if err := Sync(f); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("d")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"abc", "d"}; !reflect.DeepEqual(writes, want) {
+		t.Errorf("unexpected %v; want %v", writes, want)
+	}
+}
+
+func TestSync_ErrNotImplemented(t *testing.T) {
+	if err := Sync(&FileDelegator{}); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotImplemented)
+	}
+}
+
+func TestBufferedFS_Abort(t *testing.T) {
+	var writes []string
+	var aborted bool
+	d := &FSDelegator{
+		CreateFileFunc: func(name string, mode fs.FileMode) (WriterFile, error) {
+			f := &FileDelegator{
+				WriteFunc: func(p []byte) (int, error) {
+					writes = append(writes, string(p))
+					return len(p), nil
+				},
+				AbortFunc: func() error {
+					aborted = true
+					return nil
+				},
+			}
+			return f.File().(WriterFile), nil
+		},
+	}
+	fsys := NewBufferedFS(d, 16)
+
+	f, err := fsys.CreateFile("test.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Abort(fsys, "test.txt", f); err != nil {
+		t.Fatal(err)
+	}
+	if !aborted {
+		t.Errorf("unexpected the wrapped file is not aborted")
+	}
+	if len(writes) != 0 {
+		t.Errorf("unexpected writes %v after Abort", writes)
+	}
+}
@@ -0,0 +1,219 @@
+// Package cas provides a content-addressable store of blobs on a filesystem
+// with named references and garbage collection of unreferenced blobs.
+//
+// The tree of the filesystem is:
+//
+//	blobs/<digest[0:2]>/<digest>
+//	refs/<name>
+//
+// A digest is the hex encoded SHA-256 of the contents of a blob and a file of
+// refs contains the digest referenced by the name.
+package cas
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/jarxorg/wfs"
+)
+
+const (
+	blobsDir = "blobs"
+	refsDir  = "refs"
+)
+
+// ErrInvalidDigest is returned for a malformed digest.
+var ErrInvalidDigest = errors.New("invalid digest")
+
+// Store is a content-addressable store of blobs on a filesystem that
+// implements wfs.WriteFileFS and wfs.RemoveFileFS.
+// GC of a Store is serialized with Put and SetRef of the same Store but not
+// with other Stores on the same filesystem.
+type Store struct {
+	mutex sync.RWMutex
+	fsys  fs.FS
+}
+
+// New returns a Store on fsys.
+func New(fsys fs.FS) *Store {
+	return &Store{fsys: fsys}
+}
+
+// Digest returns the digest of p.
+func Digest(p []byte) string {
+	sum := sha256.Sum256(p)
+	return hex.EncodeToString(sum[:])
+}
+
+func blobName(digest string) (string, error) {
+	if len(digest) != sha256.Size*2 {
+		return "", fmt.Errorf("%w: %s", ErrInvalidDigest, digest)
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidDigest, digest)
+	}
+	return path.Join(blobsDir, digest[0:2], digest), nil
+}
+
+func refName(name string) (string, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return "", &fs.PathError{Op: "Ref", Path: name, Err: fs.ErrInvalid}
+	}
+	return path.Join(refsDir, name), nil
+}
+
+// Put stores p and returns its digest. Put does not write p if the blob
+// already exists.
+func (s *Store) Put(p []byte) (string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	digest := Digest(p)
+	name, _ := blobName(digest)
+	if _, err := fs.Stat(s.fsys, name); err == nil {
+		return digest, nil
+	}
+	if _, err := wfs.WriteFile(s.fsys, name, p, fs.ModePerm); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// Get returns the contents of the blob of digest.
+func (s *Store) Get(digest string) ([]byte, error) {
+	name, err := blobName(digest)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(s.fsys, name)
+}
+
+// Has reports whether the blob of digest exists.
+func (s *Store) Has(digest string) (bool, error) {
+	name, err := blobName(digest)
+	if err != nil {
+		return false, err
+	}
+	if _, err := fs.Stat(s.fsys, name); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// SetRef makes the named reference refer to digest. The blob of digest must
+// exist.
+func (s *Store) SetRef(name, digest string) error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ref, err := refName(name)
+	if err != nil {
+		return err
+	}
+	ok, err := s.Has(digest)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &fs.PathError{Op: "SetRef", Path: digest, Err: fs.ErrNotExist}
+	}
+	_, err = wfs.WriteFile(s.fsys, ref, []byte(digest), fs.ModePerm)
+	return err
+}
+
+// Ref returns the digest referred by the named reference.
+func (s *Store) Ref(name string) (string, error) {
+	ref, err := refName(name)
+	if err != nil {
+		return "", err
+	}
+	p, err := fs.ReadFile(s.fsys, ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(p)), nil
+}
+
+// DeleteRef deletes the named reference. The blob is removed by GC if no
+// other reference refers to it.
+func (s *Store) DeleteRef(name string) error {
+	ref, err := refName(name)
+	if err != nil {
+		return err
+	}
+	return wfs.RemoveFile(s.fsys, ref)
+}
+
+// Refs returns the digests keyed by the names of all references.
+func (s *Store) Refs() (map[string]string, error) {
+	refs := map[string]string{}
+	err := fs.WalkDir(s.fsys, refsDir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if name == refsDir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		p, err := fs.ReadFile(s.fsys, name)
+		if err != nil {
+			return err
+		}
+		refs[strings.TrimPrefix(name, refsDir+"/")] = strings.TrimSpace(string(p))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// GC removes the blobs that are not referred by any reference and returns
+// their digests. GC stops and returns the error of ctx if ctx is done.
+func (s *Store) GC(ctx context.Context) ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	refs, err := s.Refs()
+	if err != nil {
+		return nil, err
+	}
+	live := map[string]bool{}
+	for _, digest := range refs {
+		live[digest] = true
+	}
+
+	var removed []string
+	err = fs.WalkDir(s.fsys, blobsDir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if name == blobsDir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || live[d.Name()] {
+			return nil
+		}
+		if err := wfs.RemoveFile(s.fsys, name); err != nil {
+			return err
+		}
+		removed = append(removed, d.Name())
+		return nil
+	})
+	return removed, err
+}
@@ -0,0 +1,106 @@
+package cas
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/jarxorg/wfs/memfs"
+)
+
+func TestStore(t *testing.T) {
+	s := New(memfs.New())
+
+	d1, err := s.Put([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d1 != Digest([]byte("hello")) {
+		t.Errorf("unexpected %s", d1)
+	}
+	got, err := s.Get(d1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("unexpected %s", got)
+	}
+	if err := s.SetRef("builds/1", d1); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := s.Ref("builds/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != d1 {
+		t.Errorf("unexpected %s; want %s", ref, d1)
+	}
+	refs, err := s.Refs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"builds/1": d1}; !reflect.DeepEqual(refs, want) {
+		t.Errorf("unexpected %v; want %v", refs, want)
+	}
+}
+
+func TestStore_GC(t *testing.T) {
+	s := New(memfs.New())
+	ctx := context.Background()
+
+	if removed, err := s.GC(ctx); err != nil || len(removed) != 0 {
+		t.Fatalf("unexpected %v %v", removed, err)
+	}
+
+	d1, _ := s.Put([]byte("1"))
+	d2, _ := s.Put([]byte("2"))
+	d3, _ := s.Put([]byte("3"))
+	if err := s.SetRef("a", d1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetRef("b", d2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteRef("b"); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := s.GC(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{d2, d3}
+	sort.Strings(want)
+	if !reflect.DeepEqual(removed, want) {
+		t.Errorf("unexpected %v; want %v", removed, want)
+	}
+	for digest, want := range map[string]bool{d1: true, d2: false, d3: false} {
+		if got, _ := s.Has(digest); got != want {
+			t.Errorf("%s: unexpected %v; want %v", digest, got, want)
+		}
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	s.Put([]byte("4"))
+	if _, err := s.GC(canceled); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected %v; want %v", err, context.Canceled)
+	}
+}
+
+func TestStore_Errors(t *testing.T) {
+	s := New(memfs.New())
+	if _, err := s.Get("invalid"); !errors.Is(err, ErrInvalidDigest) {
+		t.Errorf("unexpected %v; want %v", err, ErrInvalidDigest)
+	}
+	if err := s.SetRef("a", Digest([]byte("missing"))); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	d, _ := s.Put([]byte("1"))
+	if err := s.SetRef("../a", d); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+}
@@ -0,0 +1,240 @@
+package cas
+
+import (
+	"bytes"
+	"io/fs"
+	"path"
+
+	"github.com/jarxorg/wfs"
+)
+
+// DedupFS is a filesystem that stores the contents of files in a Store, so
+// identical files are stored once. A file of DedupFS is a reference of the
+// Store named by the path of the file and reads of the file materialize the
+// contents of the referenced blob. Removed files are collected by GC of the
+// Store.
+type DedupFS struct {
+	s *Store
+}
+
+var (
+	_ fs.ReadDirFS     = (*DedupFS)(nil)
+	_ fs.ReadFileFS    = (*DedupFS)(nil)
+	_ fs.StatFS        = (*DedupFS)(nil)
+	_ wfs.WriteFileFS  = (*DedupFS)(nil)
+	_ wfs.RemoveFileFS = (*DedupFS)(nil)
+)
+
+// NewDedupFS returns a DedupFS on s.
+func NewDedupFS(s *Store) (*DedupFS, error) {
+	if err := wfs.MkdirAll(s.fsys, refsDir, fs.ModePerm); err != nil {
+		return nil, err
+	}
+	return &DedupFS{s: s}, nil
+}
+
+func (fsys *DedupFS) ref(op wfs.Op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
+	}
+	return path.Join(refsDir, name), nil
+}
+
+// Open opens the named file.
+func (fsys *DedupFS) Open(name string) (fs.File, error) {
+	ref, err := fsys.ref(wfs.OpOpen, name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fsys.Stat(name)
+	if err != nil {
+		return nil, wfs.PathError(wfs.OpOpen, name, err)
+	}
+	if info.IsDir() {
+		f, err := fsys.s.fsys.Open(ref)
+		if err != nil {
+			return nil, wfs.PathError(wfs.OpOpen, name, err)
+		}
+		d := wfs.DelegateFile(f)
+		d.StatFunc = func() (fs.FileInfo, error) {
+			return info, nil
+		}
+		if rd, ok := f.(fs.ReadDirFile); ok {
+			d.ReadDirFunc = func(n int) ([]fs.DirEntry, error) {
+				entries, err := rd.ReadDir(n)
+				return fsys.blobEntries(name, entries), err
+			}
+		}
+		return d, nil
+	}
+	p, err := fsys.ReadFile(name)
+	if err != nil {
+		return nil, wfs.PathError(wfs.OpOpen, name, err)
+	}
+	return wfs.NewBytesFile(info, p), nil
+}
+
+// ReadDir reads the named directory.
+func (fsys *DedupFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	ref, err := fsys.ref(wfs.OpReadDir, dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(fsys.s.fsys, ref)
+	if err != nil {
+		return nil, wfs.PathError(wfs.OpReadDir, dir, err)
+	}
+	return fsys.blobEntries(dir, entries), nil
+}
+
+// blobEntries replaces Info of the file entries with Stat of fsys.
+func (fsys *DedupFS) blobEntries(dir string, entries []fs.DirEntry) []fs.DirEntry {
+	for i, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := path.Join(dir, e.Name())
+		d := wfs.DelegateDirEntry(e)
+		d.InfoFunc = func() (fs.FileInfo, error) {
+			return fsys.Stat(name)
+		}
+		entries[i] = d
+	}
+	return entries
+}
+
+// ReadFile reads the named file and returns the contents of its blob.
+func (fsys *DedupFS) ReadFile(name string) ([]byte, error) {
+	if _, err := fsys.ref(wfs.OpReadFile, name); err != nil {
+		return nil, err
+	}
+	digest, err := fsys.s.Ref(name)
+	if err != nil {
+		return nil, wfs.PathError(wfs.OpReadFile, name, err)
+	}
+	return fsys.s.Get(digest)
+}
+
+// Stat returns a FileInfo describing the named file. The size of a file is
+// the size of its blob.
+func (fsys *DedupFS) Stat(name string) (fs.FileInfo, error) {
+	ref, err := fsys.ref(wfs.OpStat, name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fs.Stat(fsys.s.fsys, ref)
+	if err != nil {
+		return nil, wfs.PathError(wfs.OpStat, name, err)
+	}
+	d := wfs.DelegateFileInfo(info)
+	if name == "." {
+		d.Values.Name = "."
+	}
+	if info.IsDir() {
+		return d, nil
+	}
+	digest, err := fsys.s.Ref(name)
+	if err != nil {
+		return nil, wfs.PathError(wfs.OpStat, name, err)
+	}
+	blob, err := blobName(digest)
+	if err != nil {
+		return nil, wfs.PathError(wfs.OpStat, name, err)
+	}
+	blobInfo, err := fs.Stat(fsys.s.fsys, blob)
+	if err != nil {
+		return nil, wfs.PathError(wfs.OpStat, name, err)
+	}
+	d.Values.Size = blobInfo.Size()
+	return d, nil
+}
+
+// MkdirAll creates the named directory.
+func (fsys *DedupFS) MkdirAll(dir string, mode fs.FileMode) error {
+	ref, err := fsys.ref(wfs.OpMkdirAll, dir)
+	if err != nil {
+		return err
+	}
+	return wfs.MkdirAll(fsys.s.fsys, ref, mode)
+}
+
+// CreateFile creates the named file. The contents are stored at Close and
+// discarded by Abort.
+func (fsys *DedupFS) CreateFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+	ref, err := fsys.ref(wfs.OpCreateFile, name)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := fs.Stat(fsys.s.fsys, ref); err == nil && info.IsDir() {
+		return nil, &fs.PathError{Op: string(wfs.OpCreateFile), Path: name, Err: fs.ErrInvalid}
+	}
+	if err := wfs.MkdirAll(fsys.s.fsys, path.Dir(ref), fs.ModePerm); err != nil {
+		return nil, wfs.PathError(wfs.OpCreateFile, name, err)
+	}
+	var buf bytes.Buffer
+	return (&wfs.FileDelegator{
+		StatFunc: func() (fs.FileInfo, error) {
+			return &wfs.FileInfoDelegator{
+				Values: wfs.FileInfoValues{Name: path.Base(name), Size: int64(buf.Len()), Mode: mode},
+			}, nil
+		},
+		WriteFunc: buf.Write,
+		CloseFunc: func() error {
+			_, err := fsys.WriteFile(name, buf.Bytes(), mode)
+			return err
+		},
+		AbortFunc: func() error {
+			buf.Reset()
+			return nil
+		},
+	}).File().(wfs.WriterFile), nil
+}
+
+// WriteFile stores p in the Store and makes the named file refer to it.
+func (fsys *DedupFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	if _, err := fsys.ref(wfs.OpWriteFile, name); err != nil {
+		return 0, err
+	}
+	digest, err := fsys.s.Put(p)
+	if err != nil {
+		return 0, err
+	}
+	if err := fsys.s.SetRef(name, digest); err != nil {
+		return 0, wfs.PathError(wfs.OpWriteFile, name, err)
+	}
+	return len(p), nil
+}
+
+// RemoveFile removes the named file. The blob is removed by GC of the Store
+// if no other file refers to it.
+func (fsys *DedupFS) RemoveFile(name string) error {
+	if _, err := fsys.ref(wfs.OpRemoveFile, name); err != nil {
+		return err
+	}
+	if err := fsys.s.DeleteRef(name); err != nil {
+		return wfs.PathError(wfs.OpRemoveFile, name, err)
+	}
+	return nil
+}
+
+// RemoveAll removes path and any children it contains.
+func (fsys *DedupFS) RemoveAll(path string) error {
+	ref, err := fsys.ref(wfs.OpRemoveAll, path)
+	if err != nil {
+		return err
+	}
+	if path == "." {
+		// NOTE: Keep the root directory of references.
+		entries, err := fs.ReadDir(fsys.s.fsys, ref)
+		if err != nil {
+			return wfs.PathError(wfs.OpRemoveAll, path, err)
+		}
+		for _, e := range entries {
+			if err := wfs.RemoveAll(fsys.s.fsys, ref+"/"+e.Name()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return wfs.RemoveAll(fsys.s.fsys, ref)
+}
@@ -0,0 +1,107 @@
+package cas
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jarxorg/wfs"
+	"github.com/jarxorg/wfs/memfs"
+	"github.com/jarxorg/wfs/wfstest"
+)
+
+func newDedupFSTest(t *testing.T) (*Store, *DedupFS) {
+	s := New(memfs.New())
+	fsys, err := NewDedupFS(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, fsys
+}
+
+func TestDedupFS(t *testing.T) {
+	s, fsys := newDedupFSTest(t)
+	for _, name := range []string{"a/x.bin", "b/x.bin", "b/c/y.bin"} {
+		if _, err := fsys.WriteFile(name, []byte("same"), fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fstest.TestFS(fsys, "a/x.bin", "b/x.bin", "b/c/y.bin"); err != nil {
+		t.Fatal(err)
+	}
+
+	var blobs []string
+	fs.WalkDir(s.fsys, blobsDir, func(name string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			blobs = append(blobs, name)
+		}
+		return nil
+	})
+	if len(blobs) != 1 {
+		t.Errorf("unexpected blobs %v", blobs)
+	}
+
+	info, err := fsys.Stat("b/x.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 4 {
+		t.Errorf("unexpected size %d", info.Size())
+	}
+
+	if err := fsys.RemoveAll("."); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := s.GC(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 {
+		t.Errorf("unexpected removed %v", removed)
+	}
+}
+
+func TestDedupFS_WriteFileFS(t *testing.T) {
+	_, fsys := newDedupFSTest(t)
+	if err := wfs.MkdirAll(fsys, "tmp", fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfstest.TestWriteFileFS(fsys, "tmp"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDedupFS_RemoveFileFS(t *testing.T) {
+	_, fsys := newDedupFSTest(t)
+	if err := wfs.MkdirAll(fsys, "tmp", fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfstest.TestRemoveFileFS(fsys, "tmp"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDedupFS_CreateFileAbort(t *testing.T) {
+	_, fsys := newDedupFSTest(t)
+	if _, err := fsys.WriteFile("a.txt", []byte("original"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fsys.CreateFile("a.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.Abort(fsys, "a.txt", f); err != nil {
+		t.Fatal(err)
+	}
+	p, err := fs.ReadFile(fsys, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p) != "original" {
+		t.Errorf("unexpected %q; want %q", p, "original")
+	}
+}
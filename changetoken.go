@@ -0,0 +1,48 @@
+package wfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+)
+
+// ChangeTokenFS is the interface implemented by a filesystem that can cheaply
+// tell whether anything under a directory has changed, such as by generation
+// counters or tokens native to the backend.
+type ChangeTokenFS interface {
+	fs.FS
+	// ChangeToken returns an opaque token of the named directory or file that
+	// changes when a file under it is created, written or removed.
+	ChangeToken(dir string) (string, error)
+}
+
+// ChangeToken returns an opaque token of the named directory that changes when
+// a file under it is created, written or removed, so that a poller can skip
+// walking a directory whose token equals the token of the last poll. If the
+// filesystem implements ChangeTokenFS calls fsys.ChangeToken, otherwise the
+// token is a hash of the names, sizes, modes and modification times of the
+// tree walked by fs.WalkDir, which aggregates the modification times of a
+// filesystem such as osfs but is not cheap. Tokens are comparable only with
+// tokens of the same directory on the same filesystem.
+func ChangeToken(fsys fs.FS, dir string) (string, error) {
+	if fsys, ok := fsys.(ChangeTokenFS); ok {
+		return fsys.ChangeToken(dir)
+	}
+	h := sha256.New()
+	err := fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%q %d %o %d\n", name, info.Size(), uint32(info.Mode()), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,46 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestChangeToken(t *testing.T) {
+	m := fstest.MapFS{
+		"dir/a.txt":   {Data: []byte("a")},
+		"other/b.txt": {Data: []byte("b")},
+	}
+	token, err := ChangeToken(m, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m["other/c.txt"] = &fstest.MapFile{Data: []byte("c")}
+	if got, _ := ChangeToken(m, "dir"); got != token {
+		t.Errorf("unexpected %s; want %s", got, token)
+	}
+
+	changes := []func(){
+		func() { m["dir/a.txt"] = &fstest.MapFile{Data: []byte("a2")} },
+		func() { m["dir/a.txt"] = &fstest.MapFile{Data: []byte("a3"), ModTime: time.Unix(1, 0)} },
+		func() { m["dir/sub/d.txt"] = &fstest.MapFile{} },
+		func() { delete(m, "dir/sub/d.txt") },
+	}
+	for i, change := range changes {
+		change()
+		got, err := ChangeToken(m, "dir")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == token {
+			t.Errorf("unexpected same token after change %d", i)
+		}
+		token = got
+	}
+
+	if _, err := ChangeToken(m, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+}
@@ -0,0 +1,222 @@
+package wfs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+)
+
+// Codec encodes the contents of files on write and decodes them on read.
+// Decode(Encode(p)) must return p.
+type Codec interface {
+	Encode(name string, p []byte) ([]byte, error)
+	Decode(name string, p []byte) ([]byte, error)
+}
+
+// CodecFuncs is a Codec of functions. A nil function returns the contents
+// untouched, so a CodecFuncs of only DecodeFunc transforms contents on read
+// like TemplateFS and RedactFS.
+type CodecFuncs struct {
+	EncodeFunc func(name string, p []byte) ([]byte, error)
+	DecodeFunc func(name string, p []byte) ([]byte, error)
+}
+
+var _ Codec = (*CodecFuncs)(nil)
+
+// Encode calls EncodeFunc if it is not nil.
+func (c *CodecFuncs) Encode(name string, p []byte) ([]byte, error) {
+	if c.EncodeFunc == nil {
+		return p, nil
+	}
+	return c.EncodeFunc(name, p)
+}
+
+// Decode calls DecodeFunc if it is not nil.
+func (c *CodecFuncs) Decode(name string, p []byte) ([]byte, error) {
+	if c.DecodeFunc == nil {
+		return p, nil
+	}
+	return c.DecodeFunc(name, p)
+}
+
+var (
+	codecsMutex sync.RWMutex
+	codecs      = map[string]Codec{}
+)
+
+func init() {
+	RegisterCodec("gzip", &CodecFuncs{EncodeFunc: gzipEncode, DecodeFunc: gzipDecode})
+	RegisterCodec("base64", &CodecFuncs{EncodeFunc: base64Encode, DecodeFunc: base64Decode})
+}
+
+// RegisterCodec makes a codec available by the name for TransformRule. The
+// codecs "gzip" and "base64" are registered by default. If RegisterCodec is
+// called twice with the same name or if codec is nil, it panics.
+func RegisterCodec(name string, codec Codec) {
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+
+	if codec == nil {
+		panic("wfs: RegisterCodec codec is nil")
+	}
+	if _, ok := codecs[name]; ok {
+		panic("wfs: RegisterCodec called twice for codec " + name)
+	}
+	codecs[name] = codec
+}
+
+// LookupCodec returns the codec registered by the name.
+func LookupCodec(name string) (Codec, bool) {
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+
+	codec, ok := codecs[name]
+	return codec, ok
+}
+
+// Codecs returns a sorted list of the names of the registered codecs.
+func Codecs() []string {
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func gzipEncode(name string, p []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(p); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gzipDecode(name string, p []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(p))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+func base64Encode(name string, p []byte) ([]byte, error) {
+	buf := make([]byte, base64.StdEncoding.EncodedLen(len(p)))
+	base64.StdEncoding.Encode(buf, p)
+	return buf, nil
+}
+
+func base64Decode(name string, p []byte) ([]byte, error) {
+	buf := make([]byte, base64.StdEncoding.DecodedLen(len(p)))
+	n, err := base64.StdEncoding.Decode(buf, p)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// TransformRule is a rule of TransformFS. A Pattern that contains no "/" is
+// matched by path.Match against the base name of a file, otherwise against
+// the path of a file. Codecs are the names of registered codecs applied in
+// order on write and in reverse order on read, so []string{"gzip", "aes"}
+// compresses and then encrypts the contents to store.
+type TransformRule struct {
+	Pattern string
+	Codecs  []string
+}
+
+// TransformFS is a filesystem that encodes the contents of files matching
+// rules with a pipeline of codecs on write and decodes them on read. The first
+// matching rule of a file is used. The whole pipeline is applied at once, so
+// CreateFile buffers the contents and writes them on Close.
+//
+// The fs.FileInfo of a transformed file implements EncodedInfo that reports
+// the size stored in the wrapped filesystem. Stat and ReadDir report the size
+// of the decoded contents without reading the file if the wrapped filesystem
+// implements MetadataFS and the file was written by TransformFS with
+// MetaDecodedSize, otherwise they report UnknownSize. The Stat of a file
+// returned by Open always reports the size of the decoded contents.
+type TransformFS struct {
+	*transformFS
+}
+
+var (
+	_ fs.ReadDirFS  = (*TransformFS)(nil)
+	_ fs.ReadFileFS = (*TransformFS)(nil)
+	_ fs.StatFS     = (*TransformFS)(nil)
+	_ fs.SubFS      = (*TransformFS)(nil)
+	_ WriteFileFS   = (*TransformFS)(nil)
+	_ RemoveFileFS  = (*TransformFS)(nil)
+)
+
+// NewTransformFS returns a TransformFS. NewTransformFS returns an error if a
+// pattern is malformed or a codec is not registered.
+func NewTransformFS(fsys fs.FS, rules []TransformRule) (*TransformFS, error) {
+	pipelines := make([][]Codec, len(rules))
+	for i, r := range rules {
+		if _, err := path.Match(r.Pattern, ""); err != nil {
+			return nil, err
+		}
+		for _, name := range r.Codecs {
+			codec, ok := LookupCodec(name)
+			if !ok {
+				return nil, fmt.Errorf("NewTransformFS: codec %q is not registered", name)
+			}
+			pipelines[i] = append(pipelines[i], codec)
+		}
+	}
+	pipeline := func(name string) []Codec {
+		for i, r := range rules {
+			if MatchFile(r.Pattern, name) {
+				return pipelines[i]
+			}
+		}
+		return nil
+	}
+	t := newTransformFS(fsys, func(name string) transformFunc {
+		codecs := pipeline(name)
+		if len(codecs) == 0 {
+			return nil
+		}
+		return func(p []byte) ([]byte, error) {
+			var err error
+			for i := len(codecs) - 1; i >= 0; i-- {
+				if p, err = codecs[i].Decode(name, p); err != nil {
+					return nil, err
+				}
+			}
+			return p, nil
+		}
+	})
+	t.withEncoder(func(name string) transformFunc {
+		codecs := pipeline(name)
+		if len(codecs) == 0 {
+			return nil
+		}
+		return func(p []byte) ([]byte, error) {
+			var err error
+			for _, codec := range codecs {
+				if p, err = codec.Encode(name, p); err != nil {
+					return nil, err
+				}
+			}
+			return p, nil
+		}
+	})
+	return &TransformFS{transformFS: t}, nil
+}
@@ -0,0 +1,233 @@
+package wfs
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func init() {
+	RegisterCodec("test-reverse", &CodecFuncs{
+		EncodeFunc: func(name string, p []byte) ([]byte, error) {
+			return reverseBytes(p), nil
+		},
+		DecodeFunc: func(name string, p []byte) ([]byte, error) {
+			return reverseBytes(p), nil
+		},
+	})
+	RegisterCodec("test-fail", &CodecFuncs{
+		EncodeFunc: func(name string, p []byte) ([]byte, error) {
+			return nil, errors.New("fail")
+		},
+	})
+}
+
+func reverseBytes(p []byte) []byte {
+	r := make([]byte, len(p))
+	for i, b := range p {
+		r[len(p)-1-i] = b
+	}
+	return r
+}
+
+// metaMapWriteFS is newMapWriteFS that implements MetadataFS.
+type metaMapWriteFS struct {
+	*FSDelegator
+	meta map[string]Metadata
+}
+
+func newMetaMapWriteFS(m fstest.MapFS) *metaMapWriteFS {
+	return &metaMapWriteFS{FSDelegator: newMapWriteFS(m), meta: map[string]Metadata{}}
+}
+
+func (fsys *metaMapWriteFS) CreateFileWithMeta(name string, mode fs.FileMode, meta Metadata) (WriterFile, error) {
+	fsys.meta[name] = meta
+	return fsys.CreateFile(name, mode)
+}
+
+func (fsys *metaMapWriteFS) WriteFileWithMeta(name string, p []byte, mode fs.FileMode, meta Metadata) (int, error) {
+	fsys.meta[name] = meta
+	return fsys.WriteFile(name, p, mode)
+}
+
+func (fsys *metaMapWriteFS) StatMeta(name string) (Metadata, error) {
+	return fsys.meta[name], nil
+}
+
+func TestTransformFS(t *testing.T) {
+	m := fstest.MapFS{
+		"plain.txt": {Data: []byte("plain")},
+	}
+	fsys, err := NewTransformFS(newMetaMapWriteFS(m), []TransformRule{
+		{Pattern: "*.log", Codecs: []string{"gzip", "base64"}},
+		{Pattern: "dir/*", Codecs: []string{"test-reverse"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := bytes.Repeat([]byte("log line\n"), 100)
+	if _, err := fsys.WriteFile("app.log", data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.MkdirAll("dir", fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fsys.CreateFile("dir/a.txt", 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := string(m["dir/a.txt"].Data); got != "cba" {
+		t.Errorf("unexpected %q; want %q", got, "cba")
+	}
+	if bytes.Equal(m["app.log"].Data, data) {
+		t.Errorf("unexpected stored contents %q", m["app.log"].Data)
+	}
+
+	if err := fstest.TestFS(fsys, "plain.txt", "app.log", "dir/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string][]byte{
+		"plain.txt": []byte("plain"),
+		"app.log":   data,
+		"dir/a.txt": []byte("abc"),
+	}
+	for name, want := range tests {
+		got, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("unexpected %q; want %q", got, want)
+		}
+	}
+
+	info, err := fsys.Stat("app.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != int64(len(data)) {
+		t.Errorf("unexpected %d; want %d", info.Size(), len(data))
+	}
+	ei, ok := info.(EncodedInfo)
+	if !ok {
+		t.Fatalf("unexpected %T; want EncodedInfo", info)
+	}
+	if got, want := ei.EncodedSize(), int64(len(m["app.log"].Data)); got != want {
+		t.Errorf("unexpected %d; want %d", got, want)
+	}
+	if info, err := fsys.Stat("plain.txt"); err != nil {
+		t.Fatal(err)
+	} else if _, ok := info.(EncodedInfo); ok {
+		t.Errorf("unexpected EncodedInfo of plain.txt")
+	}
+
+	sub, err := fsys.Sub("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := fs.ReadFile(sub, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "abc" {
+		t.Errorf("unexpected %q; want %q", got, "abc")
+	}
+}
+
+func TestTransformFS_unknownSize(t *testing.T) {
+	m := fstest.MapFS{}
+	fsys, err := NewTransformFS(newMapWriteFS(m), []TransformRule{
+		{Pattern: "*.gz", Codecs: []string{"gzip"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("a.gz", []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := fsys.Stat("a.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != UnknownSize {
+		t.Errorf("unexpected %d; want %d", info.Size(), UnknownSize)
+	}
+	entries, err := fsys.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := entries[0].Info(); err != nil {
+		t.Fatal(err)
+	} else if info.Size() != UnknownSize {
+		t.Errorf("unexpected %d; want %d", info.Size(), UnknownSize)
+	}
+
+	f, err := fsys.Open("a.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil {
+		t.Fatal(err)
+	} else if info.Size() != 3 {
+		t.Errorf("unexpected %d; want %d", info.Size(), 3)
+	}
+}
+
+func TestTransformFS_errors(t *testing.T) {
+	if _, err := NewTransformFS(fstest.MapFS{}, []TransformRule{{Pattern: "*", Codecs: []string{"unknown"}}}); err == nil {
+		t.Errorf("unexpected nil error")
+	}
+	if _, err := NewTransformFS(fstest.MapFS{}, []TransformRule{{Pattern: "[", Codecs: []string{"gzip"}}}); err == nil {
+		t.Errorf("unexpected nil error")
+	}
+
+	m := fstest.MapFS{
+		"broken.gz": {Data: []byte("not gzip")},
+	}
+	fsys, err := NewTransformFS(newMapWriteFS(m), []TransformRule{
+		{Pattern: "*.gz", Codecs: []string{"gzip"}},
+		{Pattern: "*.fail", Codecs: []string{"test-fail"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.ReadFile("broken.gz"); err == nil {
+		t.Errorf("unexpected nil error")
+	}
+	if _, err := fsys.WriteFile("a.fail", []byte("a"), 0644); err == nil {
+		t.Errorf("unexpected nil error")
+	}
+	if _, ok := m["a.fail"]; ok {
+		t.Errorf("unexpected a.fail")
+	}
+}
+
+func TestCodecs(t *testing.T) {
+	got := Codecs()
+	want := []string{"base64", "gzip", "test-fail", "test-reverse"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+	if _, ok := LookupCodec("gzip"); !ok {
+		t.Errorf("unexpected not found")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("unexpected no panic")
+		}
+	}()
+	RegisterCodec("gzip", &CodecFuncs{})
+}
@@ -0,0 +1,52 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+)
+
+var (
+	// ErrPreconditionFailed "precondition failed"
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Condition is a precondition of WriteFileIf.
+type Condition struct {
+	// IfNotExists requires that the file does not exist.
+	IfNotExists bool
+	// IfMatch requires that the current version of the file matches IfMatch if
+	// it is not empty.
+	IfMatch string
+}
+
+// ConditionalWriteFS is the interface implemented by a filesystem that provides
+// conditional writes for optimistic concurrency control.
+type ConditionalWriteFS interface {
+	fs.FS
+	// WriteFileIf writes the specified bytes to the named file if cond is
+	// satisfied and returns the new version of the file. If cond is not
+	// satisfied WriteFileIf returns an error wrapping ErrPreconditionFailed.
+	WriteFileIf(name string, p []byte, mode fs.FileMode, cond Condition) (version string, err error)
+	// FileVersion returns the current version of the named file.
+	FileVersion(name string) (string, error)
+}
+
+// WriteFileIf writes the specified bytes to the named file if cond is
+// satisfied. If the filesystem implements ConditionalWriteFS calls
+// fsys.WriteFileIf otherwise returns a PathError.
+func WriteFileIf(fsys fs.FS, name string, p []byte, mode fs.FileMode, cond Condition) (version string, err error) {
+	if fsys, ok := fsys.(ConditionalWriteFS); ok {
+		return fsys.WriteFileIf(name, p, mode, cond)
+	}
+	return "", &fs.PathError{Op: string(OpWriteFileIf), Path: name, Err: ErrNotImplemented}
+}
+
+// FileVersion returns the current version of the named file. If the filesystem
+// implements ConditionalWriteFS calls fsys.FileVersion otherwise returns a
+// PathError.
+func FileVersion(fsys fs.FS, name string) (string, error) {
+	if fsys, ok := fsys.(ConditionalWriteFS); ok {
+		return fsys.FileVersion(name)
+	}
+	return "", &fs.PathError{Op: string(OpFileVersion), Path: name, Err: ErrNotImplemented}
+}
@@ -0,0 +1,59 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+type conditionalWriteFS struct {
+	*FSDelegator
+	version string
+}
+
+func (fsys *conditionalWriteFS) WriteFileIf(name string, p []byte, mode fs.FileMode, cond Condition) (string, error) {
+	if cond.IfMatch != fsys.version {
+		return "", &fs.PathError{Op: "WriteFileIf", Path: name, Err: ErrPreconditionFailed}
+	}
+	fsys.version = string(p)
+	return fsys.version, nil
+}
+
+func (fsys *conditionalWriteFS) FileVersion(name string) (string, error) {
+	return fsys.version, nil
+}
+
+func TestWriteFileIf(t *testing.T) {
+	fsys := &conditionalWriteFS{FSDelegator: &FSDelegator{}, version: "v1"}
+
+	got, err := WriteFileIf(fsys, "test.txt", []byte("v2"), fs.ModePerm, Condition{IfMatch: "v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "v2" {
+		t.Errorf("unexpected %s; want v2", got)
+	}
+	got, err = FileVersion(fsys, "test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "v2" {
+		t.Errorf("unexpected %s; want v2", got)
+	}
+
+	_, err = WriteFileIf(fsys, "test.txt", []byte("v3"), fs.ModePerm, Condition{IfMatch: "v1"})
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("unexpected %v; want %v", err, ErrPreconditionFailed)
+	}
+}
+
+func TestWriteFileIf_ErrNotImplemented(t *testing.T) {
+	fsys := &OpenFSDelegator{}
+
+	if _, err := WriteFileIf(fsys, "test.txt", nil, fs.ModePerm, Condition{}); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotImplemented)
+	}
+	if _, err := FileVersion(fsys, "test.txt"); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotImplemented)
+	}
+}
@@ -0,0 +1,254 @@
+// Package config builds a composed filesystem from a declarative
+// configuration, so that an application can reconfigure its storage stack
+// without code changes. A configuration declares a backend by URL, the
+// wrappers applied to the backend and the filesystems mounted on it:
+//
+//	{
+//	  "backend": "file:///var/data",
+//	  "wrappers": [{"name": "readonly"}],
+//	  "mounts": [
+//	    {"path": "uploads", "backend": "s3://bucket", "wrappers": [{"name": "cache"}]}
+//	  ]
+//	}
+//
+// Backends are looked up by the scheme of the URL and wrappers by the name
+// from registries. The backends "mem" and "file" and the wrappers "readonly"
+// and "sub" are registered by default; the others such as "s3" and "cache"
+// are registered by the application or the packages that provide them.
+//
+// Configurations are read as JSON. The package has no dependencies outside
+// the standard library, so a YAML configuration must be converted to JSON by
+// the application.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/jarxorg/wfs"
+	"github.com/jarxorg/wfs/memfs"
+	"github.com/jarxorg/wfs/osfs"
+)
+
+// Config declares a composed filesystem.
+type Config struct {
+	// Backend is the URL of the backend such as "mem:" or "file:///var/data".
+	Backend string `json:"backend"`
+	// Wrappers are applied to the backend in order, so the first wrapper is
+	// the innermost.
+	Wrappers []Wrapper `json:"wrappers,omitempty"`
+	// Mounts are the filesystems mounted on the wrapped backend.
+	Mounts []Mount `json:"mounts,omitempty"`
+}
+
+// Wrapper declares a wrapper by the registered name and its options.
+type Wrapper struct {
+	Name    string          `json:"name"`
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+// Mount declares a filesystem mounted at Path. The names under Path are
+// resolved by the filesystem of the mount instead of the parent.
+type Mount struct {
+	Path string `json:"path"`
+	Config
+}
+
+// BackendFunc returns the backend of the URL.
+type BackendFunc func(u *url.URL) (fs.FS, error)
+
+// WrapperFunc returns fsys wrapped with the options. The options are nil if
+// the configuration has no options.
+type WrapperFunc func(fsys fs.FS, options json.RawMessage) (fs.FS, error)
+
+var (
+	registryMutex sync.RWMutex
+	backends      = map[string]BackendFunc{}
+	wrappers      = map[string]WrapperFunc{}
+)
+
+func init() {
+	RegisterBackend("mem", memBackend)
+	RegisterBackend("file", fileBackend)
+	RegisterWrapper("readonly", readOnlyWrapper)
+	RegisterWrapper("sub", subWrapper)
+}
+
+// RegisterBackend makes a backend available by the scheme of the URL. If
+// RegisterBackend is called twice with the same scheme or if fn is nil, it
+// panics.
+func RegisterBackend(scheme string, fn BackendFunc) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if fn == nil {
+		panic("config: RegisterBackend fn is nil")
+	}
+	if _, ok := backends[scheme]; ok {
+		panic("config: RegisterBackend called twice for scheme " + scheme)
+	}
+	backends[scheme] = fn
+}
+
+// RegisterWrapper makes a wrapper available by the name. If RegisterWrapper
+// is called twice with the same name or if fn is nil, it panics.
+func RegisterWrapper(name string, fn WrapperFunc) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if fn == nil {
+		panic("config: RegisterWrapper fn is nil")
+	}
+	if _, ok := wrappers[name]; ok {
+		panic("config: RegisterWrapper called twice for wrapper " + name)
+	}
+	wrappers[name] = fn
+}
+
+// Backends returns a sorted list of the registered schemes.
+func Backends() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	schemes := make([]string, 0, len(backends))
+	for scheme := range backends {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Wrappers returns a sorted list of the names of the registered wrappers.
+func Wrappers() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	names := make([]string, 0, len(wrappers))
+	for name := range wrappers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupBackend(scheme string) (BackendFunc, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	fn, ok := backends[scheme]
+	return fn, ok
+}
+
+func lookupWrapper(name string) (WrapperFunc, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	fn, ok := wrappers[name]
+	return fn, ok
+}
+
+// Load reads a JSON configuration from r. Unknown fields are errors so that a
+// misspelled key is not ignored silently.
+func Load(r io.Reader) (*Config, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	cfg := &Config{}
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	return cfg, nil
+}
+
+// Build returns the filesystem declared by cfg. The backends of cfg and the
+// mounts are created, the wrappers are applied and the mounts are mounted on
+// the wrapped backend.
+func Build(cfg *Config) (fs.FS, error) {
+	u, err := url.Parse(cfg.Backend)
+	if err != nil {
+		return nil, fmt.Errorf("config: backend %q: %w", cfg.Backend, err)
+	}
+	newBackend, ok := lookupBackend(u.Scheme)
+	if !ok {
+		return nil, fmt.Errorf("config: backend scheme %q is not registered", u.Scheme)
+	}
+	fsys, err := newBackend(u)
+	if err != nil {
+		return nil, fmt.Errorf("config: backend %q: %w", cfg.Backend, err)
+	}
+	for _, w := range cfg.Wrappers {
+		wrap, ok := lookupWrapper(w.Name)
+		if !ok {
+			return nil, fmt.Errorf("config: wrapper %q is not registered", w.Name)
+		}
+		if fsys, err = wrap(fsys, w.Options); err != nil {
+			return nil, fmt.Errorf("config: wrapper %q: %w", w.Name, err)
+		}
+	}
+	if len(cfg.Mounts) == 0 {
+		return fsys, nil
+	}
+
+	mounts := make([]mount, len(cfg.Mounts))
+	seen := map[string]bool{}
+	for i, m := range cfg.Mounts {
+		dir := path.Clean(m.Path)
+		if !fs.ValidPath(dir) || dir == "." {
+			return nil, fmt.Errorf("config: invalid mount path %q", m.Path)
+		}
+		if seen[dir] {
+			return nil, fmt.Errorf("config: duplicate mount path %q", m.Path)
+		}
+		seen[dir] = true
+		sub, err := Build(&m.Config)
+		if err != nil {
+			return nil, err
+		}
+		mounts[i] = mount{dir: dir, fsys: sub}
+	}
+	return newMountFS(fsys, mounts), nil
+}
+
+// memBackend returns a new memfs.MemFS for "mem:". The root directory is
+// created so that an empty backend can be mounted.
+func memBackend(u *url.URL) (fs.FS, error) {
+	fsys := memfs.New()
+	if err := fsys.MkdirAll(".", fs.ModePerm); err != nil {
+		return nil, err
+	}
+	return fsys, nil
+}
+
+// fileBackend returns an osfs.OSFS of the directory of "file:///abs/dir" or
+// "file:rel/dir".
+func fileBackend(u *url.URL) (fs.FS, error) {
+	dir := u.Opaque
+	if dir == "" {
+		dir = u.Host + u.Path
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("no directory")
+	}
+	return osfs.New(dir), nil
+}
+
+// readOnlyWrapper rejects writes by wfs.ErrReadOnly.
+func readOnlyWrapper(fsys fs.FS, options json.RawMessage) (fs.FS, error) {
+	return wfs.Wrap(fsys, wfs.WithReadOnly()), nil
+}
+
+// subWrapper returns the subtree of {"dir": "..."}.
+func subWrapper(fsys fs.FS, options json.RawMessage) (fs.FS, error) {
+	var opts struct {
+		Dir string `json:"dir"`
+	}
+	if err := json.Unmarshal(options, &opts); err != nil {
+		return nil, err
+	}
+	return fs.Sub(fsys, opts.Dir)
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jarxorg/wfs"
+	"github.com/jarxorg/wfs/memfs"
+)
+
+func TestLoad(t *testing.T) {
+	cfg, err := Load(strings.NewReader(`{
+  "backend": "mem:",
+  "wrappers": [{"name": "sub", "options": {"dir": "root"}}],
+  "mounts": [{"path": "uploads", "backend": "mem:", "wrappers": [{"name": "readonly"}]}]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Config{
+		Backend:  "mem:",
+		Wrappers: []Wrapper{{Name: "sub", Options: json.RawMessage(`{"dir": "root"}`)}},
+		Mounts: []Mount{{
+			Path:   "uploads",
+			Config: Config{Backend: "mem:", Wrappers: []Wrapper{{Name: "readonly"}}},
+		}},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf(`Error unexpected %#v; want %#v`, cfg, want)
+	}
+
+	if _, err := Load(strings.NewReader(`{"backend": "mem:", "wraper": []}`)); err == nil {
+		t.Errorf(`Error no error with an unknown field`)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	uploads := memfs.New()
+	RegisterBackend("test-uploads", func(u *url.URL) (fs.FS, error) {
+		return uploads, nil
+	})
+	cfg := &Config{
+		Backend: "mem:",
+		Mounts: []Mount{
+			{Path: "uploads", Config: Config{Backend: "test-uploads:"}},
+			{Path: "static/assets", Config: Config{Backend: "mem:", Wrappers: []Wrapper{{Name: "readonly"}}}},
+		},
+	}
+	fsys, err := Build(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wfs.WriteFile(fsys, "index.html", []byte("index"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wfs.WriteFile(fsys, "uploads/a.txt", []byte("a"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := fs.ReadFile(uploads, "a.txt"); err != nil || string(p) != "a" {
+		t.Errorf(`Error unexpected %q, %v; want "a"`, p, err)
+	}
+	_, err = wfs.WriteFile(fsys, "static/assets/app.js", []byte("js"), fs.ModePerm)
+	if !errors.Is(err, wfs.ErrReadOnly) {
+		t.Errorf(`Error unexpected %v; want %v`, err, wfs.ErrReadOnly)
+	}
+	var pe *fs.PathError
+	if !errors.As(err, &pe) || pe.Path != "static/assets/app.js" {
+		t.Errorf(`Error unexpected %v; want the path on the mounted filesystem`, err)
+	}
+
+	if err := fstest.TestFS(fsys, "index.html", "uploads/a.txt", "static/assets"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"index.html", "static", "uploads"}; !reflect.DeepEqual(names, want) {
+		t.Errorf(`Error unexpected %v; want %v`, names, want)
+	}
+}
+
+func TestBuild_errors(t *testing.T) {
+	tests := map[string]*Config{
+		`backend scheme "unknown" is not registered`: {Backend: "unknown:"},
+		`wrapper "unknown" is not registered`:        {Backend: "mem:", Wrappers: []Wrapper{{Name: "unknown"}}},
+		`invalid mount path "../x"`:                  {Backend: "mem:", Mounts: []Mount{{Path: "../x", Config: Config{Backend: "mem:"}}}},
+		`duplicate mount path "a/"`: {Backend: "mem:", Mounts: []Mount{
+			{Path: "a", Config: Config{Backend: "mem:"}},
+			{Path: "a/", Config: Config{Backend: "mem:"}},
+		}},
+	}
+	for want, cfg := range tests {
+		_, err := Build(cfg)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf(`Error unexpected %v; want %s`, err, want)
+		}
+	}
+}
+
+func TestRegisterBackend_panic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf(`Error no panic`)
+		}
+	}()
+	RegisterBackend("mem", memBackend)
+}
+
+func TestRegistries(t *testing.T) {
+	for _, name := range []string{"file", "mem"} {
+		if _, ok := lookupBackend(name); !ok {
+			t.Errorf(`Error backend %s is not registered`, name)
+		}
+	}
+	if got, want := Wrappers(), []string{"readonly", "sub"}; !reflect.DeepEqual(got, want) {
+		t.Errorf(`Error unexpected %v; want %v`, got, want)
+	}
+}
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/jarxorg/wfs"
+)
+
+// mount is a filesystem mounted at dir.
+type mount struct {
+	dir  string
+	fsys fs.FS
+}
+
+// mountFS resolves the names under the directories of the mounts by the
+// filesystems of the mounts and the other names by root. The parent
+// directories of the mounts exist even if root does not have them.
+type mountFS struct {
+	root   fs.FS
+	mounts []mount
+}
+
+var (
+	_ fs.ReadDirFS     = (*mountFS)(nil)
+	_ fs.StatFS        = (*mountFS)(nil)
+	_ wfs.WriteFileFS  = (*mountFS)(nil)
+	_ wfs.RemoveFileFS = (*mountFS)(nil)
+)
+
+// newMountFS returns a mountFS. The mounts are sorted by the length of dir in
+// descending order so that the deepest mount resolves a name first.
+func newMountFS(root fs.FS, mounts []mount) *mountFS {
+	sort.SliceStable(mounts, func(i, j int) bool {
+		return len(mounts[i].dir) > len(mounts[j].dir)
+	})
+	return &mountFS{root: root, mounts: mounts}
+}
+
+// resolve returns the filesystem and the name on it of the named file and
+// whether the filesystem is of a mount.
+func (fsys *mountFS) resolve(name string) (fs.FS, string, bool) {
+	for _, m := range fsys.mounts {
+		if name == m.dir {
+			return m.fsys, ".", true
+		}
+		if strings.HasPrefix(name, m.dir+"/") {
+			return m.fsys, name[len(m.dir)+1:], true
+		}
+	}
+	return fsys.root, name, false
+}
+
+// mountDirs returns the sorted names of the children of the named directory
+// that are mounts or the parent directories of mounts.
+func (fsys *mountFS) mountDirs(dir string) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, m := range fsys.mounts {
+		rest := m.dir
+		if dir != "." {
+			if !strings.HasPrefix(m.dir, dir+"/") {
+				continue
+			}
+			rest = m.dir[len(dir)+1:]
+		}
+		if i := strings.Index(rest, "/"); i != -1 {
+			rest = rest[:i]
+		}
+		if !seen[rest] {
+			seen[rest] = true
+			names = append(names, rest)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open opens the named file.
+func (fsys *mountFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: string(wfs.OpOpen), Path: name, Err: fs.ErrInvalid}
+	}
+	if sub, rel, ok := fsys.resolve(name); ok {
+		f, err := sub.Open(rel)
+		if err != nil {
+			return nil, wfs.PathError(wfs.OpOpen, name, err)
+		}
+		if rel != "." {
+			return f, nil
+		}
+		d := wfs.DelegateFile(f)
+		d.StatFunc = func() (fs.FileInfo, error) {
+			info, err := f.Stat()
+			if err != nil {
+				return nil, err
+			}
+			return mountInfo(name, info), nil
+		}
+		return d, nil
+	}
+	if len(fsys.mountDirs(name)) == 0 {
+		return fsys.root.Open(name)
+	}
+	info, err := fsys.stat(wfs.OpOpen, name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fsys.readDir(wfs.OpOpen, name)
+	if err != nil {
+		return nil, err
+	}
+	return wfs.NewDirFile(info, entries), nil
+}
+
+// mountInfo returns info of the root of a mount named by the base name of the
+// mount instead of ".".
+func mountInfo(dir string, info fs.FileInfo) fs.FileInfo {
+	d := wfs.DelegateFileInfo(info)
+	d.Values.Name = path.Base(dir)
+	return d
+}
+
+// Stat returns a fs.FileInfo describing the named file.
+func (fsys *mountFS) Stat(name string) (fs.FileInfo, error) {
+	return fsys.stat(wfs.OpStat, name)
+}
+
+func (fsys *mountFS) stat(op wfs.Op, name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
+	}
+	sub, rel, _ := fsys.resolve(name)
+	info, err := fs.Stat(sub, rel)
+	if err == nil {
+		if rel == "." && name != "." {
+			return mountInfo(name, info), nil
+		}
+		return info, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) && sub == fsys.root && len(fsys.mountDirs(name)) > 0 {
+		return &wfs.FileInfoDelegator{
+			Values: wfs.FileInfoValues{
+				Name:  path.Base(name),
+				Mode:  fs.ModeDir | 0555,
+				IsDir: true,
+			},
+		}, nil
+	}
+	return nil, wfs.PathError(op, name, err)
+}
+
+// ReadDir reads the named directory. The entries of the mounts and their
+// parent directories replace the entries of root.
+func (fsys *mountFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	return fsys.readDir(wfs.OpReadDir, dir)
+}
+
+func (fsys *mountFS) readDir(op wfs.Op, dir string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(dir) {
+		return nil, &fs.PathError{Op: string(op), Path: dir, Err: fs.ErrInvalid}
+	}
+	sub, rel, ok := fsys.resolve(dir)
+	entries, err := fs.ReadDir(sub, rel)
+	if ok {
+		return entries, wfs.PathError(op, dir, err)
+	}
+	names := fsys.mountDirs(dir)
+	if err != nil && (len(names) == 0 || !errors.Is(err, fs.ErrNotExist)) {
+		return nil, wfs.PathError(op, dir, err)
+	}
+
+	merged := map[string]fs.DirEntry{}
+	for _, e := range entries {
+		merged[e.Name()] = e
+	}
+	for _, name := range names {
+		info, err := fsys.stat(op, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		merged[name] = &wfs.DirEntryDelegator{
+			Values: wfs.DirEntryValues{
+				Name:  name,
+				IsDir: info.IsDir(),
+				Type:  info.Mode().Type(),
+				Info:  info,
+			},
+		}
+	}
+	entries = make([]fs.DirEntry, 0, len(merged))
+	for _, e := range merged {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// MkdirAll creates the named directory on the filesystem that resolves dir.
+func (fsys *mountFS) MkdirAll(dir string, mode fs.FileMode) error {
+	sub, rel, _ := fsys.resolve(dir)
+	return wfs.PathError(wfs.OpMkdirAll, dir, wfs.MkdirAll(sub, rel, mode))
+}
+
+// CreateFile creates the named file on the filesystem that resolves name.
+func (fsys *mountFS) CreateFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+	sub, rel, _ := fsys.resolve(name)
+	f, err := wfs.CreateFile(sub, rel, mode)
+	return f, wfs.PathError(wfs.OpCreateFile, name, err)
+}
+
+// WriteFile writes the specified bytes to the named file on the filesystem
+// that resolves name.
+func (fsys *mountFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	sub, rel, _ := fsys.resolve(name)
+	n, err := wfs.WriteFile(sub, rel, p, mode)
+	return n, wfs.PathError(wfs.OpWriteFile, name, err)
+}
+
+// RemoveFile removes the named file on the filesystem that resolves name.
+func (fsys *mountFS) RemoveFile(name string) error {
+	sub, rel, _ := fsys.resolve(name)
+	return wfs.PathError(wfs.OpRemoveFile, name, wfs.RemoveFile(sub, rel))
+}
+
+// RemoveAll removes the named directory and its contents on the filesystem
+// that resolves dir. The mounts under dir are not removed.
+func (fsys *mountFS) RemoveAll(dir string) error {
+	sub, rel, _ := fsys.resolve(dir)
+	return wfs.PathError(wfs.OpRemoveAll, dir, wfs.RemoveAll(sub, rel))
+}
@@ -0,0 +1,46 @@
+package wfs
+
+import (
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+)
+
+// sniffLen is the number of bytes that are used to sniff Content-Type.
+const sniffLen = 512
+
+// detectContentType returns the Content-Type of the named file by the
+// extension of the name or by sniffing p.
+func detectContentType(name string, p []byte) string {
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		return contentType
+	}
+	if len(p) > sniffLen {
+		p = p[:sniffLen]
+	}
+	return http.DetectContentType(p)
+}
+
+// DetectContentType returns the Content-Type of the named file. The type of
+// the extension of the name is preferred, otherwise the first 512 bytes of the
+// file are sniffed by http.DetectContentType that returns
+// "application/octet-stream" if no specific type is detected.
+func DetectContentType(fsys fs.FS, name string) (string, error) {
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		return contentType, nil
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", &fs.PathError{Op: "DetectContentType", Path: name, Err: err}
+	}
+	return detectContentType(name, buf[:n]), nil
+}
@@ -0,0 +1,65 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDetectContentType(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html": {Data: []byte("plain")},
+		"page":       {Data: []byte("<!DOCTYPE html><html></html>")},
+		"image":      {Data: []byte("\x89PNG\x0D\x0A\x1A\x0A")},
+		"data":       {Data: []byte{0x00, 0x01, 0x02}},
+		"empty":      {},
+	}
+	testCases := map[string]string{
+		"index.html": "text/html; charset=utf-8",
+		"page":       "text/html; charset=utf-8",
+		"image":      "image/png",
+		"data":       "application/octet-stream",
+		"empty":      "text/plain; charset=utf-8",
+	}
+	for name, want := range testCases {
+		got, err := DetectContentType(fsys, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s: unexpected %s; want %s", name, got, want)
+		}
+	}
+	if _, err := DetectContentType(fsys, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestWriteOptions_DefaultContentType(t *testing.T) {
+	fsys := &metadataFS{FSDelegator: &FSDelegator{}, meta: map[string]Metadata{}}
+
+	if _, err := CreateFileWithOptions(fsys, "a.css", fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateFileWithOptions(fsys, "b", fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteFileWithOptions(fsys, "c", []byte("%PDF-1.7"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteFileWithOptions(fsys, "d.css", []byte{}, fs.ModePerm, WithContentType("text/plain")); err != nil {
+		t.Fatal(err)
+	}
+	testCases := map[string]string{
+		"a.css": "text/css; charset=utf-8",
+		"b":     "",
+		"c":     "application/pdf",
+		"d.css": "text/plain",
+	}
+	for name, want := range testCases {
+		if got := fsys.meta[name][MetaContentType]; got != want {
+			t.Errorf("%s: unexpected %q; want %q", name, got, want)
+		}
+	}
+}
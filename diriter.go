@@ -0,0 +1,115 @@
+package wfs
+
+import (
+	"io"
+	"io/fs"
+)
+
+// dirIterBatch is the number of entries read by a ReadDir call of DirIterator.
+const dirIterBatch = 100
+
+// DirIterator reads entries of a directory one by one using
+// fs.ReadDirFile.ReadDir(n) paging, so a huge directory can be processed with
+// constant memory. The entries are returned in the order of ReadDir of the
+// filesystem that is not always sorted by filename.
+type DirIterator struct {
+	f       fs.ReadDirFile
+	name    string
+	entries []fs.DirEntry
+	index   int
+	err     error
+}
+
+// DirIter opens the named directory and returns a DirIterator. The caller
+// must call Close of the DirIterator.
+func DirIter(fsys fs.FS, name string) (*DirIterator, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := f.(fs.ReadDirFile)
+	if !ok {
+		f.Close()
+		return nil, &fs.PathError{Op: "DirIter", Path: name, Err: ErrNotImplemented}
+	}
+	return &DirIterator{f: d, name: name}, nil
+}
+
+// Next returns the next entry of the directory. Next returns io.EOF if there
+// are no more entries.
+func (it *DirIterator) Next() (fs.DirEntry, error) {
+	for it.index >= len(it.entries) {
+		if it.err != nil {
+			return nil, it.err
+		}
+		it.entries, it.err = it.f.ReadDir(dirIterBatch)
+		it.index = 0
+		if len(it.entries) == 0 && it.err == nil {
+			// NOTE: ReadDir(n > 0) returns io.EOF at the end of the directory.
+			it.err = io.EOF
+		}
+	}
+	e := it.entries[it.index]
+	it.index++
+	return e, nil
+}
+
+// Close closes the directory.
+func (it *DirIterator) Close() error {
+	return it.f.Close()
+}
+
+// ReadDirPageFS is the interface implemented by a filesystem that can read a
+// directory page by page without loading all of the entries at once.
+type ReadDirPageFS interface {
+	fs.FS
+	// ReadDirPage calls fn with at most n entries of the named directory at a
+	// time until the end of the directory. The entries are passed in the order
+	// of the filesystem that is not always sorted by filename. If fn returns
+	// an error ReadDirPage stops and returns the error.
+	ReadDirPage(dir string, n int, fn func(entries []fs.DirEntry) error) error
+}
+
+// ReadDirPage calls fn with at most n entries of the named directory at a
+// time. If the filesystem implements ReadDirPageFS calls fsys.ReadDirPage,
+// otherwise pages the directory with fs.ReadDirFile.ReadDir(n) of the file
+// returned by Open. If n <= 0, the default page size is used.
+func ReadDirPage(fsys fs.FS, dir string, n int, fn func(entries []fs.DirEntry) error) error {
+	if n <= 0 {
+		n = dirIterBatch
+	}
+	if fsys, ok := fsys.(ReadDirPageFS); ok {
+		return fsys.ReadDirPage(dir, n, fn)
+	}
+	f, err := fsys.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	d, ok := f.(fs.ReadDirFile)
+	if !ok {
+		return &fs.PathError{Op: string(OpReadDirPage), Path: dir, Err: ErrNotImplemented}
+	}
+	return ReadDirFilePage(d, n, fn)
+}
+
+// ReadDirFilePage calls fn with the entries returned by each d.ReadDir(n)
+// until the end of the directory. ReadDirFilePage is useful to implement
+// ReadDirPageFS.
+func ReadDirFilePage(d fs.ReadDirFile, n int, fn func(entries []fs.DirEntry) error) error {
+	for {
+		entries, err := d.ReadDir(n)
+		if len(entries) > 0 {
+			if err := fn(entries); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == nil && len(entries) == 0 {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package wfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDirIter(t *testing.T) {
+	m := fstest.MapFS{}
+	var want []string
+	for i := 0; i < dirIterBatch*2+1; i++ {
+		name := fmt.Sprintf("%04d.txt", i)
+		m["dir/"+name] = &fstest.MapFile{}
+		want = append(want, name)
+	}
+
+	it, err := DirIter(m, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+
+	var got []string
+	for {
+		e, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, e.Name())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %d entries; want %d", len(got), len(want))
+	}
+	if _, err := it.Next(); err != io.EOF {
+		t.Errorf("unexpected %v; want %v", err, io.EOF)
+	}
+}
+
+func TestDirIter_Errors(t *testing.T) {
+	m := fstest.MapFS{"file.txt": {}}
+	if _, err := DirIter(m, "not-found"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	fsys := &OpenFSDelegator{
+		OpenFunc: func(name string) (fs.File, error) {
+			return NewBytesFile(nil, nil), nil
+		},
+	}
+	if _, err := DirIter(fsys, "dir"); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotImplemented)
+	}
+}
+
+func TestReadDirPage(t *testing.T) {
+	m := fstest.MapFS{}
+	for i := 0; i < 5; i++ {
+		m[fmt.Sprintf("dir/%d.txt", i)] = &fstest.MapFile{}
+	}
+
+	var sizes []int
+	err := ReadDirPage(m, "dir", 2, func(entries []fs.DirEntry) error {
+		sizes = append(sizes, len(entries))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{2, 2, 1}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("unexpected %v; want %v", sizes, want)
+	}
+
+	wantErr := errors.New("test")
+	calls := 0
+	err = ReadDirPage(m, "dir", 2, func(entries []fs.DirEntry) error {
+		calls++
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) || calls != 1 {
+		t.Errorf("unexpected %v with %d calls; want %v with 1 call", err, calls, wantErr)
+	}
+
+	if err := ReadDirPage(m, "not-found", 2, func([]fs.DirEntry) error { return nil }); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+}
@@ -0,0 +1,64 @@
+package wfs
+
+import (
+	"encoding/json"
+	"io/fs"
+)
+
+// ReadDocument reads the named file and decodes the content into v by
+// unmarshal such as json.Unmarshal or yaml.Unmarshal of gopkg.in/yaml.v3.
+// Decoding errors are returned as a PathError of the name.
+func ReadDocument(fsys fs.FS, name string, v interface{}, unmarshal func([]byte, interface{}) error) error {
+	p, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	if err := unmarshal(p, v); err != nil {
+		return &fs.PathError{Op: "ReadDocument", Path: name, Err: err}
+	}
+	return nil
+}
+
+// WriteDocument encodes v by marshal such as json.Marshal or yaml.Marshal of
+// gopkg.in/yaml.v3 and writes the content to the named file. The file is not
+// created if v cannot be encoded. The content is written to a file created by
+// CreateFile and the file is aborted by Abort if writing fails, so the
+// document is replaced atomically on a filesystem that publishes a file on
+// Close such as memfs and object storages.
+func WriteDocument(fsys fs.FS, name string, v interface{}, mode fs.FileMode, marshal func(interface{}) ([]byte, error)) error {
+	p, err := marshal(v)
+	if err != nil {
+		return &fs.PathError{Op: "WriteDocument", Path: name, Err: err}
+	}
+	f, err := CreateFile(fsys, name, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(p); err != nil {
+		Abort(fsys, name, f)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		Abort(fsys, name, f)
+		return err
+	}
+	return nil
+}
+
+// ReadJSON reads the named JSON file and decodes the content into v.
+func ReadJSON(fsys fs.FS, name string, v interface{}) error {
+	return ReadDocument(fsys, name, v, json.Unmarshal)
+}
+
+// WriteJSON writes v to the named file as indented JSON like WriteDocument.
+func WriteJSON(fsys fs.FS, name string, v interface{}, mode fs.FileMode) error {
+	return WriteDocument(fsys, name, v, mode, marshalIndentJSON)
+}
+
+func marshalIndentJSON(v interface{}) ([]byte, error) {
+	p, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(p, '\n'), nil
+}
@@ -0,0 +1,75 @@
+package wfs
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+type documentTest struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestReadWriteJSON(t *testing.T) {
+	m := fstest.MapFS{}
+	fsys := newMapWriteFS(m)
+	want := documentTest{Name: "a", Count: 1}
+	if err := WriteJSON(fsys, "config.json", want, 0644); err != nil {
+		t.Fatal(err)
+	}
+	wantData := "{\n  \"name\": \"a\",\n  \"count\": 1\n}\n"
+	if got := string(m["config.json"].Data); got != wantData {
+		t.Errorf("unexpected %q; want %q", got, wantData)
+	}
+	var got documentTest
+	if err := ReadJSON(fsys, "config.json", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+
+	if err := ReadJSON(fsys, "missing.json", &got); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	m["invalid.json"] = &fstest.MapFile{Data: []byte("{")}
+	err := ReadJSON(fsys, "invalid.json", &got)
+	var pe *fs.PathError
+	if !errors.As(err, &pe) || pe.Path != "invalid.json" {
+		t.Errorf("unexpected %v", err)
+	}
+}
+
+func TestWriteDocument_Errors(t *testing.T) {
+	m := fstest.MapFS{"config.json": {Data: []byte("old")}}
+	fsys := newMapWriteFS(m)
+
+	if err := WriteJSON(fsys, "config.json", make(chan int), 0644); err == nil {
+		t.Errorf("no error")
+	}
+	if got := string(m["config.json"].Data); got != "old" {
+		t.Errorf("unexpected %s; want old", got)
+	}
+
+	errWrite := errors.New("write")
+	aborted := false
+	fsys.CreateFileFunc = func(name string, mode fs.FileMode) (WriterFile, error) {
+		return &FileDelegator{
+			WriteFunc: func(p []byte) (int, error) { return 0, errWrite },
+			CloseFunc: func() error { return nil },
+		}, nil
+	}
+	fsys.RemoveFileFunc = func(name string) error {
+		aborted = true
+		return nil
+	}
+	if err := WriteDocument(fsys, "config.json", 1, 0644, json.Marshal); !errors.Is(err, errWrite) {
+		t.Errorf("unexpected %v; want %v", err, errWrite)
+	}
+	if !aborted {
+		t.Errorf("unexpected not aborted")
+	}
+}
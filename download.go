@@ -0,0 +1,311 @@
+package wfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sync"
+	"time"
+)
+
+// defaultChunkSize is the default size of the chunks of Download.
+const defaultChunkSize = 8 << 20
+
+// DownloadOption is an option for Download.
+type DownloadOption func(o *downloadOptions)
+
+type downloadOptions struct {
+	chunkSize int64
+	workers   int
+}
+
+// WithChunkSize sets the size of the chunks downloaded by Download. The
+// default is 8 MiB.
+func WithChunkSize(n int64) DownloadOption {
+	return func(o *downloadOptions) {
+		o.chunkSize = n
+	}
+}
+
+// WithDownloadWorkers sets the number of chunks downloaded in parallel by
+// Download. The default is 4.
+func WithDownloadWorkers(n int) DownloadOption {
+	return func(o *downloadOptions) {
+		o.workers = n
+	}
+}
+
+// downloadState identifies the source file of a download and records the
+// chunks written to its part file.
+type downloadState struct {
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"modTime"`
+	ChunkSize int64     `json:"chunkSize"`
+	// Part is the name of the part file that holds the chunks of Done.
+	Part string `json:"part"`
+	// Done is the indexes of the chunks written to Part.
+	Done []int `json:"done,omitempty"`
+}
+
+func (s *downloadState) equal(o *downloadState) bool {
+	return s.Size == o.Size && s.ModTime.Equal(o.ModTime) && s.ChunkSize == o.ChunkSize
+}
+
+// Download copies the file srcName on src to the file destName on dest in
+// chunks read in parallel by OpenRange, so a large file on a filesystem that
+// implements RangeReaderFS is downloaded by parallel range requests.
+//
+// The chunks are written by WriteAt to the part file destName+".part" on dest
+// that is moved to destName by MoveFile when all of the chunks are written.
+// If Download fails or ctx is canceled, the indexes of the written chunks are
+// recorded in the state file destName+".part.json", and calling Download
+// again resumes with the chunks already written unless the size or the
+// modification time of the source file has changed. A resumed download
+// writes the part file destName+".part.resume" instead, because CreateFile
+// truncates the part file of the previous call, and copies the written chunks
+// from the previous part file. The state file and the previous part file are
+// removed after a successful download.
+//
+// Download holds at most one chunk per worker in memory. A file whose size is
+// UnknownSize or whose part file does not implement WriterAtFile is streamed
+// sequentially to destName without chunks.
+func Download(ctx context.Context, dest WriteFileFS, destName string, src fs.FS, srcName string, opts ...DownloadOption) error {
+	o := &downloadOptions{chunkSize: defaultChunkSize, workers: 4}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.chunkSize <= 0 || o.workers <= 0 {
+		return &fs.PathError{Op: "Download", Path: srcName, Err: fs.ErrInvalid}
+	}
+	info, err := fs.Stat(src, srcName)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return &fs.PathError{Op: "Download", Path: srcName, Err: fs.ErrInvalid}
+	}
+	mode := info.Mode().Perm()
+	if info.Size() == UnknownSize {
+		return copyFileTo(dest, destName, src, srcName, mode)
+	}
+
+	d := &download{
+		dest:      dest,
+		statePath: destName + ".part.json",
+		src:       src,
+		srcName:   srcName,
+		state: &downloadState{
+			Size:      info.Size(),
+			ModTime:   info.ModTime(),
+			ChunkSize: o.chunkSize,
+			Part:      destName + ".part",
+		},
+	}
+	d.loadPrev()
+	if d.prev != nil && d.prev.Part == d.state.Part {
+		d.state.Part = destName + ".part.resume"
+	}
+	if err := dest.MkdirAll(path.Dir(destName), fs.ModePerm); err != nil {
+		return err
+	}
+	f, err := dest.CreateFile(d.state.Part, mode)
+	if err != nil {
+		return err
+	}
+	if _, ok := f.(WriterAtFile); !ok {
+		Abort(dest, d.state.Part, f)
+		if err := copyFileTo(dest, destName, src, srcName, mode); err != nil {
+			return err
+		}
+		return d.cleanup()
+	}
+
+	n := int((d.state.Size + o.chunkSize - 1) / o.chunkSize)
+	done := make([]bool, n)
+	var mutex sync.Mutex
+	err = forEachChunk(ctx, n, o.workers, func(i int) error {
+		if err := d.writeChunk(f, i); err != nil {
+			return err
+		}
+		mutex.Lock()
+		done[i] = true
+		mutex.Unlock()
+		return nil
+	})
+	if err != nil {
+		// NOTE: Keep the previous state if the part file is not stored.
+		if f.Close() == nil {
+			for i, ok := range done {
+				if ok {
+					d.state.Done = append(d.state.Done, i)
+				}
+			}
+			if d.saveState() == nil && d.prev != nil {
+				RemoveFile(dest, d.prev.Part)
+			}
+		}
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := MoveFile(dest, d.state.Part, destName); err != nil {
+		return err
+	}
+	return d.cleanup()
+}
+
+// download is a Download in progress.
+type download struct {
+	dest      WriteFileFS
+	statePath string
+	src       fs.FS
+	srcName   string
+	state     *downloadState
+	// prev is the state of the previous Download of the same source file.
+	prev     *downloadState
+	prevDone map[int]bool
+}
+
+// chunkRange returns the offset and the length of the chunk i.
+func (d *download) chunkRange(i int) (int64, int64) {
+	off := int64(i) * d.state.ChunkSize
+	length := d.state.Size - off
+	if length > d.state.ChunkSize {
+		length = d.state.ChunkSize
+	}
+	return off, length
+}
+
+// loadPrev loads the state of the previous Download if it has the same source
+// file, otherwise removes the state and its part file.
+func (d *download) loadPrev() {
+	p, err := fs.ReadFile(d.dest, d.statePath)
+	if err != nil {
+		return
+	}
+	stored := &downloadState{}
+	if json.Unmarshal(p, stored) != nil || !stored.equal(d.state) || stored.Part == "" {
+		if stored.Part != "" {
+			RemoveFile(d.dest, stored.Part)
+		}
+		RemoveFile(d.dest, d.statePath)
+		return
+	}
+	d.prev = stored
+	d.prevDone = map[int]bool{}
+	for _, i := range stored.Done {
+		d.prevDone[i] = true
+	}
+}
+
+func (d *download) saveState() error {
+	p, err := json.Marshal(d.state)
+	if err != nil {
+		return err
+	}
+	_, err = d.dest.WriteFile(d.statePath, p, 0600)
+	return err
+}
+
+// cleanup removes the state file and the part file of the previous Download.
+func (d *download) cleanup() error {
+	if d.prev == nil {
+		return nil
+	}
+	if err := RemoveFile(d.dest, d.prev.Part); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := RemoveFile(d.dest, d.statePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// writeChunk writes the chunk i to f at its offset. The chunk is copied from
+// the previous part file if it was written there, otherwise read from the
+// source file.
+func (d *download) writeChunk(f WriterFile, i int) error {
+	off, length := d.chunkRange(i)
+	if d.prevDone[i] {
+		// NOTE: Read the chunk from the source if the previous part file is
+		// short or lost.
+		if p, err := readChunk(d.dest, d.prev.Part, off, length); err == nil {
+			_, err = WriteAt(f, p, off)
+			return err
+		}
+	}
+	p, err := readChunk(d.src, d.srcName, off, length)
+	if err != nil {
+		return err
+	}
+	_, err = WriteAt(f, p, off)
+	return err
+}
+
+// readChunk reads length bytes from the offset off of the named file.
+func readChunk(fsys fs.FS, name string, off, length int64) ([]byte, error) {
+	r, err := OpenRange(fsys, name, off, length)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	p, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(p)) != length {
+		return nil, &fs.PathError{Op: "Download", Path: name, Err: io.ErrUnexpectedEOF}
+	}
+	return p, nil
+}
+
+// forEachChunk calls fn with 0 to n-1 by the workers. The first error stops
+// the remaining calls and is returned.
+func forEachChunk(ctx context.Context, n, workers int, fn func(i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	indexes := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := fn(i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+loop:
+	for i := 0; i < n; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,195 @@
+package wfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// rangeFS counts OpenRange and fails the ranges at the offsets of fail once.
+type rangeFS struct {
+	fstest.MapFS
+	mutex sync.Mutex
+	calls int
+	fail  map[int64]bool
+}
+
+var errRange = errors.New("range failed")
+
+func (fsys *rangeFS) OpenRange(name string, off, length int64) (io.ReadCloser, error) {
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	fsys.calls++
+	if fsys.fail[off] {
+		delete(fsys.fail, off)
+		return nil, errRange
+	}
+	return OpenRange(fsys.MapFS, name, off, length)
+}
+
+func newDownloadDest(m fstest.MapFS) *FSDelegator {
+	d := newAbortMapWriteFS(m)
+	d.RemoveAllFunc = func(dir string) error {
+		for name := range m {
+			if name == dir || strings.HasPrefix(name, dir+"/") {
+				delete(m, name)
+			}
+		}
+		return nil
+	}
+	return d
+}
+
+func TestDownload(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 10)
+	src := &rangeFS{
+		MapFS: fstest.MapFS{"big.bin": {Data: data, Mode: 0640}},
+		fail:  map[int64]bool{32: true},
+	}
+	m := fstest.MapFS{}
+	dest := newDownloadDest(m)
+	ctx := context.Background()
+
+	err := Download(ctx, dest, "out/big.bin", src, "big.bin", WithChunkSize(16), WithDownloadWorkers(1))
+	if !errors.Is(err, errRange) {
+		t.Fatalf("unexpected %v; want %v", err, errRange)
+	}
+	if _, ok := m["out/big.bin.part.json"]; !ok {
+		t.Fatalf("no state file")
+	}
+	if got := m["out/big.bin.part"]; got == nil || !bytes.Equal(got.Data, data[:32]) {
+		t.Fatalf("unexpected part file %v; want %q", got, data[:32])
+	}
+
+	src.calls = 0
+	if err := Download(ctx, dest, "out/big.bin", src, "big.bin", WithChunkSize(16), WithDownloadWorkers(1)); err != nil {
+		t.Fatal(err)
+	}
+	if src.calls != 5 {
+		t.Errorf("unexpected %d calls; want 5 calls resuming from the third chunk", src.calls)
+	}
+	if got := m["out/big.bin"]; got == nil || !bytes.Equal(got.Data, data) || got.Mode != 0640 {
+		t.Errorf("unexpected %v; want %q", got, data)
+	}
+	for name := range m {
+		if strings.HasPrefix(name, "out/big.bin.part") {
+			t.Errorf("unexpected %s remains", name)
+		}
+	}
+}
+
+func TestDownload_resumeTwice(t *testing.T) {
+	data := []byte("abcdefghijkl")
+	src := &rangeFS{
+		MapFS: fstest.MapFS{"a.bin": {Data: data}},
+		fail:  map[int64]bool{4: true},
+	}
+	m := fstest.MapFS{}
+	dest := newDownloadDest(m)
+	ctx := context.Background()
+	opts := []DownloadOption{WithChunkSize(4), WithDownloadWorkers(1)}
+
+	if err := Download(ctx, dest, "a.bin", src, "a.bin", opts...); !errors.Is(err, errRange) {
+		t.Fatalf("unexpected %v; want %v", err, errRange)
+	}
+	src.fail[8] = true
+	if err := Download(ctx, dest, "a.bin", src, "a.bin", opts...); !errors.Is(err, errRange) {
+		t.Fatalf("unexpected %v; want %v", err, errRange)
+	}
+	if _, ok := m["a.bin.part"]; ok {
+		t.Errorf("unexpected previous part file remains")
+	}
+	if got := m["a.bin.part.resume"]; got == nil || string(got.Data) != "abcdefgh" {
+		t.Fatalf("unexpected part file %v; want %q", got, "abcdefgh")
+	}
+
+	src.calls = 0
+	if err := Download(ctx, dest, "a.bin", src, "a.bin", opts...); err != nil {
+		t.Fatal(err)
+	}
+	if src.calls != 1 {
+		t.Errorf("unexpected %d calls; want 1", src.calls)
+	}
+	if got := m["a.bin"]; got == nil || !bytes.Equal(got.Data, data) {
+		t.Errorf("unexpected %v; want %q", got, data)
+	}
+	for name := range m {
+		if strings.HasPrefix(name, "a.bin.part") {
+			t.Errorf("unexpected %s remains", name)
+		}
+	}
+}
+
+func TestDownload_sequential(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 3)
+	src := &rangeFS{MapFS: fstest.MapFS{"a.bin": {Data: data}}}
+	m := fstest.MapFS{}
+	dest := newMapWriteFS(m)
+	if err := Download(context.Background(), dest, "a.bin", src, "a.bin", WithChunkSize(4)); err != nil {
+		t.Fatal(err)
+	}
+	if src.calls != 0 {
+		t.Errorf("unexpected %d calls; want 0", src.calls)
+	}
+	if got := m["a.bin"]; got == nil || !bytes.Equal(got.Data, data) {
+		t.Errorf("unexpected %v; want %q", got, data)
+	}
+	for name := range m {
+		if strings.HasPrefix(name, "a.bin.part") {
+			t.Errorf("unexpected %s remains", name)
+		}
+	}
+}
+
+func TestDownload_changedSource(t *testing.T) {
+	src := &rangeFS{
+		MapFS: fstest.MapFS{"a.bin": {Data: []byte("abcdefgh")}},
+		fail:  map[int64]bool{4: true},
+	}
+	m := fstest.MapFS{}
+	dest := newDownloadDest(m)
+	ctx := context.Background()
+
+	if err := Download(ctx, dest, "a.bin", src, "a.bin", WithChunkSize(4), WithDownloadWorkers(1)); !errors.Is(err, errRange) {
+		t.Fatalf("unexpected %v; want %v", err, errRange)
+	}
+	src.MapFS["a.bin"] = &fstest.MapFile{Data: []byte("ABCDEFGHIJ")}
+	if err := Download(ctx, dest, "a.bin", src, "a.bin", WithChunkSize(4), WithDownloadWorkers(1)); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(m["a.bin"].Data); got != "ABCDEFGHIJ" {
+		t.Errorf("unexpected %q; want %q", got, "ABCDEFGHIJ")
+	}
+}
+
+func TestDownload_canceled(t *testing.T) {
+	src := fstest.MapFS{"a.bin": {Data: []byte("abcdefgh")}}
+	dest := newDownloadDest(fstest.MapFS{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Download(ctx, dest, "a.bin", src, "a.bin", WithChunkSize(1)); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected %v; want %v", err, context.Canceled)
+	}
+}
+
+func TestDownload_invalid(t *testing.T) {
+	src := fstest.MapFS{"dir/a.bin": {Data: []byte("a")}}
+	dest := newDownloadDest(fstest.MapFS{})
+	ctx := context.Background()
+	if err := Download(ctx, dest, "dir", src, "dir"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+	if err := Download(ctx, dest, "a.bin", src, "dir/a.bin", WithChunkSize(0)); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+	if err := Download(ctx, dest, "a.bin", src, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+}
@@ -0,0 +1,103 @@
+// Package event provides in-process publish/subscribe of filesystem events
+// and forwarding of them to work queues.
+package event
+
+import (
+	"io/fs"
+	"sync"
+)
+
+// Op describes an operation of an Event.
+type Op int
+
+const (
+	// Mkdir is an operation that creates a directory.
+	Mkdir Op = iota + 1
+	// Write is an operation that creates or updates a file.
+	Write
+	// Remove is an operation that removes a file or a directory tree.
+	Remove
+	// Copy is an operation that copies OldPath to Path.
+	Copy
+)
+
+// String returns the name of the operation.
+func (op Op) String() string {
+	switch op {
+	case Mkdir:
+		return "MKDIR"
+	case Write:
+		return "WRITE"
+	case Remove:
+		return "REMOVE"
+	case Copy:
+		return "COPY"
+	}
+	return "UNKNOWN"
+}
+
+// Event represents a change of a filesystem.
+type Event struct {
+	Op      Op
+	Path    string
+	OldPath string
+	// Info is the fs.FileInfo of Path after the operation if available.
+	Info fs.FileInfo
+}
+
+// Handler handles published events.
+type Handler func(e Event)
+
+// Bus delivers published events to subscribers. Handlers are called
+// synchronously in the order of subscriptions.
+type Bus struct {
+	mutex    sync.RWMutex
+	nextID   int
+	handlers map[int]Handler
+	ids      []int
+}
+
+// NewBus returns a new Bus.
+func NewBus() *Bus {
+	return &Bus{
+		handlers: map[int]Handler{},
+	}
+}
+
+// Subscribe adds the handler and returns a function to remove it.
+func (b *Bus) Subscribe(h Handler) (unsubscribe func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = h
+	b.ids = append(b.ids, id)
+
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		delete(b.handlers, id)
+		for i, v := range b.ids {
+			if v == id {
+				b.ids = append(b.ids[:i], b.ids[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Publish delivers the event to all subscribers.
+func (b *Bus) Publish(e Event) {
+	b.mutex.RLock()
+	handlers := make([]Handler, 0, len(b.ids))
+	for _, id := range b.ids {
+		handlers = append(handlers, b.handlers[id])
+	}
+	b.mutex.RUnlock()
+
+	for _, h := range handlers {
+		h(e)
+	}
+}
@@ -0,0 +1,34 @@
+package event
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	var got1, got2 []Event
+	unsubscribe1 := bus.Subscribe(func(e Event) { got1 = append(got1, e) })
+	bus.Subscribe(func(e Event) { got2 = append(got2, e) })
+
+	e1 := Event{Op: Write, Path: "a.txt"}
+	e2 := Event{Op: Remove, Path: "a.txt"}
+	bus.Publish(e1)
+	unsubscribe1()
+	bus.Publish(e2)
+
+	if want := []Event{e1}; !reflect.DeepEqual(got1, want) {
+		t.Errorf("unexpected %v; want %v", got1, want)
+	}
+	if want := []Event{e1, e2}; !reflect.DeepEqual(got2, want) {
+		t.Errorf("unexpected %v; want %v", got2, want)
+	}
+}
+
+func TestOp_String(t *testing.T) {
+	for op, want := range map[Op]string{Mkdir: "MKDIR", Write: "WRITE", Remove: "REMOVE", Copy: "COPY", 0: "UNKNOWN"} {
+		if got := op.String(); got != want {
+			t.Errorf("unexpected %s; want %s", got, want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package event
+
+import (
+	"io/fs"
+	"path"
+
+	"github.com/jarxorg/wfs"
+)
+
+// NotifyFS is a filesystem that publishes events of writes to a Bus.
+type NotifyFS struct {
+	*wfs.FSDelegator
+	fsys   fs.FS
+	bus    *Bus
+	prefix string
+}
+
+var (
+	_ wfs.WriteFileFS      = (*NotifyFS)(nil)
+	_ wfs.RemoveFileFS     = (*NotifyFS)(nil)
+	_ wfs.CopyFileWithinFS = (*NotifyFS)(nil)
+)
+
+// NewNotifyFS returns a filesystem that delegates to fsys and publishes events
+// of successful writes to bus.
+func NewNotifyFS(fsys fs.FS, bus *Bus) *NotifyFS {
+	return &NotifyFS{
+		FSDelegator: wfs.DelegateFS(fsys),
+		fsys:        fsys,
+		bus:         bus,
+	}
+}
+
+func (n *NotifyFS) publish(op Op, name, oldName string) {
+	e := Event{Op: op, Path: path.Join(n.prefix, name)}
+	if oldName != "" {
+		e.OldPath = path.Join(n.prefix, oldName)
+	}
+	if op != Remove {
+		if info, err := fs.Stat(n.fsys, name); err == nil {
+			e.Info = info
+		}
+	}
+	n.bus.Publish(e)
+}
+
+// Sub returns a NotifyFS corresponding to the subtree rooted at dir. Events of
+// the sub filesystem have paths relative to the root of n.
+func (n *NotifyFS) Sub(dir string) (fs.FS, error) {
+	sub, err := fs.Sub(n.fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &NotifyFS{
+		FSDelegator: wfs.DelegateFS(sub),
+		fsys:        sub,
+		bus:         n.bus,
+		prefix:      path.Join(n.prefix, dir),
+	}, nil
+}
+
+// MkdirAll calls wfs.MkdirAll and publishes a Mkdir event.
+func (n *NotifyFS) MkdirAll(dir string, mode fs.FileMode) error {
+	if err := wfs.MkdirAll(n.fsys, dir, mode); err != nil {
+		return err
+	}
+	n.publish(Mkdir, dir, "")
+	return nil
+}
+
+// CreateFile calls wfs.CreateFile and publishes a Write event when the file
+// is closed successfully. The returned file implements the optional
+// interfaces of the created file and no event is published if it is aborted.
+func (n *NotifyFS) CreateFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+	f, err := wfs.CreateFile(n.fsys, name, mode)
+	if err != nil {
+		return nil, err
+	}
+	d := wfs.DelegateFile(f)
+	d.CloseFunc = func() error {
+		if err := f.Close(); err != nil {
+			return err
+		}
+		n.publish(Write, name, "")
+		return nil
+	}
+	return d.File().(wfs.WriterFile), nil
+}
+
+// WriteFile calls wfs.WriteFile and publishes a Write event.
+func (n *NotifyFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	written, err := wfs.WriteFile(n.fsys, name, p, mode)
+	if err != nil {
+		return written, err
+	}
+	n.publish(Write, name, "")
+	return written, nil
+}
+
+// CopyFile calls wfs.CopyFile and publishes a Copy event.
+func (n *NotifyFS) CopyFile(src, dst string) error {
+	if err := wfs.CopyFile(n.fsys, src, dst); err != nil {
+		return err
+	}
+	n.publish(Copy, dst, src)
+	return nil
+}
+
+// RemoveFile calls wfs.RemoveFile and publishes a Remove event.
+func (n *NotifyFS) RemoveFile(name string) error {
+	if err := wfs.RemoveFile(n.fsys, name); err != nil {
+		return err
+	}
+	n.publish(Remove, name, "")
+	return nil
+}
+
+// RemoveAll calls wfs.RemoveAll and publishes a Remove event.
+func (n *NotifyFS) RemoveAll(path string) error {
+	if err := wfs.RemoveAll(n.fsys, path); err != nil {
+		return err
+	}
+	n.publish(Remove, path, "")
+	return nil
+}
@@ -0,0 +1,109 @@
+package event
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+
+	"github.com/jarxorg/wfs"
+	"github.com/jarxorg/wfs/memfs"
+)
+
+func TestNotifyFS(t *testing.T) {
+	bus := NewBus()
+	var got []string
+	bus.Subscribe(func(e Event) {
+		s := e.Op.String() + " " + e.Path
+		if e.OldPath != "" {
+			s += " " + e.OldPath
+		}
+		if e.Info != nil {
+			s += " " + e.Info.Name()
+		}
+		got = append(got, s)
+	})
+
+	fsys := NewNotifyFS(memfs.New(), bus)
+	if err := wfs.MkdirAll(fsys, "dir", fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wfs.WriteFile(fsys, "dir/a.txt", []byte("a"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	f, err := wfs.CreateFile(fsys, "dir/b.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.CopyFile(fsys, "dir/a.txt", "dir/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.RemoveFile(fsys, "dir/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	sub, err := fs.Sub(fsys, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wfs.WriteFile(sub, "d.txt", []byte("d"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.RemoveAll(fsys, "dir"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wfs.WriteFile(fsys, "../invalid", []byte{}, fs.ModePerm); err == nil {
+		t.Fatal("no error")
+	}
+
+	want := []string{
+		"MKDIR dir dir",
+		"WRITE dir/a.txt a.txt",
+		"WRITE dir/b.txt b.txt",
+		"COPY dir/c.txt dir/a.txt c.txt",
+		"REMOVE dir/a.txt",
+		"WRITE dir/d.txt d.txt",
+		"REMOVE dir",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %q; want %q", got, want)
+	}
+}
+
+func TestNotifyFS_CreateFileAbort(t *testing.T) {
+	bus := NewBus()
+	var got []Event
+	bus.Subscribe(func(e Event) {
+		got = append(got, e)
+	})
+	m := memfs.New()
+	if _, err := m.WriteFile("a.txt", []byte("original"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	fsys := NewNotifyFS(m, bus)
+
+	f, err := wfs.CreateFile(fsys, "a.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.Abort(fsys, "a.txt", f); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("unexpected events %v", got)
+	}
+	p, err := fs.ReadFile(m, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p) != "original" {
+		t.Errorf("unexpected %q; want %q", p, "original")
+	}
+}
@@ -0,0 +1,91 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jarxorg/wfs"
+)
+
+// Publisher publishes messages to a work queue such as Amazon SQS, NATS or
+// Kafka. An implementation typically wraps the client of the queue and uses
+// key as a partition key or a message group ID, so the messages of a path are
+// delivered in order.
+type Publisher interface {
+	Publish(ctx context.Context, key string, body []byte) error
+}
+
+// Message is the JSON message of an Event published by Forward and
+// ForwardWatch.
+type Message struct {
+	Op      string     `json:"op"`
+	Path    string     `json:"path"`
+	OldPath string     `json:"oldPath,omitempty"`
+	IsDir   bool       `json:"isDir,omitempty"`
+	Size    int64      `json:"size,omitempty"`
+	ModTime *time.Time `json:"modTime,omitempty"`
+	// Time is the time when the message was made.
+	Time time.Time `json:"time"`
+}
+
+// NewMessage returns the Message of the event. IsDir, Size and ModTime are
+// set if the event has Info.
+func NewMessage(e Event) *Message {
+	m := &Message{
+		Op:      e.Op.String(),
+		Path:    e.Path,
+		OldPath: e.OldPath,
+		Time:    time.Now().UTC(),
+	}
+	if e.Info != nil {
+		modTime := e.Info.ModTime()
+		m.IsDir = e.Info.IsDir()
+		m.Size = e.Info.Size()
+		m.ModTime = &modTime
+	}
+	return m
+}
+
+func publishEvent(ctx context.Context, p Publisher, e Event) error {
+	body, err := json.Marshal(NewMessage(e))
+	if err != nil {
+		return err
+	}
+	return p.Publish(ctx, e.Path, body)
+}
+
+// Forward subscribes to the bus and publishes the events as JSON Messages to
+// p. Events are published synchronously in the handler of the bus, so a slow
+// publisher slows down the writes of a NotifyFS. Errors of publishing are
+// passed to onError if it is not nil.
+func Forward(ctx context.Context, bus *Bus, p Publisher, onError func(e Event, err error)) (unsubscribe func()) {
+	return bus.Subscribe(func(e Event) {
+		if err := publishEvent(ctx, p, e); err != nil && onError != nil {
+			onError(e, err)
+		}
+	})
+}
+
+// ForwardWatch publishes the events received from events, such as the channel
+// returned by wfs.Watch, as JSON Messages to p until events is closed or ctx
+// is done. ForwardWatch returns the first error of publishing or ctx.Err().
+func ForwardWatch(ctx context.Context, events <-chan wfs.WatchEvent, p Publisher) error {
+	for {
+		select {
+		case we, ok := <-events:
+			if !ok {
+				return nil
+			}
+			e := Event{Op: Write, Path: we.Name}
+			if we.Op == wfs.WatchRemove {
+				e.Op = Remove
+			}
+			if err := publishEvent(ctx, p, e); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
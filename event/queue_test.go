@@ -0,0 +1,120 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+
+	"github.com/jarxorg/wfs"
+	"github.com/jarxorg/wfs/memfs"
+)
+
+type queueMessage struct {
+	key     string
+	message Message
+}
+
+type testPublisher struct {
+	messages []queueMessage
+	err      error
+}
+
+func (p *testPublisher) Publish(ctx context.Context, key string, body []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	var m Message
+	if err := json.Unmarshal(body, &m); err != nil {
+		return err
+	}
+	p.messages = append(p.messages, queueMessage{key: key, message: m})
+	return nil
+}
+
+func TestForward(t *testing.T) {
+	bus := NewBus()
+	p := &testPublisher{}
+	unsubscribe := Forward(context.Background(), bus, p, nil)
+
+	fsys := NewNotifyFS(memfs.New(), bus)
+	if _, err := wfs.WriteFile(fsys, "a.txt", []byte("abc"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.CopyFile(fsys, "a.txt", "b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.RemoveFile(fsys, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	unsubscribe()
+	if _, err := wfs.WriteFile(fsys, "c.txt", []byte("c"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.messages) != 3 {
+		t.Fatalf("unexpected %d messages; want 3", len(p.messages))
+	}
+	m := p.messages[0]
+	if m.key != "a.txt" || m.message.Op != "WRITE" || m.message.Size != 3 || m.message.ModTime == nil {
+		t.Errorf("unexpected %+v", m)
+	}
+	if m.message.Time.IsZero() {
+		t.Errorf("unexpected zero time")
+	}
+	m = p.messages[1]
+	if m.key != "b.txt" || m.message.Op != "COPY" || m.message.OldPath != "a.txt" {
+		t.Errorf("unexpected %+v", m)
+	}
+	m = p.messages[2]
+	if m.key != "a.txt" || m.message.Op != "REMOVE" || m.message.ModTime != nil {
+		t.Errorf("unexpected %+v", m)
+	}
+}
+
+func TestForward_Error(t *testing.T) {
+	bus := NewBus()
+	errPublish := errors.New("publish")
+	var got []error
+	Forward(context.Background(), bus, &testPublisher{err: errPublish}, func(e Event, err error) {
+		got = append(got, err)
+	})
+	bus.Publish(Event{Op: Write, Path: "a.txt"})
+	if want := []error{errPublish}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+}
+
+func TestForwardWatch(t *testing.T) {
+	events := make(chan wfs.WatchEvent, 2)
+	events <- wfs.WatchEvent{Op: wfs.WatchWrite, Name: "a.txt"}
+	events <- wfs.WatchEvent{Op: wfs.WatchRemove, Name: "b.txt"}
+	close(events)
+
+	p := &testPublisher{}
+	if err := ForwardWatch(context.Background(), events, p); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range p.messages {
+		got = append(got, m.message.Op+" "+m.key)
+	}
+	if want := []string{"WRITE a.txt", "REMOVE b.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %q; want %q", got, want)
+	}
+
+	errPublish := errors.New("publish")
+	events = make(chan wfs.WatchEvent, 1)
+	events <- wfs.WatchEvent{Op: wfs.WatchWrite, Name: "a.txt"}
+	if err := ForwardWatch(context.Background(), events, &testPublisher{err: errPublish}); err != errPublish {
+		t.Errorf("unexpected %v; want %v", err, errPublish)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ForwardWatch(ctx, make(chan wfs.WatchEvent), &testPublisher{}); err != context.Canceled {
+		t.Errorf("unexpected %v; want %v", err, context.Canceled)
+	}
+}
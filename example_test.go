@@ -0,0 +1,56 @@
+package wfs_test
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/jarxorg/wfs"
+	"github.com/jarxorg/wfs/memfs"
+)
+
+func ExampleSaveMultipart() {
+	fsys := memfs.New()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		names, err := wfs.SaveMultipart(fsys, r.MultipartForm, "uploads", wfs.WithMaxFileSize(10<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, names)
+	})
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "../hello world.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprint(fw, "Hello")
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	fmt.Println(rec.Body.String())
+
+	p, err := fsys.ReadFile("uploads/hello_world.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(p))
+
+	// Output:
+	// [uploads/hello_world.txt]
+	// Hello
+}
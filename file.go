@@ -0,0 +1,87 @@
+package wfs
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"syscall"
+)
+
+// BytesFile is a read-only fs.File of the specified bytes.
+type BytesFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+var (
+	_ fs.File     = (*BytesFile)(nil)
+	_ io.ReaderAt = (*BytesFile)(nil)
+	_ io.Seeker   = (*BytesFile)(nil)
+)
+
+// NewBytesFile returns a BytesFile that reads p and returns info as Stat.
+func NewBytesFile(info fs.FileInfo, p []byte) *BytesFile {
+	return &BytesFile{Reader: bytes.NewReader(p), info: info}
+}
+
+// Stat returns the fs.FileInfo of this file.
+func (f *BytesFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// Close does nothing.
+func (f *BytesFile) Close() error {
+	return nil
+}
+
+// DirFile is a read-only fs.ReadDirFile of the specified entries.
+type DirFile struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	index   int
+}
+
+var _ fs.ReadDirFile = (*DirFile)(nil)
+
+// NewDirFile returns a DirFile that reads entries and returns info as Stat.
+// The entries should be sorted by filename.
+func NewDirFile(info fs.FileInfo, entries []fs.DirEntry) *DirFile {
+	return &DirFile{info: info, entries: entries}
+}
+
+// Stat returns the fs.FileInfo of this directory.
+func (f *DirFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// Read returns an error because this is a directory.
+func (f *DirFile) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "Read", Path: f.info.Name(), Err: syscall.EISDIR}
+}
+
+// Close does nothing.
+func (f *DirFile) Close() error {
+	return nil
+}
+
+// ReadDir reads the contents of the directory with the semantics of
+// fs.ReadDirFile.
+func (f *DirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	max := len(f.entries)
+	if f.index >= max {
+		if n <= 0 {
+			return nil, nil
+		}
+		return nil, io.EOF
+	}
+	if n <= 0 {
+		n = max - f.index
+	}
+	end := f.index + n
+	if end > max {
+		end = max
+	}
+	entries := f.entries[f.index:end]
+	f.index = end
+	return entries, nil
+}
@@ -0,0 +1,76 @@
+package wfs
+
+import (
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestBytesFile(t *testing.T) {
+	info := &FileInfoDelegator{Values: FileInfoValues{Name: "test.txt", Size: 4}}
+	f := NewBytesFile(info, []byte("test"))
+
+	got, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "test" {
+		t.Errorf("unexpected %s", got)
+	}
+	gotInfo, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotInfo != info {
+		t.Errorf("unexpected %v; want %v", gotInfo, info)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirFile(t *testing.T) {
+	info := &FileInfoDelegator{Values: FileInfoValues{Name: "dir", IsDir: true, Mode: fs.ModeDir}}
+	var entries []fs.DirEntry
+	for _, name := range []string{"a", "b", "c"} {
+		entries = append(entries, &DirEntryDelegator{Values: DirEntryValues{Name: name}})
+	}
+	f := NewDirFile(info, entries)
+
+	if _, err := f.Read(nil); err == nil {
+		t.Error("no error")
+	}
+	got, err := f.ReadDir(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, entries[:2]) {
+		t.Errorf("unexpected %v", got)
+	}
+	got, err = f.ReadDir(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, entries[2:]) {
+		t.Errorf("unexpected %v", got)
+	}
+	if _, err = f.ReadDir(2); err != io.EOF {
+		t.Errorf("unexpected %v; want %v", err, io.EOF)
+	}
+	got, err = f.ReadDir(-1)
+	if err != nil || len(got) != 0 {
+		t.Errorf("unexpected %v, %v", got, err)
+	}
+	gotInfo, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotInfo != info {
+		t.Errorf("unexpected %v; want %v", gotInfo, info)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,176 @@
+package wfs
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// FileServer returns a http.Handler that serves GET and HEAD requests with
+// the files of fsys. The handler uses http.ServeContent, so Range,
+// If-Modified-Since, If-None-Match and If-Range requests are honored.
+// Last-Modified is the ModTime of the file and ETag is the hash of the file if
+// the fs.FileInfo implements HashedInfo. If fsys implements MetadataFS the
+// Content-Type and Cache-Control of the metadata are sent. Files are read by
+// OpenRange, so a range request reads only the requested range if fsys
+// implements RangeReaderFS. A directory is served by its "index.html" and
+// directory listings are not served. A file whose Stat reports UnknownSize is
+// opened to find the size and streamed if the size is still unknown.
+func FileServer(fsys fs.FS) http.Handler {
+	return &fileServer{fsys: fsys}
+}
+
+type fileServer struct {
+	fsys fs.FS
+}
+
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = "."
+	}
+	info, err := fs.Stat(s.fsys, name)
+	if err == nil && info.IsDir() {
+		name = path.Join(name, "index.html")
+		info, err = fs.Stat(s.fsys, name)
+	}
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+	if info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	h := w.Header()
+	if hi, ok := info.(HashedInfo); ok {
+		if _, sum := hi.Hash(); sum != nil {
+			h.Set("Etag", `"`+hex.EncodeToString(sum)+`"`)
+		}
+	}
+	if _, ok := s.fsys.(MetadataFS); ok {
+		if meta, err := StatMeta(s.fsys, name); err == nil {
+			for _, key := range []string{MetaContentType, MetaCacheControl} {
+				if value := meta[key]; value != "" {
+					h.Set(key, value)
+				}
+			}
+		}
+	}
+	if info.Size() == UnknownSize {
+		s.serveUnknownSize(w, r, name, info.ModTime())
+		return
+	}
+	content := &rangeSeeker{fsys: s.fsys, name: name, size: info.Size()}
+	defer content.Close()
+
+	http.ServeContent(w, r, path.Base(name), info.ModTime(), content)
+}
+
+// serveUnknownSize serves the named file whose Stat reports UnknownSize. The
+// opened file is served by http.ServeContent if its Stat reports the size and
+// it implements io.Seeker, otherwise the contents are streamed without
+// Content-Length and Range and conditional requests are ignored.
+func (s *fileServer) serveUnknownSize(w http.ResponseWriter, r *http.Request, name string, modTime time.Time) {
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.Size() != UnknownSize {
+		if rs, ok := f.(io.ReadSeeker); ok {
+			http.ServeContent(w, r, path.Base(name), modTime, rs)
+			return
+		}
+	}
+	h := w.Header()
+	if h.Get("Content-Type") == "" {
+		if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+			h.Set("Content-Type", ct)
+		}
+	}
+	if !modTime.IsZero() {
+		h.Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		io.Copy(w, f)
+	}
+}
+
+func serveError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrInvalid):
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case errors.Is(err, fs.ErrPermission):
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// rangeSeeker is an io.ReadSeeker of the named file that opens the file by
+// OpenRange from the offset at the first Read after Seek.
+type rangeSeeker struct {
+	fsys fs.FS
+	name string
+	size int64
+	off  int64
+	r    io.ReadCloser
+}
+
+func (s *rangeSeeker) Read(p []byte) (int, error) {
+	if s.off >= s.size {
+		return 0, io.EOF
+	}
+	if s.r == nil {
+		r, err := OpenRange(s.fsys, s.name, s.off, s.size-s.off)
+		if err != nil {
+			return 0, err
+		}
+		s.r = r
+	}
+	n, err := s.r.Read(p)
+	s.off += int64(n)
+	return n, err
+}
+
+func (s *rangeSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += s.off
+	case io.SeekEnd:
+		offset += s.size
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "Seek", Path: s.name, Err: fs.ErrInvalid}
+	}
+	if offset != s.off {
+		s.Close()
+		s.off = offset
+	}
+	return offset, nil
+}
+
+func (s *rangeSeeker) Close() error {
+	if s.r == nil {
+		return nil
+	}
+	err := s.r.Close()
+	s.r = nil
+	return err
+}
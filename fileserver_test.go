@@ -0,0 +1,167 @@
+package wfs
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type hashedFileInfo struct {
+	fs.FileInfo
+}
+
+func (info hashedFileInfo) Hash() (string, []byte) {
+	return HashMD5, []byte{0xab, 0xcd}
+}
+
+func TestFileServer(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	m := fstest.MapFS{
+		"a.txt":           {Data: []byte("abcdef"), ModTime: modTime},
+		"site/index.html": {Data: []byte("<html>"), ModTime: modTime},
+	}
+	d := DelegateFS(m)
+	d.StatFunc = func(name string) (fs.FileInfo, error) {
+		info, err := m.Stat(name)
+		if err != nil || info.IsDir() {
+			return info, err
+		}
+		return hashedFileInfo{FileInfo: info}, nil
+	}
+	fsys := &metadataFS{FSDelegator: d, meta: map[string]Metadata{
+		"a.txt": {MetaContentType: "text/x-test", MetaCacheControl: "no-cache"},
+	}}
+	handler := FileServer(fsys)
+
+	testCases := []struct {
+		method  string
+		target  string
+		headers map[string]string
+		status  int
+		body    string
+		want    map[string]string
+	}{
+		{
+			method: http.MethodGet,
+			target: "/a.txt",
+			status: http.StatusOK,
+			body:   "abcdef",
+			want: map[string]string{
+				"Etag":          `"abcd"`,
+				"Last-Modified": "Tue, 02 Jan 2024 15:04:05 GMT",
+				"Content-Type":  "text/x-test",
+				"Cache-Control": "no-cache",
+			},
+		}, {
+			method:  http.MethodGet,
+			target:  "/a.txt",
+			headers: map[string]string{"If-None-Match": `"abcd"`},
+			status:  http.StatusNotModified,
+		}, {
+			method:  http.MethodGet,
+			target:  "/a.txt",
+			headers: map[string]string{"Range": "bytes=1-2"},
+			status:  http.StatusPartialContent,
+			body:    "bc",
+			want:    map[string]string{"Content-Range": "bytes 1-2/6"},
+		}, {
+			method: http.MethodHead,
+			target: "/a.txt",
+			status: http.StatusOK,
+			want:   map[string]string{"Content-Length": "6"},
+		}, {
+			method: http.MethodGet,
+			target: "/site/",
+			status: http.StatusOK,
+			body:   "<html>",
+			want:   map[string]string{"Content-Type": "text/html; charset=utf-8"},
+		}, {
+			method: http.MethodGet,
+			target: "/missing.txt",
+			status: http.StatusNotFound,
+		}, {
+			method: http.MethodGet,
+			target: "/",
+			status: http.StatusNotFound,
+		}, {
+			method: http.MethodPost,
+			target: "/a.txt",
+			status: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		for key, value := range tc.headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("%s %s: unexpected %d; want %d", tc.method, tc.target, rec.Code, tc.status)
+		}
+		if tc.body != "" && rec.Body.String() != tc.body {
+			t.Errorf("%s %s: unexpected %q; want %q", tc.method, tc.target, rec.Body.String(), tc.body)
+		}
+		for key, want := range tc.want {
+			if got := rec.Header().Get(key); got != want {
+				t.Errorf("%s %s: unexpected %s %q; want %q", tc.method, tc.target, key, got, want)
+			}
+		}
+	}
+}
+
+func TestFileServer_unknownSize(t *testing.T) {
+	m := fstest.MapFS{}
+	fsys, err := NewTransformFS(newMapWriteFS(m), []TransformRule{
+		{Pattern: "*.txt", Codecs: []string{"gzip"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("a.txt", []byte("abcdef"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/a.txt", nil)
+	r.Header.Set("Range", "bytes=1-2")
+	w := httptest.NewRecorder()
+	FileServer(fsys).ServeHTTP(w, r)
+	if w.Code != http.StatusPartialContent {
+		t.Errorf("unexpected %d; want %d", w.Code, http.StatusPartialContent)
+	}
+	if got := w.Body.String(); got != "bc" {
+		t.Errorf("unexpected %q; want %q", got, "bc")
+	}
+
+	d := DelegateFS(fsys)
+	d.OpenFunc = func(name string) (fs.File, error) {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		return &FileDelegator{
+			StatFunc:  func() (fs.FileInfo, error) { return fsys.Stat(name) },
+			ReadFunc:  f.Read,
+			CloseFunc: f.Close,
+		}, nil
+	}
+	r = httptest.NewRequest(http.MethodGet, "/a.txt", nil)
+	w = httptest.NewRecorder()
+	FileServer(d).ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("unexpected %d; want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Body.String(); got != "abcdef" {
+		t.Errorf("unexpected %q; want %q", got, "abcdef")
+	}
+	if got := w.Header().Get("Content-Length"); got != "" {
+		t.Errorf("unexpected Content-Length %q", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("unexpected %q", got)
+	}
+}
@@ -0,0 +1,172 @@
+package wfs
+
+import (
+	"io"
+	"io/fs"
+	"sync/atomic"
+)
+
+// FreezeFS is a filesystem that rejects writes with ErrReadOnly while it is
+// frozen, so that operators can put the storage of a service into maintenance
+// mode such as during a migration by CopyFS without redeploying. Reads are
+// always delegated to the wrapped filesystem.
+type FreezeFS struct {
+	fsys fs.FS
+	// frozen is 1 while frozen and is shared with the filesystems returned by
+	// Sub.
+	frozen *int32
+}
+
+var (
+	_ fs.ReadDirFS  = (*FreezeFS)(nil)
+	_ fs.ReadFileFS = (*FreezeFS)(nil)
+	_ fs.StatFS     = (*FreezeFS)(nil)
+	_ fs.SubFS      = (*FreezeFS)(nil)
+	_ WriteFileFS   = (*FreezeFS)(nil)
+	_ RemoveFileFS  = (*FreezeFS)(nil)
+)
+
+// NewFreezeFS returns a FreezeFS that is not frozen.
+func NewFreezeFS(fsys fs.FS) *FreezeFS {
+	return &FreezeFS{fsys: fsys, frozen: new(int32)}
+}
+
+// Freeze makes the writes of fsys and the filesystems returned by Sub return
+// ErrReadOnly until Thaw. Writes to the files created before Freeze are also
+// rejected.
+func (fsys *FreezeFS) Freeze() {
+	atomic.StoreInt32(fsys.frozen, 1)
+}
+
+// Thaw makes fsys writable again.
+func (fsys *FreezeFS) Thaw() {
+	atomic.StoreInt32(fsys.frozen, 0)
+}
+
+// Frozen reports whether fsys is frozen.
+func (fsys *FreezeFS) Frozen() bool {
+	return atomic.LoadInt32(fsys.frozen) == 1
+}
+
+func (fsys *FreezeFS) check(op Op, name string) error {
+	if fsys.Frozen() {
+		return &fs.PathError{Op: string(op), Path: name, Err: ErrReadOnly}
+	}
+	return nil
+}
+
+// Open opens the named file.
+func (fsys *FreezeFS) Open(name string) (fs.File, error) {
+	return fsys.fsys.Open(name)
+}
+
+// ReadDir reads the named directory.
+func (fsys *FreezeFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(fsys.fsys, dir)
+}
+
+// ReadFile reads the named file and returns its contents.
+func (fsys *FreezeFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(fsys.fsys, name)
+}
+
+// Stat returns a FileInfo describing the named file.
+func (fsys *FreezeFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(fsys.fsys, name)
+}
+
+// Sub returns a FreezeFS corresponding to the subtree rooted at dir that is
+// frozen and thawed with fsys.
+func (fsys *FreezeFS) Sub(dir string) (fs.FS, error) {
+	sub, err := fs.Sub(fsys.fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &FreezeFS{fsys: sub, frozen: fsys.frozen}, nil
+}
+
+// MkdirAll creates the named directory unless fsys is frozen.
+func (fsys *FreezeFS) MkdirAll(dir string, mode fs.FileMode) error {
+	if err := fsys.check(OpMkdirAll, dir); err != nil {
+		return err
+	}
+	return MkdirAll(fsys.fsys, dir, mode)
+}
+
+// CreateFile creates the named file unless fsys is frozen. The returned file
+// implements the optional interfaces of the created file and its writes fail
+// while fsys is frozen.
+func (fsys *FreezeFS) CreateFile(name string, mode fs.FileMode) (WriterFile, error) {
+	if err := fsys.check(OpCreateFile, name); err != nil {
+		return nil, err
+	}
+	f, err := CreateFile(fsys.fsys, name, mode)
+	if err != nil {
+		return nil, err
+	}
+	d := DelegateFile(f)
+	d.WriteFunc = func(p []byte) (int, error) {
+		if err := fsys.check(OpWriteFile, name); err != nil {
+			return 0, err
+		}
+		return f.Write(p)
+	}
+	if writeAt := d.WriteAtFunc; writeAt != nil {
+		d.WriteAtFunc = func(p []byte, off int64) (int, error) {
+			if err := fsys.check(OpWriteFile, name); err != nil {
+				return 0, err
+			}
+			return writeAt(p, off)
+		}
+	}
+	if readFrom := d.ReadFromFunc; readFrom != nil {
+		d.ReadFromFunc = func(r io.Reader) (int64, error) {
+			if err := fsys.check(OpWriteFile, name); err != nil {
+				return 0, err
+			}
+			return readFrom(r)
+		}
+	}
+	if truncate := d.TruncateFunc; truncate != nil {
+		d.TruncateFunc = func(size int64) error {
+			if err := fsys.check(OpWriteFile, name); err != nil {
+				return err
+			}
+			return truncate(size)
+		}
+	}
+	if punchHole := d.PunchHoleFunc; punchHole != nil {
+		d.PunchHoleFunc = func(off, length int64) error {
+			if err := fsys.check(OpWriteFile, name); err != nil {
+				return err
+			}
+			return punchHole(off, length)
+		}
+	}
+	return d.File().(WriterFile), nil
+}
+
+// WriteFile writes the specified bytes to the named file unless fsys is
+// frozen.
+func (fsys *FreezeFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	if err := fsys.check(OpWriteFile, name); err != nil {
+		return 0, err
+	}
+	return WriteFile(fsys.fsys, name, p, mode)
+}
+
+// RemoveFile removes the named file unless fsys is frozen.
+func (fsys *FreezeFS) RemoveFile(name string) error {
+	if err := fsys.check(OpRemoveFile, name); err != nil {
+		return err
+	}
+	return RemoveFile(fsys.fsys, name)
+}
+
+// RemoveAll removes path and any children it contains unless fsys is frozen.
+func (fsys *FreezeFS) RemoveAll(path string) error {
+	if err := fsys.check(OpRemoveAll, path); err != nil {
+		return err
+	}
+	return RemoveAll(fsys.fsys, path)
+}
@@ -0,0 +1,73 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFreezeFS(t *testing.T) {
+	m := fstest.MapFS{"dir/a.txt": {Data: []byte("a")}}
+	fsys := NewFreezeFS(newMapWriteFS(m))
+
+	w, err := fsys.CreateFile("dir/b.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys.Freeze()
+	if !fsys.Frozen() {
+		t.Errorf("unexpected not frozen")
+	}
+	if _, err := w.Write([]byte("b")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("unexpected %v; want %v", err, ErrReadOnly)
+	}
+	w.Close()
+	if _, err := fsys.WriteFile("dir/c.txt", []byte("c"), fs.ModePerm); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("unexpected %v; want %v", err, ErrReadOnly)
+	}
+	if err := fsys.RemoveFile("dir/a.txt"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("unexpected %v; want %v", err, ErrReadOnly)
+	}
+	sub, err := fs.Sub(fsys, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteFile(sub, "c.txt", []byte("c"), fs.ModePerm); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("unexpected %v; want %v", err, ErrReadOnly)
+	}
+	if err := fstest.TestFS(fsys, "dir/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys.Thaw()
+	if _, err := fsys.WriteFile("dir/c.txt", []byte("c"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.RemoveFile("dir/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(fsys, "dir/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFreezeFS_FileInterfaces(t *testing.T) {
+	m := fstest.MapFS{"a.txt": {Data: []byte("original")}}
+	fsys := NewFreezeFS(newAbortMapWriteFS(m))
+
+	w, err := fsys.CreateFile("a.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys.Freeze()
+	if _, err := WriteAt(w, []byte("b"), 1); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("unexpected %v; want %v", err, ErrReadOnly)
+	}
+	if err := Abort(fsys, "a.txt", w); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(m["a.txt"].Data); got != "original" {
+		t.Errorf("unexpected %q; want %q", got, "original")
+	}
+}
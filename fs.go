@@ -4,11 +4,24 @@ import (
 	"errors"
 	"io"
 	"io/fs"
+	"path"
+	"reflect"
+	"time"
 )
 
 var (
 	// ErrNotImplemented "not implemented"
 	ErrNotImplemented = errors.New("not implemented")
+	// ErrProtectedRoot "protected root"
+	ErrProtectedRoot = errors.New("protected root")
+	// ErrUnavailable "unavailable"
+	ErrUnavailable = errors.New("unavailable")
+	// ErrLocked "locked"
+	ErrLocked = errors.New("locked")
+	// ErrReadOnly "read-only"
+	ErrReadOnly = errors.New("read-only")
+	// ErrQuotaExceeded "quota exceeded"
+	ErrQuotaExceeded = errors.New("quota exceeded")
 )
 
 // WriterFile is a file that provides an implementation fs.File and io.Writer.
@@ -17,6 +30,147 @@ type WriterFile interface {
 	io.Writer
 }
 
+// LargeWriterFile is a WriterFile that provides an implementation of
+// io.ReaderFrom. A filesystem like a cloud storage can stream large files with
+// bounded memory using multipart or resumable uploads in ReadFrom.
+type LargeWriterFile interface {
+	WriterFile
+	io.ReaderFrom
+}
+
+// WriterAtFile is a WriterFile that provides an implementation of io.WriterAt.
+// Segments of a file can be written in parallel using WriteAt.
+type WriterAtFile interface {
+	WriterFile
+	io.WriterAt
+}
+
+// WriteAt writes the specified bytes to the file at the offset off. If the
+// file implements WriterAtFile calls f.WriteAt, else if the file implements
+// io.Seeker seeks and writes, otherwise returns ErrNotImplemented.
+func WriteAt(f WriterFile, p []byte, off int64) (int, error) {
+	if f, ok := f.(WriterAtFile); ok {
+		return f.WriteAt(p, off)
+	}
+	if s, ok := f.(io.Seeker); ok {
+		if _, err := s.Seek(off, io.SeekStart); err != nil {
+			return 0, err
+		}
+		return f.Write(p)
+	}
+	return 0, ErrNotImplemented
+}
+
+// SparseFile is a WriterFile that supports sparse files. Truncate changes the
+// size of the file and extending a file creates a hole. PunchHole deallocates
+// the specified range and reads of the range return zeros.
+type SparseFile interface {
+	WriterFile
+	Truncate(size int64) error
+	PunchHole(off, length int64) error
+}
+
+// Truncate changes the size of the file. If the file implements SparseFile
+// calls f.Truncate otherwise returns ErrNotImplemented.
+func Truncate(f WriterFile, size int64) error {
+	if f, ok := f.(SparseFile); ok {
+		return f.Truncate(size)
+	}
+	return ErrNotImplemented
+}
+
+// PunchHole deallocates the specified range of the file. If the file
+// implements SparseFile calls f.PunchHole, else if the file implements
+// WriterAtFile writes zeros in the range up to the size of the file,
+// otherwise returns ErrNotImplemented.
+func PunchHole(f WriterFile, off, length int64) error {
+	if f, ok := f.(SparseFile); ok {
+		return f.PunchHole(off, length)
+	}
+	if _, ok := f.(WriterAtFile); ok {
+		return writeZeros(f, off, length)
+	}
+	return ErrNotImplemented
+}
+
+// zerosSize is the maximum size of zeros written at once by writeZeros.
+const zerosSize = 32 * 1024
+
+// writeZeros writes zeros in the specified range of f without extending f.
+func writeZeros(f WriterFile, off, length int64) error {
+	if off < 0 || length < 0 {
+		return fs.ErrInvalid
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	// NOTE: Compare with size-off so that off+length cannot overflow.
+	if size := info.Size(); length > size-off {
+		length = size - off
+	}
+	n := int64(zerosSize)
+	if length < n {
+		n = length
+	}
+	if n <= 0 {
+		return nil
+	}
+	zeros := make([]byte, n)
+	for length > 0 {
+		if length < n {
+			n = length
+		}
+		if _, err := WriteAt(f, zeros[:n], off); err != nil {
+			return err
+		}
+		off += n
+		length -= n
+	}
+	return nil
+}
+
+// SyncWriterFile is a WriterFile that provides an implementation of Sync that
+// commits the written data to the storage.
+type SyncWriterFile interface {
+	WriterFile
+	Sync() error
+}
+
+// Sync commits the written data of the file to the storage. If the file
+// implements SyncWriterFile calls f.Sync otherwise returns ErrNotImplemented.
+func Sync(f WriterFile) error {
+	if f, ok := f.(SyncWriterFile); ok {
+		return f.Sync()
+	}
+	return ErrNotImplemented
+}
+
+// AbortWriterFile is a WriterFile that provides an implementation of Abort
+// that discards the written data instead of committing it at Close such as
+// removing a temporary file or aborting a multipart upload.
+type AbortWriterFile interface {
+	WriterFile
+	Abort() error
+}
+
+// Abort discards the file f created as the named file on the filesystem. If
+// the file implements AbortWriterFile calls f.Abort otherwise, or if f.Abort
+// returns ErrNotImplemented, closes f and removes the named file. Abort may
+// be called after Close has failed.
+func Abort(fsys fs.FS, name string, f WriterFile) error {
+	if af, ok := f.(AbortWriterFile); ok {
+		if err := af.Abort(); !errors.Is(err, ErrNotImplemented) {
+			return err
+		}
+	}
+	f.Close()
+	if err := RemoveFile(fsys, name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
 // WriteFileFS is the interface implemented by a filesystem that provides an
 // optimized implementation of MkdirAll, CreateFile, WriteFile.
 type WriteFileFS interface {
@@ -32,7 +186,7 @@ func MkdirAll(fsys fs.FS, dir string, mode fs.FileMode) error {
 	if fsys, ok := fsys.(WriteFileFS); ok {
 		return fsys.MkdirAll(dir, mode)
 	}
-	return &fs.PathError{Op: "MkdirAll", Path: dir, Err: ErrNotImplemented}
+	return &fs.PathError{Op: string(OpMkdirAll), Path: dir, Err: ErrNotImplemented}
 }
 
 // CreateFile creates the named file. If the filesystem implements
@@ -41,7 +195,7 @@ func CreateFile(fsys fs.FS, name string, mode fs.FileMode) (WriterFile, error) {
 	if fsys, ok := fsys.(WriteFileFS); ok {
 		return fsys.CreateFile(name, mode)
 	}
-	return nil, &fs.PathError{Op: "CreateFile", Path: name, Err: ErrNotImplemented}
+	return nil, &fs.PathError{Op: string(OpCreateFile), Path: name, Err: ErrNotImplemented}
 }
 
 // WriteFile writes the specified bytes to the named file. If the filesystem implements
@@ -50,11 +204,13 @@ func WriteFile(fsys fs.FS, name string, p []byte, mode fs.FileMode) (n int, err
 	if fsys, ok := fsys.(WriteFileFS); ok {
 		return fsys.WriteFile(name, p, mode)
 	}
-	return 0, &fs.PathError{Op: "WriteFile", Path: name, Err: ErrNotImplemented}
+	return 0, &fs.PathError{Op: string(OpWriteFile), Path: name, Err: ErrNotImplemented}
 }
 
 // RemoveFileFS is the interface implemented by a filesystem that provides an
-// implementation of RemoveFile.
+// implementation of RemoveFile. Like os.Remove and os.RemoveAll, RemoveFile
+// must return an error wrapping fs.ErrNotExist if the named file does not
+// exist, and RemoveAll must return nil if the path does not exist.
 type RemoveFileFS interface {
 	fs.FS
 	RemoveFile(name string) error
@@ -67,21 +223,223 @@ func RemoveFile(fsys fs.FS, name string) error {
 	if fsys, ok := fsys.(RemoveFileFS); ok {
 		return fsys.RemoveFile(name)
 	}
-	return &fs.PathError{Op: "RemoveFile", Path: name, Err: ErrNotImplemented}
+	return &fs.PathError{Op: string(OpRemoveFile), Path: name, Err: ErrNotImplemented}
+}
+
+// RemoveIfExists removes the specified named file like RemoveFile but returns
+// no error if the file does not exist.
+func RemoveIfExists(fsys fs.FS, name string) error {
+	err := RemoveFile(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// RemoveFilesFS is the interface implemented by a filesystem that provides an
+// optimized implementation of RemoveFiles such as a batch delete request.
+type RemoveFilesFS interface {
+	fs.FS
+	RemoveFiles(names []string) error
+}
+
+// RemoveFiles removes the specified named files. If the filesystem implements
+// RemoveFilesFS calls fsys.RemoveFiles otherwise calls RemoveFile for each name.
+func RemoveFiles(fsys fs.FS, names []string) error {
+	if fsys, ok := fsys.(RemoveFilesFS); ok {
+		return fsys.RemoveFiles(names)
+	}
+	for _, name := range names {
+		if err := RemoveFile(fsys, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveAllOption is an option for RemoveAll.
+type RemoveAllOption func(o *removeAllOptions)
+
+type removeAllOptions struct {
+	protectRoot bool
+}
+
+// WithProtectRoot makes RemoveAll reject removing the root directory of the
+// filesystem such as "." or "" with ErrProtectedRoot.
+func WithProtectRoot() RemoveAllOption {
+	return func(o *removeAllOptions) {
+		o.protectRoot = true
+	}
+}
+
+// isRoot reports whether the specified name points the root directory.
+func isRoot(name string) bool {
+	return path.Clean(name) == "."
 }
 
 // RemoveAll removes path and any children it contains. If the filesystem
-// implements RemoveFileFS calls fsys.RemoveAll otherwise return a PathError.
-func RemoveAll(fsys fs.FS, path string) error {
+// implements RemoveFileFS calls fsys.RemoveAll, else if the filesystem
+// implements RemoveFilesFS removes path and its children by one RemoveFiles
+// with the children before their directories, otherwise returns a PathError.
+func RemoveAll(fsys fs.FS, path string, opts ...RemoveAllOption) error {
+	o := &removeAllOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.protectRoot && isRoot(path) {
+		return &fs.PathError{Op: string(OpRemoveAll), Path: path, Err: ErrProtectedRoot}
+	}
 	if fsys, ok := fsys.(RemoveFileFS); ok {
 		return fsys.RemoveAll(path)
 	}
-	return &fs.PathError{Op: "RemoveAll", Path: path, Err: ErrNotImplemented}
+	if fsys, ok := fsys.(RemoveFilesFS); ok {
+		names, err := RemoveAllDryRun(fsys, path)
+		if err != nil {
+			return err
+		}
+		var reversed []string
+		for i := len(names) - 1; i >= 0; i-- {
+			// NOTE: Keep the root and remove only its children.
+			if !isRoot(names[i]) {
+				reversed = append(reversed, names[i])
+			}
+		}
+		if len(reversed) == 0 {
+			return nil
+		}
+		return fsys.RemoveFiles(reversed)
+	}
+	return &fs.PathError{Op: string(OpRemoveAll), Path: path, Err: ErrNotImplemented}
+}
+
+// RemoveAllDryRun returns the names of the directories and files that
+// RemoveAll(fsys, path) would remove without removing them.
+func RemoveAllDryRun(fsys fs.FS, path string) ([]string, error) {
+	var names []string
+	err := fs.WalkDir(fsys, path, func(name string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return names, err
+}
+
+// CopyFileWithinFS is the interface implemented by a filesystem that provides an
+// optimized implementation of CopyFile such as a server-side copy.
+type CopyFileWithinFS interface {
+	fs.FS
+	CopyFile(src, dst string) error
+}
+
+// CopyFile copies the named file src to dst on the same filesystem. If the
+// filesystem implements CopyFileWithinFS calls fsys.CopyFile otherwise reads
+// src and writes dst using CreateFile. If src and dst are the same name
+// CopyFile only checks that src is a file, so the file is not truncated.
+func CopyFile(fsys fs.FS, src, dst string) error {
+	if path.Clean(src) == path.Clean(dst) {
+		return checkSameFile(fsys, OpCopyFile, src)
+	}
+	if fsys, ok := fsys.(CopyFileWithinFS); ok {
+		return fsys.CopyFile(src, dst)
+	}
+	srcFile, err := fsys.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	info, err := srcFile.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return &fs.PathError{Op: string(OpCopyFile), Path: src, Err: fs.ErrInvalid}
+	}
+	dstFile, err := CreateFile(fsys, dst, info.Mode())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		dstFile.Close()
+		return err
+	}
+	return dstFile.Close()
+}
+
+// checkSameFile returns nil if the named file exists and is not a directory.
+func checkSameFile(fsys fs.FS, op Op, name string) error {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
+	}
+	return nil
+}
+
+// MoveFile moves the named file src to dst on the same filesystem using
+// CopyFile and RemoveFile. If src and dst are the same name MoveFile only
+// checks that src is a file and does not remove it.
+func MoveFile(fsys fs.FS, src, dst string) error {
+	if path.Clean(src) == path.Clean(dst) {
+		return checkSameFile(fsys, OpMoveFile, src)
+	}
+	if err := CopyFile(fsys, src, dst); err != nil {
+		return err
+	}
+	return RemoveFile(fsys, src)
+}
+
+// URLFS is the interface implemented by a filesystem that provides direct
+// access URLs of files such as pre-signed URLs of object storages.
+type URLFS interface {
+	fs.FS
+	URL(name string, method string, expiry time.Duration) (string, error)
+}
+
+// URL returns a URL to access the named file directly with the specified HTTP
+// method until the expiry has passed. If the filesystem implements URLFS calls
+// fsys.URL otherwise returns a PathError.
+func URL(fsys fs.FS, name string, method string, expiry time.Duration) (string, error) {
+	if fsys, ok := fsys.(URLFS); ok {
+		return fsys.URL(name, method, expiry)
+	}
+	return "", &fs.PathError{Op: string(OpURL), Path: name, Err: ErrNotImplemented}
+}
+
+// CopyFSOption is an option for CopyFS.
+type CopyFSOption func(o *copyFSOptions)
+
+type copyFSOptions struct {
+	cleanupOnError bool
+}
+
+// WithCleanupOnError makes CopyFS discard a partially written destination file
+// using Abort when copying or closing the file fails.
+func WithCleanupOnError() CopyFSOption {
+	return func(o *copyFSOptions) {
+		o.cleanupOnError = true
+	}
 }
 
 // CopyFS walks the specified root directory on src and copies directories and
-// files to dest filesystem.
-func CopyFS(dest, src fs.FS, root string) error {
+// files to dest filesystem. The contents are streamed until EOF without relying
+// on the sizes of the files, so files whose sizes are UnknownSize are copied
+// entirely. If dest and src are the same filesystem that implements
+// CopyFileWithinFS the files are copied by CopyFile without streaming.
+func CopyFS(dest, src fs.FS, root string, opts ...CopyFSOption) error {
+	o := &copyFSOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	_, within := dest.(CopyFileWithinFS)
+	within = within && sameFS(dest, src)
 	return fs.WalkDir(src, root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil || d == nil {
 			return err
@@ -89,24 +447,36 @@ func CopyFS(dest, src fs.FS, root string) error {
 		if d.IsDir() {
 			return MkdirAll(dest, path, d.Type())
 		}
+		if within {
+			return CopyFile(dest, path, path)
+		}
 		srcFile, err := src.Open(path)
 		if err != nil {
 			return err
 		}
+		defer srcFile.Close()
+
 		destFile, err := CreateFile(dest, path, d.Type())
 		if err != nil {
 			return err
 		}
-		defer destFile.Close()
-
-		_, err = io.Copy(destFile, srcFile)
+		if _, err = io.Copy(destFile, srcFile); err == nil {
+			err = destFile.Close()
+		} else if !o.cleanupOnError {
+			destFile.Close()
+		}
+		if err != nil && o.cleanupOnError {
+			Abort(dest, path, destFile)
+		}
 		return err
 	})
 }
 
-// Glob calls fs.Glob.
-func Glob(fsys fs.FS, pattern string) (matches []string, err error) {
-	return fs.Glob(fsys, pattern)
+// sameFS reports whether a and b are the same filesystem. Filesystems of
+// types that are not comparable such as fstest.MapFS are never the same.
+func sameFS(a, b fs.FS) bool {
+	t := reflect.TypeOf(a)
+	return t == reflect.TypeOf(b) && t.Comparable() && a == b
 }
 
 // ReadFile calls fs.ReadFile.
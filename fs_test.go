@@ -2,11 +2,16 @@ package wfs
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"io/fs"
 	"io/ioutil"
+	"math"
 	"os"
 	"reflect"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func TestMkdirAll(t *testing.T) {
@@ -167,6 +172,135 @@ func TestRemoveAll(t *testing.T) {
 	}
 }
 
+func TestRemoveFiles(t *testing.T) {
+	var got []string
+	fsys := &FSDelegator{
+		RemoveFileFunc: func(name string) error {
+			got = append(got, name)
+			return nil
+		},
+	}
+
+	want := []string{"a.txt", "dir/b.txt"}
+	err := RemoveFiles(fsys, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+}
+
+type removeFilesFS struct {
+	*FSDelegator
+	names []string
+}
+
+func (fsys *removeFilesFS) RemoveFiles(names []string) error {
+	fsys.names = names
+	return nil
+}
+
+func TestRemoveFiles_RemoveFilesFS(t *testing.T) {
+	fsys := &removeFilesFS{FSDelegator: &FSDelegator{}}
+
+	want := []string{"a.txt", "dir/b.txt"}
+	err := RemoveFiles(fsys, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fsys.names, want) {
+		t.Errorf("unexpected %v; want %v", fsys.names, want)
+	}
+}
+
+type mapRemoveFilesFS struct {
+	fstest.MapFS
+	calls [][]string
+}
+
+func (fsys *mapRemoveFilesFS) RemoveFiles(names []string) error {
+	fsys.calls = append(fsys.calls, names)
+	return nil
+}
+
+func TestRemoveAll_RemoveFilesFS(t *testing.T) {
+	fsys := &mapRemoveFilesFS{MapFS: fstest.MapFS{
+		"dir/a.txt":     {Data: []byte("a")},
+		"dir/sub/b.txt": {Data: []byte("b")},
+		"other.txt":     {Data: []byte("other")},
+	}}
+	if err := RemoveAll(fsys, "dir"); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"dir/sub/b.txt", "dir/sub", "dir/a.txt", "dir"}}
+	if !reflect.DeepEqual(fsys.calls, want) {
+		t.Errorf("unexpected %v; want %v", fsys.calls, want)
+	}
+	if err := RemoveAll(fsys, "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if len(fsys.calls) != 1 {
+		t.Errorf("unexpected RemoveFiles calls %v", fsys.calls[1:])
+	}
+}
+
+func TestRemoveFiles_ErrNotImplemented(t *testing.T) {
+	fsys := &OpenFSDelegator{}
+
+	err := RemoveFiles(fsys, []string{"test.txt"})
+	if !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotImplemented)
+	}
+}
+
+func TestRemoveAll_WithProtectRoot(t *testing.T) {
+	called := false
+	fsys := &FSDelegator{
+		RemoveAllFunc: func(name string) error {
+			called = true
+			return nil
+		},
+	}
+
+	for _, path := range []string{"", ".", "./", "dir/.."} {
+		err := RemoveAll(fsys, path, WithProtectRoot())
+		if !errors.Is(err, ErrProtectedRoot) {
+			t.Errorf("%q: unexpected %v; want %v", path, err, ErrProtectedRoot)
+		}
+	}
+	if called {
+		t.Error("called RemoveAll")
+	}
+	if err := RemoveAll(fsys, "dir", WithProtectRoot()); err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Error("not called RemoveAll")
+	}
+}
+
+func TestRemoveAllDryRun(t *testing.T) {
+	fsys := os.DirFS("osfs/testdata")
+
+	got, err := RemoveAllDryRun(fsys, "dir0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"dir0", "dir0/file01.txt", "dir0/file02.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+
+	got, err = RemoveAllDryRun(fsys, "not-found")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("unexpected %v", got)
+	}
+}
+
 func TestRemoveFile_ErrNotImplemented(t *testing.T) {
 	fsys := &OpenFSDelegator{}
 
@@ -205,6 +339,233 @@ func TestRemoveAll_ErrNotImplemented(t *testing.T) {
 	}
 }
 
+func TestCopyFile(t *testing.T) {
+	var got []byte
+	gotName := ""
+	fsys := DelegateFS(os.DirFS("osfs/testdata"))
+	fsys.CreateFileFunc = func(name string, mode fs.FileMode) (WriterFile, error) {
+		gotName = name
+		return &FileDelegator{
+			WriteFunc: func(p []byte) (int, error) {
+				got = append(got, p...)
+				return len(p), nil
+			},
+		}, nil
+	}
+
+	err := CopyFile(fsys, "dir0/file01.txt", "dir1/file01.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := fs.ReadFile(fsys, "dir0/file01.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotName != "dir1/file01.txt" {
+		t.Errorf("unexpected %s; want %s", gotName, "dir1/file01.txt")
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %s; want %s", got, want)
+	}
+}
+
+type copyFileWithinFS struct {
+	*FSDelegator
+	src, dst string
+}
+
+func (fsys *copyFileWithinFS) CopyFile(src, dst string) error {
+	fsys.src, fsys.dst = src, dst
+	return nil
+}
+
+func TestCopyFile_CopyFileWithinFS(t *testing.T) {
+	fsys := &copyFileWithinFS{FSDelegator: &FSDelegator{}}
+
+	err := CopyFile(fsys, "src.txt", "dst.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fsys.src != "src.txt" || fsys.dst != "dst.txt" {
+		t.Errorf("unexpected %s, %s", fsys.src, fsys.dst)
+	}
+}
+
+func TestCopyFile_Errors(t *testing.T) {
+	fsys := DelegateFS(os.DirFS("osfs/testdata"))
+
+	if err := CopyFile(fsys, "not-found.txt", "dst.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	if err := CopyFile(fsys, "dir0", "dst"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+	if err := CopyFile(fsys, "dir0/file01.txt", "dst.txt"); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotImplemented)
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	var calls []string
+	fsys := &copyFileWithinFS{
+		FSDelegator: &FSDelegator{
+			RemoveFileFunc: func(name string) error {
+				calls = append(calls, name)
+				return nil
+			},
+		},
+	}
+
+	err := MoveFile(fsys, "src.txt", "dst.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fsys.src != "src.txt" || fsys.dst != "dst.txt" {
+		t.Errorf("unexpected %s, %s", fsys.src, fsys.dst)
+	}
+	if !reflect.DeepEqual(calls, []string{"src.txt"}) {
+		t.Errorf("unexpected %v", calls)
+	}
+}
+
+type urlFS struct {
+	*FSDelegator
+}
+
+func (fsys *urlFS) URL(name string, method string, expiry time.Duration) (string, error) {
+	return method + " " + name + " " + expiry.String(), nil
+}
+
+func TestURL(t *testing.T) {
+	got, err := URL(&urlFS{FSDelegator: &FSDelegator{}}, "test.txt", "GET", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "GET test.txt 1m0s"
+	if got != want {
+		t.Errorf("unexpected %s; want %s", got, want)
+	}
+}
+
+func TestURL_ErrNotImplemented(t *testing.T) {
+	_, err := URL(&OpenFSDelegator{}, "test.txt", "GET", time.Minute)
+	if !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotImplemented)
+	}
+}
+
+type writerAtFile struct {
+	*FileDelegator
+	p   []byte
+	off int64
+}
+
+func (f *writerAtFile) WriteAt(p []byte, off int64) (int, error) {
+	f.p, f.off = p, off
+	return len(p), nil
+}
+
+type seekerFile struct {
+	*FileDelegator
+	off int64
+}
+
+func (f *seekerFile) Seek(off int64, whence int) (int64, error) {
+	f.off = off
+	return off, nil
+}
+
+func TestWriteAt(t *testing.T) {
+	want := []byte("test")
+
+	wf := &writerAtFile{FileDelegator: &FileDelegator{}}
+	if _, err := WriteAt(wf, want, 3); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(wf.p, want) || wf.off != 3 {
+		t.Errorf("unexpected %s %d", wf.p, wf.off)
+	}
+
+	var got []byte
+	sf := &seekerFile{FileDelegator: &FileDelegator{
+		WriteFunc: func(p []byte) (int, error) {
+			got = p
+			return len(p), nil
+		},
+	}}
+	if _, err := WriteAt(sf, want, 3); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) || sf.off != 3 {
+		t.Errorf("unexpected %s %d", got, sf.off)
+	}
+
+	if _, err := WriteAt(&FileDelegator{}, want, 3); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotImplemented)
+	}
+}
+
+type sparseFile struct {
+	*FileDelegator
+	calls []string
+}
+
+func (f *sparseFile) Truncate(size int64) error {
+	f.calls = append(f.calls, fmt.Sprintf("Truncate(%d)", size))
+	return nil
+}
+
+func (f *sparseFile) PunchHole(off, length int64) error {
+	f.calls = append(f.calls, fmt.Sprintf("PunchHole(%d,%d)", off, length))
+	return nil
+}
+
+func TestSparseFile(t *testing.T) {
+	f := &sparseFile{FileDelegator: &FileDelegator{}}
+	if err := Truncate(f, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := PunchHole(f, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Truncate(10)", "PunchHole(2,3)"}
+	if !reflect.DeepEqual(f.calls, want) {
+		t.Errorf("unexpected %v; want %v", f.calls, want)
+	}
+
+	m := fstest.MapFS{"a.txt": {Data: []byte("test")}}
+	wf := &writerAtFile{FileDelegator: &FileDelegator{
+		StatFunc: func() (fs.FileInfo, error) {
+			return m.Stat("a.txt")
+		},
+	}}
+	if err := PunchHole(wf, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(wf.p, make([]byte, 2)) || wf.off != 1 {
+		t.Errorf("unexpected %v %d", wf.p, wf.off)
+	}
+	if err := PunchHole(wf, 2, 1<<40); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(wf.p, make([]byte, 2)) || wf.off != 2 {
+		t.Errorf("unexpected %v %d; want the range clamped to the size", wf.p, wf.off)
+	}
+	if err := PunchHole(wf, 3, math.MaxInt64); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(wf.p, make([]byte, 1)) || wf.off != 3 {
+		t.Errorf("unexpected %v %d; want the range clamped to the size", wf.p, wf.off)
+	}
+
+	if err := Truncate(&FileDelegator{}, 10); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotImplemented)
+	}
+	if err := PunchHole(&FileDelegator{}, 2, 3); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotImplemented)
+	}
+}
+
 func TestCopyFS(t *testing.T) {
 	tmpDir, err := ioutil.TempDir("", "test")
 	if err != nil {
@@ -250,6 +611,47 @@ func TestCopyFS(t *testing.T) {
 	}
 }
 
+type readFromFile struct {
+	*FileDelegator
+	p []byte
+}
+
+func (f *readFromFile) ReadFrom(r io.Reader) (int64, error) {
+	p, err := ioutil.ReadAll(r)
+	f.p = p
+	return int64(len(p)), err
+}
+
+func TestCopyFS_LargeWriterFile(t *testing.T) {
+	src := os.DirFS("osfs/testdata")
+	got := map[string]*readFromFile{}
+	dest := &FSDelegator{
+		CreateFileFunc: func(name string, _ fs.FileMode) (WriterFile, error) {
+			f := &readFromFile{FileDelegator: &FileDelegator{}}
+			got[name] = f
+			return f, nil
+		},
+	}
+
+	err := CopyFS(dest, src, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"dir0/file01.txt", "dir0/file02.txt"} {
+		want, err := fs.ReadFile(src, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, ok := got[name]
+		if !ok {
+			t.Fatalf("not created %s", name)
+		}
+		if !reflect.DeepEqual(f.p, want) {
+			t.Errorf("unexpected %s; want %s", f.p, want)
+		}
+	}
+}
+
 func TestCopyFS_StatError(t *testing.T) {
 	wantErr := errors.New("test")
 
@@ -294,12 +696,90 @@ func TestCopyFS_CreateFileError(t *testing.T) {
 	}
 }
 
-func TestGlob(t *testing.T) {
-	fsys := os.DirFS(".")
-	_, err := Glob(fsys, "*.md")
+func TestCopyFS_CopyFileWithinFS(t *testing.T) {
+	d := DelegateFS(fstest.MapFS{"dir/a.txt": {Data: []byte("a")}})
+	var created []string
+	d.CreateFileFunc = func(name string, _ fs.FileMode) (WriterFile, error) {
+		created = append(created, name)
+		return nil, errors.New("unexpected CreateFile")
+	}
+	fsys := d.FS()
+
+	if err := CopyFS(fsys, fsys, "."); err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 0 {
+		t.Errorf("unexpected CreateFile %v", created)
+	}
+}
+
+func TestCopyFS_CleanupOnError(t *testing.T) {
+	wantErr := errors.New("test")
+
+	src := fstest.MapFS{
+		"a.txt": {Data: []byte("a")},
+		"b.txt": {Data: []byte("b")},
+	}
+	m := fstest.MapFS{}
+	dest := newMapWriteFS(m)
+	dest.CreateFileFunc = func(name string, mode fs.FileMode) (WriterFile, error) {
+		m[name] = &fstest.MapFile{Mode: mode}
+		return &FileDelegator{
+			WriteFunc: func(p []byte) (int, error) {
+				return len(p), nil
+			},
+			CloseFunc: func() error {
+				if name == "b.txt" {
+					return wantErr
+				}
+				return nil
+			},
+		}, nil
+	}
+
+	if err := CopyFS(dest, src, "."); !errors.Is(err, wantErr) {
+		t.Errorf("unexpected %v; want %v", err, wantErr)
+	}
+	if _, ok := m["b.txt"]; !ok {
+		t.Errorf("unexpected removed b.txt")
+	}
+
+	delete(m, "a.txt")
+	delete(m, "b.txt")
+	if err := CopyFS(dest, src, ".", WithCleanupOnError()); !errors.Is(err, wantErr) {
+		t.Errorf("unexpected %v; want %v", err, wantErr)
+	}
+	if _, ok := m["a.txt"]; !ok {
+		t.Errorf("not created a.txt")
+	}
+	if _, ok := m["b.txt"]; ok {
+		t.Errorf("unexpected partial b.txt")
+	}
+}
+
+func TestCopyFS_UnknownSize(t *testing.T) {
+	src, err := NewTransformFS(newMapWriteFS(fstest.MapFS{}), []TransformRule{
+		{Pattern: "*.txt", Codecs: []string{"gzip"}},
+	})
 	if err != nil {
 		t.Fatal(err)
 	}
+	if _, err := src.WriteFile("a.txt", []byte("abcdef"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := src.Stat("a.txt"); err != nil {
+		t.Fatal(err)
+	} else if info.Size() != UnknownSize {
+		t.Fatalf("unexpected %d; want %d", info.Size(), UnknownSize)
+	}
+
+	m := fstest.MapFS{}
+	if err := CopyFS(newMapWriteFS(m), src, "."); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(m["a.txt"].Data); got != "abcdef" {
+		t.Errorf("unexpected %q; want %q", got, "abcdef")
+	}
 }
 
 func TestReadFile(t *testing.T) {
@@ -327,3 +807,23 @@ func TestWalkDir(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestRemoveIfExists(t *testing.T) {
+	wantErr := errors.New("test")
+	fsys := DelegateFS(fstest.MapFS{})
+	fsys.RemoveFileFunc = func(name string) error {
+		if name == "error.txt" {
+			return wantErr
+		}
+		return &fs.PathError{Op: string(OpRemoveFile), Path: name, Err: fs.ErrNotExist}
+	}
+	if err := RemoveIfExists(fsys, "missing.txt"); err != nil {
+		t.Errorf("unexpected %v; want nil", err)
+	}
+	if err := RemoveIfExists(fsys, "error.txt"); !errors.Is(err, wantErr) {
+		t.Errorf("unexpected %v; want %v", err, wantErr)
+	}
+	if err := RemoveIfExists(fstest.MapFS{}, "a.txt"); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotImplemented)
+	}
+}
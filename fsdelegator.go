@@ -1,6 +1,8 @@
 package wfs
 
 import (
+	"context"
+	"io"
 	"io/fs"
 	"time"
 )
@@ -13,7 +15,7 @@ type OpenFSDelegator struct {
 // Open calls OpenFunc(name).
 func (d *OpenFSDelegator) Open(name string) (fs.File, error) {
 	if d.OpenFunc == nil {
-		return nil, &fs.PathError{Op: "Open", Path: name, Err: ErrNotImplemented}
+		return nil, &fs.PathError{Op: string(OpOpen), Path: name, Err: ErrNotImplemented}
 	}
 	return d.OpenFunc(name)
 }
@@ -23,6 +25,8 @@ func DelegateOpenFS(fsys fs.FS) *OpenFSDelegator {
 	return &OpenFSDelegator{OpenFunc: fsys.Open}
 }
 
+//go:generate go run gen_delegator.go
+
 // FSDelegator implements all filesystem interfaces in io/fs and WriteFileFS.
 type FSDelegator struct {
 	OpenFunc       func(name string) (fs.File, error)
@@ -36,6 +40,19 @@ type FSDelegator struct {
 	WriteFileFunc  func(name string, p []byte, mode fs.FileMode) (int, error)
 	RemoveFileFunc func(name string) error
 	RemoveAllFunc  func(path string) error
+
+	// The functions of the optional interfaces are called only by the
+	// filesystem returned by FS.
+	CopyFileFunc           func(src, dst string) error
+	OpenRangeFunc          func(name string, off, length int64) (io.ReadCloser, error)
+	URLFunc                func(name string, method string, expiry time.Duration) (string, error)
+	RemoveFilesFunc        func(names []string) error
+	WatchFunc              func(ctx context.Context, dir string) (<-chan WatchEvent, error)
+	CreateFileWithMetaFunc func(name string, mode fs.FileMode, meta Metadata) (WriterFile, error)
+	WriteFileWithMetaFunc  func(name string, p []byte, mode fs.FileMode, meta Metadata) (int, error)
+	StatMetaFunc           func(name string) (Metadata, error)
+	WriteFileIfFunc        func(name string, p []byte, mode fs.FileMode, cond Condition) (string, error)
+	FileVersionFunc        func(name string) (string, error)
 }
 
 var (
@@ -52,7 +69,7 @@ var (
 // Open calls OpenFunc(name).
 func (d *FSDelegator) Open(name string) (fs.File, error) {
 	if d.OpenFunc == nil {
-		return nil, &fs.PathError{Op: "Open", Path: name, Err: ErrNotImplemented}
+		return nil, &fs.PathError{Op: string(OpOpen), Path: name, Err: ErrNotImplemented}
 	}
 	return d.OpenFunc(name)
 }
@@ -60,7 +77,7 @@ func (d *FSDelegator) Open(name string) (fs.File, error) {
 // ReadDir calls ReadDirFunc(name).
 func (d *FSDelegator) ReadDir(name string) ([]fs.DirEntry, error) {
 	if d.ReadDirFunc == nil {
-		return nil, &fs.PathError{Op: "ReadDir", Path: name, Err: ErrNotImplemented}
+		return nil, &fs.PathError{Op: string(OpReadDir), Path: name, Err: ErrNotImplemented}
 	}
 	return d.ReadDirFunc(name)
 }
@@ -68,7 +85,7 @@ func (d *FSDelegator) ReadDir(name string) ([]fs.DirEntry, error) {
 // ReadFile calls ReadFileFunc(name).
 func (d *FSDelegator) ReadFile(name string) ([]byte, error) {
 	if d.ReadFileFunc == nil {
-		return nil, &fs.PathError{Op: "ReadFile", Path: name, Err: ErrNotImplemented}
+		return nil, &fs.PathError{Op: string(OpReadFile), Path: name, Err: ErrNotImplemented}
 	}
 	return d.ReadFileFunc(name)
 }
@@ -76,7 +93,7 @@ func (d *FSDelegator) ReadFile(name string) ([]byte, error) {
 // Glob calls GlobFunc(name).
 func (d *FSDelegator) Glob(pattern string) ([]string, error) {
 	if d.GlobFunc == nil {
-		return nil, &fs.PathError{Op: "Glob", Path: pattern, Err: ErrNotImplemented}
+		return nil, &fs.PathError{Op: string(OpGlob), Path: pattern, Err: ErrNotImplemented}
 	}
 	return d.GlobFunc(pattern)
 }
@@ -84,7 +101,7 @@ func (d *FSDelegator) Glob(pattern string) ([]string, error) {
 // Stat calls StatFunc(name).
 func (d *FSDelegator) Stat(name string) (fs.FileInfo, error) {
 	if d.StatFunc == nil {
-		return nil, &fs.PathError{Op: "Stat", Path: name, Err: ErrNotImplemented}
+		return nil, &fs.PathError{Op: string(OpStat), Path: name, Err: ErrNotImplemented}
 	}
 	return d.StatFunc(name)
 }
@@ -92,7 +109,7 @@ func (d *FSDelegator) Stat(name string) (fs.FileInfo, error) {
 // Sub calls SubFunc(name).
 func (d *FSDelegator) Sub(name string) (fs.FS, error) {
 	if d.SubFunc == nil {
-		return nil, &fs.PathError{Op: "Sub", Path: name, Err: ErrNotImplemented}
+		return nil, &fs.PathError{Op: string(OpSub), Path: name, Err: ErrNotImplemented}
 	}
 	return d.SubFunc(name)
 }
@@ -109,7 +126,7 @@ func (d *FSDelegator) MkdirAll(dir string, mode fs.FileMode) error {
 // CreateFile calls CreateFileFunc(name).
 func (d *FSDelegator) CreateFile(name string, mode fs.FileMode) (WriterFile, error) {
 	if d.CreateFileFunc == nil {
-		return nil, &fs.PathError{Op: "CreateFile", Path: name, Err: ErrNotImplemented}
+		return nil, &fs.PathError{Op: string(OpCreateFile), Path: name, Err: ErrNotImplemented}
 	}
 	return d.CreateFileFunc(name, mode)
 }
@@ -117,7 +134,7 @@ func (d *FSDelegator) CreateFile(name string, mode fs.FileMode) (WriterFile, err
 // WriteFile calls WriteFileFunc(name).
 func (d *FSDelegator) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
 	if d.WriteFileFunc == nil {
-		return 0, &fs.PathError{Op: "WriteFile", Path: name, Err: ErrNotImplemented}
+		return 0, &fs.PathError{Op: string(OpWriteFile), Path: name, Err: ErrNotImplemented}
 	}
 	return d.WriteFileFunc(name, p, mode)
 }
@@ -125,7 +142,7 @@ func (d *FSDelegator) WriteFile(name string, p []byte, mode fs.FileMode) (int, e
 // RemoveFile calls RemoveFileFunc(name).
 func (d *FSDelegator) RemoveFile(name string) error {
 	if d.RemoveFileFunc == nil {
-		return &fs.PathError{Op: "RemoveFile", Path: name, Err: ErrNotImplemented}
+		return &fs.PathError{Op: string(OpRemoveFile), Path: name, Err: ErrNotImplemented}
 	}
 	return d.RemoveFileFunc(name)
 }
@@ -133,13 +150,48 @@ func (d *FSDelegator) RemoveFile(name string) error {
 // RemoveAll calls RemoveAllFunc(name).
 func (d *FSDelegator) RemoveAll(path string) error {
 	if d.RemoveAllFunc == nil {
-		return &fs.PathError{Op: "RemoveAll", Path: path, Err: ErrNotImplemented}
+		return &fs.PathError{Op: string(OpRemoveAll), Path: path, Err: ErrNotImplemented}
 	}
 	return d.RemoveAllFunc(path)
 }
 
+// FS returns a filesystem that calls the functions of d. Unlike d, the
+// returned filesystem implements WriteFileFS only if CreateFileFunc or
+// WriteFileFunc is set and RemoveFileFS only if RemoveFileFunc or RemoveAllFunc
+// is set, so WriteFile and RemoveFile report ErrNotImplemented before calling.
+//
+// The returned filesystem also implements CopyFileWithinFS with WriteFileFS and
+// RemoveFilesFS with RemoveFileFS. If their functions are not set they fall
+// back like CopyFile and RemoveFiles with the other functions of d.
+// RangeReaderFS, URLFS and WatchFS are implemented only if their functions
+// are set, and MetadataFS and ConditionalWriteFS with WriteFileFS only if any
+// of their functions is set, because callers check them to select a behavior.
+func (d *FSDelegator) FS() fs.FS {
+	isWrite := d.CreateFileFunc != nil || d.WriteFileFunc != nil
+	i := 0
+	for bit, ok := range []bool{
+		isWrite,
+		d.RemoveFileFunc != nil || d.RemoveAllFunc != nil,
+		isWrite && (d.CreateFileWithMetaFunc != nil || d.WriteFileWithMetaFunc != nil || d.StatMetaFunc != nil),
+		isWrite && (d.WriteFileIfFunc != nil || d.FileVersionFunc != nil),
+		d.OpenRangeFunc != nil,
+		d.URLFunc != nil,
+		d.WatchFunc != nil,
+	} {
+		if ok {
+			i |= 1 << bit
+		}
+	}
+	return fsDelegatorFSs[i](d)
+}
+
 // DelegateFS returns a FSDelegator delegates the functions of the specified filesystem.
 // If you want to delegate an open only filesystem like os.DirFS(dir string) use DelegateOpenFS instead.
+// The FSDelegator always implements WriteFileFS and RemoveFileFS even if the
+// specified filesystem does not. MkdirAll is delegated to the specified
+// filesystem if it implements WriteFileFS, otherwise it returns no error. Use
+// FS of the FSDelegator or Wrap to preserve the interfaces of the specified
+// filesystem.
 func DelegateFS(fsys fs.FS) *FSDelegator {
 	d := &FSDelegator{
 		OpenFunc: fsys.Open,
@@ -162,7 +214,7 @@ func DelegateFS(fsys fs.FS) *FSDelegator {
 		d.GlobFunc = casted.Glob
 	} else {
 		d.GlobFunc = func(pattern string) ([]string, error) {
-			return fs.Glob(fsys, pattern)
+			return Glob(fsys, pattern)
 		}
 	}
 	if casted, ok := fsys.(fs.StatFS); ok {
@@ -180,6 +232,7 @@ func DelegateFS(fsys fs.FS) *FSDelegator {
 		}
 	}
 	if casted, ok := fsys.(WriteFileFS); ok {
+		d.MkdirAllFunc = casted.MkdirAll
 		d.CreateFileFunc = casted.CreateFile
 		d.WriteFileFunc = casted.WriteFile
 	}
@@ -187,6 +240,30 @@ func DelegateFS(fsys fs.FS) *FSDelegator {
 		d.RemoveFileFunc = casted.RemoveFile
 		d.RemoveAllFunc = casted.RemoveAll
 	}
+	if casted, ok := fsys.(CopyFileWithinFS); ok {
+		d.CopyFileFunc = casted.CopyFile
+	}
+	if casted, ok := fsys.(RangeReaderFS); ok {
+		d.OpenRangeFunc = casted.OpenRange
+	}
+	if casted, ok := fsys.(URLFS); ok {
+		d.URLFunc = casted.URL
+	}
+	if casted, ok := fsys.(RemoveFilesFS); ok {
+		d.RemoveFilesFunc = casted.RemoveFiles
+	}
+	if casted, ok := fsys.(WatchFS); ok {
+		d.WatchFunc = casted.Watch
+	}
+	if casted, ok := fsys.(MetadataFS); ok {
+		d.CreateFileWithMetaFunc = casted.CreateFileWithMeta
+		d.WriteFileWithMetaFunc = casted.WriteFileWithMeta
+		d.StatMetaFunc = casted.StatMeta
+	}
+	if casted, ok := fsys.(ConditionalWriteFS); ok {
+		d.WriteFileIfFunc = casted.WriteFileIf
+		d.FileVersionFunc = casted.FileVersion
+	}
 	return d
 }
 
@@ -197,6 +274,17 @@ type FileDelegator struct {
 	CloseFunc   func() error
 	ReadDirFunc func(n int) ([]fs.DirEntry, error)
 	WriteFunc   func(p []byte) (int, error)
+
+	// The functions of the optional interfaces are called only by the file
+	// returned by File.
+	SeekFunc      func(offset int64, whence int) (int64, error)
+	ReadAtFunc    func(p []byte, off int64) (int, error)
+	ReadFromFunc  func(r io.Reader) (int64, error)
+	WriteAtFunc   func(p []byte, off int64) (int, error)
+	SyncFunc      func() error
+	TruncateFunc  func(size int64) error
+	PunchHoleFunc func(off, length int64) error
+	AbortFunc     func() error
 }
 
 var (
@@ -246,6 +334,28 @@ func (f *FileDelegator) Write(p []byte) (int, error) {
 	return f.WriteFunc(p)
 }
 
+// File returns f as a file that implements the optional interfaces io.Seeker,
+// io.ReaderAt, io.ReaderFrom, io.WriterAt, SyncWriterFile, SparseFile and
+// AbortWriterFile only if their functions are set, so a wrapper of a file
+// keeps the capabilities of the wrapped file.
+func (f *FileDelegator) File() fs.File {
+	i := 0
+	for bit, ok := range []bool{
+		f.SeekFunc != nil,
+		f.ReadAtFunc != nil,
+		f.ReadFromFunc != nil,
+		f.WriteAtFunc != nil,
+		f.SyncFunc != nil,
+		f.TruncateFunc != nil || f.PunchHoleFunc != nil,
+		f.AbortFunc != nil,
+	} {
+		if ok {
+			i |= 1 << bit
+		}
+	}
+	return fileDelegatorFiles[i](f)
+}
+
 // DelegateFile returns a FileDelegator delegates the functions of the specified file.
 func DelegateFile(f fs.File) *FileDelegator {
 	d := &FileDelegator{
@@ -259,9 +369,104 @@ func DelegateFile(f fs.File) *FileDelegator {
 	if f, ok := f.(WriterFile); ok {
 		d.WriteFunc = f.Write
 	}
+	if f, ok := f.(io.Seeker); ok {
+		d.SeekFunc = f.Seek
+	}
+	if f, ok := f.(io.ReaderAt); ok {
+		d.ReadAtFunc = f.ReadAt
+	}
+	if f, ok := f.(io.ReaderFrom); ok {
+		d.ReadFromFunc = f.ReadFrom
+	}
+	if f, ok := f.(io.WriterAt); ok {
+		d.WriteAtFunc = f.WriteAt
+	}
+	if f, ok := f.(SyncWriterFile); ok {
+		d.SyncFunc = f.Sync
+	}
+	if f, ok := f.(SparseFile); ok {
+		d.TruncateFunc = f.Truncate
+		d.PunchHoleFunc = f.PunchHole
+	}
+	if f, ok := f.(AbortWriterFile); ok {
+		d.AbortFunc = f.Abort
+	}
 	return d
 }
 
+// fileSeeker exposes io.Seeker of FileDelegator.
+type fileSeeker struct {
+	d *FileDelegator
+}
+
+func (f fileSeeker) Seek(offset int64, whence int) (int64, error) {
+	return f.d.SeekFunc(offset, whence)
+}
+
+// fileReaderAt exposes io.ReaderAt of FileDelegator.
+type fileReaderAt struct {
+	d *FileDelegator
+}
+
+func (f fileReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	return f.d.ReadAtFunc(p, off)
+}
+
+// fileReaderFrom exposes io.ReaderFrom of FileDelegator.
+type fileReaderFrom struct {
+	d *FileDelegator
+}
+
+func (f fileReaderFrom) ReadFrom(r io.Reader) (int64, error) {
+	return f.d.ReadFromFunc(r)
+}
+
+// fileWriterAt exposes io.WriterAt of FileDelegator.
+type fileWriterAt struct {
+	d *FileDelegator
+}
+
+func (f fileWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	return f.d.WriteAtFunc(p, off)
+}
+
+// fileSyncer exposes Sync of SyncWriterFile of FileDelegator.
+type fileSyncer struct {
+	d *FileDelegator
+}
+
+func (f fileSyncer) Sync() error {
+	return f.d.SyncFunc()
+}
+
+// fileSparse exposes Truncate and PunchHole of SparseFile of FileDelegator.
+type fileSparse struct {
+	d *FileDelegator
+}
+
+func (f fileSparse) Truncate(size int64) error {
+	if f.d.TruncateFunc == nil {
+		return ErrNotImplemented
+	}
+	return f.d.TruncateFunc(size)
+}
+
+func (f fileSparse) PunchHole(off, length int64) error {
+	if f.d.PunchHoleFunc == nil {
+		return ErrNotImplemented
+	}
+	return f.d.PunchHoleFunc(off, length)
+}
+
+// fileAborter exposes Abort of AbortWriterFile of FileDelegator.
+type fileAborter struct {
+	d *FileDelegator
+}
+
+func (f fileAborter) Abort() error {
+	return f.d.AbortFunc()
+}
+
 // DirEntryValues holds values for fs.DirEntry.
 type DirEntryValues struct {
 	Name  string
@@ -2,6 +2,7 @@ package wfs
 
 import (
 	"errors"
+	"io"
 	"io/fs"
 	"os"
 	"reflect"
@@ -32,6 +33,39 @@ func TestFSDelegator_TestFS(t *testing.T) {
 	}
 }
 
+func TestFSDelegator_FS(t *testing.T) {
+	d := DelegateFS(os.DirFS("osfs/testdata"))
+	fsys := d.FS()
+	if err := fstest.TestFS(fsys, "dir0/file01.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fsys.(WriteFileFS); ok {
+		t.Errorf("unexpected %T implements WriteFileFS", fsys)
+	}
+	if _, ok := fsys.(RemoveFileFS); ok {
+		t.Errorf("unexpected %T implements RemoveFileFS", fsys)
+	}
+
+	d.WriteFileFunc = func(name string, p []byte, mode fs.FileMode) (int, error) {
+		return len(p), nil
+	}
+	fsys = d.FS()
+	if _, ok := fsys.(WriteFileFS); !ok {
+		t.Errorf("unexpected %T does not implement WriteFileFS", fsys)
+	}
+	if _, ok := fsys.(RemoveFileFS); ok {
+		t.Errorf("unexpected %T implements RemoveFileFS", fsys)
+	}
+
+	d.RemoveAllFunc = func(path string) error {
+		return nil
+	}
+	fsys = d.FS()
+	if _, ok := fsys.(RemoveFileFS); !ok {
+		t.Errorf("unexpected %T does not implement RemoveFileFS", fsys)
+	}
+}
+
 func testFSDelegatorErrors(t *testing.T, d *FSDelegator, wantErr error) {
 	var err error
 	if _, err = d.Open(""); !errors.Is(err, wantErr) {
@@ -202,6 +236,17 @@ func TestDelegateFS_Sub(t *testing.T) {
 	}
 }
 
+func TestDelegateFS_MkdirAll(t *testing.T) {
+	m := fstest.MapFS{}
+	d := DelegateFS(newMapWriteFS(m))
+	if err := d.MkdirAll("dir", fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := m["dir"]; !ok || !f.Mode.IsDir() {
+		t.Errorf("unexpected %v; want dir", m)
+	}
+}
+
 func TestDelegateFile(t *testing.T) {
 	DelegateFile(&FileDelegator{})
 }
@@ -251,6 +296,73 @@ func TestFileDelegator(t *testing.T) {
 	}, wantErr)
 }
 
+func TestFileDelegator_File(t *testing.T) {
+	d := &FileDelegator{}
+	f := d.File()
+	if _, ok := f.(io.Seeker); ok {
+		t.Errorf("unexpected io.Seeker without SeekFunc")
+	}
+	if _, ok := f.(AbortWriterFile); ok {
+		t.Errorf("unexpected AbortWriterFile without AbortFunc")
+	}
+
+	var calls []string
+	d.WriteAtFunc = func(p []byte, off int64) (int, error) {
+		calls = append(calls, "WriteAt")
+		return len(p), nil
+	}
+	d.SyncFunc = func() error {
+		calls = append(calls, "Sync")
+		return nil
+	}
+	d.AbortFunc = func() error {
+		calls = append(calls, "Abort")
+		return nil
+	}
+	f = d.File()
+	if _, ok := f.(io.ReaderAt); ok {
+		t.Errorf("unexpected io.ReaderAt without ReadAtFunc")
+	}
+	if _, ok := f.(SparseFile); ok {
+		t.Errorf("unexpected SparseFile without TruncateFunc")
+	}
+	w := f.(WriterFile)
+	if _, err := WriteAt(w, []byte("a"), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := Sync(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := Abort(nil, "", w); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"WriteAt", "Sync", "Abort"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("unexpected %v; want %v", calls, want)
+	}
+}
+
+func TestDelegateFile_File(t *testing.T) {
+	m := fstest.MapFS{"a.txt": {Data: []byte("abc")}}
+	f, err := m.Open("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	df := DelegateFile(f).File()
+	if _, ok := df.(io.Seeker); !ok {
+		t.Errorf("unexpected io.Seeker is not forwarded")
+	}
+	p := make([]byte, 2)
+	if _, err := df.(io.ReaderAt).ReadAt(p, 1); err != nil {
+		t.Fatal(err)
+	}
+	if string(p) != "bc" {
+		t.Errorf("unexpected %q; want %q", p, "bc")
+	}
+}
+
 func TestDirEntryDelegator(t *testing.T) {
 	fsys := os.DirFS("osfs/testdata")
 	ds, err := fs.ReadDir(fsys, ".")
@@ -0,0 +1,94 @@
+//go:build ignore
+// +build ignore
+
+// gen_delegator generates delegator_gen.go that has the combinations of the
+// optional interfaces selected by FSDelegator.FS and FileDelegator.File.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+)
+
+// fsParts are the types that expose the optional interfaces of an
+// FSDelegator in the order of the bits of the index of fsDelegatorFSs.
+var fsParts = []string{
+	"writeFS",
+	"removeFS",
+	"metaFS",
+	"condFS",
+	"delegateRangeFS",
+	"delegateURLFS",
+	"delegateWatchFS",
+}
+
+// fileParts are the types that expose the optional interfaces of a
+// FileDelegator in the order of the bits of the index of fileDelegatorFiles.
+var fileParts = []string{
+	"fileSeeker",
+	"fileReaderAt",
+	"fileReaderFrom",
+	"fileWriterAt",
+	"fileSyncer",
+	"fileSparse",
+	"fileAborter",
+}
+
+func main() {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "// Code generated by gen_delegator.go. DO NOT EDIT.")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "package wfs")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, `import "io/fs"`)
+	fmt.Fprintln(&b)
+	writeTable(&b, "fsDelegatorFSs", "FSDelegator", "fs.FS", "readFS", fsParts)
+	fmt.Fprintln(&b)
+	writeTable(&b, "fileDelegatorFiles", "FileDelegator", "fs.File", "*FileDelegator", fileParts)
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("delegator_gen.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeTable writes the table of functions that return the base type embedded
+// with the parts selected by the bits of the index.
+func writeTable(b *bytes.Buffer, table, delegator, typ, base string, parts []string) {
+	fmt.Fprintf(b, "// %s returns %s that exposes the parts of %s\n", table, typ, delegator)
+	fmt.Fprintf(b, "// selected by the bits of the index in the order of the parts in\n")
+	fmt.Fprintf(b, "// gen_delegator.go.\n")
+	fmt.Fprintf(b, "var %s = [%d]func(d *%s) %s{\n", table, 1<<len(parts), delegator, typ)
+	baseValue := strings.TrimPrefix(base, "*") + "{d}"
+	if strings.HasPrefix(base, "*") {
+		baseValue = "d"
+	}
+	for i := 0; i < 1<<len(parts); i++ {
+		fields := []string{base}
+		values := []string{baseValue}
+		for j, part := range parts {
+			if i&(1<<j) != 0 {
+				fields = append(fields, part)
+				values = append(values, part+"{d}")
+			}
+		}
+		if len(fields) == 1 {
+			if baseValue == "d" {
+				fmt.Fprintf(b, "func(d *%s) %s { return d },\n", delegator, typ)
+			} else {
+				fmt.Fprintf(b, "func(d *%s) %s { return &%s },\n", delegator, typ, baseValue)
+			}
+			continue
+		}
+		fmt.Fprintf(b, "func(d *%s) %s {\nreturn &struct {\n%s\n}{%s}\n},\n",
+			delegator, typ, strings.Join(fields, "\n"), strings.Join(values, ", "))
+	}
+	fmt.Fprintln(b, "}")
+}
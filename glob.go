@@ -0,0 +1,127 @@
+package wfs
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// globConcurrency is the maximum number of concurrent ReadDir calls in Glob.
+const globConcurrency = 16
+
+// Glob returns the lexically sorted names of all files matching pattern or nil
+// if there is no matching file. If the filesystem implements fs.GlobFS calls
+// fsys.Glob and sorts the names if the filesystem did not. Otherwise Glob
+// reads only the directories required by the pattern and issues ReadDir calls
+// of each directory level concurrently, so patterns like "logs/*/2024-*/*.gz"
+// are matched faster than fs.Glob on remote filesystems. Unlike fs.Glob the
+// names are sorted as whole paths, not directory by directory, so the order
+// does not depend on the backend.
+func Glob(fsys fs.FS, pattern string) (matches []string, err error) {
+	if fsys, ok := fsys.(fs.GlobFS); ok {
+		matches, err := fsys.Glob(pattern)
+		if err == nil && !sort.StringsAreSorted(matches) {
+			sort.Strings(matches)
+		}
+		return matches, err
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(pattern, "/")
+	names := []string{""}
+	for i, part := range parts {
+		if !hasMeta(part) {
+			for j, name := range names {
+				names[j] = joinGlob(name, part)
+			}
+			if i == len(parts)-1 {
+				names = globEach(names, func(name string) []string {
+					if _, err := fs.Stat(fsys, name); err != nil {
+						return nil
+					}
+					return []string{name}
+				})
+			}
+			continue
+		}
+		names = globEach(names, func(dir string) []string {
+			d := dir
+			if d == "" {
+				d = "."
+			}
+			entries, err := fs.ReadDir(fsys, d)
+			if err != nil {
+				return nil
+			}
+			var matched []string
+			for _, e := range entries {
+				if ok, _ := path.Match(part, e.Name()); ok {
+					matched = append(matched, joinGlob(dir, e.Name()))
+				}
+			}
+			return matched
+		})
+		if len(names) == 0 {
+			return nil, nil
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// MatchFile reports whether the named file matches the path.Match pattern. A
+// pattern that contains no "/" is matched against the base name of the file,
+// otherwise against the whole name.
+func MatchFile(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		name = path.Base(name)
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// hasMeta reports whether the pattern contains any of the magic characters
+// recognized by path.Match.
+func hasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+func joinGlob(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// globEach calls fn for each name concurrently and returns the concatenated
+// results in the order of names.
+func globEach(names []string, fn func(name string) []string) []string {
+	results := make([][]string, len(names))
+	sem := make(chan struct{}, globConcurrency)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, name string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = fn(name)
+		}(i, name)
+	}
+	wg.Wait()
+
+	var matches []string
+	for _, r := range results {
+		matches = append(matches, r...)
+	}
+	return matches
+}
@@ -0,0 +1,82 @@
+package wfs
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGlob(t *testing.T) {
+	fsys := os.DirFS(".")
+	_, err := Glob(fsys, "*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGlob_ReadDir(t *testing.T) {
+	m := fstest.MapFS{
+		"logs/app/2023-12/a.gz":  {},
+		"logs/app/2024-01/b.gz":  {},
+		"logs/app/2024-01/c.txt": {},
+		"logs/db/2024-02/d.gz":   {},
+		"logs/db/2024-02/e.gz":   {},
+		"logs/web.gz":            {},
+		"README.md":              {},
+	}
+	// NOTE: OpenFSDelegator hides fstest.MapFS.Glob.
+	fsys := DelegateOpenFS(m)
+
+	patterns := []string{
+		"logs/*/2024-*/*.gz",
+		"logs/*",
+		"logs/app/2024-01/b.gz",
+		"logs/app/2024-01/z.gz",
+		"*/db/*/[d-e].gz",
+		"*.md",
+		"*",
+		".",
+		"logs/*/missing/*",
+	}
+	for _, pattern := range patterns {
+		want, err := fs.Glob(m, pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Glob(fsys, pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: unexpected %v; want %v", pattern, got, want)
+		}
+	}
+}
+
+func TestGlob_ErrBadPattern(t *testing.T) {
+	_, err := Glob(DelegateOpenFS(fstest.MapFS{}), "[")
+	if err != path.ErrBadPattern {
+		t.Errorf("unexpected %v; want %v", err, path.ErrBadPattern)
+	}
+}
+
+func TestMatchFile(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "*.css", name: "assets/app.css", want: true},
+		{pattern: "assets/*.css", name: "assets/app.css", want: true},
+		{pattern: "*/app.css", name: "assets/css/app.css", want: false},
+		{pattern: "*.js", name: "assets/app.css", want: false},
+	}
+	for _, tc := range testCases {
+		if got := MatchFile(tc.pattern, tc.name); got != tc.want {
+			t.Errorf("unexpected MatchFile(%q, %q) %v; want %v", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+}
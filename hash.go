@@ -0,0 +1,191 @@
+package wfs
+
+import (
+	"bytes"
+	"crypto/md5"
+	"hash"
+	"io"
+	"io/fs"
+)
+
+// HashMD5 is the algorithm name of MD5 returned by HashedInfo.Hash.
+const HashMD5 = "md5"
+
+// HashedInfo is the interface implemented by a fs.FileInfo or fs.DirEntry that
+// provides a content hash such as an ETag of S3 or a CRC32C of GCS.
+// Hash returns a nil sum if the hash is not available.
+type HashedInfo interface {
+	Hash() (algo string, sum []byte)
+}
+
+// HashReader returns the MD5 sum of the data read from r.
+func HashReader(r io.Reader) ([]byte, error) {
+	h := md5.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// FileHash returns the hash of the named file. If the fs.FileInfo of the file
+// implements HashedInfo returns its hash otherwise reads the file and returns
+// the MD5 sum.
+func FileHash(fsys fs.FS, name string) (algo string, sum []byte, err error) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return "", nil, err
+	}
+	return fileHash(fsys, name, info)
+}
+
+func fileHash(fsys fs.FS, name string, info fs.FileInfo) (string, []byte, error) {
+	if info.IsDir() {
+		return "", nil, &fs.PathError{Op: "FileHash", Path: name, Err: fs.ErrInvalid}
+	}
+	if h, ok := info.(HashedInfo); ok {
+		if algo, sum := h.Hash(); sum != nil {
+			return algo, sum, nil
+		}
+	}
+	sum, err := readHash(fsys, name)
+	if err != nil {
+		return "", nil, err
+	}
+	return HashMD5, sum, nil
+}
+
+func readHash(fsys fs.FS, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return HashReader(f)
+}
+
+// EqualFile reports whether the named file on fsys1 and the named file on
+// fsys2 have the same content. EqualFile compares sizes and hashes provided by
+// HashedInfo to avoid reading file bodies if possible. Sizes are not compared
+// if either of them is UnknownSize.
+func EqualFile(fsys1 fs.FS, name1 string, fsys2 fs.FS, name2 string) (bool, error) {
+	info1, err := fs.Stat(fsys1, name1)
+	if err != nil {
+		return false, err
+	}
+	info2, err := fs.Stat(fsys2, name2)
+	if err != nil {
+		return false, err
+	}
+	size1, size2 := info1.Size(), info2.Size()
+	if size1 != UnknownSize && size2 != UnknownSize && size1 != size2 {
+		return false, nil
+	}
+	if h1, ok := info1.(HashedInfo); ok {
+		if h2, ok := info2.(HashedInfo); ok {
+			algo1, sum1 := h1.Hash()
+			algo2, sum2 := h2.Hash()
+			if sum1 != nil && sum2 != nil && algo1 == algo2 {
+				return bytes.Equal(sum1, sum2), nil
+			}
+		}
+	}
+	sum1, err := readHash(fsys1, name1)
+	if err != nil {
+		return false, err
+	}
+	sum2, err := readHash(fsys2, name2)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(sum1, sum2), nil
+}
+
+// SumWriterFile is a WriterFile that provides the hash sum of the bytes
+// written to the file.
+type SumWriterFile interface {
+	WriterFile
+	Sum() []byte
+}
+
+// HashingWriter is a SumWriterFile that computes the hash of the bytes written
+// to the wrapped file, so the checksum of an uploaded file is available at
+// Close without reading the file again.
+type HashingWriter struct {
+	WriterFile
+	h hash.Hash
+	// fsys and name are set by HashingFS to discard the file by Abort.
+	fsys fs.FS
+	name string
+}
+
+var (
+	_ SumWriterFile   = (*HashingWriter)(nil)
+	_ AbortWriterFile = (*HashingWriter)(nil)
+)
+
+// NewHashingWriter returns a HashingWriter that writes to f and h.
+func NewHashingWriter(f WriterFile, h hash.Hash) *HashingWriter {
+	return &HashingWriter{WriterFile: f, h: h}
+}
+
+// Write writes p to the wrapped file and the hash.
+func (w *HashingWriter) Write(p []byte) (int, error) {
+	n, err := w.WriterFile.Write(p)
+	w.h.Write(p[:n])
+	return n, err
+}
+
+// Sum returns the hash sum of the bytes written.
+func (w *HashingWriter) Sum() []byte {
+	return w.h.Sum(nil)
+}
+
+// Abort calls Abort of the wrapped file. If the writer is not created by
+// HashingFS and the wrapped file does not implement AbortWriterFile it returns
+// ErrNotImplemented.
+func (w *HashingWriter) Abort() error {
+	if w.fsys != nil {
+		return Abort(w.fsys, w.name, w.WriterFile)
+	}
+	if af, ok := w.WriterFile.(AbortWriterFile); ok {
+		return af.Abort()
+	}
+	return ErrNotImplemented
+}
+
+// HashingFS is a filesystem that returns a HashingWriter from CreateFile.
+type HashingFS struct {
+	*FSDelegator
+	fsys    fs.FS
+	newHash func() hash.Hash
+}
+
+var _ WriteFileFS = (*HashingFS)(nil)
+
+// NewHashingFS returns a HashingFS that computes hashes by newHash such as
+// sha256.New.
+func NewHashingFS(fsys fs.FS, newHash func() hash.Hash) *HashingFS {
+	return &HashingFS{
+		FSDelegator: DelegateFS(fsys),
+		fsys:        fsys,
+		newHash:     newHash,
+	}
+}
+
+// FS returns fsys as a filesystem that implements WriteFileFS and
+// RemoveFileFS only if the wrapped filesystem implements them.
+func (fsys *HashingFS) FS() fs.FS {
+	return narrowFS(fsys, fsys.fsys)
+}
+
+// CreateFile creates the named file and returns a HashingWriter of the file.
+func (fsys *HashingFS) CreateFile(name string, mode fs.FileMode) (WriterFile, error) {
+	f, err := CreateFile(fsys.fsys, name, mode)
+	if err != nil {
+		return nil, err
+	}
+	w := NewHashingWriter(f, fsys.newHash())
+	w.fsys, w.name = fsys.fsys, name
+	return w, nil
+}
@@ -0,0 +1,211 @@
+package wfs
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type hashedInfo struct {
+	*FileInfoDelegator
+	algo string
+	sum  []byte
+}
+
+func (info *hashedInfo) Hash() (string, []byte) {
+	return info.algo, info.sum
+}
+
+func newHashedFS(fsys fs.FS, algo string, sum []byte) *FSDelegator {
+	d := DelegateFS(fsys)
+	d.StatFunc = func(name string) (fs.FileInfo, error) {
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		return &hashedInfo{FileInfoDelegator: DelegateFileInfo(info), algo: algo, sum: sum}, nil
+	}
+	return d
+}
+
+func TestFileHash(t *testing.T) {
+	fsys := os.DirFS("osfs/testdata")
+	name := "dir0/file01.txt"
+	p, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := md5.Sum(p)
+
+	algo, got, err := FileHash(fsys, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if algo != HashMD5 || !reflect.DeepEqual(got, want[:]) {
+		t.Errorf("unexpected %s %x; want %s %x", algo, got, HashMD5, want)
+	}
+
+	algo, got, err = FileHash(newHashedFS(fsys, "test", []byte("sum")), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if algo != "test" || string(got) != "sum" {
+		t.Errorf("unexpected %s %s", algo, got)
+	}
+}
+
+func TestFileHash_Errors(t *testing.T) {
+	fsys := os.DirFS("osfs/testdata")
+
+	if _, _, err := FileHash(fsys, "not-found.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	if _, _, err := FileHash(fsys, "dir0"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+}
+
+func TestEqualFile_UnknownSize(t *testing.T) {
+	fsys, err := NewTransformFS(newMapWriteFS(fstest.MapFS{}), []TransformRule{
+		{Pattern: "*.gz", Codecs: []string{"gzip"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("a.gz", []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	plain := fstest.MapFS{
+		"a.txt": {Data: []byte("hello")},
+		"b.txt": {Data: []byte("world")},
+	}
+	for name, want := range map[string]bool{"a.txt": true, "b.txt": false} {
+		got, err := EqualFile(fsys, "a.gz", plain, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("unexpected %v for %s; want %v", got, name, want)
+		}
+	}
+}
+
+func TestEqualFile(t *testing.T) {
+	fsys := os.DirFS("osfs/testdata")
+	opened := 0
+	counting := DelegateFS(fsys)
+	counting.OpenFunc = func(name string) (fs.File, error) {
+		opened++
+		return fsys.Open(name)
+	}
+
+	testCases := []struct {
+		fsys1, fsys2 fs.FS
+		name1, name2 string
+		want         bool
+	}{
+		{
+			fsys1: fsys, name1: "dir0/file01.txt",
+			fsys2: fsys, name2: "dir0/file01.txt",
+			want: true,
+		}, {
+			fsys1: fsys, name1: "dir0/file01.txt",
+			fsys2: fsys, name2: "dir0/file02.txt",
+			want: false,
+		}, {
+			fsys1: fsys, name1: "dir0/file01.txt",
+			fsys2: os.DirFS("."), name2: "README.md",
+			want: false,
+		}, {
+			fsys1: newHashedFS(fsys, "test", []byte("a")), name1: "dir0/file01.txt",
+			fsys2: newHashedFS(fsys, "test", []byte("b")), name2: "dir0/file01.txt",
+			want: false,
+		}, {
+			fsys1: newHashedFS(counting, "test", []byte("a")), name1: "dir0/file01.txt",
+			fsys2: newHashedFS(counting, "test", []byte("a")), name2: "dir0/file02.txt",
+			want: true,
+		},
+	}
+	for i, tc := range testCases {
+		got, err := EqualFile(tc.fsys1, tc.name1, tc.fsys2, tc.name2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("tests[%d] unexpected %v; want %v", i, got, tc.want)
+		}
+	}
+	if opened != 0 {
+		t.Errorf("unexpected opened %d", opened)
+	}
+}
+
+func TestHashingWriter(t *testing.T) {
+	m := fstest.MapFS{}
+	fsys := NewHashingFS(newMapWriteFS(m), sha256.New)
+
+	f, err := fsys.CreateFile("test.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(f, strings.NewReader("hello,world")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sf, ok := f.(SumWriterFile)
+	if !ok {
+		t.Fatalf("unexpected %T does not implement SumWriterFile", f)
+	}
+	want := sha256.Sum256([]byte("hello,world"))
+	if got := sf.Sum(); !reflect.DeepEqual(got, want[:]) {
+		t.Errorf("unexpected %x; want %x", got, want)
+	}
+	if got := string(m["test.txt"].Data); got != "hello,world" {
+		t.Errorf("unexpected %s", got)
+	}
+}
+
+func TestHashingWriter_Abort(t *testing.T) {
+	m := fstest.MapFS{}
+	fsys := NewHashingFS(newMapWriteFS(m), sha256.New)
+
+	f, err := fsys.CreateFile("test.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Abort(fsys, "test.txt", f); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["test.txt"]; ok {
+		t.Errorf("unexpected test.txt is committed")
+	}
+
+	var aborted bool
+	w := NewHashingWriter((&FileDelegator{
+		AbortFunc: func() error {
+			aborted = true
+			return nil
+		},
+	}).File().(WriterFile), sha256.New())
+	if err := w.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	if !aborted {
+		t.Errorf("unexpected the wrapped file is not aborted")
+	}
+	if err := NewHashingWriter(&FileDelegator{}, sha256.New()).Abort(); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotImplemented)
+	}
+}
@@ -0,0 +1,365 @@
+package wfs
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// IndexFS is a filesystem that keeps an in-memory index of paths and file
+// infos of the wrapped filesystem. Writes through IndexFS update the index so
+// Glob, ReadDir, Stat and Search are served without accessing the wrapped
+// filesystem. Changes made directly to the wrapped filesystem are not visible
+// until Reindex is called.
+type IndexFS struct {
+	fsys     fs.FS
+	mutex    sync.RWMutex
+	infos    map[string]fs.FileInfo
+	children map[string]map[string]bool
+	hashes   map[string]indexHash
+}
+
+type indexHash struct {
+	algo string
+	sum  []byte
+}
+
+var (
+	_ fs.GlobFS        = (*IndexFS)(nil)
+	_ fs.ReadDirFS     = (*IndexFS)(nil)
+	_ fs.ReadFileFS    = (*IndexFS)(nil)
+	_ fs.StatFS        = (*IndexFS)(nil)
+	_ fs.SubFS         = (*IndexFS)(nil)
+	_ WriteFileFS      = (*IndexFS)(nil)
+	_ RemoveFileFS     = (*IndexFS)(nil)
+	_ CopyFileWithinFS = (*IndexFS)(nil)
+)
+
+// NewIndexFS returns an IndexFS that indexes all files of fsys.
+func NewIndexFS(fsys fs.FS) (*IndexFS, error) {
+	x := &IndexFS{
+		fsys: fsys,
+	}
+	if err := x.Reindex(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// FS returns x as a filesystem that implements WriteFileFS and RemoveFileFS
+// only if the indexed filesystem implements them. The returned filesystem
+// reads the index but does not provide Reindex and Search.
+func (x *IndexFS) FS() fs.FS {
+	return narrowFS(x, x.fsys)
+}
+
+// Open opens the named file of the wrapped filesystem.
+func (x *IndexFS) Open(name string) (fs.File, error) {
+	return x.fsys.Open(name)
+}
+
+// ReadFile reads the named file of the wrapped filesystem.
+func (x *IndexFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(x.fsys, name)
+}
+
+// Sub returns the subtree of the wrapped filesystem rooted at dir. The
+// returned filesystem is not indexed.
+func (x *IndexFS) Sub(dir string) (fs.FS, error) {
+	return fs.Sub(x.fsys, dir)
+}
+
+// Reindex rebuilds the index by walking the wrapped filesystem.
+func (x *IndexFS) Reindex() error {
+	infos := map[string]fs.FileInfo{}
+	children := map[string]map[string]bool{}
+	err := fs.WalkDir(x.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		infos[name] = info
+		if name != "." {
+			dir := path.Dir(name)
+			if children[dir] == nil {
+				children[dir] = map[string]bool{}
+			}
+			children[dir][path.Base(name)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	x.infos = infos
+	x.children = children
+	x.hashes = map[string]indexHash{}
+	return nil
+}
+
+// update indexes the named file and its parent directories.
+func (x *IndexFS) update(name string) {
+	info, err := fs.Stat(x.fsys, name)
+	if err != nil {
+		return
+	}
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	x.infos[name] = info
+	delete(x.hashes, name)
+	for name != "." {
+		dir := path.Dir(name)
+		if x.children[dir] == nil {
+			x.children[dir] = map[string]bool{}
+		}
+		x.children[dir][path.Base(name)] = true
+		if _, ok := x.infos[dir]; !ok {
+			if info, err := fs.Stat(x.fsys, dir); err == nil {
+				x.infos[dir] = info
+			}
+		}
+		name = dir
+	}
+}
+
+// remove removes the named path and its children from the index.
+func (x *IndexFS) remove(name string) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	name = path.Clean(name)
+	if name == "." {
+		// NOTE: Keep the root and clear only its children.
+		for k := range x.infos {
+			if k != "." {
+				delete(x.infos, k)
+				delete(x.children, k)
+				delete(x.hashes, k)
+			}
+		}
+		x.children["."] = map[string]bool{}
+		return
+	}
+	prefix := name + "/"
+	for k := range x.infos {
+		if k == name || strings.HasPrefix(k, prefix) {
+			delete(x.infos, k)
+			delete(x.children, k)
+			delete(x.hashes, k)
+		}
+	}
+	if c := x.children[path.Dir(name)]; c != nil {
+		delete(c, path.Base(name))
+	}
+}
+
+// Stat returns the indexed FileInfo of the named file.
+func (x *IndexFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: string(OpStat), Path: name, Err: fs.ErrInvalid}
+	}
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+
+	info, ok := x.infos[name]
+	if !ok {
+		return nil, &fs.PathError{Op: string(OpStat), Path: name, Err: fs.ErrNotExist}
+	}
+	return info, nil
+}
+
+// Hash returns the hash of the named file by FileHash. The hash is cached in
+// the index until the file is changed through IndexFS.
+func (x *IndexFS) Hash(name string) (algo string, sum []byte, err error) {
+	info, err := x.Stat(name)
+	if err != nil {
+		return "", nil, err
+	}
+	x.mutex.RLock()
+	h, ok := x.hashes[name]
+	x.mutex.RUnlock()
+	if ok {
+		return h.algo, h.sum, nil
+	}
+	algo, sum, err = fileHash(x.fsys, name, info)
+	if err != nil {
+		return "", nil, err
+	}
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	if _, ok := x.infos[name]; ok {
+		x.hashes[name] = indexHash{algo: algo, sum: sum}
+	}
+	return algo, sum, nil
+}
+
+// ReadDir returns the indexed entries of the named directory sorted by
+// filename.
+func (x *IndexFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(dir) {
+		return nil, &fs.PathError{Op: string(OpReadDir), Path: dir, Err: fs.ErrInvalid}
+	}
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+
+	info, ok := x.infos[dir]
+	if !ok {
+		return nil, &fs.PathError{Op: string(OpReadDir), Path: dir, Err: fs.ErrNotExist}
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: string(OpReadDir), Path: dir, Err: fs.ErrInvalid}
+	}
+	names := make([]string, 0, len(x.children[dir]))
+	for name := range x.children[dir] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]fs.DirEntry, len(names))
+	for i, name := range names {
+		info := x.infos[path.Join(dir, name)]
+		entries[i] = &DirEntryDelegator{
+			Values: DirEntryValues{
+				Name:  name,
+				IsDir: info.IsDir(),
+				Type:  info.Mode().Type(),
+				Info:  info,
+			},
+		}
+	}
+	return entries, nil
+}
+
+// Glob returns the indexed names matching pattern sorted by name.
+func (x *IndexFS) Glob(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+
+	var names []string
+	for name := range x.infos {
+		if ok, _ := path.Match(pattern, name); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Search returns the indexed names sorted by name that contain all tokens of
+// query. Names and query are split into tokens by non-alphanumeric characters
+// and each token of query matches a prefix of a token of a name ignoring case.
+func (x *IndexFS) Search(query string) []string {
+	qs := tokenize(query)
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+
+	var names []string
+	for name := range x.infos {
+		if name != "." && matchTokens(tokenize(name), qs) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func matchTokens(tokens, queries []string) bool {
+	for _, q := range queries {
+		found := false
+		for _, t := range tokens {
+			if strings.HasPrefix(t, q) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// MkdirAll calls MkdirAll of the wrapped filesystem and updates the index.
+func (x *IndexFS) MkdirAll(dir string, mode fs.FileMode) error {
+	if err := MkdirAll(x.fsys, dir, mode); err != nil {
+		return err
+	}
+	x.update(dir)
+	return nil
+}
+
+// CreateFile calls CreateFile of the wrapped filesystem and updates the index
+// when the file is closed. The returned file implements the optional
+// interfaces of the created file and the index is not updated if the file is
+// aborted.
+func (x *IndexFS) CreateFile(name string, mode fs.FileMode) (WriterFile, error) {
+	f, err := CreateFile(x.fsys, name, mode)
+	if err != nil {
+		return nil, err
+	}
+	d := DelegateFile(f)
+	d.CloseFunc = func() error {
+		err := f.Close()
+		x.update(name)
+		return err
+	}
+	return d.File().(WriterFile), nil
+}
+
+// WriteFile calls WriteFile of the wrapped filesystem and updates the index.
+func (x *IndexFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	n, err := WriteFile(x.fsys, name, p, mode)
+	if err != nil {
+		return n, err
+	}
+	x.update(name)
+	return n, nil
+}
+
+// CopyFile calls CopyFile of the wrapped filesystem and updates the index.
+func (x *IndexFS) CopyFile(src, dst string) error {
+	if err := CopyFile(x.fsys, src, dst); err != nil {
+		return err
+	}
+	x.update(dst)
+	return nil
+}
+
+// RemoveFile calls RemoveFile of the wrapped filesystem and updates the index.
+func (x *IndexFS) RemoveFile(name string) error {
+	if err := RemoveFile(x.fsys, name); err != nil {
+		return err
+	}
+	x.remove(name)
+	return nil
+}
+
+// RemoveAll calls RemoveAll of the wrapped filesystem and updates the index.
+func (x *IndexFS) RemoveAll(path string) error {
+	if err := RemoveAll(x.fsys, path); err != nil {
+		return err
+	}
+	x.remove(path)
+	return nil
+}
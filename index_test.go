@@ -0,0 +1,224 @@
+package wfs
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func newMapWriteFS(m fstest.MapFS) *FSDelegator {
+	d := DelegateFS(m)
+	d.WriteFileFunc = func(name string, p []byte, mode fs.FileMode) (int, error) {
+		m[name] = &fstest.MapFile{Data: p, Mode: mode}
+		return len(p), nil
+	}
+	d.MkdirAllFunc = func(dir string, mode fs.FileMode) error {
+		if dir != "." {
+			m[dir] = &fstest.MapFile{Mode: mode | fs.ModeDir}
+		}
+		return nil
+	}
+	d.CreateFileFunc = func(name string, mode fs.FileMode) (WriterFile, error) {
+		var buf bytes.Buffer
+		return &FileDelegator{
+			WriteFunc: buf.Write,
+			CloseFunc: func() error {
+				m[name] = &fstest.MapFile{Data: buf.Bytes(), Mode: mode}
+				return nil
+			},
+		}, nil
+	}
+	d.RemoveFileFunc = func(name string) error {
+		delete(m, name)
+		return nil
+	}
+	return d
+}
+
+// newAbortMapWriteFS returns newMapWriteFS whose created files implement
+// WriterAtFile and AbortWriterFile like the files of osfs and memfs.
+func newAbortMapWriteFS(m fstest.MapFS) *FSDelegator {
+	d := newMapWriteFS(m)
+	d.CreateFileFunc = func(name string, mode fs.FileMode) (WriterFile, error) {
+		var p []byte
+		f := &FileDelegator{
+			WriteFunc: func(b []byte) (int, error) {
+				p = append(p, b...)
+				return len(b), nil
+			},
+			WriteAtFunc: func(b []byte, off int64) (int, error) {
+				if n := off + int64(len(b)); n > int64(len(p)) {
+					p = append(p, make([]byte, n-int64(len(p)))...)
+				}
+				return copy(p[off:], b), nil
+			},
+			CloseFunc: func() error {
+				m[name] = &fstest.MapFile{Data: p, Mode: mode}
+				return nil
+			},
+			AbortFunc: func() error {
+				p = nil
+				return nil
+			},
+		}
+		return f.File().(WriterFile), nil
+	}
+	return d
+}
+
+func TestIndexFS(t *testing.T) {
+	m := fstest.MapFS{
+		"logs/2024-01/app.log": {Data: []byte("app")},
+		"logs/2024-02/db.log":  {Data: []byte("db")},
+		"README.md":            {Data: []byte("readme")},
+	}
+	fsys, err := NewIndexFS(newMapWriteFS(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := fsys.Glob("logs/*/*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"logs/2024-01/app.log", "logs/2024-02/db.log"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+
+	if _, err := fsys.WriteFile("logs/2024-03/web.log", []byte("web"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := fsys.ReadDir("logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want = []string{"2024-01", "2024-02", "2024-03"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("unexpected %v; want %v", names, want)
+	}
+
+	if got := fsys.Search("LOG 2024 web"); !reflect.DeepEqual(got, []string{"logs/2024-03/web.log"}) {
+		t.Errorf("unexpected %v", got)
+	}
+
+	if err := fsys.RemoveFile("logs/2024-01/app.log"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("logs/2024-01/app.log"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestIndexFS_RemoveAllRoot(t *testing.T) {
+	m := fstest.MapFS{
+		"logs/app.log": {Data: []byte("app")},
+		"README.md":    {Data: []byte("readme")},
+	}
+	d := newMapWriteFS(m)
+	d.RemoveAllFunc = func(path string) error {
+		for name := range m {
+			delete(m, name)
+		}
+		return nil
+	}
+	fsys, err := NewIndexFS(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.RemoveAll("."); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("."); err != nil {
+		t.Errorf("unexpected %v", err)
+	}
+	entries, err := fsys.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("unexpected %v; want no entries", entries)
+	}
+	if _, err := fsys.WriteFile("new.txt", []byte("new"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = fsys.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "new.txt" {
+		t.Errorf("unexpected %v; want new.txt", entries)
+	}
+}
+
+func TestIndexFS_Hash(t *testing.T) {
+	m := fstest.MapFS{"test.txt": {Data: []byte("v1")}}
+	fsys, err := NewIndexFS(newMapWriteFS(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, sum1, err := fsys.Hash("test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("test.txt", []byte("v2"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	_, sum2, err := fsys.Hash("test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(sum1, sum2) {
+		t.Errorf("unexpected same hash %x", sum1)
+	}
+	if _, _, err := fsys.Hash("missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestIndexFS_CreateFileAbort(t *testing.T) {
+	m := fstest.MapFS{}
+	d := newMapWriteFS(m)
+	d.CreateFileFunc = func(name string, mode fs.FileMode) (WriterFile, error) {
+		var buf bytes.Buffer
+		f := &FileDelegator{
+			WriteFunc: buf.Write,
+			CloseFunc: func() error {
+				m[name] = &fstest.MapFile{Data: buf.Bytes(), Mode: mode}
+				return nil
+			},
+			AbortFunc: func() error {
+				return nil
+			},
+		}
+		return f.File().(WriterFile), nil
+	}
+	fsys, err := NewIndexFS(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := fsys.CreateFile("a.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Abort(fsys, "a.txt", f); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["a.txt"]; ok {
+		t.Errorf("unexpected a.txt is committed")
+	}
+	if _, err := fsys.Stat("a.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+}
@@ -0,0 +1,57 @@
+//go:build go1.23
+// +build go1.23
+
+package wfs
+
+import (
+	"io/fs"
+	"iter"
+	"path"
+)
+
+// Entries returns an iterator over the path and the fs.DirEntry of each entry
+// in the named directory. Entries reads the directory using DirIter so a huge
+// directory is iterated with constant memory. Like fs.Glob, Entries ignores
+// filesystem errors; use DirIter to handle them.
+func Entries(fsys fs.FS, dir string) iter.Seq2[string, fs.DirEntry] {
+	return func(yield func(string, fs.DirEntry) bool) {
+		it, err := DirIter(fsys, dir)
+		if err != nil {
+			return
+		}
+		defer it.Close()
+
+		for {
+			e, err := it.Next()
+			if err != nil {
+				return
+			}
+			if !yield(path.Join(dir, e.Name()), e) {
+				return
+			}
+		}
+	}
+}
+
+// Files returns an iterator over the path and the fs.DirEntry of each file
+// that is not a directory in the tree rooted at root in lexical order. Like
+// fs.Glob, Files ignores filesystem errors; use WalkDir to handle them.
+func Files(fsys fs.FS, root string) iter.Seq2[string, fs.DirEntry] {
+	return func(yield func(string, fs.DirEntry) bool) {
+		fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if d != nil && d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if !yield(name, d) {
+				return fs.SkipAll
+			}
+			return nil
+		})
+	}
+}
@@ -0,0 +1,65 @@
+//go:build go1.23
+// +build go1.23
+
+package wfs
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEntries(t *testing.T) {
+	m := fstest.MapFS{
+		"dir/a.txt":     {},
+		"dir/b.txt":     {},
+		"dir/sub/c.txt": {},
+	}
+	var got []string
+	for name, e := range Entries(m, "dir") {
+		got = append(got, name)
+		if name == "dir/sub" && !e.IsDir() {
+			t.Errorf("unexpected %s is not a directory", name)
+		}
+	}
+	want := []string{"dir/a.txt", "dir/b.txt", "dir/sub"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+
+	for name := range Entries(m, "not-found") {
+		t.Errorf("unexpected %s", name)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	m := fstest.MapFS{
+		"dir/a.txt":     {},
+		"dir/sub/b.txt": {},
+		"dir/sub/c.txt": {},
+		"other.txt":     {},
+	}
+	var got []string
+	for name, e := range Files(m, "dir") {
+		if e.IsDir() {
+			t.Errorf("unexpected directory %s", name)
+		}
+		got = append(got, name)
+	}
+	want := []string{"dir/a.txt", "dir/sub/b.txt", "dir/sub/c.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+
+	got = nil
+	for name := range Files(m, ".") {
+		got = append(got, name)
+		if len(got) == 2 {
+			break
+		}
+	}
+	want = []string{"dir/a.txt", "dir/sub/b.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,366 @@
+// Package k8sfs provides a filesystem of ConfigMaps and Secrets of a
+// Kubernetes namespace.
+//
+// The tree of the filesystem is:
+//
+//	configmaps/<name>/<key>
+//	secrets/<name>/<key>
+//
+// A directory of a ConfigMap or Secret contains a file per data key. WriteFile
+// updates the data of the resource using Client.Update.
+package k8sfs
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/jarxorg/wfs"
+)
+
+// Kind represents a kind of resources.
+type Kind string
+
+const (
+	// ConfigMaps is the kind of ConfigMaps.
+	ConfigMaps Kind = "configmaps"
+	// Secrets is the kind of Secrets.
+	Secrets Kind = "secrets"
+)
+
+var kinds = []Kind{ConfigMaps, Secrets}
+
+// Client is the interface of a client to access resources of a namespace.
+// An implementation typically wraps k8s.io/client-go. Get and Delete must
+// return an error wrapping fs.ErrNotExist if the resource does not exist.
+type Client interface {
+	// List returns the names of the resources of the kind.
+	List(kind Kind) ([]string, error)
+	// Get returns the data of the named resource.
+	Get(kind Kind, name string) (map[string][]byte, error)
+	// Update creates or updates the named resource with the data.
+	Update(kind Kind, name string, data map[string][]byte) error
+	// Delete deletes the named resource.
+	Delete(kind Kind, name string) error
+}
+
+// K8sFS represents a filesystem of ConfigMaps and Secrets.
+type K8sFS struct {
+	client Client
+}
+
+var (
+	_ fs.FS            = (*K8sFS)(nil)
+	_ fs.ReadDirFS     = (*K8sFS)(nil)
+	_ fs.ReadFileFS    = (*K8sFS)(nil)
+	_ fs.StatFS        = (*K8sFS)(nil)
+	_ wfs.WriteFileFS  = (*K8sFS)(nil)
+	_ wfs.RemoveFileFS = (*K8sFS)(nil)
+)
+
+// New returns a filesystem using the specified client.
+func New(client Client) *K8sFS {
+	return &K8sFS{client: client}
+}
+
+// location is a parsed name.
+type location struct {
+	kind Kind
+	name string
+	key  string
+}
+
+// depth returns 0 for the root, 1 for a kind, 2 for a resource and 3 for a key.
+func (l *location) depth() int {
+	switch {
+	case l.kind == "":
+		return 0
+	case l.name == "":
+		return 1
+	case l.key == "":
+		return 2
+	}
+	return 3
+}
+
+func parse(op wfs.Op, name string) (*location, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &location{}, nil
+	}
+	elems := strings.Split(name, "/")
+	if len(elems) > 3 {
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrNotExist}
+	}
+	l := &location{kind: Kind(elems[0])}
+	if l.kind != ConfigMaps && l.kind != Secrets {
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrNotExist}
+	}
+	if len(elems) > 1 {
+		l.name = elems[1]
+	}
+	if len(elems) > 2 {
+		l.key = elems[2]
+	}
+	return l, nil
+}
+
+func dirInfo(name string) fs.FileInfo {
+	return &wfs.FileInfoDelegator{
+		Values: wfs.FileInfoValues{
+			Name:  path.Base(name),
+			Mode:  fs.ModeDir | 0555,
+			IsDir: true,
+		},
+	}
+}
+
+func fileInfo(name string, size int) fs.FileInfo {
+	return &wfs.FileInfoDelegator{
+		Values: wfs.FileInfoValues{
+			Name: path.Base(name),
+			Size: int64(size),
+			Mode: 0444,
+		},
+	}
+}
+
+func dirEntry(info fs.FileInfo) fs.DirEntry {
+	return &wfs.DirEntryDelegator{
+		Values: wfs.DirEntryValues{
+			Name:  info.Name(),
+			IsDir: info.IsDir(),
+			Type:  info.Mode().Type(),
+			Info:  info,
+		},
+	}
+}
+
+func (fsys *K8sFS) get(op wfs.Op, name string, l *location) (map[string][]byte, error) {
+	data, err := fsys.client.Get(l.kind, l.name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrNotExist}
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// Open opens the named file.
+func (fsys *K8sFS) Open(name string) (fs.File, error) {
+	l, err := parse(wfs.OpOpen, name)
+	if err != nil {
+		return nil, err
+	}
+	if l.depth() == 3 {
+		data, err := fsys.get(wfs.OpOpen, name, l)
+		if err != nil {
+			return nil, err
+		}
+		p, ok := data[l.key]
+		if !ok {
+			return nil, &fs.PathError{Op: string(wfs.OpOpen), Path: name, Err: fs.ErrNotExist}
+		}
+		return wfs.NewBytesFile(fileInfo(name, len(p)), p), nil
+	}
+	entries, err := fsys.readDir(wfs.OpOpen, name, l)
+	if err != nil {
+		return nil, err
+	}
+	return wfs.NewDirFile(dirInfo(name), entries), nil
+}
+
+// ReadDir reads the named directory and returns a list of directory entries
+// sorted by filename.
+func (fsys *K8sFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	l, err := parse(wfs.OpReadDir, name)
+	if err != nil {
+		return nil, err
+	}
+	return fsys.readDir(wfs.OpReadDir, name, l)
+}
+
+func (fsys *K8sFS) readDir(op wfs.Op, name string, l *location) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	switch l.depth() {
+	case 0:
+		for _, kind := range kinds {
+			entries = append(entries, dirEntry(dirInfo(string(kind))))
+		}
+	case 1:
+		names, err := fsys.client.List(l.kind)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			entries = append(entries, dirEntry(dirInfo(n)))
+		}
+	case 2:
+		data, err := fsys.get(op, name, l)
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(data))
+		for k := range data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			entries = append(entries, dirEntry(fileInfo(k, len(data[k]))))
+		}
+	default:
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
+	}
+	return entries, nil
+}
+
+// ReadFile reads the named file and returns its contents.
+func (fsys *K8sFS) ReadFile(name string) ([]byte, error) {
+	l, err := parse(wfs.OpReadFile, name)
+	if err != nil {
+		return nil, err
+	}
+	if l.depth() != 3 {
+		return nil, &fs.PathError{Op: string(wfs.OpReadFile), Path: name, Err: fs.ErrInvalid}
+	}
+	data, err := fsys.get(wfs.OpReadFile, name, l)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := data[l.key]
+	if !ok {
+		return nil, &fs.PathError{Op: string(wfs.OpReadFile), Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte{}, p...), nil
+}
+
+// Stat returns a FileInfo describing the file.
+func (fsys *K8sFS) Stat(name string) (fs.FileInfo, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return f.Stat()
+}
+
+// MkdirAll creates the named resource with empty data if it does not exist.
+func (fsys *K8sFS) MkdirAll(dir string, mode fs.FileMode) error {
+	l, err := parse(wfs.OpMkdirAll, dir)
+	if err != nil {
+		return err
+	}
+	switch l.depth() {
+	case 0, 1:
+		return nil
+	case 2:
+		_, err := fsys.client.Get(l.kind, l.name)
+		if errors.Is(err, fs.ErrNotExist) {
+			return fsys.client.Update(l.kind, l.name, map[string][]byte{})
+		}
+		return err
+	}
+	return &fs.PathError{Op: string(wfs.OpMkdirAll), Path: dir, Err: fs.ErrInvalid}
+}
+
+// CreateFile creates the named file. The data is updated on Close.
+func (fsys *K8sFS) CreateFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+	l, err := parse(wfs.OpCreateFile, name)
+	if err != nil {
+		return nil, err
+	}
+	if l.depth() != 3 {
+		return nil, &fs.PathError{Op: string(wfs.OpCreateFile), Path: name, Err: fs.ErrInvalid}
+	}
+	var buf []byte
+	return &wfs.FileDelegator{
+		StatFunc: func() (fs.FileInfo, error) {
+			return fileInfo(name, len(buf)), nil
+		},
+		WriteFunc: func(p []byte) (int, error) {
+			buf = append(buf, p...)
+			return len(p), nil
+		},
+		CloseFunc: func() error {
+			return fsys.update(wfs.OpCreateFile, name, l, buf)
+		},
+	}, nil
+}
+
+// WriteFile writes the specified bytes to the named file.
+func (fsys *K8sFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	l, err := parse(wfs.OpWriteFile, name)
+	if err != nil {
+		return 0, err
+	}
+	if l.depth() != 3 {
+		return 0, &fs.PathError{Op: string(wfs.OpWriteFile), Path: name, Err: fs.ErrInvalid}
+	}
+	if err := fsys.update(wfs.OpWriteFile, name, l, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (fsys *K8sFS) update(op wfs.Op, name string, l *location, p []byte) error {
+	data, err := fsys.client.Get(l.kind, l.name)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		data = map[string][]byte{}
+	}
+	data[l.key] = append([]byte{}, p...)
+	return fsys.client.Update(l.kind, l.name, data)
+}
+
+// RemoveFile removes the named key from the resource.
+func (fsys *K8sFS) RemoveFile(name string) error {
+	l, err := parse(wfs.OpRemoveFile, name)
+	if err != nil {
+		return err
+	}
+	if l.depth() != 3 {
+		return &fs.PathError{Op: string(wfs.OpRemoveFile), Path: name, Err: fs.ErrInvalid}
+	}
+	data, err := fsys.get(wfs.OpRemoveFile, name, l)
+	if err != nil {
+		return err
+	}
+	if _, ok := data[l.key]; !ok {
+		return &fs.PathError{Op: string(wfs.OpRemoveFile), Path: name, Err: fs.ErrNotExist}
+	}
+	delete(data, l.key)
+	return fsys.client.Update(l.kind, l.name, data)
+}
+
+// RemoveAll removes the named key or resource. RemoveAll does not remove
+// all resources of a kind.
+func (fsys *K8sFS) RemoveAll(path string) error {
+	l, err := parse(wfs.OpRemoveAll, path)
+	if err != nil {
+		return err
+	}
+	switch l.depth() {
+	case 2:
+		err := fsys.client.Delete(l.kind, l.name)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	case 3:
+		err := fsys.RemoveFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	return &fs.PathError{Op: string(wfs.OpRemoveAll), Path: path, Err: fs.ErrInvalid}
+}
@@ -0,0 +1,153 @@
+package k8sfs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jarxorg/wfs"
+)
+
+type fakeClient struct {
+	resources map[Kind]map[string]map[string][]byte
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		resources: map[Kind]map[string]map[string][]byte{
+			ConfigMaps: {
+				"app": {"config.yaml": []byte("key: value\n")},
+			},
+			Secrets: {
+				"db": {"password": []byte("secret")},
+			},
+		},
+	}
+}
+
+func (c *fakeClient) List(kind Kind) ([]string, error) {
+	var names []string
+	for name := range c.resources[kind] {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (c *fakeClient) Get(kind Kind, name string) (map[string][]byte, error) {
+	data, ok := c.resources[kind][name]
+	if !ok {
+		return nil, fmt.Errorf("%s/%s: %w", kind, name, fs.ErrNotExist)
+	}
+	copied := map[string][]byte{}
+	for k, v := range data {
+		copied[k] = v
+	}
+	return copied, nil
+}
+
+func (c *fakeClient) Update(kind Kind, name string, data map[string][]byte) error {
+	c.resources[kind][name] = data
+	return nil
+}
+
+func (c *fakeClient) Delete(kind Kind, name string) error {
+	if _, ok := c.resources[kind][name]; !ok {
+		return fmt.Errorf("%s/%s: %w", kind, name, fs.ErrNotExist)
+	}
+	delete(c.resources[kind], name)
+	return nil
+}
+
+func TestFS(t *testing.T) {
+	fsys := New(newFakeClient())
+	if err := fstest.TestFS(fsys, "configmaps/app/config.yaml", "secrets/db/password"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	client := newFakeClient()
+	fsys := New(client)
+
+	if _, err := wfs.WriteFile(fsys, "configmaps/app/new.txt", []byte("new"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	f, err := wfs.CreateFile(fsys, "secrets/api/token", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("token")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[Kind]map[string]map[string][]byte{
+		ConfigMaps: {
+			"app": {"config.yaml": []byte("key: value\n"), "new.txt": []byte("new")},
+		},
+		Secrets: {
+			"db":  {"password": []byte("secret")},
+			"api": {"token": []byte("token")},
+		},
+	}
+	if !reflect.DeepEqual(client.resources, want) {
+		t.Errorf("unexpected %v; want %v", client.resources, want)
+	}
+
+	for _, name := range []string{"configmaps", "configmaps/app", "configmaps/app/key/invalid", "unknown/app/key"} {
+		if _, err := wfs.WriteFile(fsys, name, []byte{}, fs.ModePerm); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestMkdirAll(t *testing.T) {
+	client := newFakeClient()
+	fsys := New(client)
+
+	if err := fsys.MkdirAll("configmaps/empty", fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := fsys.ReadDir("configmaps/empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("unexpected %v", entries)
+	}
+	if err := fsys.MkdirAll("configmaps/app/key", fs.ModePerm); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	client := newFakeClient()
+	fsys := New(client)
+
+	if err := fsys.RemoveFile("configmaps/app/config.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("configmaps/app/config.yaml"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	if err := fsys.RemoveFile("configmaps/app/config.yaml"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	if err := fsys.RemoveAll("secrets/db"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("secrets/db"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	if err := fsys.RemoveAll("secrets/db"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.RemoveAll("secrets"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+}
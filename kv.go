@@ -0,0 +1,135 @@
+package wfs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// KV is a key-value store that stores each value as a file in a directory of
+// a filesystem. Keys are arbitrary non-empty strings that are escaped to
+// portable file names.
+type KV struct {
+	fsys WriteFileFS
+	dir  string
+}
+
+// NewKV returns a KV that stores values in the specified directory of fsys.
+// The directory is created by the first Put.
+func NewKV(fsys WriteFileFS, dir string) *KV {
+	return &KV{fsys: fsys, dir: dir}
+}
+
+// kvKeySafe reports whether the byte is stored as it is in an escaped key.
+func kvKeySafe(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '_' || c == '.'
+}
+
+// escapeKey returns the file name of the key. Bytes other than ASCII
+// letters, digits, '-', '_' and '.' are escaped as "%XX". Leading and trailing
+// dots and the first byte of reserved Windows device names such as "CON" are
+// also escaped so that the name is portable.
+func escapeKey(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if kvKeySafe(c) && !(c == '.' && (i == 0 || i == len(key)-1)) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	name := b.String()
+	if !validPortableElem(name) {
+		name = fmt.Sprintf("%%%02X", key[0]) + name[1:]
+	}
+	return name
+}
+
+func (kv *KV) name(key string) (string, error) {
+	if key == "" {
+		return "", &fs.PathError{Op: "KV", Path: key, Err: fs.ErrInvalid}
+	}
+	return path.Join(kv.dir, escapeKey(key)), nil
+}
+
+// Get returns the value of the key. Get returns an error wrapping
+// fs.ErrNotExist if the key does not exist.
+func (kv *KV) Get(key string) ([]byte, error) {
+	name, err := kv.name(key)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(kv.fsys, name)
+}
+
+// Put stores the value of the key.
+func (kv *KV) Put(key string, value []byte) error {
+	name, err := kv.name(key)
+	if err != nil {
+		return err
+	}
+	if err := kv.fsys.MkdirAll(kv.dir, fs.ModePerm); err != nil {
+		return err
+	}
+	_, err = kv.fsys.WriteFile(name, value, 0644)
+	return err
+}
+
+// Delete deletes the key. Delete returns nil if the key does not exist.
+func (kv *KV) Delete(key string) error {
+	name, err := kv.name(key)
+	if err != nil {
+		return err
+	}
+	return RemoveIfExists(kv.fsys, name)
+}
+
+// List returns the sorted keys. Files that are not valid escaped keys are
+// ignored.
+func (kv *KV) List() ([]string, error) {
+	entries, err := fs.ReadDir(kv.fsys, kv.dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var keys []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		key, err := url.PathUnescape(e.Name())
+		if err != nil || escapeKey(key) != e.Name() {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// GetJSON decodes the JSON value of the key into v.
+func (kv *KV) GetJSON(key string, v interface{}) error {
+	p, err := kv.Get(key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(p, v)
+}
+
+// PutJSON stores v encoded as JSON as the value of the key.
+func (kv *KV) PutJSON(key string, v interface{}) error {
+	p, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return kv.Put(key, p)
+}
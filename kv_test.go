@@ -0,0 +1,104 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEscapeKey(t *testing.T) {
+	testCases := map[string]string{
+		"user-1_a.json": "user-1_a.json",
+		"a/b":           "a%2Fb",
+		"..":            "%2E%2E",
+		".hidden":       "%2Ehidden",
+		"trailing.":     "trailing%2E",
+		"CON":           "%43ON",
+		"nul.txt":       "%6Eul.txt",
+		"日本":            "%E6%97%A5%E6%9C%AC",
+		"a%b c":         "a%25b%20c",
+	}
+	for key, want := range testCases {
+		got := escapeKey(key)
+		if got != want {
+			t.Errorf("%q: unexpected %s; want %s", key, got, want)
+		}
+		if _, err := CleanName(got); err != nil {
+			t.Errorf("%q: unexpected %v", key, err)
+		}
+	}
+}
+
+func TestKV(t *testing.T) {
+	m := fstest.MapFS{}
+	kv := NewKV(newMapWriteFS(m), "data")
+
+	keys, err := kv.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("unexpected %v", keys)
+	}
+	for _, key := range []string{"b", "a/b", "CON"} {
+		if err := kv.Put(key, []byte("value of "+key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := kv.Get("a/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "value of a/b" {
+		t.Errorf("unexpected %s; want value of a/b", got)
+	}
+	if _, ok := m["data/a%2Fb"]; !ok {
+		t.Errorf("unexpected %v; want data/a%%2Fb", m)
+	}
+	m["data/a%2fb"] = &fstest.MapFile{Data: []byte("not a key")}
+
+	keys, err = kv.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"CON", "a/b", "b"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("unexpected %v; want %v", keys, want)
+	}
+
+	if err := kv.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := kv.Delete("b"); err != nil {
+		t.Errorf("unexpected %v", err)
+	}
+	if _, err := kv.Get("b"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	if err := kv.Put("", nil); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+}
+
+func TestKV_JSON(t *testing.T) {
+	kv := NewKV(newMapWriteFS(fstest.MapFS{}), "data")
+	type value struct {
+		Name  string
+		Count int
+	}
+	want := value{Name: "a", Count: 1}
+	if err := kv.PutJSON("a", want); err != nil {
+		t.Fatal(err)
+	}
+	var got value
+	if err := kv.GetJSON("a", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+	if err := kv.GetJSON("missing", &got); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+}
@@ -0,0 +1,371 @@
+// Package kvfs provides a filesystem over a key value store such as etcd or
+// Consul KV. Keys are file names and directories are key prefixes separated by
+// "/". Directories are implicit and exist while they contain any key.
+package kvfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/jarxorg/wfs"
+)
+
+// KeyEvent represents a change of a key notified by Client.Watch.
+type KeyEvent struct {
+	Key     string
+	Deleted bool
+}
+
+// Client is the interface of a client of a key value store. An implementation
+// typically wraps go.etcd.io/etcd/client/v3 or github.com/hashicorp/consul/api.
+// Get must return an error wrapping fs.ErrNotExist if the key does not exist.
+type Client interface {
+	// Get returns the value of the key.
+	Get(key string) ([]byte, error)
+	// Put sets the value of the key.
+	Put(key string, value []byte) error
+	// Delete deletes the key. Delete returns no error if the key does not exist.
+	Delete(key string) error
+	// Keys returns the keys that start with the prefix.
+	Keys(prefix string) ([]string, error)
+	// Watch notifies changes of the keys that start with the prefix until the
+	// context is done.
+	Watch(ctx context.Context, prefix string) (<-chan KeyEvent, error)
+}
+
+// KVFS represents a filesystem over a key value store.
+type KVFS struct {
+	client Client
+}
+
+var (
+	_ fs.FS            = (*KVFS)(nil)
+	_ fs.GlobFS        = (*KVFS)(nil)
+	_ fs.ReadDirFS     = (*KVFS)(nil)
+	_ fs.ReadFileFS    = (*KVFS)(nil)
+	_ fs.StatFS        = (*KVFS)(nil)
+	_ wfs.WriteFileFS  = (*KVFS)(nil)
+	_ wfs.RemoveFileFS = (*KVFS)(nil)
+	_ wfs.WatchFS      = (*KVFS)(nil)
+)
+
+// New returns a filesystem using the specified client.
+func New(client Client) *KVFS {
+	return &KVFS{client: client}
+}
+
+func dirPrefix(dir string) string {
+	if dir == "." {
+		return ""
+	}
+	return dir + "/"
+}
+
+func dirInfo(name string) fs.FileInfo {
+	return &wfs.FileInfoDelegator{
+		Values: wfs.FileInfoValues{
+			Name:  path.Base(name),
+			Mode:  fs.ModeDir | fs.ModePerm,
+			IsDir: true,
+		},
+	}
+}
+
+func fileInfo(name string, size int) fs.FileInfo {
+	return &wfs.FileInfoDelegator{
+		Values: wfs.FileInfoValues{
+			Name: path.Base(name),
+			Size: int64(size),
+			Mode: 0666,
+		},
+	}
+}
+
+// Open opens the named file.
+func (fsys *KVFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: string(wfs.OpOpen), Path: name, Err: fs.ErrInvalid}
+	}
+	if name != "." {
+		p, err := fsys.client.Get(name)
+		if err == nil {
+			return wfs.NewBytesFile(fileInfo(name, len(p)), p), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	entries, err := fsys.readDir(wfs.OpOpen, name)
+	if err != nil {
+		return nil, err
+	}
+	return wfs.NewDirFile(dirInfo(name), entries), nil
+}
+
+// ReadDir reads the named directory and returns a list of directory entries
+// sorted by filename.
+func (fsys *KVFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(dir) {
+		return nil, &fs.PathError{Op: string(wfs.OpReadDir), Path: dir, Err: fs.ErrInvalid}
+	}
+	return fsys.readDir(wfs.OpReadDir, dir)
+}
+
+func (fsys *KVFS) readDir(op wfs.Op, dir string) ([]fs.DirEntry, error) {
+	prefix := dirPrefix(dir)
+	keys, err := fsys.client.Keys(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 && dir != "." {
+		return nil, &fs.PathError{Op: string(op), Path: dir, Err: fs.ErrNotExist}
+	}
+	sort.Strings(keys)
+
+	var entries []fs.DirEntry
+	seen := map[string]bool{}
+	for _, key := range keys {
+		rel := key[len(prefix):]
+		i := strings.Index(rel, "/")
+		var info fs.FileInfo
+		if i == -1 {
+			p, err := fsys.client.Get(key)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				return nil, err
+			}
+			info = fileInfo(rel, len(p))
+		} else {
+			rel = rel[:i]
+			info = dirInfo(rel)
+		}
+		if seen[rel] {
+			continue
+		}
+		seen[rel] = true
+		entries = append(entries, &wfs.DirEntryDelegator{
+			Values: wfs.DirEntryValues{
+				Name:  rel,
+				IsDir: info.IsDir(),
+				Type:  info.Mode().Type(),
+				Info:  info,
+			},
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// Glob returns the lexically sorted names of all files and directories
+// matching pattern. Glob lists the keys under the directory of the leading
+// components of pattern without magic characters at once instead of reading
+// each directory.
+func (fsys *KVFS) Glob(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	parts := strings.Split(pattern, "/")
+	i := 0
+	for i < len(parts) && !strings.ContainsAny(parts[i], `*?[\`) {
+		i++
+	}
+	if i == len(parts) {
+		if _, err := fsys.Stat(pattern); err != nil {
+			return nil, nil
+		}
+		return []string{pattern}, nil
+	}
+	dir := "."
+	if i > 0 {
+		dir = path.Join(parts[:i]...)
+	}
+	keys, err := fsys.client.Keys(dirPrefix(dir))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	seen := map[string]bool{}
+	for _, key := range keys {
+		keyParts := strings.Split(key, "/")
+		if len(keyParts) < len(parts) {
+			continue
+		}
+		name := strings.Join(keyParts[:len(parts)], "/")
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if ok, _ := path.Match(pattern, name); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ReadFile reads the named file and returns its contents.
+func (fsys *KVFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return nil, &fs.PathError{Op: string(wfs.OpReadFile), Path: name, Err: fs.ErrInvalid}
+	}
+	p, err := fsys.client.Get(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, &fs.PathError{Op: string(wfs.OpReadFile), Path: name, Err: fs.ErrNotExist}
+		}
+		return nil, err
+	}
+	return append([]byte{}, p...), nil
+}
+
+// Stat returns a FileInfo describing the file.
+func (fsys *KVFS) Stat(name string) (fs.FileInfo, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, wfs.PathError(wfs.OpStat, name, err)
+	}
+	defer f.Close()
+
+	return f.Stat()
+}
+
+// MkdirAll does nothing because directories are implicit.
+func (fsys *KVFS) MkdirAll(dir string, mode fs.FileMode) error {
+	if !fs.ValidPath(dir) {
+		return &fs.PathError{Op: string(wfs.OpMkdirAll), Path: dir, Err: fs.ErrInvalid}
+	}
+	return nil
+}
+
+// CreateFile creates the named file. The value is put on Close.
+func (fsys *KVFS) CreateFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+	if err := fsys.checkWrite(wfs.OpCreateFile, name); err != nil {
+		return nil, err
+	}
+	var buf []byte
+	return &wfs.FileDelegator{
+		StatFunc: func() (fs.FileInfo, error) {
+			return fileInfo(name, len(buf)), nil
+		},
+		WriteFunc: func(p []byte) (int, error) {
+			buf = append(buf, p...)
+			return len(p), nil
+		},
+		CloseFunc: func() error {
+			return fsys.client.Put(name, buf)
+		},
+	}, nil
+}
+
+// WriteFile writes the specified bytes to the named file.
+func (fsys *KVFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	if err := fsys.checkWrite(wfs.OpWriteFile, name); err != nil {
+		return 0, err
+	}
+	if err := fsys.client.Put(name, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// checkWrite reports an error if the name is invalid, is a directory or any
+// parent of the name is a file.
+func (fsys *KVFS) checkWrite(op wfs.Op, name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
+	}
+	keys, err := fsys.client.Keys(name + "/")
+	if err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
+	}
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		_, err := fsys.client.Get(dir)
+		if err == nil {
+			return &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveFile removes the named file.
+func (fsys *KVFS) RemoveFile(name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: string(wfs.OpRemoveFile), Path: name, Err: fs.ErrInvalid}
+	}
+	if _, err := fsys.client.Get(name); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		keys, err := fsys.client.Keys(dirPrefix(name))
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			return &fs.PathError{Op: string(wfs.OpRemoveFile), Path: name, Err: errNotEmpty}
+		}
+		return &fs.PathError{Op: string(wfs.OpRemoveFile), Path: name, Err: fs.ErrNotExist}
+	}
+	return fsys.client.Delete(name)
+}
+
+// RemoveAll removes path and any children it contains.
+func (fsys *KVFS) RemoveAll(path string) error {
+	if !fs.ValidPath(path) {
+		return &fs.PathError{Op: string(wfs.OpRemoveAll), Path: path, Err: fs.ErrInvalid}
+	}
+	keys, err := fsys.client.Keys(dirPrefix(path))
+	if err != nil {
+		return err
+	}
+	if path != "." {
+		keys = append(keys, path)
+	}
+	for _, key := range keys {
+		if err := fsys.client.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Watch watches changes of files under the named directory using
+// Client.Watch.
+func (fsys *KVFS) Watch(ctx context.Context, dir string) (<-chan wfs.WatchEvent, error) {
+	if !fs.ValidPath(dir) {
+		return nil, &fs.PathError{Op: string(wfs.OpWatch), Path: dir, Err: fs.ErrInvalid}
+	}
+	keyEvents, err := fsys.client.Watch(ctx, dirPrefix(dir))
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan wfs.WatchEvent)
+	go func() {
+		defer close(events)
+		for e := range keyEvents {
+			event := wfs.WatchEvent{Op: wfs.WatchWrite, Name: e.Key}
+			if e.Deleted {
+				event.Op = wfs.WatchRemove
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
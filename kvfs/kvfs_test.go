@@ -0,0 +1,173 @@
+package kvfs
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jarxorg/wfs"
+	"github.com/jarxorg/wfs/wfstest"
+)
+
+type fakeClient struct {
+	mutex    sync.Mutex
+	values   map[string][]byte
+	watchers []chan KeyEvent
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		values: map[string][]byte{
+			"dir0/file01.txt": []byte("content01"),
+			"dir0/file02.txt": []byte("content02"),
+			"dir1/sub/a.txt":  []byte("a"),
+		},
+	}
+}
+
+func (c *fakeClient) Get(key string) ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	v, ok := c.values[key]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", key, fs.ErrNotExist)
+	}
+	return v, nil
+}
+
+func (c *fakeClient) Put(key string, value []byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.values[key] = append([]byte{}, value...)
+	c.notify(KeyEvent{Key: key})
+	return nil
+}
+
+func (c *fakeClient) Delete(key string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.values[key]; ok {
+		delete(c.values, key)
+		c.notify(KeyEvent{Key: key, Deleted: true})
+	}
+	return nil
+}
+
+func (c *fakeClient) Keys(prefix string) ([]string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var keys []string
+	for k := range c.values {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (c *fakeClient) Watch(ctx context.Context, prefix string) (<-chan KeyEvent, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ch := make(chan KeyEvent, 10)
+	c.watchers = append(c.watchers, ch)
+	return ch, nil
+}
+
+func (c *fakeClient) notify(e KeyEvent) {
+	for _, ch := range c.watchers {
+		ch <- e
+	}
+}
+
+func TestFS(t *testing.T) {
+	fsys := New(newFakeClient())
+	if err := fstest.TestFS(fsys, "dir0/file01.txt", "dir1/sub/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteFileFS(t *testing.T) {
+	fsys := New(newFakeClient())
+	if err := wfstest.TestWriteFileFS(fsys, "tmp"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGlobFS(t *testing.T) {
+	fsys := New(newFakeClient())
+	if err := wfstest.TestGlobFS(fsys, "tmp"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPathErrorOps(t *testing.T) {
+	fsys := New(newFakeClient())
+	if err := wfstest.TestPathErrorOps(fsys, "tmp"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoveFileFS(t *testing.T) {
+	fsys := New(newFakeClient())
+	if err := wfstest.TestRemoveFileFS(fsys, "tmp"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOrdering(t *testing.T) {
+	fsys := New(newFakeClient())
+	if err := wfstest.TestOrdering(fsys, "tmp"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoveAll(t *testing.T) {
+	client := newFakeClient()
+	fsys := New(client)
+
+	if err := fsys.RemoveAll("dir0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("dir0"); err == nil {
+		t.Error("dir0 exists")
+	}
+	if _, err := fsys.Stat("dir1/sub/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	fsys := New(newFakeClient())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := wfs.Watch(ctx, fsys, "dir0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("dir0/new.txt", []byte("new"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.RemoveFile("dir0/new.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []wfs.WatchEvent{
+		{Op: wfs.WatchWrite, Name: "dir0/new.txt"},
+		{Op: wfs.WatchRemove, Name: "dir0/new.txt"},
+	}
+	for _, w := range want {
+		if got := <-events; got != w {
+			t.Errorf("unexpected %v; want %v", got, w)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !plan9
+// +build !plan9
+
+package kvfs
+
+import (
+	"syscall"
+)
+
+// errNotEmpty is the error of removing a directory that is not empty.
+var errNotEmpty error = syscall.ENOTEMPTY
@@ -0,0 +1,9 @@
+package kvfs
+
+import (
+	"errors"
+)
+
+// errNotEmpty is the error of removing a directory that is not empty.
+// syscall.ENOTEMPTY is not defined on plan9.
+var errNotEmpty = errors.New("directory not empty")
@@ -0,0 +1,167 @@
+package wfs
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"time"
+)
+
+// TreeOption is an option for Tree.
+type TreeOption func(o *treeOptions)
+
+type treeOptions struct {
+	maxDepth int
+	sizes    bool
+}
+
+// WithTreeDepth limits the depth of directories printed by Tree.
+func WithTreeDepth(n int) TreeOption {
+	return func(o *treeOptions) {
+		o.maxDepth = n
+	}
+}
+
+// WithTreeSizes makes Tree print the sizes of files.
+func WithTreeSizes() TreeOption {
+	return func(o *treeOptions) {
+		o.sizes = true
+	}
+}
+
+// Tree writes the tree rooted at root to w like the tree command:
+//
+//	root
+//	├── a.txt
+//	└── dir
+//	    └── b.txt
+//
+//	2 directories, 2 files
+func Tree(w io.Writer, fsys fs.FS, root string, opts ...TreeOption) error {
+	o := &treeOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if _, err := fmt.Fprintln(w, root); err != nil {
+		return err
+	}
+	dirs, files := 1, 0
+	if err := writeTree(w, fsys, root, "", 1, o, &dirs, &files); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%s, %s\n", plural(dirs, "directory", "directories"), plural(files, "file", "files"))
+	return err
+}
+
+func writeTree(w io.Writer, fsys fs.FS, dir, prefix string, depth int, o *treeOptions, dirs, files *int) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for i, e := range entries {
+		branch, indent := "├── ", "│   "
+		if i == len(entries)-1 {
+			branch, indent = "└── ", "    "
+		}
+		label := e.Name()
+		if e.IsDir() {
+			*dirs++
+		} else {
+			*files++
+			if o.sizes {
+				info, err := e.Info()
+				if err != nil {
+					return err
+				}
+				label = fmt.Sprintf("[%d] %s", info.Size(), label)
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s%s%s\n", prefix, branch, label); err != nil {
+			return err
+		}
+		if e.IsDir() && (o.maxDepth <= 0 || depth < o.maxDepth) {
+			if err := writeTree(w, fsys, path.Join(dir, e.Name()), prefix+indent, depth+1, o, dirs, files); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, one)
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// ListEntry is an entry written by ListJSON.
+type ListEntry struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	Mode    string    `json:"mode"`
+	ModTime time.Time `json:"modTime"`
+	IsDir   bool      `json:"isDir"`
+	Hash    string    `json:"hash,omitempty"`
+	Meta    Metadata  `json:"meta,omitempty"`
+}
+
+// ListJSON writes an entry per directory and file of the tree rooted at root
+// to w as newline-delimited JSON. The hash of a file is written if its
+// fs.FileInfo implements HashedInfo and the metadata is written if the
+// filesystem implements MetadataFS. Entries are written as the tree is walked,
+// so a large tree is streamed without holding the listing in memory.
+func ListJSON(w io.Writer, fsys fs.FS, root string) error {
+	enc := json.NewEncoder(w)
+	_, isMetaFS := fsys.(MetadataFS)
+	return fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		e := &ListEntry{
+			Name:    name,
+			Size:    info.Size(),
+			Mode:    info.Mode().String(),
+			ModTime: info.ModTime(),
+			IsDir:   info.IsDir(),
+		}
+		if h, ok := info.(HashedInfo); ok {
+			if algo, sum := h.Hash(); sum != nil {
+				e.Hash = algo + ":" + hex.EncodeToString(sum)
+			}
+		}
+		if isMetaFS && !info.IsDir() {
+			if e.Meta, err = StatMeta(fsys, name); err != nil {
+				return err
+			}
+		}
+		return enc.Encode(e)
+	})
+}
+
+// ReadListJSON reads the entries written by ListJSON from r and calls fn with
+// each entry as it is decoded, so a listing streamed over the wire is
+// iterated lazily with bounded memory. If fn returns an error ReadListJSON
+// stops and returns the error.
+func ReadListJSON(r io.Reader, fn func(e *ListEntry) error) error {
+	dec := json.NewDecoder(r)
+	for {
+		e := &ListEntry{}
+		if err := dec.Decode(e); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package wfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTree(t *testing.T) {
+	fsys := fstest.MapFS{
+		"root/a.txt":       {Data: []byte("a")},
+		"root/dir/b.txt":   {Data: []byte("bb")},
+		"root/dir/sub/c":   {Data: []byte("c")},
+		"root/z/other.txt": {Data: []byte("o")},
+	}
+	var buf bytes.Buffer
+	if err := Tree(&buf, fsys, "root", WithTreeDepth(2), WithTreeSizes()); err != nil {
+		t.Fatal(err)
+	}
+	want := `root
+├── [1] a.txt
+├── dir
+│   ├── [2] b.txt
+│   └── sub
+└── z
+    └── [1] other.txt
+
+4 directories, 3 files
+`
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected\n%s; want\n%s", got, want)
+	}
+}
+
+func TestListJSON(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dir/a.txt": {Data: []byte("abc"), Mode: 0644},
+	}
+	var buf bytes.Buffer
+	if err := ListJSON(&buf, fsys, "dir"); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		e := &ListEntry{}
+		if err := dec.Decode(e); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, e.Name+" "+e.Mode)
+	}
+	want := []string{"dir dr-xr-xr-x", "dir/a.txt -rw-r--r--"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+}
+
+func TestReadListJSON(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dir/a.txt":     {Data: []byte("abc"), Mode: 0644},
+		"dir/sub/b.txt": {Data: []byte("b"), Mode: 0600},
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(ListJSON(pw, fsys, "dir"))
+	}()
+	var got []string
+	err := ReadListJSON(pr, func(e *ListEntry) error {
+		got = append(got, fmt.Sprintf("%s %d", e.Name, e.Size))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"dir 0", "dir/a.txt 3", "dir/sub 0", "dir/sub/b.txt 1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+
+	errStop := errors.New("stop")
+	n := 0
+	err = ReadListJSON(strings.NewReader("{\"name\":\"a\"}\n{\"name\":\"b\"}\n"), func(e *ListEntry) error {
+		n++
+		return errStop
+	})
+	if err != errStop || n != 1 {
+		t.Errorf("unexpected %v, %d; want %v, 1", err, n, errStop)
+	}
+	if err := ReadListJSON(strings.NewReader("{"), func(e *ListEntry) error { return nil }); err == nil {
+		t.Errorf("unexpected nil; want an error")
+	}
+}
@@ -29,3 +29,36 @@ func ExampleNew() {
 
 	// Output: Hello
 }
+
+func ExampleFromTree() {
+	fsys, err := memfs.FromTree(map[string]interface{}{
+		"index.html": "<html></html>",
+		"assets": map[string]interface{}{
+			"css/style.css": "body {}",
+			"js": map[string]interface{}{
+				"app.js": "main()",
+			},
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			fmt.Println(name)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// assets/css/style.css
+	// assets/js/app.js
+	// index.html
+}
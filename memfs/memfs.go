@@ -5,10 +5,14 @@ import (
 	"bytes"
 	"io"
 	"io/fs"
+	"io/ioutil"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/jarxorg/wfs"
 )
@@ -16,114 +20,321 @@ import (
 // MemFS represents an in-memory filesystem.
 // MemFS keeps fs.FileMode but that permission is not checked.
 type MemFS struct {
-	mutex sync.Mutex
-	dir   string
-	store *store
+	mutex           sync.Mutex
+	dir             string
+	store           *store
+	initialCapacity int
+	strictCreate    bool
+	leases          bool
+	waitLease       bool
 }
 
 var (
-	_ fs.FS            = (*MemFS)(nil)
-	_ fs.GlobFS        = (*MemFS)(nil)
-	_ fs.ReadDirFS     = (*MemFS)(nil)
-	_ fs.ReadFileFS    = (*MemFS)(nil)
-	_ fs.StatFS        = (*MemFS)(nil)
-	_ fs.SubFS         = (*MemFS)(nil)
-	_ wfs.WriteFileFS  = (*MemFS)(nil)
-	_ wfs.RemoveFileFS = (*MemFS)(nil)
+	_ fs.FS                  = (*MemFS)(nil)
+	_ fs.GlobFS              = (*MemFS)(nil)
+	_ fs.ReadDirFS           = (*MemFS)(nil)
+	_ fs.ReadFileFS          = (*MemFS)(nil)
+	_ fs.StatFS              = (*MemFS)(nil)
+	_ fs.SubFS               = (*MemFS)(nil)
+	_ wfs.WriteFileFS        = (*MemFS)(nil)
+	_ wfs.RemoveFileFS       = (*MemFS)(nil)
+	_ wfs.RemoveFilesFS      = (*MemFS)(nil)
+	_ wfs.CopyFileWithinFS   = (*MemFS)(nil)
+	_ wfs.MetadataFS         = (*MemFS)(nil)
+	_ wfs.ConditionalWriteFS = (*MemFS)(nil)
+	_ wfs.RangeReaderFS      = (*MemFS)(nil)
+	_ wfs.ChangeTokenFS      = (*MemFS)(nil)
 )
 
+// Option is an option for New.
+type Option func(fsys *MemFS)
+
+// WithInitialCapacity sets the initial capacity in bytes of the buffer of a
+// file created by CreateFile. Files written in a known size avoid growing the
+// buffer.
+func WithInitialCapacity(n int) Option {
+	return func(fsys *MemFS) {
+		fsys.initialCapacity = n
+	}
+}
+
+// WithStrictCreate makes CreateFile and the other writes return an error of
+// fs.ErrNotExist like os.Create if the parent directory does not exist. By
+// default the missing parent directories are created implicitly.
+func WithStrictCreate() Option {
+	return func(fsys *MemFS) {
+		fsys.strictCreate = true
+	}
+}
+
+// WithWriteLeases makes CreateFile take a write lease of the named file that
+// is released by Close or Abort of the returned file. While the lease is held
+// another CreateFile of the same name returns an error of wfs.ErrLocked, or
+// waits until the lease is released if wait is true. WriteFile does not take
+// a lease. By default concurrent writers of the same name are not detected and
+// the last Close wins.
+func WithWriteLeases(wait bool) Option {
+	return func(fsys *MemFS) {
+		fsys.leases = true
+		fsys.waitLease = wait
+	}
+}
+
 // New returns a new MemFS.
-func New() *MemFS {
-	return &MemFS{
+func New(opts ...Option) *MemFS {
+	fsys := &MemFS{
 		dir:   "/",
 		store: newStore(),
 	}
+	for _, opt := range opts {
+		opt(fsys)
+	}
+	return fsys
+}
+
+// FromFS returns a new MemFS that contains the tree of the root directory of
+// src, such as an embed.FS, as its root. The modes and the modification times
+// of the files and directories of src are preserved.
+func FromFS(src fs.FS, root string, opts ...Option) (*MemFS, error) {
+	sub, err := fs.Sub(src, root)
+	if err != nil {
+		return nil, err
+	}
+	fsys := New(opts...)
+	err = fs.WalkDir(sub, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		mode := info.Mode()
+		if d.IsDir() {
+			if err := fsys.MkdirAll(name, mode.Perm()); err != nil {
+				return err
+			}
+		} else {
+			p, err := fs.ReadFile(sub, name)
+			if err != nil {
+				return err
+			}
+			mode &^= fs.ModeType
+			if _, err := fsys.WriteFile(name, p, mode); err != nil {
+				return err
+			}
+		}
+		fsys.mutex.Lock()
+		defer fsys.mutex.Unlock()
+
+		if v := fsys.store.get(fsys.key(name)); v != nil {
+			v.mode = mode
+			v.modTime = info.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fsys, nil
+}
+
+// FromTree returns a new MemFS that contains the tree literal as its root.
+// A map[string]interface{} is a directory whose keys are the names of its
+// entries, and a string or a []byte is the contents of a file, so a tree
+// decoded from JSON can be loaded as is. A key may contain slashes to create
+// nested directories. Files are created with fs.ModePerm.
+func FromTree(tree map[string]interface{}, opts ...Option) (*MemFS, error) {
+	fsys := New(opts...)
+	if err := fsys.MkdirAll(".", fs.ModePerm); err != nil {
+		return nil, err
+	}
+	if err := fsys.writeTree(".", tree); err != nil {
+		return nil, err
+	}
+	return fsys, nil
+}
+
+func (fsys *MemFS) writeTree(dir string, tree map[string]interface{}) error {
+	names := make([]string, 0, len(tree))
+	for name := range tree {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, key := range names {
+		name := path.Join(dir, key)
+		var err error
+		switch v := tree[key].(type) {
+		case map[string]interface{}:
+			if err = fsys.MkdirAll(name, fs.ModePerm); err == nil {
+				err = fsys.writeTree(name, v)
+			}
+		case string:
+			_, err = fsys.WriteFile(name, []byte(v), fs.ModePerm)
+		case []byte:
+			_, err = fsys.WriteFile(name, v, fs.ModePerm)
+		default:
+			err = &fs.PathError{Op: "FromTree", Path: name, Err: fs.ErrInvalid}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bufferPool pools the buffers of MemFile to reduce allocations under heavy
+// churn such as temporary build directories. The data of a file is copied
+// from the buffer at Close so that the buffer can be reused.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// maxInt is the max value of int that limits the size of a file.
+const maxInt = int(^uint(0) >> 1)
+
+// maxPooledBufferCap is the max capacity of a buffer returned to bufferPool
+// so that a few large files do not pin the memory.
+const maxPooledBufferCap = 1 << 20
+
+func getBuffer(capacity int) *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	if capacity > 0 {
+		buf.Grow(capacity)
+	}
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf == nil || buf.Cap() > maxPooledBufferCap {
+		return
+	}
+	bufferPool.Put(buf)
 }
 
 func (fsys *MemFS) key(name string) string {
 	return path.Clean(path.Join(fsys.dir, name))
 }
 
-func (fsys *MemFS) rel(name string) string {
-	return strings.TrimPrefix(name, fsys.dir)
+func (fsys *MemFS) rel(key string) string {
+	if key == fsys.dir {
+		return "."
+	}
+	return strings.TrimPrefix(key, strings.TrimSuffix(fsys.dir, "/")+"/")
 }
 
-func (fsys *MemFS) open(name string) (*value, error) {
+func (fsys *MemFS) open(op wfs.Op, name string) (*value, error) {
 	if !fs.ValidPath(name) {
-		return nil, &fs.PathError{Op: "Open", Path: name, Err: fs.ErrInvalid}
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
 	}
 	v := fsys.store.get(fsys.key(name))
 	if v == nil {
-		return nil, &fs.PathError{Op: "Open", Path: name, Err: fs.ErrNotExist}
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrNotExist}
 	}
 	return v, nil
 }
 
 func (fsys *MemFS) mkdirAll(dir string, mode fs.FileMode) error {
 	if !fs.ValidPath(dir) {
-		return &fs.PathError{Op: "MkdirAll", Path: dir, Err: fs.ErrInvalid}
+		return &fs.PathError{Op: string(wfs.OpMkdirAll), Path: dir, Err: fs.ErrInvalid}
 	}
 	keys := strings.Split(fsys.key(dir), "/")
 	for i, k := range keys {
 		key := fsys.key(path.Join(keys[0 : i+1]...))
 		if v := fsys.store.get(key); v != nil {
 			if !v.isDir {
-				return &fs.PathError{Op: "MkdirAll", Path: dir, Err: fs.ErrInvalid}
+				return &fs.PathError{Op: string(wfs.OpMkdirAll), Path: dir, Err: fs.ErrInvalid}
 			}
 			continue
 		}
 		if k == "" {
 			k = "."
 		}
-		v := &value{name: k, mode: mode | fs.ModeDir, isDir: true}
+		v := &value{name: k, mode: mode | fs.ModeDir, isDir: true, birthTime: time.Now()}
 		fsys.store.put(key, v)
 	}
 	return nil
 }
 
-func (fsys *MemFS) create(name string, mode fs.FileMode) (*value, error) {
+// mkdirParent creates the parent directories of the named file unless
+// WithStrictCreate is set. With WithStrictCreate mkdirParent returns an error
+// if the parent directory does not exist.
+func (fsys *MemFS) mkdirParent(op wfs.Op, name string, mode fs.FileMode) error {
+	dir := path.Dir(name)
+	if !fsys.strictCreate {
+		return wfs.PathError(op, name, fsys.mkdirAll(dir, mode))
+	}
+	key := fsys.key(dir)
+	v := fsys.store.get(key)
+	if v == nil {
+		if key == "/" {
+			// NOTE: The root of the store is created by the first write.
+			return wfs.PathError(op, name, fsys.mkdirAll(dir, mode))
+		}
+		return &fs.PathError{Op: string(op), Path: name, Err: fs.ErrNotExist}
+	}
+	if !v.isDir {
+		return &fs.PathError{Op: string(op), Path: name, Err: syscall.ENOTDIR}
+	}
+	return nil
+}
+
+func (fsys *MemFS) create(op wfs.Op, name string, mode fs.FileMode) (*value, error) {
 	if !fs.ValidPath(name) {
-		return nil, &fs.PathError{Op: "Create", Path: name, Err: fs.ErrInvalid}
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
 	}
-	err := fsys.mkdirAll(path.Dir(name), mode)
-	if err != nil {
+	if err := fsys.mkdirParent(op, name, mode); err != nil {
 		return nil, err
 	}
 	key := fsys.key(name)
 	v := fsys.store.get(key)
 	if v == nil {
-		v = &value{name: key, mode: mode}
+		v = &value{name: key, mode: mode, birthTime: time.Now()}
 		fsys.store.put(key, v)
 	} else if v.isDir {
-		return nil, &fs.PathError{Op: "Create", Path: name, Err: fs.ErrInvalid}
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
 	}
 	return v, nil
 }
 
 // Open opens the named file.
 func (fsys *MemFS) Open(name string) (fs.File, error) {
+	fsys.store.counters.count(wfs.OpOpen)
+
 	fsys.mutex.Lock()
 	defer fsys.mutex.Unlock()
 
-	v, err := fsys.open(name)
+	v, err := fsys.open(wfs.OpOpen, name)
 	if err != nil {
 		return nil, err
 	}
 
 	f := &MemFile{
-		fsys: fsys,
-		name: name,
-		mode: v.mode,
+		fsys:  fsys,
+		name:  name,
+		mode:  v.mode,
+		isDir: v.isDir,
+		info:  v.snapshot(),
+		open:  true,
 	}
 	if !v.isDir {
-		f.buf = bytes.NewBuffer(v.data)
+		// NOTE: v.data is never modified in place so the slice can be shared.
+		f.data = v.data
+		f.r = bytes.NewReader(v.data)
 	}
+	fsys.store.counters.addOpenFiles(1)
 	return f, nil
 }
 
-// Glob returns the names of all files matching pattern, providing an implementation
-// of the top-level Glob function.
+// Glob returns the lexically sorted names of all files matching pattern,
+// providing an implementation of the top-level Glob function.
 func (fsys *MemFS) Glob(pattern string) ([]string, error) {
+	fsys.store.counters.count(wfs.OpGlob)
+
 	fsys.mutex.Lock()
 	defer fsys.mutex.Unlock()
 
@@ -136,136 +347,454 @@ func (fsys *MemFS) Glob(pattern string) ([]string, error) {
 	for _, key := range keys {
 		names = append(names, fsys.rel(key))
 	}
+	sort.Strings(names)
 	return names, nil
 }
 
 // ReadDir reads the named directory and returns a list of directory entries sorted
 // by filename.
 func (fsys *MemFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	fsys.store.counters.count(wfs.OpReadDir)
+	entries, err := fsys.readDirAfter(dir, "", -1)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return entries, nil
+}
+
+// readDirAfter returns at most n entries of the named directory sorted by
+// filename whose names are after the specified name. If n <= 0,
+// readDirAfter returns all of the entries after the name.
+func (fsys *MemFS) readDirAfter(dir, after string, n int) ([]fs.DirEntry, error) {
 	fsys.mutex.Lock()
 	defer fsys.mutex.Unlock()
 
-	v, err := fsys.open(dir)
+	v, err := fsys.open(wfs.OpReadDir, dir)
 	if err != nil {
 		return nil, err
 	}
 	if !v.isDir {
-		return nil, &fs.PathError{Op: "ReadDir", Path: dir, Err: syscall.ENOTDIR}
+		return nil, &fs.PathError{Op: string(wfs.OpReadDir), Path: dir, Err: syscall.ENOTDIR}
 	}
 
-	prefix := fsys.key(dir)
-	keys := fsys.store.prefixKeys(prefix)
-	var dirEntries []fs.DirEntry
-	for _, key := range keys {
-		dirEntries = append(dirEntries, fsys.store.get(key))
+	keys := fsys.store.childKeysAfter(fsys.key(dir), after, n)
+	dirEntries := make([]fs.DirEntry, len(keys))
+	for i, key := range keys {
+		dirEntries[i] = fsys.store.get(key)
 	}
 	return dirEntries, nil
 }
 
-// ReadFile reads the named file and returns its contents.
+// ReadFile reads the named file and returns a copy of its contents that the
+// caller may modify. See ReadFileShared to avoid copying.
 func (fsys *MemFS) ReadFile(name string) ([]byte, error) {
+	fsys.store.counters.count(wfs.OpReadFile)
+
 	fsys.mutex.Lock()
 	defer fsys.mutex.Unlock()
 
-	v, err := fsys.open(name)
+	v, err := fsys.open(wfs.OpReadFile, name)
 	if err != nil {
 		return nil, err
 	}
 	if v.isDir {
-		return nil, &fs.PathError{Op: "ReadFile", Path: name, Err: fs.ErrInvalid}
+		return nil, &fs.PathError{Op: string(wfs.OpReadFile), Path: name, Err: fs.ErrInvalid}
 	}
 	dest := make([]byte, len(v.data))
 	copy(dest, v.data)
 	return dest, nil
 }
 
+// ReadFileShared reads the named file and returns its contents without
+// copying. The returned slice is shared with the filesystem and the other
+// callers so it must not be modified. The slice stays valid after the file is
+// written or removed because MemFS never modifies the data in place but
+// replaces it. Use ReadFileShared for read-heavy servers and ReadFile, which
+// returns a defensive copy, otherwise.
+func (fsys *MemFS) ReadFileShared(name string) ([]byte, error) {
+	fsys.store.counters.count(wfs.OpReadFile)
+
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	v, err := fsys.open(wfs.OpReadFile, name)
+	if err != nil {
+		return nil, err
+	}
+	if v.isDir {
+		return nil, &fs.PathError{Op: string(wfs.OpReadFile), Path: name, Err: fs.ErrInvalid}
+	}
+	return v.data, nil
+}
+
+// OpenRange returns a reader that reads length bytes from the offset off of
+// the named file. If length is negative the reader reads until the end.
+func (fsys *MemFS) OpenRange(name string, off, length int64) (io.ReadCloser, error) {
+	fsys.store.counters.count(wfs.OpOpenRange)
+
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	v, err := fsys.open(wfs.OpOpenRange, name)
+	if err != nil {
+		return nil, err
+	}
+	if v.isDir {
+		return nil, &fs.PathError{Op: string(wfs.OpOpenRange), Path: name, Err: syscall.EISDIR}
+	}
+	if off < 0 {
+		return nil, &fs.PathError{Op: string(wfs.OpOpenRange), Path: name, Err: fs.ErrInvalid}
+	}
+	size := int64(len(v.data))
+	if off > size {
+		off = size
+	}
+	end := size
+	if length >= 0 && length < size-off {
+		end = off + length
+	}
+	// NOTE: v.data is never modified in place so the slice can be shared.
+	return ioutil.NopCloser(bytes.NewReader(v.data[off:end])), nil
+}
+
 // Stat returns a FileInfo describing the file. If there is an error, it should be
 // of type *PathError.
 func (fsys *MemFS) Stat(name string) (fs.FileInfo, error) {
+	fsys.store.counters.count(wfs.OpStat)
+
 	fsys.mutex.Lock()
 	defer fsys.mutex.Unlock()
 
-	return fsys.open(name)
+	v, err := fsys.open(wfs.OpStat, name)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
 }
 
 // Sub returns an FS corresponding to the subtree rooted at dir.
 func (fsys *MemFS) Sub(dir string) (fs.FS, error) {
+	fsys.store.counters.count(wfs.OpSub)
+
 	fsys.mutex.Lock()
 	defer fsys.mutex.Unlock()
 
 	if !fs.ValidPath(dir) {
-		return nil, &fs.PathError{Op: "Sub", Path: dir, Err: fs.ErrInvalid}
+		return nil, &fs.PathError{Op: string(wfs.OpSub), Path: dir, Err: fs.ErrInvalid}
 	}
-	info, err := fsys.open(dir)
+	info, err := fsys.open(wfs.OpSub, dir)
 	if err != nil {
 		return nil, err
 	}
 	if !info.isDir {
-		return nil, &fs.PathError{Op: "Sub", Path: dir, Err: fs.ErrInvalid}
+		return nil, &fs.PathError{Op: string(wfs.OpSub), Path: dir, Err: fs.ErrInvalid}
 	}
 	return &MemFS{
-		dir:   path.Join(fsys.dir, dir),
-		store: fsys.store,
+		dir:             path.Join(fsys.dir, dir),
+		store:           fsys.store,
+		initialCapacity: fsys.initialCapacity,
+		strictCreate:    fsys.strictCreate,
+		leases:          fsys.leases,
+		waitLease:       fsys.waitLease,
 	}, nil
 }
 
 // MkdirAll creates the named directory.
 func (fsys *MemFS) MkdirAll(dir string, mode fs.FileMode) error {
+	fsys.store.counters.count(wfs.OpMkdirAll)
+
 	fsys.mutex.Lock()
 	defer fsys.mutex.Unlock()
 
 	return fsys.mkdirAll(dir, mode)
 }
 
-// CreateFile creates the named file.
-func (fsys *MemFS) CreateFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+// acquireLease takes the write lease of the key. fsys.mutex must be held and
+// may be released while waiting for the lease.
+func (fsys *MemFS) acquireLease(name, key string) error {
+	for {
+		ch, ok := fsys.store.leases[key]
+		if !ok {
+			fsys.store.leases[key] = make(chan struct{})
+			return nil
+		}
+		if !fsys.waitLease {
+			return &fs.PathError{Op: string(wfs.OpCreateFile), Path: name, Err: wfs.ErrLocked}
+		}
+		fsys.mutex.Unlock()
+		<-ch
+		fsys.mutex.Lock()
+	}
+}
+
+func (fsys *MemFS) releaseLease(key string) {
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	if ch, ok := fsys.store.leases[key]; ok {
+		delete(fsys.store.leases, key)
+		close(ch)
+	}
+}
+
+func (fsys *MemFS) createFile(name string, mode fs.FileMode, meta wfs.Metadata) (wfs.WriterFile, error) {
 	fsys.mutex.Lock()
 	defer fsys.mutex.Unlock()
 
-	if _, err := fsys.create(name, mode); err != nil {
+	lease := ""
+	if fsys.leases && fs.ValidPath(name) {
+		lease = fsys.key(name)
+		if err := fsys.acquireLease(name, lease); err != nil {
+			return nil, err
+		}
+	}
+	existed := fsys.store.get(fsys.key(name)) != nil
+	v, err := fsys.create(wfs.OpCreateFile, name, mode)
+	if err != nil {
+		if lease != "" {
+			close(fsys.store.leases[lease])
+			delete(fsys.store.leases, lease)
+		}
 		return nil, err
 	}
-	return &MemFile{
-		fsys: fsys,
-		name: name,
-		buf:  new(bytes.Buffer),
-		mode: mode,
-	}, nil
+	v.meta = meta.Clone()
+	f := &MemFile{
+		fsys:  fsys,
+		name:  name,
+		buf:   getBuffer(fsys.initialCapacity),
+		mode:  mode,
+		meta:  v.meta,
+		lease: lease,
+		open:  true,
+	}
+	if !existed {
+		f.created = v
+	}
+	fsys.store.counters.addOpenFiles(1)
+	return f, nil
+}
+
+func (fsys *MemFS) writeFile(name string, p []byte, mode fs.FileMode, meta wfs.Metadata) (int, error) {
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	v, err := fsys.create(wfs.OpWriteFile, name, mode)
+	if err != nil {
+		return 0, err
+	}
+	data := make([]byte, len(p))
+	n := copy(data, p)
+	fsys.store.setData(v, data)
+	v.meta = meta.Clone()
+	v.gen = fsys.store.nextGen(v.name)
+	return n, nil
+}
+
+// abortCreated removes the value created by createFile if it is still empty.
+func (fsys *MemFS) abortCreated(v *value) {
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	if fsys.store.get(v.name) == v && v.gen == 0 {
+		fsys.store.remove(v.name)
+	}
+}
+
+// CreateFile creates the named file. The missing parent directories are
+// created unless WithStrictCreate is set.
+func (fsys *MemFS) CreateFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+	fsys.store.counters.count(wfs.OpCreateFile)
+	return fsys.createFile(name, mode, nil)
 }
 
 // WriteFile writes the specified bytes to the named file.
 func (fsys *MemFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	fsys.store.counters.count(wfs.OpWriteFile)
+	return fsys.writeFile(name, p, mode, nil)
+}
+
+// CreateFileWithMeta creates the named file with the specified metadata.
+func (fsys *MemFS) CreateFileWithMeta(name string, mode fs.FileMode, meta wfs.Metadata) (wfs.WriterFile, error) {
+	fsys.store.counters.count(wfs.OpCreateFile)
+	return fsys.createFile(name, mode, meta)
+}
+
+// WriteFileWithMeta writes the specified bytes and metadata to the named file.
+func (fsys *MemFS) WriteFileWithMeta(name string, p []byte, mode fs.FileMode, meta wfs.Metadata) (int, error) {
+	fsys.store.counters.count(wfs.OpWriteFile)
+	return fsys.writeFile(name, p, mode, meta)
+}
+
+// StatMeta returns the metadata of the named file.
+func (fsys *MemFS) StatMeta(name string) (wfs.Metadata, error) {
+	fsys.store.counters.count(wfs.OpStatMeta)
+
 	fsys.mutex.Lock()
 	defer fsys.mutex.Unlock()
 
-	v, err := fsys.create(name, mode)
+	v, err := fsys.open(wfs.OpStatMeta, name)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	return v.meta.Clone(), nil
+}
+
+// CopyFile copies the named file src to dst without reading through a MemFile.
+func (fsys *MemFS) CopyFile(src, dst string) error {
+	fsys.store.counters.count(wfs.OpCopyFile)
+
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	sv, err := fsys.open(wfs.OpCopyFile, src)
+	if err != nil {
+		return err
+	}
+	if sv.isDir {
+		return &fs.PathError{Op: string(wfs.OpCopyFile), Path: src, Err: fs.ErrInvalid}
+	}
+	dv, err := fsys.create(wfs.OpCopyFile, dst, sv.mode)
+	if err != nil {
+		return err
+	}
+	data := make([]byte, len(sv.data))
+	copy(data, sv.data)
+	fsys.store.setData(dv, data)
+	dv.meta = sv.meta.Clone()
+	dv.gen = fsys.store.nextGen(dv.name)
+	return nil
+}
+
+// WriteFileIf writes the specified bytes to the named file if cond is
+// satisfied. The version of a file is a generation number that is updated on
+// every write.
+func (fsys *MemFS) WriteFileIf(name string, p []byte, mode fs.FileMode, cond wfs.Condition) (string, error) {
+	fsys.store.counters.count(wfs.OpWriteFileIf)
+
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: string(wfs.OpWriteFileIf), Path: name, Err: fs.ErrInvalid}
+	}
+	v := fsys.store.get(fsys.key(name))
+	if cond.IfNotExists && v != nil {
+		return "", &fs.PathError{Op: string(wfs.OpWriteFileIf), Path: name, Err: wfs.ErrPreconditionFailed}
+	}
+	if cond.IfMatch != "" && (v == nil || v.isDir || version(v) != cond.IfMatch) {
+		return "", &fs.PathError{Op: string(wfs.OpWriteFileIf), Path: name, Err: wfs.ErrPreconditionFailed}
+	}
+	v, err := fsys.create(wfs.OpWriteFileIf, name, mode)
+	if err != nil {
+		return "", err
+	}
+	data := make([]byte, len(p))
+	copy(data, p)
+	fsys.store.setData(v, data)
+	v.meta = nil
+	v.gen = fsys.store.nextGen(v.name)
+	return version(v), nil
+}
+
+// FileVersion returns the current version of the named file.
+func (fsys *MemFS) FileVersion(name string) (string, error) {
+	fsys.store.counters.count(wfs.OpFileVersion)
+
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	v, err := fsys.open(wfs.OpFileVersion, name)
+	if err != nil {
+		return "", err
 	}
-	v.data = make([]byte, len(p))
-	return copy(v.data, p), nil
+	if v.isDir {
+		return "", &fs.PathError{Op: string(wfs.OpFileVersion), Path: name, Err: fs.ErrInvalid}
+	}
+	return version(v), nil
 }
 
-// RemoveFile removes the specified named file.
+// ChangeToken returns the generation of the last change of the named file or
+// of any file under the named directory, so it is cheap regardless of the
+// size of the tree.
+func (fsys *MemFS) ChangeToken(dir string) (string, error) {
+	fsys.store.counters.count(wfs.OpChangeToken)
+
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	if _, err := fsys.open(wfs.OpChangeToken, dir); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(fsys.store.changeGen(fsys.key(dir)), 10), nil
+}
+
+func version(v *value) string {
+	return strconv.FormatInt(v.gen, 10)
+}
+
+// RemoveFile removes the specified named file or empty directory.
 func (fsys *MemFS) RemoveFile(name string) error {
+	fsys.store.counters.count(wfs.OpRemoveFile)
+
 	fsys.mutex.Lock()
 	defer fsys.mutex.Unlock()
 
+	return fsys.removeFile(wfs.OpRemoveFile, name)
+}
+
+// removeFile removes the named file or empty directory. fsys.mutex must be
+// held.
+func (fsys *MemFS) removeFile(op wfs.Op, name string) error {
 	if !fs.ValidPath(name) {
-		return &fs.PathError{Op: "RemoveFile", Path: name, Err: fs.ErrInvalid}
+		return &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
 	}
+	key := fsys.key(name)
+	v := fsys.store.get(key)
+	if v == nil {
+		return &fs.PathError{Op: string(op), Path: name, Err: fs.ErrNotExist}
+	}
+	if v.isDir && len(fsys.store.prefixKeys(key)) > 0 {
+		return &fs.PathError{Op: string(op), Path: name, Err: errNotEmpty}
+	}
+	fsys.store.remove(key)
+	return nil
+}
+
+// RemoveFiles removes the specified named files or empty directories in order
+// like RemoveFile. No file is removed if any of the names is invalid. If a
+// file does not exist or a directory is not empty RemoveFiles stops with the
+// error and the files before it are removed.
+func (fsys *MemFS) RemoveFiles(names []string) error {
+	fsys.store.counters.count(wfs.OpRemoveFiles)
 
-	fsys.store.remove(fsys.key(name))
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	for _, name := range names {
+		if !fs.ValidPath(name) {
+			return &fs.PathError{Op: string(wfs.OpRemoveFiles), Path: name, Err: fs.ErrInvalid}
+		}
+	}
+	for _, name := range names {
+		if err := fsys.removeFile(wfs.OpRemoveFiles, name); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // RemoveAll removes path and any children it contains.
 func (fsys *MemFS) RemoveAll(path string) error {
+	fsys.store.counters.count(wfs.OpRemoveAll)
+
 	fsys.mutex.Lock()
 	defer fsys.mutex.Unlock()
 
 	if !fs.ValidPath(path) {
-		return &fs.PathError{Op: "RemoveAll", Path: path, Err: fs.ErrInvalid}
+		return &fs.PathError{Op: string(wfs.OpRemoveAll), Path: path, Err: fs.ErrInvalid}
 	}
 
 	fsys.store.removeAll(fsys.key(path))
@@ -273,79 +802,285 @@ func (fsys *MemFS) RemoveAll(path string) error {
 }
 
 // MemFile represents an in-memory file.
-// MemFile implements fs.File, fs.ReadDirFile and wfs.WriterFile.
+// MemFile implements fs.File, fs.ReadDirFile, io.Seeker, io.ReaderAt,
+// wfs.WriterFile, wfs.WriterAtFile and wfs.SparseFile.
+//
+// A MemFile returned by Open reads a snapshot of the data at Open with its own
+// offset, so files opened concurrently are independent and are not affected
+// by writes to the same name. Writes to the MemFile are applied to a copy of
+// the snapshot and stored at Close.
 type MemFile struct {
-	fsys       *MemFS
-	name       string
-	buf        *bytes.Buffer
-	mode       fs.FileMode
-	dirRead    bool
-	dirEntries []fs.DirEntry
-	dirIndex   int
-	wrote      bool
+	mutex   sync.Mutex
+	fsys    *MemFS
+	name    string
+	isDir   bool
+	data    []byte
+	r       *bytes.Reader
+	buf     *bytes.Buffer
+	mode    fs.FileMode
+	dirLast string
+	info    *value
+	wrote   bool
+	meta    wfs.Metadata
+	created *value
+	lease   string
+	open    bool
+	closed  bool
 }
 
 var (
-	_ fs.File        = (*MemFile)(nil)
-	_ fs.ReadDirFile = (*MemFile)(nil)
-	_ wfs.WriterFile = (*MemFile)(nil)
+	_ fs.File             = (*MemFile)(nil)
+	_ fs.ReadDirFile      = (*MemFile)(nil)
+	_ io.Seeker           = (*MemFile)(nil)
+	_ io.ReaderAt         = (*MemFile)(nil)
+	_ wfs.WriterFile      = (*MemFile)(nil)
+	_ wfs.LargeWriterFile = (*MemFile)(nil)
+	_ wfs.WriterAtFile    = (*MemFile)(nil)
+	_ wfs.SparseFile      = (*MemFile)(nil)
+	_ wfs.AbortWriterFile = (*MemFile)(nil)
 )
 
 // Read reads bytes from this file.
 func (f *MemFile) Read(p []byte) (int, error) {
-	if f.buf == nil {
+	if f.isDir {
 		return 0, &fs.PathError{Op: "Read", Path: f.name, Err: syscall.EISDIR}
 	}
+	if f.r != nil {
+		return f.r.Read(p)
+	}
+	if f.buf == nil {
+		return 0, &fs.PathError{Op: "Read", Path: f.name, Err: fs.ErrClosed}
+	}
 	return f.buf.Read(p)
 }
 
-// Stat returns the fs.FileInfo of this file.
+// Seek sets the offset of the snapshot read by a file returned by Open.
+func (f *MemFile) Seek(offset int64, whence int) (int64, error) {
+	if f.r == nil {
+		return 0, &fs.PathError{Op: "Seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	return f.r.Seek(offset, whence)
+}
+
+// ReadAt reads bytes of the snapshot of a file returned by Open at the offset
+// off.
+func (f *MemFile) ReadAt(p []byte, off int64) (int, error) {
+	if f.r == nil {
+		return 0, &fs.PathError{Op: "ReadAt", Path: f.name, Err: fs.ErrInvalid}
+	}
+	return f.r.ReadAt(p, off)
+}
+
+// writeBuf returns the buffer to write. The buffer of a file returned by Open
+// is initialized by a copy of the snapshot.
+func (f *MemFile) writeBuf() *bytes.Buffer {
+	if f.buf == nil {
+		f.buf = getBuffer(len(f.data))
+		f.buf.Write(f.data)
+	}
+	f.wrote = true
+	return f.buf
+}
+
+// Stat returns the fs.FileInfo of this file. The fs.FileInfo of a file
+// returned by Open describes the snapshot taken at Open. Stat is not counted
+// as an operation of the filesystem.
 func (f *MemFile) Stat() (fs.FileInfo, error) {
-	return f.fsys.Stat(f.name)
+	if f.info != nil {
+		return f.info, nil
+	}
+	f.fsys.mutex.Lock()
+	defer f.fsys.mutex.Unlock()
+
+	v, err := f.fsys.open(wfs.OpStat, f.name)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
 }
 
-// Close closes streams.
+// Close closes streams. The written data is stored and the buffer is
+// released so that Close stores the data only once. The writes after Close
+// return fs.ErrClosed.
 func (f *MemFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var err error
 	if f.wrote {
-		var err error
-		_, err = f.fsys.WriteFile(f.name, f.buf.Bytes(), f.mode)
-		return err
+		_, err = f.fsys.writeFile(f.name, f.buf.Bytes(), f.mode, f.meta)
+		f.wrote = false
+	}
+	putBuffer(f.buf)
+	f.buf = nil
+	f.dirLast = ""
+	f.closed = true
+	f.releaseLease()
+	f.release()
+	return err
+}
+
+// releaseLease releases the write lease taken by CreateFile. f.mutex must be
+// held.
+func (f *MemFile) releaseLease() {
+	if f.lease != "" {
+		f.fsys.releaseLease(f.lease)
+		f.lease = ""
 	}
-	f.dirEntries = nil
+}
+
+// release stops counting this file as open. f.mutex must be held.
+func (f *MemFile) release() {
+	if f.open {
+		f.open = false
+		f.fsys.store.counters.addOpenFiles(-1)
+	}
+}
+
+// Abort drops the staged data of this file. If the file was newly created by
+// CreateFile and has not been written since, Abort also removes it.
+func (f *MemFile) Abort() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.wrote = false
+	putBuffer(f.buf)
+	f.buf = nil
+	if f.created != nil {
+		f.fsys.abortCreated(f.created)
+		f.created = nil
+	}
+	f.closed = true
+	f.releaseLease()
+	f.release()
 	return nil
 }
 
-// ReadDir reads sub directories.
+// ReadDir reads the contents of the directory in the order of filename.
+// Each call lists the directory at the time of the call and returns the
+// entries after the last entry returned by the previous call, so entries
+// created or removed after Open are reflected in later calls and no entry is
+// returned twice. If n > 0 and there are no more entries, ReadDir returns an
+// empty slice and io.EOF. If n <= 0, ReadDir returns all of the remaining
+// entries and a nil error, with an empty slice at the end of the directory.
 func (f *MemFile) ReadDir(n int) ([]fs.DirEntry, error) {
-	if !f.dirRead {
-		f.dirRead = true
-		var err error
-		f.dirEntries, err = f.fsys.ReadDir(f.name)
-		if err != nil {
-			return nil, err
-		}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	entries, err := f.fsys.readDirAfter(f.name, f.dirLast, n)
+	if err != nil {
+		return nil, err
 	}
-	max := len(f.dirEntries)
-	if f.dirIndex >= max {
-		if n <= 0 {
-			return nil, nil
+	if len(entries) == 0 {
+		if n > 0 {
+			return entries, io.EOF
 		}
-		return nil, io.EOF
+		return entries, nil
 	}
-	if n <= 0 {
-		n = max - f.dirIndex
+	f.dirLast = entries[len(entries)-1].Name()
+	return entries, nil
+}
+
+// writable returns an error if this file is a directory or closed. f.mutex
+// must be held.
+func (f *MemFile) writable(op string) error {
+	if f.isDir {
+		return &fs.PathError{Op: op, Path: f.name, Err: syscall.EISDIR}
 	}
-	end := f.dirIndex + n
-	if end > max {
-		end = max
+	if f.closed {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
 	}
-	defer func() { f.dirIndex = end }()
-
-	return f.dirEntries[f.dirIndex:end], nil
+	return nil
 }
 
 // Write writes the specified bytes to this file.
 func (f *MemFile) Write(p []byte) (int, error) {
-	f.wrote = true
-	return f.buf.Write(p)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.writable("Write"); err != nil {
+		return 0, err
+	}
+	return f.writeBuf().Write(p)
+}
+
+// ReadFrom reads data from r until EOF and appends it to this file.
+func (f *MemFile) ReadFrom(r io.Reader) (int64, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.writable("ReadFrom"); err != nil {
+		return 0, err
+	}
+	return f.writeBuf().ReadFrom(r)
+}
+
+// WriteAt writes the specified bytes at the offset off of this file. The file
+// grows if needed and a gap is filled with zeros. WriteAt is safe for
+// concurrent use.
+func (f *MemFile) WriteAt(p []byte, off int64) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.writable("WriteAt"); err != nil {
+		return 0, err
+	}
+	if off < 0 || off > int64(maxInt-len(p)) {
+		return 0, &fs.PathError{Op: "WriteAt", Path: f.name, Err: fs.ErrInvalid}
+	}
+	data := f.writeBuf().Bytes()
+	end := int(off) + len(p)
+	if end > len(data) {
+		grown := make([]byte, end)
+		copy(grown, data)
+		data = grown
+	}
+	copy(data[off:], p)
+	f.buf = bytes.NewBuffer(data)
+	return len(p), nil
+}
+
+// Truncate changes the size of this file. Extending the file fills zeros.
+func (f *MemFile) Truncate(size int64) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.writable("Truncate"); err != nil {
+		return err
+	}
+	if size < 0 || size > int64(maxInt) {
+		return &fs.PathError{Op: "Truncate", Path: f.name, Err: fs.ErrInvalid}
+	}
+	data := f.writeBuf().Bytes()
+	if int(size) <= len(data) {
+		f.buf = bytes.NewBuffer(data[:size])
+		return nil
+	}
+	grown := make([]byte, size)
+	copy(grown, data)
+	f.buf = bytes.NewBuffer(grown)
+	return nil
+}
+
+// PunchHole fills zeros in the specified range of this file. MemFile does not
+// deallocate memory of the range.
+func (f *MemFile) PunchHole(off, length int64) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.writable("PunchHole"); err != nil {
+		return err
+	}
+	if off < 0 || length < 0 {
+		return &fs.PathError{Op: "PunchHole", Path: f.name, Err: fs.ErrInvalid}
+	}
+	data := f.writeBuf().Bytes()
+	end := off + length
+	if end > int64(len(data)) {
+		end = int64(len(data))
+	}
+	for i := off; i < end; i++ {
+		data[i] = 0
+	}
+	return nil
 }
@@ -1,22 +1,28 @@
 package memfs
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
+	"io/ioutil"
+	"math"
 	"os"
 	"reflect"
 	"strings"
+	"sync"
+	"syscall"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/jarxorg/wfs"
 	"github.com/jarxorg/wfs/wfstest"
 )
 
 func newMemFSTest(t *testing.T) *MemFS {
-	fsys := New()
-	err := wfs.CopyFS(fsys, os.DirFS("../osfs/testdata"), ".")
+	fsys, err := FromFS(os.DirFS("../osfs/testdata"), ".")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -30,17 +36,266 @@ func TestFS(t *testing.T) {
 	}
 }
 
+func TestFromFS(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	src := fstest.MapFS{
+		"assets":           {Mode: fs.ModeDir | 0750, ModTime: modTime},
+		"assets/a.txt":     {Data: []byte("a"), Mode: 0444, ModTime: modTime},
+		"assets/dir/b.txt": {Data: []byte("b"), Mode: 0600, ModTime: modTime},
+		"other.txt":        {Data: []byte("other")},
+	}
+	fsys, err := FromFS(src, "assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(fsys, "a.txt", "dir/b.txt"); err != nil {
+		t.Errorf(`Error testing/fstest: %+v`, err)
+	}
+	tests := map[string]fs.FileMode{
+		"a.txt":     0444,
+		"dir/b.txt": 0600,
+	}
+	for name, want := range tests {
+		info, err := fsys.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode() != want {
+			t.Errorf(`Error mode of %s %v; want %v`, name, info.Mode(), want)
+		}
+		if !info.ModTime().Equal(modTime) {
+			t.Errorf(`Error modTime of %s %v; want %v`, name, info.ModTime(), modTime)
+		}
+	}
+	if _, err := fsys.Stat("other.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`Error Stat other.txt %v; want %v`, err, fs.ErrNotExist)
+	}
+	if _, err := FromFS(src, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`Error FromFS missing %v; want %v`, err, fs.ErrNotExist)
+	}
+}
+
+func TestFromTree(t *testing.T) {
+	fsys, err := FromTree(map[string]interface{}{
+		"README.md": "readme",
+		"dir": map[string]interface{}{
+			"a.txt": []byte("a"),
+			"empty": map[string]interface{}{},
+		},
+		"deep/nested/b.txt": "b",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(fsys, "README.md", "dir/a.txt", "dir/empty", "deep/nested/b.txt"); err != nil {
+		t.Errorf(`Error testing/fstest: %+v`, err)
+	}
+	got, err := fsys.ReadFile("deep/nested/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "b" {
+		t.Errorf(`Error ReadFile("deep/nested/b.txt") got "%s"; want "b"`, got)
+	}
+
+	if _, err := FromTree(map[string]interface{}{}); err != nil {
+		t.Errorf(`Error FromTree empty %v`, err)
+	}
+	_, err = FromTree(map[string]interface{}{
+		"dir": map[string]interface{}{"n.txt": 1},
+	})
+	if !errors.Is(err, fs.ErrInvalid) || !strings.Contains(err.Error(), "dir/n.txt") {
+		t.Errorf(`Error FromTree invalid %v; want %v of dir/n.txt`, err, fs.ErrInvalid)
+	}
+}
+
 func TestWriteFileFS(t *testing.T) {
 	fsys := New()
 	tmpdir := "tmpdir"
 	if err := fsys.mkdirAll(tmpdir, fs.ModePerm); err != nil {
 		t.Fatal(err)
 	}
-	if err := wfstest.TestWriteFileFS(fsys, tmpdir); err != nil {
+	if err := wfstest.TestWriteFileFS(wfstest.LeakCheck(t, fsys), tmpdir); err != nil {
+		t.Errorf(`Error wfs/wfstest: %+v`, err)
+	}
+}
+
+func TestConditionalWriteFS(t *testing.T) {
+	fsys := New()
+	tmpdir := "tmpdir"
+	if err := fsys.mkdirAll(tmpdir, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfstest.TestConditionalWriteFS(fsys, tmpdir); err != nil {
+		t.Errorf(`Error wfs/wfstest: %+v`, err)
+	}
+}
+
+func TestGlobFS(t *testing.T) {
+	fsys := New()
+	tmpdir := "tmpdir"
+	if err := fsys.mkdirAll(tmpdir, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfstest.TestGlobFS(fsys, tmpdir); err != nil {
+		t.Errorf(`Error wfs/wfstest: %+v`, err)
+	}
+}
+
+func TestOrdering(t *testing.T) {
+	fsys := New()
+	tmpdir := "tmpdir"
+	if err := fsys.mkdirAll(tmpdir, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfstest.TestOrdering(fsys, tmpdir); err != nil {
+		t.Errorf(`Error wfs/wfstest: %+v`, err)
+	}
+}
+
+func TestPathErrorOps(t *testing.T) {
+	fsys := New()
+	tmpdir := "tmpdir"
+	if err := fsys.mkdirAll(tmpdir, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfstest.TestPathErrorOps(fsys, tmpdir); err != nil {
+		t.Errorf(`Error wfs/wfstest: %+v`, err)
+	}
+}
+
+func TestRemoveFileFS(t *testing.T) {
+	fsys := New()
+	tmpdir := "tmpdir"
+	if err := fsys.mkdirAll(tmpdir, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfstest.TestRemoveFileFS(fsys, tmpdir); err != nil {
+		t.Errorf(`Error wfs/wfstest: %+v`, err)
+	}
+}
+
+func TestSubFS(t *testing.T) {
+	fsys := New()
+	tmpdir := "tmpdir"
+	if err := fsys.mkdirAll(tmpdir, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfstest.TestSub(fsys, tmpdir); err != nil {
+		t.Errorf(`Error wfs/wfstest: %+v`, err)
+	}
+}
+
+func TestReadDirFile(t *testing.T) {
+	fsys := New()
+	tmpdir := "tmpdir"
+	if err := fsys.mkdirAll(tmpdir, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfstest.TestReadDirFile(fsys, tmpdir); err != nil {
 		t.Errorf(`Error wfs/wfstest: %+v`, err)
 	}
 }
 
+func BenchmarkFS(b *testing.B) {
+	wfstest.BenchmarkFS(b, func(b *testing.B) fs.FS {
+		return New()
+	})
+}
+
+func benchmarkCreateFile(b *testing.B, fsys *MemFS, size int) {
+	p := make([]byte, 512)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f, err := fsys.CreateFile("tmp/file.o", fs.ModePerm)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for n := 0; n < size; n += len(p) {
+			if _, err := f.Write(p); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := f.Close(); err != nil {
+			b.Fatal(err)
+		}
+		if err := fsys.RemoveFile("tmp/file.o"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCreateFile_Allocs(b *testing.B) {
+	const size = 64 << 10
+	b.Run("default", func(b *testing.B) {
+		benchmarkCreateFile(b, New(), size)
+	})
+	b.Run("WithInitialCapacity", func(b *testing.B) {
+		benchmarkCreateFile(b, New(WithInitialCapacity(size)), size)
+	})
+}
+
+// newMemFSSiblings returns a MemFS with n files in "big" and a few files in
+// "small" for benchmarks that should not depend on the size of "big".
+func newMemFSSiblings(b *testing.B, n int) *MemFS {
+	fsys := New()
+	for i := 0; i < n; i++ {
+		if _, err := fsys.WriteFile(fmt.Sprintf("big/%06d.txt", i), []byte{}, fs.ModePerm); err != nil {
+			b.Fatal(err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := fsys.WriteFile(fmt.Sprintf("small/%d.txt", i), []byte{}, fs.ModePerm); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return fsys
+}
+
+func BenchmarkReadDir_100kSiblings(b *testing.B) {
+	fsys := newMemFSSiblings(b, 100000)
+	b.Run("small", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := fsys.ReadDir("small"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("big", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := fsys.ReadDir("big"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkGlob_100kSiblings(b *testing.B) {
+	fsys := newMemFSSiblings(b, 100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := fsys.Glob("small/*.txt"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRemoveAll_100kSiblings(b *testing.B) {
+	fsys := newMemFSSiblings(b, 100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		if _, err := fsys.WriteFile("small/sub/a.txt", []byte{}, fs.ModePerm); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		if err := fsys.RemoveAll("small/sub"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func TestCreateFile(t *testing.T) {
 	testCases := []struct {
 		name   string
@@ -52,13 +307,13 @@ func TestCreateFile(t *testing.T) {
 			name: "newDir/file.txt",
 		}, {
 			name:   "newDir",
-			errStr: "Create newDir: invalid argument",
+			errStr: "CreateFile newDir: invalid argument",
 		}, {
 			name:   "newDir/file.txt/invalid",
-			errStr: "MkdirAll newDir/file.txt: invalid argument",
+			errStr: "CreateFile newDir/file.txt/invalid: invalid argument",
 		}, {
 			name:   "../invalid",
-			errStr: "Create ../invalid: invalid argument",
+			errStr: "CreateFile ../invalid: invalid argument",
 		}, {
 			name: "dir0/file01.txt",
 		},
@@ -147,6 +402,12 @@ func TestGlob(t *testing.T) {
 				"dir0/file02.txt",
 			},
 			pattern: "dir0/*.txt",
+		}, {
+			want:    []string{"dir0"},
+			pattern: "dir0",
+		}, {
+			want:    []string{"."},
+			pattern: ".",
 		}, {
 			pattern: "no-match",
 		}, {
@@ -190,13 +451,13 @@ func TestReadDir(t *testing.T) {
 			dir: "dir0",
 		}, {
 			dir:    "not-found",
-			errStr: "Open not-found: file does not exist",
+			errStr: "ReadDir not-found: file does not exist",
 		}, {
 			dir:    "dir0/file01.txt",
 			errStr: "ReadDir dir0/file01.txt: not a directory",
 		}, {
 			dir:    "../invalid",
-			errStr: "Open ../invalid: invalid argument",
+			errStr: "ReadDir ../invalid: invalid argument",
 		},
 	}
 
@@ -231,13 +492,13 @@ func TestReadFile(t *testing.T) {
 			name: "dir0/file01.txt",
 		}, {
 			name:   "not-found",
-			errStr: "Open not-found: file does not exist",
+			errStr: "ReadFile not-found: file does not exist",
 		}, {
 			name:   "dir0",
 			errStr: "ReadFile dir0: invalid argument",
 		}, {
 			name:   "../invalid.txt",
-			errStr: "Open ../invalid.txt: invalid argument",
+			errStr: "ReadFile ../invalid.txt: invalid argument",
 		},
 	}
 
@@ -257,6 +518,61 @@ func TestReadFile(t *testing.T) {
 	}
 }
 
+func TestReadFile_Copy(t *testing.T) {
+	fsys := New()
+	if _, err := fsys.WriteFile("a.txt", []byte("hello"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	p, err := fsys.ReadFile("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p[0] = 'H'
+
+	got, err := fsys.ReadFile("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Errorf(`Error ReadFile returns %s; want hello`, got)
+	}
+}
+
+func TestReadFileShared(t *testing.T) {
+	fsys := New()
+	if _, err := fsys.WriteFile("a.txt", []byte("hello"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	p1, err := fsys.ReadFileShared("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := fsys.ReadFileShared("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &p1[0] != &p2[0] {
+		t.Errorf(`Error ReadFileShared returns copies`)
+	}
+
+	if _, err := fsys.WriteFile("a.txt", []byte("world"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if string(p1) != "hello" {
+		t.Errorf(`Error ReadFileShared returns %s after WriteFile; want hello`, p1)
+	}
+
+	if _, err := fsys.ReadFileShared("not-found"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`Error ReadFileShared("not-found") returns %v; want %v`, err, fs.ErrNotExist)
+	}
+	if err := fsys.MkdirAll("dir", fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.ReadFileShared("dir"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf(`Error ReadFileShared("dir") returns %v; want %v`, err, fs.ErrInvalid)
+	}
+}
+
 func TestSub(t *testing.T) {
 	fsys := newMemFSTest(t)
 	dir0, err := fsys.Sub("dir0")
@@ -294,7 +610,7 @@ func TestSub_Errors(t *testing.T) {
 			errStr: "Sub ../invalid: invalid argument",
 		}, {
 			dir:    "not-found",
-			errStr: "Open not-found: file does not exist",
+			errStr: "Sub not-found: file does not exist",
 		}, {
 			dir:    "dir0/file01.txt",
 			errStr: "Sub dir0/file01.txt: invalid argument",
@@ -326,10 +642,10 @@ func TestWriteFile(t *testing.T) {
 			name: "dir0/file01.txt",
 		}, {
 			name:   "dir0",
-			errStr: "Create dir0: invalid argument",
+			errStr: "WriteFile dir0: invalid argument",
 		}, {
 			name:   "../invalid.txt",
-			errStr: "Create ../invalid.txt: invalid argument",
+			errStr: "WriteFile ../invalid.txt: invalid argument",
 		},
 	}
 
@@ -349,6 +665,68 @@ func TestWriteFile(t *testing.T) {
 	}
 }
 
+func TestCopyFile(t *testing.T) {
+	fsys := newMemFSTest(t)
+	src := "dir0/file01.txt"
+	dst := "dir1/file01.txt"
+
+	err := fsys.CopyFile(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := fsys.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := fsys.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(`Error CopyFile("%s", "%s") got %s; want %s`, src, dst, got, want)
+	}
+}
+
+func TestCopyFile_SameName(t *testing.T) {
+	fsys := newMemFSTest(t)
+	name := "dir0/file01.txt"
+	want, err := fsys.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.CopyFile(fsys, name, "./"+name); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.MoveFile(fsys, name, name); err != nil {
+		t.Fatal(err)
+	}
+	got, err := fsys.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(`Error ReadFile("%s") got %s; want %s`, name, got, want)
+	}
+	if err := wfs.MoveFile(fsys, "not-found.txt", "not-found.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`Error MoveFile returns %v; want %v`, err, fs.ErrNotExist)
+	}
+}
+
+func TestCopyFile_Errors(t *testing.T) {
+	fsys := newMemFSTest(t)
+
+	if err := fsys.CopyFile("not-found.txt", "dst.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`Error CopyFile returns %v; want %v`, err, fs.ErrNotExist)
+	}
+	if err := fsys.CopyFile("dir0", "dst"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf(`Error CopyFile returns %v; want %v`, err, fs.ErrInvalid)
+	}
+	if err := fsys.CopyFile("dir0/file01.txt", "dir0"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf(`Error CopyFile returns %v; want %v`, err, fs.ErrInvalid)
+	}
+}
+
 func TestRemoveFile(t *testing.T) {
 	fsys := newMemFSTest(t)
 	name := "dir0/file01.txt"
@@ -372,14 +750,71 @@ func TestRemoveFile(t *testing.T) {
 }
 
 func TestRemoveFile_Errors(t *testing.T) {
+	fsys := newMemFSTest(t)
+	testCases := []struct {
+		name string
+		err  error
+	}{
+		{
+			name: "../invalid",
+			err:  fs.ErrInvalid,
+		}, {
+			name: "dir0/missing.txt",
+			err:  fs.ErrNotExist,
+		}, {
+			name: "dir0",
+			err:  errNotEmpty,
+		},
+	}
+	for _, tc := range testCases {
+		want := &fs.PathError{Op: "RemoveFile", Path: tc.name, Err: tc.err}
+		got := fsys.RemoveFile(tc.name)
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf(`Error RemoveFile("%s") returns %v; want %v`, tc.name, got, want)
+		}
+	}
+}
+
+func TestRemoveFiles(t *testing.T) {
+	fsys := newMemFSTest(t)
+	names := []string{"dir0/file01.txt", "dir0/file02.txt"}
+
+	err := fsys.RemoveFiles(names)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range names {
+		info, err := fsys.Stat(name)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf(`Error RemoveFiles("%s") after Stat returns %v`, name, info)
+		}
+	}
+}
+
+func TestRemoveFiles_Errors(t *testing.T) {
 	fsys := newMemFSTest(t)
 	name := "../invalid"
 
-	want := &fs.PathError{Op: "RemoveFile", Path: name, Err: fs.ErrInvalid}
-	got := fsys.RemoveFile(name)
+	want := &fs.PathError{Op: "RemoveFiles", Path: name, Err: fs.ErrInvalid}
+	got := fsys.RemoveFiles([]string{"dir0/file01.txt", name})
 
 	if !reflect.DeepEqual(got, want) {
-		t.Errorf(`Error RemoveFile("%s") returns %v; want %v`, name, got, want)
+		t.Errorf(`Error RemoveFiles("%s") returns %v; want %v`, name, got, want)
+	}
+	if _, err := fsys.Stat("dir0/file01.txt"); err != nil {
+		t.Errorf(`Error RemoveFiles removes files on error: %v`, err)
+	}
+
+	if err := fsys.RemoveFiles([]string{"dir0"}); !errors.Is(err, errNotEmpty) {
+		t.Errorf(`Error RemoveFiles("dir0") returns %v; want %v`, err, errNotEmpty)
+	}
+	if _, err := fsys.Stat("dir0/file01.txt"); err != nil {
+		t.Errorf(`Error RemoveFiles removes children of a directory: %v`, err)
+	}
+	if err := fsys.RemoveFiles([]string{"dir0/file01.txt", "missing"}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`Error RemoveFiles("missing") returns %v; want %v`, err, fs.ErrNotExist)
 	}
 }
 
@@ -388,7 +823,7 @@ func TestRemoveAll(t *testing.T) {
 	dir := "dir0"
 
 	var want []string
-	for _, k := range fsys.store.keys {
+	for _, k := range storeKeys(fsys.store) {
 		if !strings.HasPrefix(k, "/"+dir) {
 			want = append(want, k)
 		}
@@ -399,7 +834,7 @@ func TestRemoveAll(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	got := fsys.store.keys
+	got := storeKeys(fsys.store)
 	if !reflect.DeepEqual(got, want) {
 		t.Errorf(`Error RemoveAll("%s") after keys %v; want %v`, dir, got, want)
 	}
@@ -437,25 +872,152 @@ func TestMemFile_Read_Errors(t *testing.T) {
 	}
 }
 
-func TestMemFile_ReadDir(t *testing.T) {
-	fsys := newMemFSTest(t)
-	dir := "dir0"
+func TestMemFile_ConcurrentOpen(t *testing.T) {
+	fsys := New()
+	name := "file.txt"
+	want := []byte("hello,world")
+	if _, err := fsys.WriteFile(name, want, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
 
-	f, err := fsys.Open(dir)
+	const n = 8
+	fs0, err := fsys.Open(name)
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer fs0.Close()
 
-	memf, ok := f.(*MemFile)
-	if !ok {
-		t.Fatalf(`Fatal not MemFile: %#v`, f)
-	}
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			f, err := fsys.Open(name)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer f.Close()
 
-	testCases := []struct {
-		name string
-		err  error
-	}{
-		{
+			got, err := ioutil.ReadAll(f)
+			if err == nil && !reflect.DeepEqual(got, want) {
+				err = fmt.Errorf(`ReadAll returns %s; want %s`, got, want)
+			}
+			errs <- err
+		}()
+		if _, err := fsys.WriteFile(name, []byte("changed"), fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fsys.WriteFile(name, want, fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+
+	// NOTE: fs0 reads the snapshot at Open even if the file is changed.
+	if _, err := fsys.WriteFile(name, []byte("changed"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(fs0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(`Error ReadAll returns %s; want %s`, got, want)
+	}
+}
+
+func TestMemFile_StatSnapshot(t *testing.T) {
+	fsys := New()
+	if _, err := fsys.WriteFile("a.txt", []byte("snap"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fsys.Open("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := fsys.WriteFile("a.txt", []byte("longer data"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	stats := fsys.Stats().Ops[wfs.OpStat]
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := info.Size(), int64(len("snap")); got != want {
+		t.Errorf("unexpected size %d; want %d", got, want)
+	}
+	if got := fsys.Stats().Ops[wfs.OpStat]; got != stats {
+		t.Errorf("unexpected %d Stat ops; want %d", got, stats)
+	}
+}
+
+func TestMemFile_WriteOpened(t *testing.T) {
+	fsys := New()
+	name := "file.txt"
+	if _, err := fsys.WriteFile(name, []byte("hello"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	f1, err := fsys.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f2, err := fsys.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f2.Close()
+
+	if err := wfs.PunchHole(f1.(wfs.WriterFile), 0, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f1.(wfs.WriterFile).Write([]byte(",world")); err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: Writes to f1 do not affect f2 until f1 is closed.
+	got, err := ioutil.ReadAll(f2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Errorf(`Error ReadAll returns %s; want hello`, got)
+	}
+	if err := f1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got, err = fsys.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\x00\x00llo,world"; string(got) != want {
+		t.Errorf(`Error ReadFile returns %q; want %q`, got, want)
+	}
+}
+
+func TestMemFile_ReadDir(t *testing.T) {
+	fsys := newMemFSTest(t)
+	dir := "dir0"
+
+	f, err := fsys.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memf, ok := f.(*MemFile)
+	if !ok {
+		t.Fatalf(`Fatal not MemFile: %#v`, f)
+	}
+
+	testCases := []struct {
+		name string
+		err  error
+	}{
+		{
 			name: "file01.txt",
 		}, {
 			name: "file02.txt",
@@ -504,3 +1066,788 @@ func TestMemFile_ReadDir_Errors(t *testing.T) {
 		t.Fatalf(`Fatal ReadDir(1) returns no error`)
 	}
 }
+
+func TestMemFile_ReadDir_LiveView(t *testing.T) {
+	fsys := New()
+	for _, name := range []string{"dir/a.txt", "dir/b.txt", "dir/c.txt"} {
+		if _, err := fsys.WriteFile(name, []byte{}, fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := fsys.Open("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	d := f.(fs.ReadDirFile)
+
+	entries, err := d.ReadDir(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := entries[0].Name(); got != "a.txt" {
+		t.Errorf(`Error ReadDir(1) returns %s; want a.txt`, got)
+	}
+
+	if _, err := fsys.WriteFile("dir/0.txt", []byte{}, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("dir/d.txt", []byte{}, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.RemoveFile("dir/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err = d.ReadDir(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	want := []string{"c.txt", "d.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(`Error ReadDir(-1) returns %v; want %v`, got, want)
+	}
+}
+
+func TestStat_BirthTime(t *testing.T) {
+	fsys := New()
+	before := time.Now()
+	if _, err := fsys.WriteFile("dir/a.txt", []byte("a"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"dir", "dir/a.txt"} {
+		info, err := fsys.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		birth, ok := wfs.BirthTime(info)
+		if !ok || birth.Before(before) {
+			t.Errorf(`Error BirthTime of %s returns %v %v; want after %v`, name, birth, ok, before)
+		}
+
+		if _, err := fsys.WriteFile("dir/a.txt", []byte("b"), fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		info, err = fsys.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := wfs.BirthTime(info); !got.Equal(birth) {
+			t.Errorf(`Error BirthTime of %s changed by WriteFile %v; want %v`, name, got, birth)
+		}
+	}
+}
+
+func TestWithStrictCreate(t *testing.T) {
+	fsys := New(WithStrictCreate())
+	if _, err := fsys.CreateFile("dir/a.txt", fs.ModePerm); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`Error CreateFile returns %v; want %v`, err, fs.ErrNotExist)
+	}
+	if _, err := fsys.WriteFile("dir/a.txt", []byte{}, fs.ModePerm); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`Error WriteFile returns %v; want %v`, err, fs.ErrNotExist)
+	}
+	if _, err := fsys.WriteFile("a.txt", []byte{}, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.ReadDir("."); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("a.txt/b.txt", []byte{}, fs.ModePerm); !errors.Is(err, syscall.ENOTDIR) {
+		t.Errorf(`Error WriteFile returns %v; want %v`, err, syscall.ENOTDIR)
+	}
+
+	if err := fsys.MkdirAll("dir", fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	sub, err := fsys.Sub("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wfs.WriteFile(sub, "a.txt", []byte{}, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wfs.WriteFile(sub, "sub/a.txt", []byte{}, fs.ModePerm); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`Error Sub WriteFile returns %v; want %v`, err, fs.ErrNotExist)
+	}
+}
+
+func TestWithWriteLeases(t *testing.T) {
+	fsys := New(WithWriteLeases(false))
+	f, err := fsys.CreateFile("a.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.CreateFile("a.txt", fs.ModePerm); !errors.Is(err, wfs.ErrLocked) {
+		t.Errorf(`Error CreateFile with lease returns %v; want %v`, err, wfs.ErrLocked)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err = fsys.CreateFile("a.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.Abort(fsys, "a.txt", f); err != nil {
+		t.Fatal(err)
+	}
+	f, err = fsys.CreateFile("a.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if _, err := fsys.CreateFile("file.txt/.", fs.ModePerm); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf(`Error CreateFile returns %v; want %v`, err, fs.ErrInvalid)
+	}
+	if _, err := fsys.CreateFile("a.txt/b.txt", fs.ModePerm); err == nil {
+		t.Errorf(`Error CreateFile under a file returns no error`)
+	}
+	if len(fsys.store.leases) != 0 {
+		t.Errorf(`Error leases are not released: %v`, fsys.store.leases)
+	}
+}
+
+func TestWithWriteLeases_Wait(t *testing.T) {
+	fsys := New(WithWriteLeases(true))
+	f1, err := fsys.CreateFile("a.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		f2, err := fsys.CreateFile("a.txt", fs.ModePerm)
+		if err != nil {
+			done <- err
+			return
+		}
+		if _, err := f2.Write([]byte("second")); err != nil {
+			done <- err
+			return
+		}
+		done <- f2.Close()
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf(`Fatal second CreateFile does not wait: %v`, err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	if _, err := f1.Write([]byte("first")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := fsys.ReadFile("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "second" {
+		t.Errorf(`Error ReadFile returns %s; want second`, got)
+	}
+}
+
+func TestWithInitialCapacity(t *testing.T) {
+	fsys := New(WithInitialCapacity(1024))
+	if err := fsys.MkdirAll("dir", fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	sub, err := fsys.Sub("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := wfs.CreateFile(sub, "a.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.(*MemFile).buf.Cap(); got < 1024 {
+		t.Errorf(`Error buffer capacity %d; want >= 1024`, got)
+	}
+	if _, err := f.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf(`Error second Close returns %v`, err)
+	}
+	if _, err := f.Read(make([]byte, 1)); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf(`Error Read after Close returns %v; want %v`, err, fs.ErrClosed)
+	}
+
+	got, err := fs.ReadFile(fsys, "dir/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Errorf(`Error ReadFile returns %s; want hello`, got)
+	}
+}
+
+func TestMemFile_WriteAfterClose(t *testing.T) {
+	fsys := New()
+	for _, abort := range []bool{false, true} {
+		f, err := fsys.CreateFile("a.txt", fs.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+		if abort {
+			err = wfs.Abort(fsys, "a.txt", f)
+		} else {
+			err = f.Close()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		mf := f.(*MemFile)
+		errs := map[string]error{}
+		_, errs["Write"] = mf.Write([]byte("x"))
+		_, errs["ReadFrom"] = mf.ReadFrom(strings.NewReader("x"))
+		_, errs["WriteAt"] = mf.WriteAt([]byte("x"), 0)
+		errs["Truncate"] = mf.Truncate(0)
+		errs["PunchHole"] = mf.PunchHole(0, 1)
+		for op, err := range errs {
+			if !errors.Is(err, fs.ErrClosed) {
+				t.Errorf(`Error %s after Close returns %v; want %v`, op, err, fs.ErrClosed)
+			}
+		}
+		if err := mf.Close(); err != nil {
+			t.Errorf(`Error second Close returns %v`, err)
+		}
+	}
+	got, err := fs.ReadFile(fsys, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Errorf(`Error ReadFile returns %s; want hello`, got)
+	}
+}
+
+func TestMemFile_ReadFrom(t *testing.T) {
+	fsys := New()
+	name := "dir/file.txt"
+	want := []byte("hello,world")
+
+	f, err := fsys.CreateFile(name, fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := f.(wfs.LargeWriterFile).ReadFrom(strings.NewReader(string(want)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(want)) {
+		t.Errorf(`Error ReadFrom returns %d; want %d`, n, len(want))
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := fsys.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(`Error ReadFile("%s") returns %s; want %s`, name, got, want)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	fsys := New()
+	want := wfs.Metadata{wfs.MetaContentType: "text/plain"}
+
+	if _, err := fsys.WriteFileWithMeta("a.txt", []byte("a"), fs.ModePerm, want); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fsys.CreateFileWithMeta("b.txt", fs.ModePerm, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.CopyFile("a.txt", "c.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		got, err := fsys.StatMeta(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf(`Error StatMeta("%s") returns %v; want %v`, name, got, want)
+		}
+	}
+
+	if _, err := fsys.WriteFile("a.txt", []byte("a"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	got, err := fsys.StatMeta("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf(`Error StatMeta after WriteFile returns %v; want nil`, got)
+	}
+
+	if _, err := fsys.StatMeta("not-found.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`Error StatMeta returns %v; want %v`, err, fs.ErrNotExist)
+	}
+}
+
+func TestOpenRange(t *testing.T) {
+	fsys := newMemFSTest(t)
+	name := "dir0/file01.txt"
+	p, err := fsys.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		off, length int64
+		want        string
+	}{
+		{off: 0, length: -1, want: string(p)},
+		{off: 2, length: 3, want: string(p[2:5])},
+		{off: 2, length: 100, want: string(p[2:])},
+		{off: 2, length: math.MaxInt64, want: string(p[2:])},
+		{off: 100, length: 3, want: ""},
+	}
+	for i, tc := range testCases {
+		r, err := fsys.OpenRange(name, tc.off, tc.length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("tests[%d] unexpected %q; want %q", i, got, tc.want)
+		}
+	}
+
+	if _, err := fsys.OpenRange(name, -1, 1); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+	if _, err := fsys.OpenRange("not-found.txt", 0, 1); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestMemFile_WriteAt(t *testing.T) {
+	fsys := New()
+	name := "file.txt"
+
+	f, err := fsys.CreateFile(name, fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wf := f.(wfs.WriterAtFile)
+
+	chunks := []string{"aaa", "bbb", "ccc"}
+	done := make(chan error, len(chunks))
+	for i, chunk := range chunks {
+		go func(i int, chunk string) {
+			_, err := wf.WriteAt([]byte(chunk), int64(i*3))
+			done <- err
+		}(i, chunk)
+	}
+	for range chunks {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := wf.WriteAt([]byte("d"), 10); err != nil {
+		t.Fatal(err)
+	}
+	for _, off := range []int64{-1, math.MaxInt64} {
+		if _, err := wf.WriteAt([]byte("x"), off); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf(`Error WriteAt at %d returns %v; want %v`, off, err, fs.ErrInvalid)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := fsys.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte("aaabbbccc\x00d")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(`Error WriteAt got %q; want %q`, got, want)
+	}
+}
+
+func TestMemFile_SparseFile(t *testing.T) {
+	fsys := New()
+	name := "test.img"
+
+	f, err := fsys.CreateFile(name, fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("abcdefgh")); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.Truncate(f, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.PunchHole(f, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := fsys.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte("ab\x00\x00\x00fgh\x00\x00")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(`Error got %q; want %q`, got, want)
+	}
+
+	f, err = fsys.CreateFile(name, fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("abcdefgh")); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.Truncate(f, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.Truncate(f, -1); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf(`Error Truncate returns %v; want %v`, err, fs.ErrInvalid)
+	}
+	if err := wfs.PunchHole(f, -1, 1); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf(`Error PunchHole returns %v; want %v`, err, fs.ErrInvalid)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got, err = fsys.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "abc" {
+		t.Errorf(`Error got %q; want "abc"`, got)
+	}
+}
+
+func TestMemFile_Abort(t *testing.T) {
+	fsys := New()
+	if _, err := fsys.WriteFile("old.txt", []byte("old"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"old.txt", "new.txt"} {
+		f, err := fsys.CreateFile(name, fs.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte("partial")); err != nil {
+			t.Fatal(err)
+		}
+		if err := wfs.Abort(fsys, name, f); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := fsys.ReadFile("old.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "old" {
+		t.Errorf(`Error got %q; want "old"`, got)
+	}
+	if _, err := fsys.Stat("new.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`Error Stat returns %v; want %v`, err, fs.ErrNotExist)
+	}
+}
+
+func TestNextSequence(t *testing.T) {
+	fsys := New()
+	const workers, count = 8, 10
+
+	var mutex sync.Mutex
+	seen := map[int64]bool{}
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for j := 0; j < count; j++ {
+				n, err := wfs.NextSequence(fsys, "seq")
+				if err != nil {
+					errs <- err
+					return
+				}
+				mutex.Lock()
+				seen[n] = true
+				mutex.Unlock()
+			}
+			errs <- nil
+		}()
+	}
+	for i := 0; i < workers; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for n := int64(1); n <= workers*count; n++ {
+		if !seen[n] {
+			t.Errorf(`Error sequence %d is not returned`, n)
+		}
+	}
+}
+
+func TestDownload(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789", 100))
+	src := New()
+	if _, err := src.WriteFile("big.bin", data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	fsys := New()
+	err := wfs.Download(context.Background(), fsys, "dir/big.bin", src, "big.bin",
+		wfs.WithChunkSize(64), wfs.WithDownloadWorkers(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := fsys.ReadFile("dir/big.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(data) {
+		t.Errorf(`Error Download got %d bytes; want %d bytes`, len(got), len(data))
+	}
+	if _, err := fsys.Stat("dir/big.bin.part"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`Error part directory remains %v`, err)
+	}
+}
+
+func TestMemFS_ChangeToken(t *testing.T) {
+	fsys := New()
+	if _, err := fsys.WriteFile("dir/a.txt", []byte("a"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("other/b.txt", []byte("b"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	sub, err := fsys.Sub("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := wfs.ChangeToken(fsys, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := wfs.ChangeToken(sub, "."); got != token {
+		t.Errorf(`Error ChangeToken of Sub %s; want %s`, got, token)
+	}
+	if _, err := fsys.WriteFile("other/c.txt", []byte("c"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := wfs.ChangeToken(fsys, "dir"); got != token {
+		t.Errorf(`Error ChangeToken changed by another directory %s; want %s`, got, token)
+	}
+
+	changes := []func() error{
+		func() error {
+			_, err := fsys.WriteFile("dir/a.txt", []byte("a2"), fs.ModePerm)
+			return err
+		},
+		func() error {
+			f, err := fsys.CreateFile("dir/sub/d.txt", fs.ModePerm)
+			if err != nil {
+				return err
+			}
+			f.Write([]byte("d"))
+			return f.Close()
+		},
+		func() error { return fsys.RemoveFile("dir/sub/d.txt") },
+		func() error { return fsys.RemoveAll("dir/sub") },
+		func() error { return fsys.CopyFile("other/b.txt", "dir/b.txt") },
+	}
+	for i, change := range changes {
+		if err := change(); err != nil {
+			t.Fatal(err)
+		}
+		got, err := wfs.ChangeToken(fsys, "dir")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == token {
+			t.Errorf(`Error ChangeToken not changed by change %d`, i)
+		}
+		token = got
+	}
+	if _, err := fsys.ChangeToken("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`Error ChangeToken %v; want %v`, err, fs.ErrNotExist)
+	}
+}
+
+func TestMemFS_Stats(t *testing.T) {
+	fsys := New()
+	if _, err := fsys.WriteFile("dir/a.txt", []byte("abc"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.CopyFile("dir/a.txt", "dir/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("dir/a.txt", []byte("a"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fsys.Open("dir/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := fsys.CreateFile("dir/c.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("cc")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Stats{
+		Files:     3,
+		Dirs:      2,
+		Bytes:     4,
+		OpenFiles: 2,
+		Ops: map[wfs.Op]int64{
+			wfs.OpWriteFile:  2,
+			"CopyFile":       1,
+			wfs.OpOpen:       1,
+			wfs.OpCreateFile: 1,
+		},
+	}
+	if got := fsys.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf(`Error Stats() returns %+v; want %+v`, got, want)
+	}
+
+	f.Close()
+	w.Close()
+	got := fsys.Stats()
+	if got.OpenFiles != 0 || got.Bytes != 6 {
+		t.Errorf(`Error Stats() after Close returns %+v; want OpenFiles 0 and Bytes 6`, got)
+	}
+
+	sub, err := fsys.Sub("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.RemoveAll("dir"); err != nil {
+		t.Fatal(err)
+	}
+	got = sub.(*MemFS).Stats()
+	if got.Files != 0 || got.Dirs != 1 || got.Bytes != 0 {
+		t.Errorf(`Error Stats() after RemoveAll returns %+v; want Files 0, Dirs 1 and Bytes 0`, got)
+	}
+}
+
+func TestStat_HashWhileWriting(t *testing.T) {
+	fsys := New()
+	if _, err := fsys.WriteFile("a.txt", []byte("a"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	info, err := fsys.Stat("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			fsys.WriteFile("a.txt", make([]byte, i), fs.ModePerm)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		info.Size()
+		info.(wfs.HashedInfo).Hash()
+	}
+	<-done
+	if got := info.Size(); got != 99 {
+		t.Errorf(`Error Size() returns %d; want 99`, got)
+	}
+}
+
+func TestMemFS_NonPortableName(t *testing.T) {
+	fsys := New()
+	names := []string{
+		`dir\a.txt`,
+		"logs/2024-01-01T10:00:00.log",
+		"aux.go",
+		"what?.md",
+		"trailing.",
+	}
+	for _, name := range names {
+		if _, err := fsys.WriteFile(name, []byte{}, fs.ModePerm); err != nil {
+			t.Errorf(`Error WriteFile(%q) returns %v`, name, err)
+		}
+		if _, err := fsys.WriteFileIf(name, []byte{}, fs.ModePerm, wfs.Condition{}); err != nil {
+			t.Errorf(`Error WriteFileIf(%q) returns %v`, name, err)
+		}
+	}
+	if err := fsys.MkdirAll("dir/CON", fs.ModePerm); err != nil {
+		t.Errorf(`Error MkdirAll returns %v`, err)
+	}
+}
+
+func TestACLFS_OpenSeeker(t *testing.T) {
+	fsys := New()
+	if _, err := fsys.WriteFile("dir/a.txt", []byte("hello"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	acl, err := wfs.NewACLFS(fsys, []wfs.ACLRule{{Pattern: "**", Access: wfs.AccessRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := acl.Open("dir/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, ok := f.(io.Writer); ok {
+		t.Errorf(`Error Open returns %T; want a read-only file`, f)
+	}
+	s, ok := f.(io.Seeker)
+	if !ok {
+		t.Fatalf(`Error Open returns %T; want an io.Seeker`, f)
+	}
+	if _, err := s.Seek(1, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	p, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p) != "ello" {
+		t.Errorf(`Error ReadAll returns %q; want "ello"`, p)
+	}
+}
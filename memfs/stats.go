@@ -0,0 +1,75 @@
+package memfs
+
+import (
+	"sync"
+
+	"github.com/jarxorg/wfs"
+)
+
+// Stats is a snapshot of the size and the usage of a MemFS.
+type Stats struct {
+	// Files is the number of files.
+	Files int64
+	// Dirs is the number of directories including the root.
+	Dirs int64
+	// Bytes is the total size of the files.
+	Bytes int64
+	// OpenFiles is the number of files opened by Open or CreateFile that are
+	// not closed or aborted yet.
+	OpenFiles int64
+	// Ops is the number of calls per operation.
+	Ops map[wfs.Op]int64
+}
+
+// counters holds the counters of the operations of a store. counters has its
+// own mutex so it can be updated without the mutex of MemFS.
+type counters struct {
+	mutex     sync.Mutex
+	ops       map[wfs.Op]int64
+	openFiles int64
+}
+
+func (c *counters) count(op wfs.Op) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.ops == nil {
+		c.ops = map[wfs.Op]int64{}
+	}
+	c.ops[op]++
+}
+
+func (c *counters) addOpenFiles(n int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.openFiles += n
+}
+
+// Stats returns a snapshot of the size and the usage of the whole store. The
+// filesystems returned by Sub share the store, so their Stats are the same as
+// the Stats of fsys. Stats can be published by expvar as JSON such as
+// expvar.Publish("memfs", expvar.Func(func() interface{} { return fsys.Stats() })),
+// and other monitoring systems such as Prometheus can poll Stats in their
+// collectors. memfs does not import expvar, so importing memfs does not
+// register the /debug/vars handler.
+func (fsys *MemFS) Stats() *Stats {
+	fsys.mutex.Lock()
+	st := &Stats{
+		Files: fsys.store.files,
+		Dirs:  fsys.store.dirs,
+		Bytes: fsys.store.bytes,
+		Ops:   map[wfs.Op]int64{},
+	}
+	fsys.mutex.Unlock()
+
+	c := fsys.store.counters
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	st.OpenFiles = c.openFiles
+	for op, n := range c.ops {
+		st.Ops[op] = n
+	}
+	return st
+}
@@ -1,26 +1,38 @@
 package memfs
 
 import (
+	"crypto/md5"
 	"io/fs"
 	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/jarxorg/wfs"
 )
 
 // Value works as fs.DirEntry or fs.FileInfo.
 type value struct {
-	name    string
-	data    []byte
-	mode    fs.FileMode
-	modTime time.Time
-	isDir   bool
+	name string
+	// mutex guards data that is replaced by the store while v is used as a
+	// fs.FileInfo returned by Stat or ReadDir.
+	mutex     sync.RWMutex
+	data      []byte
+	mode      fs.FileMode
+	modTime   time.Time
+	birthTime time.Time
+	isDir     bool
+	meta      wfs.Metadata
+	gen       int64
 }
 
 var (
-	_ fs.DirEntry = (*value)(nil)
-	_ fs.FileInfo = (*value)(nil)
+	_ fs.DirEntry       = (*value)(nil)
+	_ fs.FileInfo       = (*value)(nil)
+	_ wfs.HashedInfo    = (*value)(nil)
+	_ wfs.BirthTimeInfo = (*value)(nil)
 )
 
 func (v *value) Name() string {
@@ -31,7 +43,29 @@ func (v *value) Size() int64 {
 	if v.isDir {
 		return 0
 	}
-	return int64(len(v.data))
+	return int64(len(v.bytes()))
+}
+
+// snapshot returns a copy of v that is not changed by the store.
+func (v *value) snapshot() *value {
+	return &value{
+		name:      v.name,
+		data:      v.bytes(),
+		mode:      v.mode,
+		modTime:   v.modTime,
+		birthTime: v.birthTime,
+		isDir:     v.isDir,
+		meta:      v.meta,
+		gen:       v.gen,
+	}
+}
+
+// bytes returns the data of v. The returned slice is never modified in place.
+func (v *value) bytes() []byte {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	return v.data
 }
 
 func (v *value) Mode() fs.FileMode {
@@ -58,119 +92,221 @@ func (v *value) Info() (fs.FileInfo, error) {
 	return v, nil
 }
 
+// BirthTime returns the time when the value was created in the store.
+func (v *value) BirthTime() (time.Time, bool) {
+	return v.birthTime, !v.birthTime.IsZero()
+}
+
+// Hash returns the MD5 sum of the data. Hash returns a nil sum if v is a
+// directory.
+func (v *value) Hash() (string, []byte) {
+	if v.isDir {
+		return "", nil
+	}
+	sum := md5.Sum(v.bytes())
+	return wfs.HashMD5, sum[:]
+}
+
 // Store represents an in-memory key value store.
-// store.keys is always sorted.
+// store.children holds the sorted child keys of each parent key so that
+// listing and removing a directory are proportional to its subtree.
 // All functions of the store are not thread safety.
 type store struct {
-	keys   []string
-	values map[string]*value
+	values   map[string]*value
+	children map[string][]string
+	leases   map[string]chan struct{}
+	gen      int64
+	// changes holds the generation of the last change of each key or of any
+	// key under it.
+	changes map[string]int64
+	files   int64
+	dirs    int64
+	bytes   int64
+	// counters is shared by the MemFS and its subs like the store.
+	counters *counters
 }
 
 func newStore() *store {
 	return &store{
-		values: map[string]*value{},
+		values:   map[string]*value{},
+		children: map[string][]string{},
+		leases:   map[string]chan struct{}{},
+		changes:  map[string]int64{},
+		counters: &counters{},
 	}
 }
 
+// nextGen returns a new generation number that is unique in the store and
+// records it as the change of the key and its ancestors.
+func (s *store) nextGen(key string) int64 {
+	s.gen++
+	for k := key; ; k = path.Dir(k) {
+		s.changes[k] = s.gen
+		if path.Dir(k) == k {
+			break
+		}
+	}
+	return s.gen
+}
+
+// changeGen returns the generation of the last change of the key or of any key
+// under it.
+func (s *store) changeGen(key string) int64 {
+	return s.changes[key]
+}
+
 func (s *store) get(k string) *value {
 	return s.values[k]
 }
 
 func (s *store) put(k string, v *value) *value {
-	if _, ok := s.values[k]; !ok {
-		s.keys = append(s.keys, k)
-		sort.Strings(s.keys)
+	if old, ok := s.values[k]; !ok {
+		if parent := path.Dir(k); parent != k {
+			s.addChild(parent, k)
+		}
+		s.nextGen(k)
+	} else {
+		s.account(old, -1)
 	}
 
 	s.values[k] = v
+	s.account(v, 1)
 	return v
 }
 
+// setData replaces the data of v in the store.
+func (s *store) setData(v *value, data []byte) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	s.bytes += int64(len(data) - len(v.data))
+	v.data = data
+}
+
+// account adds v to the totals of the store if sign is 1 or subtracts it if
+// sign is -1.
+func (s *store) account(v *value, sign int64) {
+	if v.isDir {
+		s.dirs += sign
+		return
+	}
+	s.files += sign
+	s.bytes += sign * int64(len(v.data))
+}
+
+func (s *store) addChild(parent, key string) {
+	cs := s.children[parent]
+	i := sort.SearchStrings(cs, key)
+	cs = append(cs, "")
+	copy(cs[i+1:], cs[i:])
+	cs[i] = key
+	s.children[parent] = cs
+}
+
+func (s *store) removeChild(parent, key string) {
+	cs := s.children[parent]
+	i := sort.SearchStrings(cs, key)
+	if i == len(cs) || cs[i] != key {
+		return
+	}
+	cs = append(cs[:i], cs[i+1:]...)
+	if len(cs) == 0 {
+		delete(s.children, parent)
+		return
+	}
+	s.children[parent] = cs
+}
+
 func (s *store) remove(key string) *value {
-	i := s.keyIndex(key)
-	if i == -1 {
+	v, ok := s.values[key]
+	if !ok {
 		return nil
 	}
-	v := s.values[key]
-	s.keys = append(s.keys[0:i], s.keys[i+1:]...)
 	delete(s.values, key)
+	delete(s.changes, key)
+	s.account(v, -1)
+	if parent := path.Dir(key); parent != key {
+		s.removeChild(parent, key)
+		s.nextGen(parent)
+	}
 	return v
 }
 
 func (s *store) removeAll(prefix string) {
-	from := s.keyIndex(prefix)
-	if from == -1 {
-		return
+	prefix = path.Clean(prefix)
+	s.removeTree(prefix)
+	if parent := path.Dir(prefix); parent != prefix {
+		s.removeChild(parent, prefix)
+		s.nextGen(parent)
 	}
-
-	max := len(s.keys)
-	to := -1
-	for i := from; i < max; i++ {
-		key := s.keys[i]
-		if !strings.HasPrefix(key, prefix) {
-			break
-		}
-		delete(s.values, key)
-		to = i
-	}
-	s.keys = append(s.keys[0:from], s.keys[to+1:]...)
 }
 
-func (s *store) keyIndex(key string) int {
-	i := sort.SearchStrings(s.keys, key)
-	if i < len(s.keys) && s.keys[i] == key {
-		return i
+func (s *store) removeTree(key string) {
+	for _, child := range s.children[key] {
+		s.removeTree(child)
 	}
-	return -1
+	if v, ok := s.values[key]; ok {
+		s.account(v, -1)
+	}
+	delete(s.children, key)
+	delete(s.values, key)
+	delete(s.changes, key)
 }
 
+// prefixKeys returns the sorted keys of the direct children of prefix.
 func (s *store) prefixKeys(prefix string) []string {
-	i := s.keyIndex(prefix)
-	if i == -1 {
+	if _, ok := s.values[prefix]; !ok {
 		return nil
 	}
-	if !strings.HasSuffix(prefix, "/") {
-		prefix = prefix + "/"
-	}
+	return append([]string(nil), s.children[prefix]...)
+}
 
-	var keys []string
-	max := len(s.keys)
-	for i++; i < max; i++ {
-		key := s.keys[i]
-		if !strings.HasPrefix(key, prefix) {
-			break
-		}
-		if strings.Contains(key[len(prefix):], "/") {
-			continue
-		}
-		keys = append(keys, key)
+// childKeysAfter returns at most n sorted keys of the direct children of
+// prefix whose base names are after the specified name. If n <= 0,
+// childKeysAfter returns all of the keys after the name.
+func (s *store) childKeysAfter(prefix, after string, n int) []string {
+	cs := s.children[prefix]
+	i := 0
+	if after != "" {
+		i = sort.Search(len(cs), func(i int) bool {
+			return path.Base(cs[i]) > after
+		})
+	}
+	cs = cs[i:]
+	if n > 0 && len(cs) > n {
+		cs = cs[:n]
 	}
-	return keys
+	return append([]string(nil), cs...)
 }
 
+// prefixGlobKeys returns the keys under prefix matching pattern. The pattern
+// is matched component-wise like fs.Glob so the keys are sorted by directory
+// and then by name.
 func (s *store) prefixGlobKeys(prefix, pattern string) ([]string, error) {
-	i := s.keyIndex(prefix)
-	if i == -1 {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	if _, ok := s.values[prefix]; !ok {
 		return nil, nil
 	}
-	if !strings.HasSuffix(prefix, "/") {
-		prefix = prefix + "/"
+	if pattern == "." {
+		return []string{prefix}, nil
 	}
 
-	var keys []string
-	max := len(s.keys)
-	for i++; i < max; i++ {
-		key := s.keys[i]
-		if !strings.HasPrefix(key, prefix) {
-			break
-		}
-		ok, err := path.Match(pattern, key[len(prefix):])
-		if err != nil {
-			return nil, err
+	keys := []string{prefix}
+	for _, part := range strings.Split(pattern, "/") {
+		var next []string
+		for _, key := range keys {
+			for _, child := range s.prefixKeys(key) {
+				if ok, _ := path.Match(part, path.Base(child)); ok {
+					next = append(next, child)
+				}
+			}
 		}
-		if ok {
-			keys = append(keys, key)
+		if len(next) == 0 {
+			return nil, nil
 		}
+		keys = next
 	}
 	return keys, nil
 }
@@ -1,12 +1,15 @@
 package memfs
 
 import (
+	"crypto/md5"
 	"io/fs"
 	"reflect"
 	"sort"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/jarxorg/wfs"
 )
 
 func TestValue(t *testing.T) {
@@ -48,6 +51,14 @@ func TestValue(t *testing.T) {
 	if info != v {
 		t.Errorf(`Info returns %v; want %v`, info, v)
 	}
+	if algo, sum := v.Hash(); algo != "" || sum != nil {
+		t.Errorf(`dir Hash returns %s %x; want nil`, algo, sum)
+	}
+	v.isDir = false
+	want := md5.Sum(v.data)
+	if algo, sum := v.Hash(); algo != wfs.HashMD5 || !reflect.DeepEqual(sum, want[:]) {
+		t.Errorf(`Hash returns %s %x; want %s %x`, algo, sum, wfs.HashMD5, want)
+	}
 }
 
 var testStoreSrc = map[string]*value{
@@ -62,6 +73,16 @@ var testStoreSrc = map[string]*value{
 	"/file2.txt":       {name: "file2.txt", mode: fs.ModePerm, isDir: false},
 }
 
+// storeKeys returns the sorted keys of the store.
+func storeKeys(s *store) []string {
+	var keys []string
+	for k := range s.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func newStoreTest() *store {
 	s := newStore()
 	for k, v := range testStoreSrc {
@@ -79,8 +100,8 @@ func TestStore(t *testing.T) {
 	}
 	sort.Strings(wantKeys)
 
-	if !reflect.DeepEqual(s.keys, wantKeys) {
-		t.Errorf(`Error store.keys is %v; want %v`, s.keys, wantKeys)
+	if !reflect.DeepEqual(storeKeys(s), wantKeys) {
+		t.Errorf(`Error store.keys is %v; want %v`, storeKeys(s), wantKeys)
 	}
 
 	key := "/dir0/file02.txt"
@@ -106,7 +127,7 @@ func TestStore_remove(t *testing.T) {
 		t.Errorf(`Error found %s: %v`, key, v)
 	}
 
-	for _, k := range s.keys {
+	for _, k := range storeKeys(s) {
 		if k == key {
 			t.Errorf(`Error found %s`, key)
 		}
@@ -124,7 +145,7 @@ func TestStore_removeAll(t *testing.T) {
 	prefix := "/dir0"
 	s.removeAll(prefix)
 
-	for _, key := range s.keys {
+	for _, key := range storeKeys(s) {
 		if strings.HasPrefix(key, prefix) {
 			t.Errorf(`Error found %s`, key)
 		}
@@ -134,9 +155,9 @@ func TestStore_removeAll(t *testing.T) {
 		}
 	}
 
-	want := len(s.keys)
+	want := len(storeKeys(s))
 	s.removeAll(prefix)
-	got := len(s.keys)
+	got := len(storeKeys(s))
 
 	if got != want {
 		t.Errorf(`Error keys length %d; want %d`, got, want)
@@ -176,6 +197,21 @@ func TestStore_prefixKeys(t *testing.T) {
 	}
 }
 
+func TestStore_children(t *testing.T) {
+	s := newStoreTest()
+	s.removeAll("/dir0")
+	s.remove("/file1.txt")
+	s.put("/dir0", &value{name: "dir0", mode: fs.ModePerm, isDir: true})
+
+	want := []string{"/dir0", "/dir1", "/file2.txt"}
+	if got := s.prefixKeys("/"); !reflect.DeepEqual(got, want) {
+		t.Errorf(`Error prefixKeys("/") got %v; want %v`, got, want)
+	}
+	if got := s.prefixKeys("/dir0"); got != nil {
+		t.Errorf(`Error prefixKeys("/dir0") got %v; want nil`, got)
+	}
+}
+
 func TestStore_prefixGlobKeys(t *testing.T) {
 	testCases := []struct {
 		want    []string
@@ -197,6 +233,14 @@ func TestStore_prefixGlobKeys(t *testing.T) {
 			},
 			prefix:  "/dir0",
 			pattern: "*.txt",
+		}, {
+			want:    []string{"/dir0"},
+			prefix:  "/",
+			pattern: "dir0",
+		}, {
+			want:    []string{"/dir0"},
+			prefix:  "/dir0",
+			pattern: ".",
 		}, {
 			prefix:  "/not-found",
 			pattern: "*.*",
@@ -0,0 +1,160 @@
+package wfs
+
+import (
+	"io/fs"
+	"mime"
+	"path"
+)
+
+const (
+	// MetaContentType is the metadata key of Content-Type.
+	MetaContentType = "Content-Type"
+	// MetaCacheControl is the metadata key of Cache-Control.
+	MetaCacheControl = "Cache-Control"
+	// MetaStorageClass is the metadata key of a storage class such as
+	// STANDARD_IA.
+	MetaStorageClass = "Storage-Class"
+	// MetaServerSideEncryption is the metadata key of a server-side encryption
+	// setting such as aws:kms.
+	MetaServerSideEncryption = "Server-Side-Encryption"
+	// MetaDecodedSize is the metadata key of the size of the contents of a
+	// file before encoded by TransformFS.
+	MetaDecodedSize = "Decoded-Size"
+)
+
+// Metadata holds metadata of a file such as Content-Type, Cache-Control and
+// arbitrary keys.
+type Metadata map[string]string
+
+// Clone returns a copy of m.
+func (m Metadata) Clone() Metadata {
+	if m == nil {
+		return nil
+	}
+	c := make(Metadata, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// MetadataFS is the interface implemented by a filesystem that can store
+// metadata with files.
+type MetadataFS interface {
+	fs.FS
+	CreateFileWithMeta(name string, mode fs.FileMode, meta Metadata) (WriterFile, error)
+	WriteFileWithMeta(name string, p []byte, mode fs.FileMode, meta Metadata) (n int, err error)
+	StatMeta(name string) (Metadata, error)
+}
+
+// CreateFileWithMeta creates the named file with the specified metadata. If
+// the filesystem implements MetadataFS calls fsys.CreateFileWithMeta otherwise
+// returns a PathError.
+func CreateFileWithMeta(fsys fs.FS, name string, mode fs.FileMode, meta Metadata) (WriterFile, error) {
+	if fsys, ok := fsys.(MetadataFS); ok {
+		return fsys.CreateFileWithMeta(name, mode, meta)
+	}
+	return nil, &fs.PathError{Op: string(OpCreateFileWithMeta), Path: name, Err: ErrNotImplemented}
+}
+
+// WriteFileWithMeta writes the specified bytes and metadata to the named file.
+// If the filesystem implements MetadataFS calls fsys.WriteFileWithMeta
+// otherwise returns a PathError.
+func WriteFileWithMeta(fsys fs.FS, name string, p []byte, mode fs.FileMode, meta Metadata) (n int, err error) {
+	if fsys, ok := fsys.(MetadataFS); ok {
+		return fsys.WriteFileWithMeta(name, p, mode, meta)
+	}
+	return 0, &fs.PathError{Op: string(OpWriteFileWithMeta), Path: name, Err: ErrNotImplemented}
+}
+
+// StatMeta returns the metadata of the named file. If the filesystem
+// implements MetadataFS calls fsys.StatMeta otherwise returns a PathError.
+func StatMeta(fsys fs.FS, name string) (Metadata, error) {
+	if fsys, ok := fsys.(MetadataFS); ok {
+		return fsys.StatMeta(name)
+	}
+	return nil, &fs.PathError{Op: string(OpStatMeta), Path: name, Err: ErrNotImplemented}
+}
+
+// WriteOption is a backend-agnostic option for writing a file. Options are
+// stored as Metadata and ignored by a filesystem that does not implement
+// MetadataFS.
+type WriteOption func(meta Metadata)
+
+// WithMeta sets the specified metadata key and value.
+func WithMeta(key, value string) WriteOption {
+	return func(meta Metadata) {
+		meta[key] = value
+	}
+}
+
+// WithContentType sets Content-Type.
+func WithContentType(contentType string) WriteOption {
+	return WithMeta(MetaContentType, contentType)
+}
+
+// WithCacheControl sets Cache-Control.
+func WithCacheControl(cacheControl string) WriteOption {
+	return WithMeta(MetaCacheControl, cacheControl)
+}
+
+// WithStorageClass sets a storage class.
+func WithStorageClass(storageClass string) WriteOption {
+	return WithMeta(MetaStorageClass, storageClass)
+}
+
+// WithServerSideEncryption sets a server-side encryption setting.
+func WithServerSideEncryption(encryption string) WriteOption {
+	return WithMeta(MetaServerSideEncryption, encryption)
+}
+
+func writeOptionsMeta(opts []WriteOption) Metadata {
+	if len(opts) == 0 {
+		return nil
+	}
+	meta := Metadata{}
+	for _, opt := range opts {
+		opt(meta)
+	}
+	return meta
+}
+
+// defaultContentType sets contentType to meta if meta has no Content-Type.
+func defaultContentType(meta Metadata, contentType string) Metadata {
+	if contentType == "" {
+		return meta
+	}
+	if _, ok := meta[MetaContentType]; ok {
+		return meta
+	}
+	if meta == nil {
+		meta = Metadata{}
+	}
+	meta[MetaContentType] = contentType
+	return meta
+}
+
+// CreateFileWithOptions creates the named file with the specified options. If
+// the filesystem implements MetadataFS calls fsys.CreateFileWithMeta otherwise
+// calls CreateFile ignoring the options. Content-Type defaults to the type of
+// the extension of the name.
+func CreateFileWithOptions(fsys fs.FS, name string, mode fs.FileMode, opts ...WriteOption) (WriterFile, error) {
+	if fsys, ok := fsys.(MetadataFS); ok {
+		meta := defaultContentType(writeOptionsMeta(opts), mime.TypeByExtension(path.Ext(name)))
+		return fsys.CreateFileWithMeta(name, mode, meta)
+	}
+	return CreateFile(fsys, name, mode)
+}
+
+// WriteFileWithOptions writes the specified bytes to the named file with the
+// specified options. If the filesystem implements MetadataFS calls
+// fsys.WriteFileWithMeta otherwise calls WriteFile ignoring the options.
+// Content-Type defaults to the type detected by the extension of the name or
+// the content like DetectContentType.
+func WriteFileWithOptions(fsys fs.FS, name string, p []byte, mode fs.FileMode, opts ...WriteOption) (n int, err error) {
+	if fsys, ok := fsys.(MetadataFS); ok {
+		meta := defaultContentType(writeOptionsMeta(opts), detectContentType(name, p))
+		return fsys.WriteFileWithMeta(name, p, mode, meta)
+	}
+	return WriteFile(fsys, name, p, mode)
+}
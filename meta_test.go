@@ -0,0 +1,131 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+)
+
+type metadataFS struct {
+	*FSDelegator
+	meta map[string]Metadata
+}
+
+func (fsys *metadataFS) CreateFileWithMeta(name string, mode fs.FileMode, meta Metadata) (WriterFile, error) {
+	fsys.meta[name] = meta
+	return &FileDelegator{}, nil
+}
+
+func (fsys *metadataFS) WriteFileWithMeta(name string, p []byte, mode fs.FileMode, meta Metadata) (int, error) {
+	fsys.meta[name] = meta
+	return len(p), nil
+}
+
+func (fsys *metadataFS) StatMeta(name string) (Metadata, error) {
+	return fsys.meta[name], nil
+}
+
+func TestMetadata_Clone(t *testing.T) {
+	m := Metadata{MetaContentType: "text/plain"}
+	c := m.Clone()
+	if !reflect.DeepEqual(c, m) {
+		t.Errorf("unexpected %v; want %v", c, m)
+	}
+	c[MetaCacheControl] = "no-cache"
+	if _, ok := m[MetaCacheControl]; ok {
+		t.Errorf("unexpected %v", m)
+	}
+	if Metadata(nil).Clone() != nil {
+		t.Error("unexpected not nil")
+	}
+}
+
+func TestMetadataFS(t *testing.T) {
+	fsys := &metadataFS{FSDelegator: &FSDelegator{}, meta: map[string]Metadata{}}
+	want := Metadata{MetaContentType: "text/plain"}
+
+	if _, err := CreateFileWithMeta(fsys, "a.txt", fs.ModePerm, want); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteFileWithMeta(fsys, "b.txt", []byte{}, fs.ModePerm, want); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "b.txt"} {
+		got, err := StatMeta(fsys, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("unexpected %v; want %v", got, want)
+		}
+	}
+}
+
+func TestMetadataFS_ErrNotImplemented(t *testing.T) {
+	fsys := &OpenFSDelegator{}
+
+	if _, err := CreateFileWithMeta(fsys, "", fs.ModePerm, nil); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v", err)
+	}
+	if _, err := WriteFileWithMeta(fsys, "", nil, fs.ModePerm, nil); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v", err)
+	}
+	if _, err := StatMeta(fsys, ""); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v", err)
+	}
+}
+
+func TestWriteOptions(t *testing.T) {
+	fsys := &metadataFS{FSDelegator: &FSDelegator{}, meta: map[string]Metadata{}}
+	opts := []WriteOption{
+		WithContentType("text/html"),
+		WithCacheControl("max-age=60"),
+		WithStorageClass("STANDARD_IA"),
+		WithServerSideEncryption("aws:kms"),
+		WithMeta("X-Custom", "custom"),
+	}
+	want := Metadata{
+		MetaContentType:          "text/html",
+		MetaCacheControl:         "max-age=60",
+		MetaStorageClass:         "STANDARD_IA",
+		MetaServerSideEncryption: "aws:kms",
+		"X-Custom":               "custom",
+	}
+
+	if _, err := CreateFileWithOptions(fsys, "a.html", fs.ModePerm, opts...); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteFileWithOptions(fsys, "b.html", []byte{}, fs.ModePerm, opts...); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.html", "b.html"} {
+		if got := fsys.meta[name]; !reflect.DeepEqual(got, want) {
+			t.Errorf("unexpected %v; want %v", got, want)
+		}
+	}
+}
+
+func TestWriteOptions_Ignored(t *testing.T) {
+	created, wrote := false, false
+	fsys := &FSDelegator{
+		CreateFileFunc: func(_ string, _ fs.FileMode) (WriterFile, error) {
+			created = true
+			return &FileDelegator{}, nil
+		},
+		WriteFileFunc: func(_ string, p []byte, _ fs.FileMode) (int, error) {
+			wrote = true
+			return len(p), nil
+		},
+	}
+
+	if _, err := CreateFileWithOptions(fsys, "a.html", fs.ModePerm, WithContentType("text/html")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteFileWithOptions(fsys, "b.html", []byte{}, fs.ModePerm, WithContentType("text/html")); err != nil {
+		t.Fatal(err)
+	}
+	if !created || !wrote {
+		t.Errorf("unexpected created %v, wrote %v", created, wrote)
+	}
+}
@@ -0,0 +1,273 @@
+package wfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrDiverged "diverged"
+var ErrDiverged = errors.New("diverged")
+
+// MigratePhase is a phase of Migrate.
+type MigratePhase string
+
+const (
+	// MigrateCopy is the phase that copies the whole tree.
+	MigrateCopy MigratePhase = "copy"
+	// MigrateCatchUp is the phase that copies the files changed during the
+	// previous passes reported by Watch.
+	MigrateCatchUp MigratePhase = "catch-up"
+	// MigrateDelta is the phase that copies the files that differ after the
+	// source is frozen.
+	MigrateDelta MigratePhase = "delta"
+	// MigrateVerify is the phase that verifies dest by the manifest of src.
+	// The files that differ are reported in the phase.
+	MigrateVerify MigratePhase = "verify"
+)
+
+// MigrateProgress is reported by Migrate after each file is copied or
+// removed and for each file that differs in MigrateVerify.
+type MigrateProgress struct {
+	Phase MigratePhase
+	// Name is the name of the file.
+	Name string
+	// Files is the number of files reported in the phase.
+	Files int
+}
+
+// maxCatchUpPasses is the maximum number of catch-up passes, so that a source
+// that never settles still proceeds to the delta pass.
+const maxCatchUpPasses = 10
+
+// MigrateOption is an option for Migrate.
+type MigrateOption func(o *migrateOptions)
+
+type migrateOptions struct {
+	progress func(p MigrateProgress)
+	freeze   *FreezeFS
+	settle   time.Duration
+}
+
+// WithMigrateProgress sets the function called by Migrate with the progress.
+func WithMigrateProgress(fn func(p MigrateProgress)) MigrateOption {
+	return func(o *migrateOptions) {
+		o.progress = fn
+	}
+}
+
+// WithMigrateFreeze sets the FreezeFS that the applications write src
+// through. Migrate freezes it before the delta pass and leaves it frozen
+// after a successful migration so that the applications can be switched to
+// dest. It is thawed if Migrate fails.
+func WithMigrateFreeze(f *FreezeFS) MigrateOption {
+	return func(o *migrateOptions) {
+		o.freeze = f
+	}
+}
+
+// WithMigrateSettle sets the period without changes that ends the catch-up
+// phase. The default is 1 second.
+func WithMigrateSettle(d time.Duration) MigrateOption {
+	return func(o *migrateOptions) {
+		o.settle = d
+	}
+}
+
+// Migrate moves the tree of src to dest with minimal downtime. Migrate copies
+// the whole tree, then copies the files changed in the meantime reported by
+// Watch until the source settles, then freezes the source if
+// WithMigrateFreeze is set and copies or removes the files that still differ
+// by a Manifest of src, and finally verifies dest by the manifest. If dest
+// differs from the manifest Migrate returns a fs.PathError of ErrDiverged.
+//
+// The catch-up phase is skipped if src does not implement WatchFS. The delta
+// and verify phases read all of the files of src and dest to hash them.
+// Directories left empty by removals in src are not removed from dest.
+func Migrate(ctx context.Context, dest WriteFileFS, src fs.FS, opts ...MigrateOption) (err error) {
+	o := &migrateOptions{settle: time.Second}
+	for _, opt := range opts {
+		opt(o)
+	}
+	m := &migration{dest: dest, src: src, progress: o.progress}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, werr := Watch(watchCtx, src, ".")
+	if werr != nil && !errors.Is(werr, ErrNotImplemented) {
+		return werr
+	}
+	var changes *changeSet
+	if events != nil {
+		changes = newChangeSet(events)
+	}
+
+	if err := m.copyAll(ctx); err != nil {
+		return err
+	}
+	if changes != nil {
+		if err := m.catchUp(ctx, changes, o.settle); err != nil {
+			return err
+		}
+	}
+
+	if o.freeze != nil {
+		o.freeze.Freeze()
+		defer func() {
+			if err != nil {
+				o.freeze.Thaw()
+			}
+		}()
+	}
+	manifest, err := NewManifest(src)
+	if err != nil {
+		return err
+	}
+	names, err := VerifyManifest(dest, manifest)
+	if err != nil {
+		return err
+	}
+	m.setPhase(MigrateDelta)
+	if err := m.apply(ctx, names); err != nil {
+		return err
+	}
+
+	m.setPhase(MigrateVerify)
+	names, err = VerifyManifest(dest, manifest)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		m.report(name)
+	}
+	if len(names) > 0 {
+		return &fs.PathError{Op: "Migrate", Path: names[0], Err: ErrDiverged}
+	}
+	return nil
+}
+
+// migration is a Migrate in progress.
+type migration struct {
+	dest     WriteFileFS
+	src      fs.FS
+	progress func(p MigrateProgress)
+	phase    MigratePhase
+	files    int
+}
+
+func (m *migration) report(name string) {
+	m.files++
+	if m.progress != nil {
+		m.progress(MigrateProgress{Phase: m.phase, Name: name, Files: m.files})
+	}
+}
+
+func (m *migration) setPhase(phase MigratePhase) {
+	m.phase = phase
+	m.files = 0
+}
+
+// copyAll copies the whole tree of src.
+func (m *migration) copyAll(ctx context.Context) error {
+	m.setPhase(MigrateCopy)
+	return fs.WalkDir(m.src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return m.dest.MkdirAll(name, fs.ModePerm)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := copyFileTo(m.dest, name, m.src, name, info.Mode().Perm()); err != nil {
+			return err
+		}
+		m.report(name)
+		return nil
+	})
+}
+
+// catchUp copies the changed files until no change is reported for settle.
+func (m *migration) catchUp(ctx context.Context, changes *changeSet, settle time.Duration) error {
+	m.setPhase(MigrateCatchUp)
+	for i := 0; i < maxCatchUpPasses; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(settle):
+		}
+		names := changes.take()
+		if len(names) == 0 {
+			return nil
+		}
+		if err := m.apply(ctx, names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply copies the named files from src or removes them from dest if they do
+// not exist on src.
+func (m *migration) apply(ctx context.Context, names []string) error {
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		info, err := fs.Stat(m.src, name)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			err = RemoveIfExists(m.dest, name)
+		case err != nil:
+		case info.IsDir():
+			err = m.dest.MkdirAll(name, fs.ModePerm)
+		default:
+			err = copyFileTo(m.dest, name, m.src, name, info.Mode().Perm())
+		}
+		if err != nil {
+			return err
+		}
+		m.report(name)
+	}
+	return nil
+}
+
+// changeSet collects the names of the changed files reported by Watch.
+type changeSet struct {
+	mutex sync.Mutex
+	names map[string]bool
+}
+
+func newChangeSet(events <-chan WatchEvent) *changeSet {
+	c := &changeSet{names: map[string]bool{}}
+	go func() {
+		for e := range events {
+			c.mutex.Lock()
+			c.names[e.Name] = true
+			c.mutex.Unlock()
+		}
+	}()
+	return c
+}
+
+// take returns the sorted names collected since the last take.
+func (c *changeSet) take() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	names := make([]string, 0, len(c.names))
+	for name := range c.names {
+		names = append(names, name)
+	}
+	c.names = map[string]bool{}
+	sort.Strings(names)
+	return names
+}
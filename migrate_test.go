@@ -0,0 +1,139 @@
+package wfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// watchMapFS is a writable map filesystem whose writes are reported by Watch.
+type watchMapFS struct {
+	*FSDelegator
+	events chan WatchEvent
+}
+
+func newWatchMapFS(m fstest.MapFS) *watchMapFS {
+	fsys := &watchMapFS{FSDelegator: newMapWriteFS(m), events: make(chan WatchEvent, 16)}
+	writeFile, removeFile := fsys.WriteFileFunc, fsys.RemoveFileFunc
+	fsys.WriteFileFunc = func(name string, p []byte, mode fs.FileMode) (int, error) {
+		n, err := writeFile(name, p, mode)
+		fsys.events <- WatchEvent{Op: WatchWrite, Name: name}
+		return n, err
+	}
+	fsys.RemoveFileFunc = func(name string) error {
+		err := removeFile(name)
+		fsys.events <- WatchEvent{Op: WatchRemove, Name: name}
+		return err
+	}
+	return fsys
+}
+
+func (fsys *watchMapFS) Watch(ctx context.Context, dir string) (<-chan WatchEvent, error) {
+	out := make(chan WatchEvent)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e := <-fsys.events:
+				out <- e
+			}
+		}
+	}()
+	return out, nil
+}
+
+func TestMigrate(t *testing.T) {
+	srcMap := fstest.MapFS{
+		"a.txt":     {Data: []byte("a")},
+		"dir/b.txt": {Data: []byte("b")},
+		"dir/c.txt": {Data: []byte("c")},
+	}
+	src := newWatchMapFS(srcMap)
+	app := NewFreezeFS(src)
+	destMap := fstest.MapFS{}
+	dest := newMapWriteFS(destMap)
+
+	phases := map[MigratePhase][]string{}
+	progress := func(p MigrateProgress) {
+		phases[p.Phase] = append(phases[p.Phase], p.Name)
+		if p.Phase == MigrateCopy && p.Files == 3 {
+			// NOTE: Changes during the copy are caught up by Watch.
+			if _, err := app.WriteFile("dir/d.txt", []byte("d"), fs.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			if err := app.RemoveFile("dir/b.txt"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if p.Phase == MigrateCatchUp && p.Files == 2 {
+			// NOTE: A change that is not reported is copied by the delta pass.
+			srcMap["a.txt"] = &fstest.MapFile{Data: []byte("a2")}
+		}
+	}
+	err := Migrate(context.Background(), dest, src,
+		WithMigrateProgress(progress),
+		WithMigrateFreeze(app),
+		WithMigrateSettle(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[MigratePhase][]string{
+		MigrateCopy:    {"a.txt", "dir/b.txt", "dir/c.txt"},
+		MigrateCatchUp: {"dir/b.txt", "dir/d.txt"},
+		MigrateDelta:   {"a.txt"},
+	}
+	if !reflect.DeepEqual(phases, want) {
+		t.Errorf("unexpected %v; want %v", phases, want)
+	}
+	if !app.Frozen() {
+		t.Errorf("unexpected not frozen")
+	}
+	if names, err := VerifyManifest(dest, mustManifest(t, src)); err != nil || len(names) > 0 {
+		t.Errorf("unexpected %v, %v; want no differences", names, err)
+	}
+}
+
+func TestMigrate_diverged(t *testing.T) {
+	src := newMapWriteFS(fstest.MapFS{"a.txt": {Data: []byte("a")}})
+	app := NewFreezeFS(src)
+	dest := newMapWriteFS(fstest.MapFS{})
+	// NOTE: dest drops the contents written by the delta pass.
+	writes := 0
+	createFile := dest.CreateFileFunc
+	dest.CreateFileFunc = func(name string, mode fs.FileMode) (WriterFile, error) {
+		writes++
+		if writes > 1 {
+			return &FileDelegator{WriteFunc: func(p []byte) (int, error) { return len(p), nil }}, nil
+		}
+		return createFile(name, mode)
+	}
+	progress := func(p MigrateProgress) {
+		if p.Phase == MigrateCopy {
+			if _, err := src.WriteFile("a.txt", []byte("a2"), fs.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	err := Migrate(context.Background(), dest, src, WithMigrateProgress(progress), WithMigrateFreeze(app))
+	if !errors.Is(err, ErrDiverged) {
+		t.Errorf("unexpected %v; want %v", err, ErrDiverged)
+	}
+	if app.Frozen() {
+		t.Errorf("unexpected frozen after a failure")
+	}
+}
+
+func mustManifest(t *testing.T, fsys fs.FS) *Manifest {
+	m, err := NewManifest(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
@@ -0,0 +1,155 @@
+package wfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrTooLarge "too large"
+var ErrTooLarge = errors.New("too large")
+
+// MultipartOption is an option for SaveMultipart.
+type MultipartOption func(o *multipartOptions)
+
+type multipartOptions struct {
+	maxFileSize int64
+	maxFiles    int
+	mode        fs.FileMode
+}
+
+// WithMaxFileSize makes SaveMultipart reject a file larger than n bytes with
+// ErrTooLarge.
+func WithMaxFileSize(n int64) MultipartOption {
+	return func(o *multipartOptions) {
+		o.maxFileSize = n
+	}
+}
+
+// WithMaxFiles makes SaveMultipart reject a form that has more than n files
+// with ErrTooLarge.
+func WithMaxFiles(n int) MultipartOption {
+	return func(o *multipartOptions) {
+		o.maxFiles = n
+	}
+}
+
+// WithFileMode sets the mode of the files saved by SaveMultipart. The default
+// is 0644.
+func WithFileMode(mode fs.FileMode) MultipartOption {
+	return func(o *multipartOptions) {
+		o.mode = mode
+	}
+}
+
+// SanitizeFilename returns the base name of the specified client supplied
+// filename that is safe to use as a path element. Directory components
+// including Windows separators are removed, leading dots are trimmed and
+// characters other than letters, digits, '.', '-' and '_' are replaced with
+// '_'. SanitizeFilename returns "" if no valid name remains or the name is
+// rejected by CleanName such as reserved Windows device names.
+func SanitizeFilename(filename string) string {
+	filename = path.Base(strings.ReplaceAll(filename, `\`, "/"))
+	if filename == "/" {
+		return ""
+	}
+	filename = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, filename)
+	filename = strings.TrimLeft(filename, ".")
+	if _, err := CleanName(filename); err != nil {
+		return ""
+	}
+	return filename
+}
+
+// SaveMultipart streams the files of the parsed multipart form into the
+// directory dir on the filesystem and returns the names of the saved files.
+// The files are saved in order of the form field names with the sanitized
+// filenames by SanitizeFilename, and a later file of the same name replaces
+// the earlier one. A file that fails to be saved is removed using Abort.
+//
+// Typical usage inside a http.Handler is:
+//
+//	if err := r.ParseMultipartForm(32 << 20); err != nil {
+//	  http.Error(w, err.Error(), http.StatusBadRequest)
+//	  return
+//	}
+//	defer r.MultipartForm.RemoveAll()
+//	names, err := wfs.SaveMultipart(fsys, r.MultipartForm, "uploads", wfs.WithMaxFileSize(10<<20))
+func SaveMultipart(fsys WriteFileFS, form *multipart.Form, dir string, opts ...MultipartOption) ([]string, error) {
+	o := &multipartOptions{mode: 0644}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var fields []string
+	count := 0
+	for field, fhs := range form.File {
+		fields = append(fields, field)
+		count += len(fhs)
+	}
+	sort.Strings(fields)
+	if o.maxFiles > 0 && count > o.maxFiles {
+		return nil, &fs.PathError{Op: "SaveMultipart", Path: dir, Err: ErrTooLarge}
+	}
+
+	var names []string
+	for _, field := range fields {
+		for _, fh := range form.File[field] {
+			filename := SanitizeFilename(fh.Filename)
+			if filename == "" {
+				return names, &fs.PathError{Op: "SaveMultipart", Path: fh.Filename, Err: fs.ErrInvalid}
+			}
+			name := path.Join(dir, filename)
+			if err := saveMultipartFile(fsys, fh, name, o); err != nil {
+				return names, err
+			}
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// saveMultipartFile streams the file of fh to the named file.
+func saveMultipartFile(fsys WriteFileFS, fh *multipart.FileHeader, name string, o *multipartOptions) error {
+	if o.maxFileSize > 0 && fh.Size > o.maxFileSize {
+		return &fs.PathError{Op: "SaveMultipart", Path: name, Err: ErrTooLarge}
+	}
+	src, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	var r io.Reader = src
+	if o.maxFileSize > 0 {
+		r = io.LimitReader(src, o.maxFileSize+1)
+	}
+	if err := fsys.MkdirAll(path.Dir(name), fs.ModePerm); err != nil {
+		return err
+	}
+	f, err := fsys.CreateFile(name, o.mode)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, r)
+	if err == nil && o.maxFileSize > 0 && n > o.maxFileSize {
+		err = &fs.PathError{Op: "SaveMultipart", Path: name, Err: ErrTooLarge}
+	}
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		Abort(fsys, name, f)
+	}
+	return err
+}
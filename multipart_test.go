@@ -0,0 +1,100 @@
+package wfs
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"mime/multipart"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newMultipartFormTest(t *testing.T, files map[string]string) *multipart.Form {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for filename, content := range files {
+		w, err := mw.CreateFormFile("file", filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	form, err := multipart.NewReader(&buf, mw.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	testCases := map[string]string{
+		"report.pdf":            "report.pdf",
+		"../../etc/passwd":      "passwd",
+		`C:\Users\me\photo.jpg`: "photo.jpg",
+		".htaccess":             "htaccess",
+		"my file (1).txt":       "my_file__1_.txt",
+		"日本語.txt":               "日本語.txt",
+		"..":                    "",
+		"/":                     "",
+		"nul.txt":               "",
+		"":                      "",
+	}
+	for filename, want := range testCases {
+		if got := SanitizeFilename(filename); got != want {
+			t.Errorf("unexpected %q; want %q", got, want)
+		}
+	}
+}
+
+func TestSaveMultipart(t *testing.T) {
+	form := newMultipartFormTest(t, map[string]string{
+		"../a.txt": "a",
+	})
+	m := fstest.MapFS{}
+	names, err := SaveMultipart(newMapWriteFS(m), form, "uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"uploads/a.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("unexpected %v; want %v", names, want)
+	}
+	if got := string(m["uploads/a.txt"].Data); got != "a" {
+		t.Errorf("unexpected %s; want a", got)
+	}
+}
+
+func TestSaveMultipart_TooLarge(t *testing.T) {
+	form := newMultipartFormTest(t, map[string]string{
+		"large.txt": strings.Repeat("x", 11),
+	})
+	m := fstest.MapFS{}
+	_, err := SaveMultipart(newMapWriteFS(m), form, "uploads", WithMaxFileSize(10))
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("unexpected %v; want %v", err, ErrTooLarge)
+	}
+	if _, ok := m["uploads/large.txt"]; ok {
+		t.Errorf("unexpected saved large.txt")
+	}
+
+	form = newMultipartFormTest(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+	_, err = SaveMultipart(newMapWriteFS(m), form, "uploads", WithMaxFiles(1))
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("unexpected %v; want %v", err, ErrTooLarge)
+	}
+}
+
+func TestSaveMultipart_InvalidFilename(t *testing.T) {
+	form := newMultipartFormTest(t, map[string]string{"..": "x"})
+	_, err := SaveMultipart(newMapWriteFS(fstest.MapFS{}), form, "uploads")
+	if !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+}
@@ -0,0 +1,82 @@
+package wfs
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// winReservedChars are the characters that are not allowed in a name on
+// Windows. Backslashes are rejected instead of being treated as separators.
+const winReservedChars = `<>:"|?*\`
+
+// winReservedNames are the device names that are not allowed as a base name
+// with or without an extension on Windows.
+var winReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// validPortableElem reports whether the path element is valid on all of the
+// supported platforms.
+func validPortableElem(elem string) bool {
+	if elem == ".." || strings.HasSuffix(elem, ".") && elem != "." || strings.HasSuffix(elem, " ") {
+		return false
+	}
+	for _, r := range elem {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(winReservedChars, r) {
+			return false
+		}
+	}
+	base := elem
+	if i := strings.IndexByte(base, '.'); i >= 0 {
+		base = base[:i]
+	}
+	return !winReservedNames[strings.ToUpper(base)]
+}
+
+// CleanName returns the cleaned form of the specified untrusted name that is
+// valid by fs.ValidPath and portable across backends. CleanName returns a
+// PathError of fs.ErrInvalid if the name is empty or absolute, contains ".."
+// elements, invalid UTF-8, control characters, characters reserved on Windows
+// such as '\' and ':' or reserved Windows device names such as "CON" and
+// "nul.txt". Elements ending with a dot or a space are also rejected.
+func CleanName(name string) (string, error) {
+	if name == "" || !utf8.ValidString(name) || strings.HasPrefix(name, "/") {
+		return "", &fs.PathError{Op: "CleanName", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, elem := range strings.Split(name, "/") {
+		if !validPortableElem(elem) {
+			return "", &fs.PathError{Op: "CleanName", Path: name, Err: fs.ErrInvalid}
+		}
+	}
+	return path.Clean("./" + name), nil
+}
+
+// SafeJoin joins the specified trusted base directory and untrusted name
+// cleaned by CleanName. The returned path is always under base.
+func SafeJoin(base, unsafe string) (string, error) {
+	if !fs.ValidPath(base) {
+		return "", &fs.PathError{Op: "SafeJoin", Path: base, Err: fs.ErrInvalid}
+	}
+	name, err := CleanName(unsafe)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(base, name), nil
+}
+
+// ValidName reports whether the name is valid by fs.ValidPath and portable
+// across backends as accepted by CleanName without cleaning. Backends keep
+// their own checks; use PortabilityFS to reject non-portable names on writes.
+func ValidName(name string) bool {
+	if !fs.ValidPath(name) {
+		return false
+	}
+	_, err := CleanName(name)
+	return err == nil
+}
@@ -0,0 +1,85 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestCleanName(t *testing.T) {
+	testCases := []struct {
+		name string
+		want string
+	}{
+		{name: "a.txt", want: "a.txt"},
+		{name: "./dir//b.txt", want: "dir/b.txt"},
+		{name: "dir/", want: "dir"},
+		{name: "console.log", want: "console.log"},
+		{name: "日本語.txt", want: "日本語.txt"},
+	}
+	for _, tc := range testCases {
+		got, err := CleanName(tc.name)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.name, err)
+		}
+		if got != tc.want {
+			t.Errorf("unexpected %q; want %q", got, tc.want)
+		}
+	}
+
+	for _, name := range []string{
+		"",
+		"../a.txt",
+		"dir/../../a.txt",
+		"/etc/passwd",
+		`dir\a.txt`,
+		"C:/a.txt",
+		"a\x00.txt",
+		"\xff.txt",
+		"CON",
+		"dir/nul.txt",
+		"Lpt1",
+		"trailing.",
+		"trailing ",
+	} {
+		if _, err := CleanName(name); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("%q: unexpected %v; want %v", name, err, fs.ErrInvalid)
+		}
+	}
+}
+
+func TestSafeJoin(t *testing.T) {
+	got, err := SafeJoin("uploads", "./user/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "uploads/user/a.txt"; got != want {
+		t.Errorf("unexpected %s; want %s", got, want)
+	}
+	if _, err := SafeJoin("uploads", "../secret"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+	if _, err := SafeJoin("/abs", "a.txt"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+	if _, err := SafeJoin("uploads", ""); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+}
+
+func TestValidName(t *testing.T) {
+	testCases := map[string]bool{
+		".":         true,
+		"dir/a.txt": true,
+		"":          false,
+		"./a.txt":   false,
+		"dir/":      false,
+		"a:b.txt":   false,
+		"dir/CON":   false,
+	}
+	for name, want := range testCases {
+		if got := ValidName(name); got != want {
+			t.Errorf("unexpected ValidName(%q) %v; want %v", name, got, want)
+		}
+	}
+}
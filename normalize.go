@@ -0,0 +1,186 @@
+package wfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// WithNameNormalizer makes the wrapped filesystem normalize names by
+// normalize on writes and lookups, such as norm.NFC.String or
+// norm.NFD.String of golang.org/x/text/unicode/norm, so that names that look
+// the same are stored with the same bytes across backends such as macOS and
+// Linux. Lookups of names that do not exist in the normalized form are
+// retried with the names as given, so files written without normalization
+// can still be read and removed. Filesystems returned by Sub are also
+// normalized.
+func WithNameNormalizer(normalize func(name string) string) WrapOption {
+	return func(d *FSDelegator) {
+		open, readDir, readFile := d.OpenFunc, d.ReadDirFunc, d.ReadFileFunc
+		glob, stat, sub := d.GlobFunc, d.StatFunc, d.SubFunc
+		mkdirAll, createFile, writeFile := d.MkdirAllFunc, d.CreateFileFunc, d.WriteFileFunc
+		removeFile, removeAll := d.RemoveFileFunc, d.RemoveAllFunc
+		copyFile, openRange, url, watch := d.CopyFileFunc, d.OpenRangeFunc, d.URLFunc, d.WatchFunc
+		createFileWithMeta, writeFileWithMeta, statMeta := d.CreateFileWithMetaFunc, d.WriteFileWithMetaFunc, d.StatMetaFunc
+		writeFileIf, fileVersion := d.WriteFileIfFunc, d.FileVersionFunc
+		// NOTE: RemoveFiles removes the files by the normalized RemoveFileFunc.
+		d.RemoveFilesFunc = nil
+
+		d.OpenFunc = func(name string) (f fs.File, err error) {
+			err = lookupNormalized(normalize, name, func(name string) error {
+				f, err = open(name)
+				return err
+			})
+			return f, err
+		}
+		if readDir != nil {
+			d.ReadDirFunc = func(name string) (entries []fs.DirEntry, err error) {
+				err = lookupNormalized(normalize, name, func(name string) error {
+					entries, err = readDir(name)
+					return err
+				})
+				return entries, err
+			}
+		}
+		if readFile != nil {
+			d.ReadFileFunc = func(name string) (p []byte, err error) {
+				err = lookupNormalized(normalize, name, func(name string) error {
+					p, err = readFile(name)
+					return err
+				})
+				return p, err
+			}
+		}
+		if glob != nil {
+			d.GlobFunc = func(pattern string) ([]string, error) {
+				return glob(normalize(pattern))
+			}
+		}
+		if stat != nil {
+			d.StatFunc = func(name string) (info fs.FileInfo, err error) {
+				err = lookupNormalized(normalize, name, func(name string) error {
+					info, err = stat(name)
+					return err
+				})
+				return info, err
+			}
+		}
+		if sub != nil {
+			d.SubFunc = func(dir string) (subFS fs.FS, err error) {
+				err = lookupNormalized(normalize, dir, func(dir string) error {
+					subFS, err = sub(dir)
+					return err
+				})
+				if err != nil {
+					return nil, err
+				}
+				return Wrap(subFS, WithNameNormalizer(normalize)), nil
+			}
+		}
+		if mkdirAll != nil {
+			d.MkdirAllFunc = func(dir string, mode fs.FileMode) error {
+				return mkdirAll(normalize(dir), mode)
+			}
+		}
+		if createFile != nil {
+			d.CreateFileFunc = func(name string, mode fs.FileMode) (WriterFile, error) {
+				return createFile(normalize(name), mode)
+			}
+		}
+		if writeFile != nil {
+			d.WriteFileFunc = func(name string, p []byte, mode fs.FileMode) (int, error) {
+				return writeFile(normalize(name), p, mode)
+			}
+		}
+		if removeFile != nil {
+			d.RemoveFileFunc = func(name string) error {
+				return lookupNormalized(normalize, name, removeFile)
+			}
+		}
+		if removeAll != nil {
+			d.RemoveAllFunc = func(name string) error {
+				normalized := normalize(name)
+				if err := removeAll(normalized); err != nil || normalized == name {
+					return err
+				}
+				return removeAll(name)
+			}
+		}
+		if copyFile != nil {
+			d.CopyFileFunc = func(src, dst string) error {
+				return lookupNormalized(normalize, src, func(src string) error {
+					return copyFile(src, normalize(dst))
+				})
+			}
+		}
+		if openRange != nil {
+			d.OpenRangeFunc = func(name string, off, length int64) (r io.ReadCloser, err error) {
+				err = lookupNormalized(normalize, name, func(name string) error {
+					r, err = openRange(name, off, length)
+					return err
+				})
+				return r, err
+			}
+		}
+		if url != nil {
+			d.URLFunc = func(name string, method string, expiry time.Duration) (u string, err error) {
+				err = lookupNormalized(normalize, name, func(name string) error {
+					u, err = url(name, method, expiry)
+					return err
+				})
+				return u, err
+			}
+		}
+		if watch != nil {
+			d.WatchFunc = func(ctx context.Context, dir string) (<-chan WatchEvent, error) {
+				return watch(ctx, normalize(dir))
+			}
+		}
+		if createFileWithMeta != nil {
+			d.CreateFileWithMetaFunc = func(name string, mode fs.FileMode, meta Metadata) (WriterFile, error) {
+				return createFileWithMeta(normalize(name), mode, meta)
+			}
+		}
+		if writeFileWithMeta != nil {
+			d.WriteFileWithMetaFunc = func(name string, p []byte, mode fs.FileMode, meta Metadata) (int, error) {
+				return writeFileWithMeta(normalize(name), p, mode, meta)
+			}
+		}
+		if statMeta != nil {
+			d.StatMetaFunc = func(name string) (meta Metadata, err error) {
+				err = lookupNormalized(normalize, name, func(name string) error {
+					meta, err = statMeta(name)
+					return err
+				})
+				return meta, err
+			}
+		}
+		if writeFileIf != nil {
+			d.WriteFileIfFunc = func(name string, p []byte, mode fs.FileMode, cond Condition) (string, error) {
+				return writeFileIf(normalize(name), p, mode, cond)
+			}
+		}
+		if fileVersion != nil {
+			d.FileVersionFunc = func(name string) (v string, err error) {
+				err = lookupNormalized(normalize, name, func(name string) error {
+					v, err = fileVersion(name)
+					return err
+				})
+				return v, err
+			}
+		}
+	}
+}
+
+// lookupNormalized calls fn with the normalized name and retries with the
+// name as given if the normalized name does not exist.
+func lookupNormalized(normalize func(string) string, name string, fn func(name string) error) error {
+	normalized := normalize(name)
+	err := fn(normalized)
+	if normalized != name && errors.Is(err, fs.ErrNotExist) {
+		return fn(name)
+	}
+	return err
+}
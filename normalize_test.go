@@ -0,0 +1,81 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const (
+	nfcCafe = "café.txt"
+	nfdCafe = "café.txt"
+)
+
+// composeE composes "e" and U+0301 to U+00E9 like NFC.
+func composeE(name string) string {
+	return strings.ReplaceAll(name, "é", "é")
+}
+
+func TestWithNameNormalizer(t *testing.T) {
+	m := fstest.MapFS{
+		"legacy/" + nfdCafe: {Data: []byte("legacy")},
+	}
+	d := newMapWriteFS(m)
+	d.RemoveFileFunc = func(name string) error {
+		if _, ok := m[name]; !ok {
+			return &fs.PathError{Op: "RemoveFile", Path: name, Err: fs.ErrNotExist}
+		}
+		delete(m, name)
+		return nil
+	}
+	fsys := Wrap(d, WithNameNormalizer(composeE))
+
+	if _, err := WriteFile(fsys, nfdCafe, []byte("nfd"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteFile(fsys, nfcCafe, []byte("nfc"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if want := []string{nfcCafe, "legacy/" + nfdCafe}; !reflect.DeepEqual(names, want) {
+		t.Errorf("unexpected %q; want %q", names, want)
+	}
+	for _, name := range []string{nfcCafe, nfdCafe} {
+		got, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "nfc" {
+			t.Errorf("unexpected %s; want nfc", got)
+		}
+	}
+
+	sub, err := fs.Sub(fsys, "legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := fs.ReadFile(sub, nfdCafe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "legacy" {
+		t.Errorf("unexpected %s; want legacy", got)
+	}
+	if _, err := fs.Stat(sub, nfcCafe); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	if err := RemoveFile(fsys, "legacy/"+nfdCafe); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["legacy/"+nfdCafe]; ok {
+		t.Errorf("unexpected not removed")
+	}
+}
@@ -0,0 +1,78 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// Op is the name of a filesystem operation. The values are the same as Op of
+// fs.PathError returned by the operations.
+type Op string
+
+const (
+	// OpOpen is the operation of fs.FS.Open.
+	OpOpen Op = "Open"
+	// OpReadDir is the operation of fs.ReadDirFS.ReadDir.
+	OpReadDir Op = "ReadDir"
+	// OpReadFile is the operation of fs.ReadFileFS.ReadFile.
+	OpReadFile Op = "ReadFile"
+	// OpGlob is the operation of fs.GlobFS.Glob.
+	OpGlob Op = "Glob"
+	// OpStat is the operation of fs.StatFS.Stat.
+	OpStat Op = "Stat"
+	// OpSub is the operation of fs.SubFS.Sub.
+	OpSub Op = "Sub"
+	// OpMkdirAll is the operation of WriteFileFS.MkdirAll.
+	OpMkdirAll Op = "MkdirAll"
+	// OpCreateFile is the operation of WriteFileFS.CreateFile.
+	OpCreateFile Op = "CreateFile"
+	// OpWriteFile is the operation of WriteFileFS.WriteFile.
+	OpWriteFile Op = "WriteFile"
+	// OpRemoveFile is the operation of RemoveFileFS.RemoveFile.
+	OpRemoveFile Op = "RemoveFile"
+	// OpRemoveAll is the operation of RemoveFileFS.RemoveAll.
+	OpRemoveAll Op = "RemoveAll"
+	// OpRemoveFiles is the operation of RemoveFilesFS.RemoveFiles.
+	OpRemoveFiles Op = "RemoveFiles"
+	// OpCopyFile is the operation of CopyFileWithinFS.CopyFile.
+	OpCopyFile Op = "CopyFile"
+	// OpMoveFile is the operation of MoveFile.
+	OpMoveFile Op = "MoveFile"
+	// OpOpenRange is the operation of RangeReaderFS.OpenRange.
+	OpOpenRange Op = "OpenRange"
+	// OpURL is the operation of URLFS.URL.
+	OpURL Op = "URL"
+	// OpWatch is the operation of WatchFS.Watch.
+	OpWatch Op = "Watch"
+	// OpReadDirPage is the operation of ReadDirPageFS.ReadDirPage.
+	OpReadDirPage Op = "ReadDirPage"
+	// OpChangeToken is the operation of ChangeTokenFS.ChangeToken.
+	OpChangeToken Op = "ChangeToken"
+	// OpPrefetch is the operation of PrefetchFS.Prefetch.
+	OpPrefetch Op = "Prefetch"
+	// OpCreateFileWithMeta is the operation of MetadataFS.CreateFileWithMeta.
+	OpCreateFileWithMeta Op = "CreateFileWithMeta"
+	// OpWriteFileWithMeta is the operation of MetadataFS.WriteFileWithMeta.
+	OpWriteFileWithMeta Op = "WriteFileWithMeta"
+	// OpStatMeta is the operation of MetadataFS.StatMeta.
+	OpStatMeta Op = "StatMeta"
+	// OpWriteFileIf is the operation of ConditionalWriteFS.WriteFileIf.
+	OpWriteFileIf Op = "WriteFileIf"
+	// OpFileVersion is the operation of ConditionalWriteFS.FileVersion.
+	OpFileVersion Op = "FileVersion"
+)
+
+// PathError returns a PathError of the operation on the named file. If err is
+// a PathError such as an error of the os package, its Op and Path are
+// replaced so that errors are the same across filesystems. PathError returns
+// nil if err is nil.
+func PathError(op Op, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		err = pe.Err
+	}
+	return &fs.PathError{Op: string(op), Path: name, Err: err}
+}
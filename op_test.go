@@ -0,0 +1,29 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+)
+
+func TestPathError(t *testing.T) {
+	if err := PathError(OpStat, "a.txt", nil); err != nil {
+		t.Errorf("unexpected %v; want nil", err)
+	}
+
+	inner := &fs.PathError{Op: "lstat", Path: "/tmp/dir/a.txt", Err: os.ErrNotExist}
+	err := PathError(OpStat, "a.txt", inner)
+	if got, want := err.Error(), "Stat a.txt: file does not exist"; got != want {
+		t.Errorf("unexpected %s; want %s", got, want)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+
+	wantErr := errors.New("test")
+	err = PathError(OpRemoveFile, "b.txt", wantErr)
+	if got, want := err.Error(), "RemoveFile b.txt: test"; got != want {
+		t.Errorf("unexpected %s; want %s", got, want)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build darwin || freebsd || netbsd
+// +build darwin freebsd netbsd
+
+package osfs
+
+import (
+	"syscall"
+	"time"
+)
+
+func birthTime(sys interface{}) (time.Time, bool) {
+	st, ok := sys.(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(st.Birthtimespec.Sec), int64(st.Birthtimespec.Nsec)), true
+}
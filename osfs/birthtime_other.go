@@ -0,0 +1,14 @@
+//go:build !darwin && !freebsd && !netbsd && !windows
+// +build !darwin,!freebsd,!netbsd,!windows
+
+package osfs
+
+import (
+	"time"
+)
+
+// birthTime returns false because the creation time is not provided by
+// syscall.Stat_t on the platform.
+func birthTime(sys interface{}) (time.Time, bool) {
+	return time.Time{}, false
+}
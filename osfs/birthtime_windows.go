@@ -0,0 +1,14 @@
+package osfs
+
+import (
+	"syscall"
+	"time"
+)
+
+func birthTime(sys interface{}) (time.Time, bool) {
+	d, ok := sys.(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, d.CreationTime.Nanoseconds()), true
+}
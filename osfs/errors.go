@@ -0,0 +1,59 @@
+package osfs
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/jarxorg/wfs"
+)
+
+// sentinelError is an error of the OS that also matches a sentinel error of
+// wfs by errors.Is.
+type sentinelError struct {
+	err      error
+	sentinel error
+}
+
+func (e *sentinelError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sentinelError) Unwrap() error {
+	return e.err
+}
+
+func (e *sentinelError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+// osError makes the errors of a read-only filesystem and of no space left
+// match wfs.ErrReadOnly and wfs.ErrQuotaExceeded by errors.Is keeping the
+// errors of the OS.
+func osError(err error) error {
+	var sentinel error
+	switch {
+	case err == nil:
+		return nil
+	case isAny(err, readOnlyErrors):
+		sentinel = wfs.ErrReadOnly
+	case isAny(err, quotaErrors):
+		sentinel = wfs.ErrQuotaExceeded
+	default:
+		return err
+	}
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return &fs.PathError{Op: pe.Op, Path: pe.Path, Err: &sentinelError{err: pe.Err, sentinel: sentinel}}
+	}
+	return &sentinelError{err: err, sentinel: sentinel}
+}
+
+// isAny reports whether err matches any of targets.
+func isAny(err error, targets []error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
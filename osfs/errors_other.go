@@ -0,0 +1,15 @@
+//go:build !plan9 && !windows
+// +build !plan9,!windows
+
+package osfs
+
+import (
+	"syscall"
+)
+
+var (
+	// readOnlyErrors are the errors of the OS of a read-only filesystem.
+	readOnlyErrors = []error{syscall.EROFS}
+	// quotaErrors are the errors of the OS of no space left.
+	quotaErrors = []error{syscall.ENOSPC, syscall.EDQUOT}
+)
@@ -0,0 +1,10 @@
+package osfs
+
+var (
+	// readOnlyErrors are empty because plan9 reports the errors of the OS
+	// by strings.
+	readOnlyErrors []error
+	// quotaErrors are empty because plan9 reports the errors of the OS by
+	// strings.
+	quotaErrors []error
+)
@@ -0,0 +1,14 @@
+package osfs
+
+import (
+	"syscall"
+)
+
+var (
+	// readOnlyErrors are the errors of the OS of a read-only filesystem such
+	// as ERROR_WRITE_PROTECT.
+	readOnlyErrors = []error{syscall.Errno(19)}
+	// quotaErrors are the errors of the OS of no space left such as
+	// ERROR_HANDLE_DISK_FULL and ERROR_DISK_FULL.
+	quotaErrors = []error{syscall.Errno(39), syscall.Errno(112)}
+)
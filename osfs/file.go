@@ -0,0 +1,198 @@
+package osfs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/jarxorg/wfs"
+)
+
+// file is an os.File that implements wfs.SparseFile. A file returned by
+// CreateFile with WithStagedCreate writes a temporary file in the same
+// directory that replaces the named file by a rename on Close and is removed
+// by Abort. The errors of the file are reported against the name of the file
+// on the filesystem.
+type file struct {
+	*os.File
+	// name is the name of the file on the filesystem.
+	name string
+	// path is the OS path of the named file.
+	path string
+	// tmp is the OS path of the temporary file until Close or Abort.
+	tmp string
+	// meta stores m for path on Close.
+	meta *metaStore
+	m    wfs.Metadata
+	done bool
+}
+
+var (
+	_ wfs.WriterAtFile    = (*file)(nil)
+	_ wfs.LargeWriterFile = (*file)(nil)
+	_ wfs.SparseFile      = (*file)(nil)
+	_ wfs.SyncWriterFile  = (*file)(nil)
+	_ wfs.AbortWriterFile = (*file)(nil)
+)
+
+// tempSuffixLen is the length of the random suffix of tempPath.
+const tempSuffixLen = 16
+
+// tempPath returns a path of a temporary file in the directory of path.
+func tempPath(path string) (string, error) {
+	var p [tempSuffixLen / 2]byte
+	if _, err := rand.Read(p[:]); err != nil {
+		return "", err
+	}
+	dir, base := filepath.Split(path)
+	return filepath.Join(dir, "."+base+".tmp"+hex.EncodeToString(p[:])), nil
+}
+
+// isTempName reports whether name is a base name returned by tempPath.
+func isTempName(name string) bool {
+	n := len(name) - tempSuffixLen
+	if n < len("..tmp") || name[0] != '.' || name[n-len(".tmp"):n] != ".tmp" {
+		return false
+	}
+	_, err := hex.DecodeString(name[n:])
+	return err == nil
+}
+
+// pathError returns err with the name of the file instead of the OS path.
+func (f *file) pathError(err error) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		err = &fs.PathError{Op: pe.Op, Path: f.name, Err: pe.Err}
+	}
+	return osError(err)
+}
+
+// Name returns the OS path of the named file.
+func (f *file) Name() string {
+	return f.path
+}
+
+// Stat returns the fs.FileInfo of this file with the name of the file instead
+// of the name of the temporary file.
+func (f *file) Stat() (fs.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, f.pathError(err)
+	}
+	return &namedInfo{FileInfo: info, name: filepath.Base(f.path)}, nil
+}
+
+// Close closes this file, renames the temporary file to the named file and
+// stores the metadata of the file.
+func (f *file) Close() error {
+	err := f.File.Close()
+	if f.done {
+		return f.pathError(err)
+	}
+	f.done = true
+	if f.tmp != "" {
+		if err == nil {
+			err = os.Rename(f.tmp, f.path)
+		}
+		if err != nil {
+			os.Remove(f.tmp)
+		}
+	}
+	if err != nil {
+		return osError(wfs.PathError("Close", f.name, err))
+	}
+	f.meta.put(f.path, f.m)
+	return nil
+}
+
+// Abort closes this file and removes the temporary file, so the named file is
+// kept as it was before CreateFile. Without WithStagedCreate Abort removes the
+// named file and its metadata.
+func (f *file) Abort() error {
+	f.File.Close()
+	if f.done {
+		return nil
+	}
+	f.done = true
+	path := f.tmp
+	if path == "" {
+		path = f.path
+		f.meta.remove(f.path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return f.pathError(err)
+	}
+	return nil
+}
+
+// namedInfo is a fs.FileInfo whose Name is replaced.
+type namedInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (info *namedInfo) Name() string {
+	return info.name
+}
+
+// PunchHole deallocates the specified range of this file. PunchHole uses
+// fallocate on Linux and writes zeros on other platforms.
+func (f *file) PunchHole(off, length int64) error {
+	if off < 0 || length < 0 {
+		return &fs.PathError{Op: "PunchHole", Path: f.name, Err: fs.ErrInvalid}
+	}
+	return f.pathError(punchHole(f.File, off, length))
+}
+
+// Read reads from this file.
+func (f *file) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	return n, f.pathError(err)
+}
+
+// ReadAt reads from this file at the offset.
+func (f *file) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.File.ReadAt(p, off)
+	return n, f.pathError(err)
+}
+
+// Seek sets the offset of this file.
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	n, err := f.File.Seek(offset, whence)
+	return n, f.pathError(err)
+}
+
+// Write writes p to this file. An error of no space left matches
+// wfs.ErrQuotaExceeded.
+func (f *file) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+	return n, f.pathError(err)
+}
+
+// WriteAt writes p to this file at the offset. An error of no space left
+// matches wfs.ErrQuotaExceeded.
+func (f *file) WriteAt(p []byte, off int64) (int, error) {
+	n, err := f.File.WriteAt(p, off)
+	return n, f.pathError(err)
+}
+
+// ReadFrom reads from r until EOF and writes to this file. An error of no
+// space left matches wfs.ErrQuotaExceeded.
+func (f *file) ReadFrom(r io.Reader) (int64, error) {
+	n, err := f.File.ReadFrom(r)
+	return n, f.pathError(err)
+}
+
+// Truncate changes the size of this file.
+func (f *file) Truncate(size int64) error {
+	return f.pathError(f.File.Truncate(size))
+}
+
+// Sync commits the contents of this file to stable storage.
+func (f *file) Sync() error {
+	return f.pathError(f.File.Sync())
+}
@@ -0,0 +1,10 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !plan9
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris,!plan9
+
+package osfs
+
+// fileID returns false because the identifier of a file is not provided by
+// fs.FileInfo on the platform.
+func fileID(sys interface{}) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,14 @@
+package osfs
+
+import (
+	"syscall"
+)
+
+// fileID returns the unique path of the qid of a file.
+func fileID(sys interface{}) (uint64, bool) {
+	d, ok := sys.(*syscall.Dir)
+	if !ok {
+		return 0, false
+	}
+	return d.Qid.Path, true
+}
@@ -0,0 +1,17 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package osfs
+
+import (
+	"syscall"
+)
+
+// fileID returns the inode number of a file.
+func fileID(sys interface{}) (uint64, bool) {
+	st, ok := sys.(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Ino), true
+}
@@ -0,0 +1,134 @@
+package osfs
+
+import (
+	"container/list"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jarxorg/wfs"
+)
+
+// fileInfo is a fs.FileInfo that implements wfs.HashedInfo and
+// wfs.BirthTimeInfo.
+type fileInfo struct {
+	fs.FileInfo
+	path   string
+	hashes *hashCache
+}
+
+var (
+	_ wfs.HashedInfo    = (*fileInfo)(nil)
+	_ wfs.BirthTimeInfo = (*fileInfo)(nil)
+)
+
+// Hash returns the MD5 sum of the file. The sum is cached by the OSFS while
+// the version of the file that is made of its inode, creation time,
+// modification time and size is unchanged, so the file is read again after
+// it is replaced by the OSFS. A file that is modified in place by another
+// process without changing its size within the resolution of the
+// modification time keeps the cached sum. Hash returns a nil sum if the file
+// is a directory or cannot be read.
+func (info *fileInfo) Hash() (string, []byte) {
+	if info.IsDir() {
+		return "", nil
+	}
+	version := fileVersion(info.FileInfo)
+	if sum, ok := info.hashes.get(info.path, version); ok {
+		return wfs.HashMD5, sum
+	}
+	f, err := os.Open(info.path)
+	if err != nil {
+		return "", nil
+	}
+	defer f.Close()
+
+	sum, err := wfs.HashReader(f)
+	if err != nil {
+		return "", nil
+	}
+	info.hashes.put(info.path, version, sum)
+	return wfs.HashMD5, sum
+}
+
+// BirthTime returns the creation time of the file. BirthTime returns false on
+// platforms whose stat does not provide the creation time such as linux.
+func (info *fileInfo) BirthTime() (time.Time, bool) {
+	return birthTime(info.Sys())
+}
+
+// hashCacheSize is the maximum number of hashes cached by a hashCache.
+const hashCacheSize = 4096
+
+// hashCache caches the hashes of files by path and version. hashCache keeps at
+// most size hashes and evicts the least recently used hash.
+type hashCache struct {
+	mutex  sync.Mutex
+	size   int
+	values map[string]*list.Element
+	lru    *list.List
+}
+
+type cachedHash struct {
+	path    string
+	version string
+	sum     []byte
+}
+
+func newHashCache() *hashCache {
+	return &hashCache{
+		size:   hashCacheSize,
+		values: map[string]*list.Element{},
+		lru:    list.New(),
+	}
+}
+
+func (c *hashCache) get(path, version string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.values[path]
+	if !ok {
+		return nil, false
+	}
+	h := e.Value.(*cachedHash)
+	if h.version != version {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return h.sum, true
+}
+
+func (c *hashCache) put(path, version string, sum []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, ok := c.values[path]; ok {
+		h := e.Value.(*cachedHash)
+		h.version, h.sum = version, sum
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.values[path] = c.lru.PushFront(&cachedHash{path: path, version: version, sum: sum})
+	for c.lru.Len() > c.size {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.values, e.Value.(*cachedHash).path)
+	}
+}
+
+func (c *hashCache) removeAll(path string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	prefix := path + string(filepath.Separator)
+	for k, e := range c.values {
+		if k == path || strings.HasPrefix(k, prefix) {
+			c.lru.Remove(e)
+			delete(c.values, k)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package osfs
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jarxorg/wfs"
+)
+
+// metaStore holds metadata of files in memory. The metadata is not persisted.
+type metaStore struct {
+	mutex  sync.Mutex
+	values map[string]wfs.Metadata
+}
+
+func newMetaStore() *metaStore {
+	return &metaStore{
+		values: map[string]wfs.Metadata{},
+	}
+}
+
+func (s *metaStore) get(path string) wfs.Metadata {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.values[path].Clone()
+}
+
+func (s *metaStore) put(path string, meta wfs.Metadata) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(meta) == 0 {
+		delete(s.values, path)
+		return
+	}
+	s.values[path] = meta.Clone()
+}
+
+func (s *metaStore) remove(path string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.values, path)
+}
+
+func (s *metaStore) removeAll(path string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	prefix := path + string(filepath.Separator)
+	for k := range s.values {
+		if k == path || strings.HasPrefix(k, prefix) {
+			delete(s.values, k)
+		}
+	}
+}
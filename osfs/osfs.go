@@ -2,11 +2,19 @@
 package osfs
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"io/fs"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/jarxorg/wfs"
 )
@@ -17,9 +25,11 @@ func DirFS(dir string) fs.FS {
 	return New(dir)
 }
 
-// containsDenyWin reports whether any path characters of windows are within s.
+// containsDenyWin reports whether s contains characters or names that are
+// reserved on windows by wfs.CleanName.
 func containsDenyWin(s string) bool {
-	return strings.ContainsAny(s, `\:`)
+	_, err := wfs.CleanName(s)
+	return err != nil
 }
 
 // isInvalidPath reports whether the given path name is valid for use in a call to Create and Write.
@@ -27,37 +37,41 @@ func isInvalidPath(name string) bool {
 	return !fs.ValidPath(name) || runtime.GOOS == "windows" && containsDenyWin(name)
 }
 
-var osCreateFunc = func(name string) (*os.File, error) {
-	return os.Create(name)
-}
-
-var osMkdirAllFunc = func(dir string, perm os.FileMode) error {
-	return os.MkdirAll(dir, perm)
-}
-
-var osRemoveFunc = func(name string) error {
-	return os.Remove(name)
-}
-
-var osRemoveAllFunc = func(path string) error {
-	return os.RemoveAll(path)
-}
-
 // OSFS represents a filesystem for the OS.
+// OSFS keeps metadata written by WriteFileWithMeta in memory only.
 type OSFS struct {
-	Dir  string
-	osFS *wfs.FSDelegator
+	Dir          string
+	osFS         *wfs.FSDelegator
+	meta         *metaStore
+	hashes       *hashCache
+	condMutex    *sync.Mutex
+	strictCreate bool
+	stagedCreate bool
+	dirMode      fs.FileMode
+	fileMode     bool
+	fileModeMask fs.FileMode
+
+	createFunc    func(name string) (*os.File, error)
+	openFileFunc  func(name string, flag int, perm os.FileMode) (*os.File, error)
+	mkdirAllFunc  func(dir string, perm os.FileMode) error
+	removeFunc    func(name string) error
+	removeAllFunc func(path string) error
 }
 
 var (
-	_ fs.FS            = (*OSFS)(nil)
-	_ fs.GlobFS        = (*OSFS)(nil)
-	_ fs.ReadDirFS     = (*OSFS)(nil)
-	_ fs.ReadFileFS    = (*OSFS)(nil)
-	_ fs.StatFS        = (*OSFS)(nil)
-	_ fs.SubFS         = (*OSFS)(nil)
-	_ wfs.WriteFileFS  = (*OSFS)(nil)
-	_ wfs.RemoveFileFS = (*OSFS)(nil)
+	_ fs.FS                  = (*OSFS)(nil)
+	_ fs.GlobFS              = (*OSFS)(nil)
+	_ fs.ReadDirFS           = (*OSFS)(nil)
+	_ fs.ReadFileFS          = (*OSFS)(nil)
+	_ fs.StatFS              = (*OSFS)(nil)
+	_ fs.SubFS               = (*OSFS)(nil)
+	_ wfs.WriteFileFS        = (*OSFS)(nil)
+	_ wfs.RemoveFileFS       = (*OSFS)(nil)
+	_ wfs.URLFS              = (*OSFS)(nil)
+	_ wfs.MetadataFS         = (*OSFS)(nil)
+	_ wfs.ConditionalWriteFS = (*OSFS)(nil)
+	_ wfs.RangeReaderFS      = (*OSFS)(nil)
+	_ wfs.ReadDirPageFS      = (*OSFS)(nil)
 )
 
 // NewOSFS returns a filesystem for the tree of files rooted at the directory dir.
@@ -66,91 +80,602 @@ func NewOSFS(dir string) *OSFS {
 	return New(dir)
 }
 
+// Option is an option for New.
+type Option func(fsys *OSFS)
+
+// WithStrictCreate makes CreateFile and the other writes return an error of
+// fs.ErrNotExist like os.Create if the parent directory does not exist. By
+// default the missing parent directories are created implicitly.
+func WithStrictCreate() Option {
+	return func(fsys *OSFS) {
+		fsys.strictCreate = true
+	}
+}
+
+// WithStagedCreate makes CreateFile and the other writes store the written
+// data in a temporary file in the same directory that replaces the named file
+// by a rename on Close and is removed by wfs.Abort, so readers never see a
+// partially written file and the file that existed is kept on Abort. The
+// temporary files are hidden from Open, ReadDir, ReadDirPage and Glob of fsys
+// but are left behind if the process crashes. A symbolic link or a hard link
+// at the name is replaced instead of written through. By default the named
+// file is truncated and written directly and wfs.Abort removes it.
+func WithStagedCreate() Option {
+	return func(fsys *OSFS) {
+		fsys.stagedCreate = true
+	}
+}
+
+// WithDirMode makes MkdirAll and the implicit creation of parent directories
+// apply the permission bits of mode to the created directories regardless of
+// the mode passed by the caller and the umask of the process. By default the
+// mode passed by the caller is used and the umask is applied.
+func WithDirMode(mode fs.FileMode) Option {
+	return func(fsys *OSFS) {
+		fsys.dirMode = mode.Perm()
+	}
+}
+
+// WithFileModeMask makes CreateFile and the other writes apply the permission
+// bits of the mode passed by the caller to the file with the bits in mask
+// cleared like umask, regardless of the umask of the process. For example
+// WithFileModeMask(0022) applies 0755 to a file written with fs.ModePerm. By
+// default files are created with 0666 before the umask like os.Create and
+// the mode passed by the caller is ignored.
+func WithFileModeMask(mask fs.FileMode) Option {
+	return func(fsys *OSFS) {
+		fsys.fileMode = true
+		fsys.fileModeMask = mask.Perm()
+	}
+}
+
+// WithCreateFunc replaces os.Create called by CreateFile and the other writes
+// of the filesystem and the filesystems returned by Sub. It is intended for
+// fault injection in tests that run in parallel.
+func WithCreateFunc(fn func(name string) (*os.File, error)) Option {
+	return func(fsys *OSFS) {
+		fsys.createFunc = fn
+	}
+}
+
+// WithOpenFileFunc replaces os.OpenFile called by the writes that open files
+// with flags such as WriteFileIf. It is intended for fault injection in tests.
+func WithOpenFileFunc(fn func(name string, flag int, perm os.FileMode) (*os.File, error)) Option {
+	return func(fsys *OSFS) {
+		fsys.openFileFunc = fn
+	}
+}
+
+// WithMkdirAllFunc replaces os.MkdirAll called by MkdirAll and the implicit
+// creation of parent directories. It is intended for fault injection in tests.
+func WithMkdirAllFunc(fn func(dir string, perm os.FileMode) error) Option {
+	return func(fsys *OSFS) {
+		fsys.mkdirAllFunc = fn
+	}
+}
+
+// WithRemoveFunc replaces os.Remove called by RemoveFile. It is intended for
+// fault injection in tests.
+func WithRemoveFunc(fn func(name string) error) Option {
+	return func(fsys *OSFS) {
+		fsys.removeFunc = fn
+	}
+}
+
+// WithRemoveAllFunc replaces os.RemoveAll called by RemoveAll. It is intended
+// for fault injection in tests.
+func WithRemoveAllFunc(fn func(path string) error) Option {
+	return func(fsys *OSFS) {
+		fsys.removeAllFunc = fn
+	}
+}
+
 // New returns a filesystem for the tree of files rooted at the directory dir.
-func New(dir string) *OSFS {
+func New(dir string, opts ...Option) *OSFS {
+	fsys := newOSFS(dir, newMetaStore(), newHashCache(), &sync.Mutex{})
+	for _, opt := range opts {
+		opt(fsys)
+	}
+	return fsys
+}
+
+func newOSFS(dir string, meta *metaStore, hashes *hashCache, condMutex *sync.Mutex) *OSFS {
 	return &OSFS{
-		Dir:  dir,
-		osFS: wfs.DelegateFS(os.DirFS(dir)),
+		Dir:       dir,
+		osFS:      wfs.DelegateFS(os.DirFS(dir)),
+		meta:      meta,
+		hashes:    hashes,
+		condMutex: condMutex,
+
+		createFunc:    os.Create,
+		openFileFunc:  os.OpenFile,
+		mkdirAllFunc:  os.MkdirAll,
+		removeFunc:    os.Remove,
+		removeAllFunc: os.RemoveAll,
 	}
 }
 
 // Open opens the named file.
 func (fsys *OSFS) Open(name string) (fs.File, error) {
-	return fsys.osFS.Open(name)
+	f, err := fsys.osFS.Open(name)
+	if err != nil {
+		return nil, wfs.PathError(wfs.OpOpen, name, err)
+	}
+	if !fsys.stagedCreate {
+		return f, nil
+	}
+	rd, ok := f.(fs.ReadDirFile)
+	if !ok {
+		return f, nil
+	}
+	d := wfs.DelegateFile(f)
+	d.ReadDirFunc = func(n int) ([]fs.DirEntry, error) {
+		for {
+			entries, err := rd.ReadDir(n)
+			entries = withoutTemp(entries)
+			// NOTE: ReadDir(n > 0) must not return no entries without an error.
+			if n <= 0 || len(entries) > 0 || err != nil {
+				return entries, err
+			}
+		}
+	}
+	return d.File(), nil
+}
+
+// withoutTemp returns entries without the temporary files of WithStagedCreate.
+func withoutTemp(entries []fs.DirEntry) []fs.DirEntry {
+	filtered := entries[:0]
+	for _, entry := range entries {
+		if !isTempName(entry.Name()) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
 }
 
-// Glob returns the names of all files matching pattern, providing an implementation
-// of the top-level Glob function.
+// Glob returns the lexically sorted names of all files matching pattern,
+// providing an implementation of the top-level Glob function.
 func (fsys *OSFS) Glob(pattern string) ([]string, error) {
-	return fsys.osFS.Glob(pattern)
+	names, err := fsys.osFS.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if fsys.stagedCreate {
+		filtered := names[:0]
+		for _, name := range names {
+			if !isTempName(name[strings.LastIndex(name, "/")+1:]) {
+				filtered = append(filtered, name)
+			}
+		}
+		names = filtered
+	}
+	sort.Strings(names)
+	return names, nil
 }
 
 // ReadDir reads the named directory and returns a list of directory entries sorted
 // by filename.
 func (fsys *OSFS) ReadDir(dir string) ([]fs.DirEntry, error) {
-	return fsys.osFS.ReadDir(dir)
+	entries, err := fsys.osFS.ReadDir(dir)
+	if err != nil {
+		return nil, wfs.PathError(wfs.OpReadDir, dir, err)
+	}
+	if fsys.stagedCreate {
+		entries = withoutTemp(entries)
+	}
+	return entries, nil
+}
+
+// ReadDirPage calls fn with at most n entries of the named directory at a
+// time using os.File.ReadDir(n), so a directory with millions of files is
+// not loaded at once. The entries are passed in the directory order.
+func (fsys *OSFS) ReadDirPage(dir string, n int, fn func(entries []fs.DirEntry) error) error {
+	if !fs.ValidPath(dir) {
+		return &fs.PathError{Op: string(wfs.OpReadDirPage), Path: dir, Err: fs.ErrInvalid}
+	}
+	f, err := os.Open(filepath.Join(fsys.Dir, dir))
+	if err != nil {
+		return wfs.PathError(wfs.OpReadDirPage, dir, err)
+	}
+	defer f.Close()
+
+	if !fsys.stagedCreate {
+		return wfs.ReadDirFilePage(f, n, fn)
+	}
+	return wfs.ReadDirFilePage(f, n, func(entries []fs.DirEntry) error {
+		if entries = withoutTemp(entries); len(entries) == 0 {
+			return nil
+		}
+		return fn(entries)
+	})
 }
 
 // ReadFile reads the named file and returns its contents.
 func (fsys *OSFS) ReadFile(name string) ([]byte, error) {
-	return fsys.osFS.ReadFile(name)
+	p, err := fsys.osFS.ReadFile(name)
+	if err != nil {
+		return nil, wfs.PathError(wfs.OpReadFile, name, err)
+	}
+	return p, nil
 }
 
 // Stat returns a FileInfo describing the file. If there is an error, it should be
 // of type *PathError.
 func (fsys *OSFS) Stat(name string) (fs.FileInfo, error) {
-	return fsys.osFS.Stat(name)
+	info, err := fsys.osFS.Stat(name)
+	if err != nil {
+		return nil, wfs.PathError(wfs.OpStat, name, err)
+	}
+	return &fileInfo{FileInfo: info, path: filepath.Join(fsys.Dir, name), hashes: fsys.hashes}, nil
 }
 
-// Sub returns an FS corresponding to the subtree rooted at dir.
+// OpenRange returns a reader that reads length bytes from the offset off of
+// the named file using ReadAt. If length is negative the reader reads until
+// EOF.
+func (fsys *OSFS) OpenRange(name string, off, length int64) (io.ReadCloser, error) {
+	if isInvalidPath(name) {
+		return nil, &fs.PathError{Op: string(wfs.OpOpenRange), Path: name, Err: fs.ErrInvalid}
+	}
+	if off < 0 {
+		return nil, &fs.PathError{Op: string(wfs.OpOpenRange), Path: name, Err: fs.ErrInvalid}
+	}
+	f, err := os.Open(filepath.Join(fsys.Dir, name))
+	if err != nil {
+		return nil, wfs.PathError(wfs.OpOpenRange, name, err)
+	}
+	if length < 0 {
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, wfs.PathError(wfs.OpOpenRange, name, err)
+		}
+		length = info.Size() - off
+	}
+	return &rangeReadCloser{SectionReader: io.NewSectionReader(f, off, length), f: f}, nil
+}
+
+type rangeReadCloser struct {
+	*io.SectionReader
+	f *os.File
+}
+
+func (r *rangeReadCloser) Close() error {
+	return r.f.Close()
+}
+
+// Sub returns an FS corresponding to the subtree rooted at dir. Like
+// memfs.MemFS.Sub, dir must be a valid path of an existing directory. The
+// names on the returned FS are validated in the same way as fsys, so they
+// cannot refer to files outside of dir by "..".
 func (fsys *OSFS) Sub(dir string) (fs.FS, error) {
-	return NewOSFS(filepath.Join(fsys.Dir, dir)), nil
+	if isInvalidPath(dir) {
+		return nil, &fs.PathError{Op: string(wfs.OpSub), Path: dir, Err: fs.ErrInvalid}
+	}
+	info, err := os.Stat(filepath.Join(fsys.Dir, dir))
+	if err != nil {
+		return nil, wfs.PathError(wfs.OpSub, dir, err)
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: string(wfs.OpSub), Path: dir, Err: fs.ErrInvalid}
+	}
+	sub := newOSFS(filepath.Join(fsys.Dir, dir), fsys.meta, fsys.hashes, fsys.condMutex)
+	sub.strictCreate = fsys.strictCreate
+	sub.stagedCreate = fsys.stagedCreate
+	sub.dirMode = fsys.dirMode
+	sub.fileMode = fsys.fileMode
+	sub.fileModeMask = fsys.fileModeMask
+	sub.createFunc = fsys.createFunc
+	sub.openFileFunc = fsys.openFileFunc
+	sub.mkdirAllFunc = fsys.mkdirAllFunc
+	sub.removeFunc = fsys.removeFunc
+	sub.removeAllFunc = fsys.removeAllFunc
+	return sub, nil
 }
 
 // MkdirAll creates the named directory.
 func (fsys *OSFS) MkdirAll(dir string, mode fs.FileMode) error {
 	if isInvalidPath(dir) {
-		return &fs.PathError{Op: "MkdirAll", Path: dir, Err: fs.ErrInvalid}
+		return &fs.PathError{Op: string(wfs.OpMkdirAll), Path: dir, Err: fs.ErrInvalid}
 	}
-	return osMkdirAllFunc(filepath.Join(fsys.Dir, dir), mode)
+	return osError(wfs.PathError(wfs.OpMkdirAll, dir, fsys.mkdirAll(filepath.Join(fsys.Dir, dir), mode)))
 }
 
-// CreateFile creates the named file.
-func (fsys *OSFS) CreateFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
-	if isInvalidPath(name) {
-		return nil, &fs.PathError{Op: "Create", Path: name, Err: fs.ErrInvalid}
+// mkdirAll creates the directory of the OS path and the missing parents. If
+// WithDirMode is set mkdirAll applies the mode to the created directories.
+func (fsys *OSFS) mkdirAll(path string, mode fs.FileMode) error {
+	if fsys.dirMode == 0 {
+		return fsys.mkdirAllFunc(path, mode)
 	}
-	path := filepath.Join(fsys.Dir, name)
-	err := osMkdirAllFunc(filepath.Dir(path), mode)
+	var created []string
+	for p := path; ; p = filepath.Dir(p) {
+		if _, err := os.Stat(p); !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		created = append(created, p)
+		if filepath.Dir(p) == p {
+			break
+		}
+	}
+	if err := fsys.mkdirAllFunc(path, fsys.dirMode); err != nil {
+		return err
+	}
+	for i := len(created) - 1; i >= 0; i-- {
+		if err := os.Chmod(created[i], fsys.dirMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openFile opens the OS path with the flag that includes os.O_CREATE. If
+// WithFileModeMask is set openFile applies the masked mode to the file.
+func (fsys *OSFS) openFile(path string, flag int, mode fs.FileMode) (*os.File, error) {
+	if !fsys.fileMode {
+		return fsys.openFileFunc(path, flag, 0666)
+	}
+	perm := mode.Perm() &^ fsys.fileModeMask
+	f, err := fsys.openFileFunc(path, flag, perm)
 	if err != nil {
 		return nil, err
 	}
-	return osCreateFunc(path)
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// mkdirParent creates the parent directories of the OS path unless
+// WithStrictCreate is set.
+func (fsys *OSFS) mkdirParent(path string, mode fs.FileMode) error {
+	if fsys.strictCreate {
+		return nil
+	}
+	return fsys.mkdirAll(filepath.Dir(path), mode)
+}
+
+// CreateFile creates the named file. The missing parent directories are
+// created unless WithStrictCreate is set. The file is truncated and written
+// directly and is removed by wfs.Abort unless WithStagedCreate is set.
+//
+// The returned file is not an *os.File, because its name and Close differ
+// from the OS file. Use the interfaces of wfs that it implements such as
+// wfs.WriterAtFile, wfs.SparseFile, wfs.SyncWriterFile and
+// wfs.AbortWriterFile instead of asserting *os.File.
+func (fsys *OSFS) CreateFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+	return fsys.createFile(wfs.OpCreateFile, name, mode, nil)
 }
 
 // WriteFile writes the specified bytes to the named file.
 func (fsys *OSFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
-	f, err := fsys.CreateFile(name, mode)
+	return fsys.WriteFileWithMeta(name, p, mode, nil)
+}
+
+func (fsys *OSFS) createFile(op wfs.Op, name string, mode fs.FileMode, meta wfs.Metadata) (*file, error) {
+	if isInvalidPath(name) {
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
+	}
+	path := filepath.Join(fsys.Dir, name)
+	err := fsys.mkdirParent(path, mode)
+	if err != nil {
+		return nil, osError(wfs.PathError(op, name, err))
+	}
+	if !fsys.stagedCreate {
+		var f *os.File
+		if fsys.fileMode {
+			f, err = fsys.openFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, mode)
+		} else {
+			f, err = fsys.createFunc(path)
+		}
+		if err != nil {
+			return nil, osError(wfs.PathError(op, name, err))
+		}
+		return &file{File: f, name: name, path: path, meta: fsys.meta, m: meta}, nil
+	}
+	info, err := os.Stat(path)
+	if err == nil && info.IsDir() {
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: syscall.EISDIR}
+	}
+	tmp, err := tempPath(path)
+	if err != nil {
+		return nil, wfs.PathError(op, name, err)
+	}
+	var f *os.File
+	if fsys.fileMode {
+		f, err = fsys.openFile(tmp, os.O_RDWR|os.O_CREATE|os.O_EXCL, mode)
+	} else if f, err = fsys.createFunc(tmp); err == nil && info != nil {
+		// NOTE: Keep the mode of the file to replace like os.Create.
+		if err = f.Chmod(info.Mode().Perm()); err != nil {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}
+	if err != nil {
+		return nil, osError(wfs.PathError(op, name, err))
+	}
+	return &file{File: f, name: name, path: path, tmp: tmp, meta: fsys.meta, m: meta}, nil
+}
+
+// CreateFileWithMeta creates the named file with the specified metadata.
+func (fsys *OSFS) CreateFileWithMeta(name string, mode fs.FileMode, meta wfs.Metadata) (wfs.WriterFile, error) {
+	return fsys.createFile(wfs.OpCreateFile, name, mode, meta)
+}
+
+// WriteFileWithMeta writes the specified bytes and metadata to the named file.
+func (fsys *OSFS) WriteFileWithMeta(name string, p []byte, mode fs.FileMode, meta wfs.Metadata) (int, error) {
+	f, err := fsys.createFile(wfs.OpWriteFile, name, mode, meta)
 	if err != nil {
 		return 0, err
 	}
-	defer f.Close()
+	n, err := f.Write(p)
+	if err != nil {
+		f.Abort()
+		return n, err
+	}
+	return n, f.Close()
+}
+
+// StatMeta returns the metadata of the named file.
+func (fsys *OSFS) StatMeta(name string) (wfs.Metadata, error) {
+	if _, err := fsys.Stat(name); err != nil {
+		return nil, err
+	}
+	return fsys.meta.get(filepath.Join(fsys.Dir, name)), nil
+}
+
+// WriteFileIf writes the specified bytes to the named file if cond is
+// satisfied. The bytes are written to a temporary file that replaces the
+// named file by a rename, or is linked to the name for IfNotExists so that an
+// existing file is not replaced even by another process. The check of cond
+// and the replacement are serialized within fsys and the filesystems returned
+// by its Sub but not across processes. The file is created with the
+// permission bits of mode masked by WithFileModeMask or the umask.
+//
+// The version of a file is made of its inode, creation time, modification
+// time and size. A file written by fsys is a new file, so the version changes
+// on every write even if the content is the same.
+func (fsys *OSFS) WriteFileIf(name string, p []byte, mode fs.FileMode, cond wfs.Condition) (string, error) {
+	if isInvalidPath(name) {
+		return "", &fs.PathError{Op: string(wfs.OpWriteFileIf), Path: name, Err: fs.ErrInvalid}
+	}
+	fsys.condMutex.Lock()
+	defer fsys.condMutex.Unlock()
 
-	return f.Write(p)
+	path := filepath.Join(fsys.Dir, name)
+	info, err := os.Stat(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", osError(wfs.PathError(wfs.OpWriteFileIf, name, err))
+	}
+	exists := err == nil
+	if cond.IfNotExists && exists || cond.IfMatch != "" && (!exists || fileVersion(info) != cond.IfMatch) {
+		return "", &fs.PathError{Op: string(wfs.OpWriteFileIf), Path: name, Err: wfs.ErrPreconditionFailed}
+	}
+	if err := fsys.mkdirParent(path, mode); err != nil {
+		return "", osError(wfs.PathError(wfs.OpWriteFileIf, name, err))
+	}
+	tmp, err := fsys.writeTemp(path, p, mode)
+	if err != nil {
+		return "", osError(wfs.PathError(wfs.OpWriteFileIf, name, err))
+	}
+	defer os.Remove(tmp)
+
+	// NOTE: Rename and Link keep the version of the temporary file.
+	info, err = os.Stat(tmp)
+	if err != nil {
+		return "", osError(wfs.PathError(wfs.OpWriteFileIf, name, err))
+	}
+	if cond.IfNotExists {
+		err = os.Link(tmp, path)
+	} else {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return "", &fs.PathError{Op: string(wfs.OpWriteFileIf), Path: name, Err: wfs.ErrPreconditionFailed}
+		}
+		return "", osError(wfs.PathError(wfs.OpWriteFileIf, name, err))
+	}
+	fsys.meta.remove(path)
+	return fileVersion(info), nil
+}
+
+// writeTemp writes p to a new temporary file in the directory of path with
+// mode and returns the path of the temporary file.
+func (fsys *OSFS) writeTemp(path string, p []byte, mode fs.FileMode) (string, error) {
+	tmp, err := tempPath(path)
+	if err != nil {
+		return "", err
+	}
+	flag := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	var f *os.File
+	if fsys.fileMode {
+		f, err = fsys.openFile(tmp, flag, mode)
+	} else {
+		f, err = fsys.openFileFunc(tmp, flag, mode.Perm())
+	}
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(p)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
+
+// fileVersion returns the version of a file.
+func fileVersion(info fs.FileInfo) string {
+	id, _ := fileID(info.Sys())
+	var birth int64
+	if t, ok := birthTime(info.Sys()); ok {
+		birth = t.UnixNano()
+	}
+	return fmt.Sprintf("%x-%x-%x-%x", id, birth, info.ModTime().UnixNano(), info.Size())
+}
+
+// FileVersion returns the current version of the named file.
+func (fsys *OSFS) FileVersion(name string) (string, error) {
+	if isInvalidPath(name) {
+		return "", &fs.PathError{Op: string(wfs.OpFileVersion), Path: name, Err: fs.ErrInvalid}
+	}
+	info, err := os.Stat(filepath.Join(fsys.Dir, name))
+	if err != nil {
+		return "", osError(wfs.PathError(wfs.OpFileVersion, name, err))
+	}
+	if info.IsDir() {
+		return "", &fs.PathError{Op: string(wfs.OpFileVersion), Path: name, Err: syscall.EISDIR}
+	}
+	return fileVersion(info), nil
 }
 
 // RemoveFile removes the specified named file.
 func (fsys *OSFS) RemoveFile(name string) error {
 	if isInvalidPath(name) {
-		return &fs.PathError{Op: "Remove", Path: name, Err: fs.ErrInvalid}
+		return &fs.PathError{Op: string(wfs.OpRemoveFile), Path: name, Err: fs.ErrInvalid}
 	}
-	return osRemoveFunc(filepath.Join(fsys.Dir, name))
+	path := filepath.Join(fsys.Dir, name)
+	if err := fsys.removeFunc(path); err != nil {
+		return osError(wfs.PathError(wfs.OpRemoveFile, name, err))
+	}
+	fsys.meta.remove(path)
+	fsys.hashes.removeAll(path)
+	return nil
 }
 
 // RemoveAll removes path and any children it contains.
 func (fsys *OSFS) RemoveAll(path string) error {
 	if isInvalidPath(path) {
-		return &fs.PathError{Op: "RemoveAll", Path: path, Err: fs.ErrInvalid}
+		return &fs.PathError{Op: string(wfs.OpRemoveAll), Path: path, Err: fs.ErrInvalid}
+	}
+	osPath := filepath.Join(fsys.Dir, path)
+	if err := fsys.removeAllFunc(osPath); err != nil {
+		// NOTE: A parent of path is a file so that path does not exist.
+		if !errors.Is(err, syscall.ENOTDIR) {
+			return osError(wfs.PathError(wfs.OpRemoveAll, path, err))
+		}
+	}
+	fsys.meta.removeAll(osPath)
+	fsys.hashes.removeAll(osPath)
+	return nil
+}
+
+// URL returns a file URL of the named file. The method and expiry are ignored.
+func (fsys *OSFS) URL(name string, method string, expiry time.Duration) (string, error) {
+	if isInvalidPath(name) {
+		return "", &fs.PathError{Op: string(wfs.OpURL), Path: name, Err: fs.ErrInvalid}
+	}
+	path, err := filepath.Abs(filepath.Join(fsys.Dir, name))
+	if err != nil {
+		return "", err
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
 	}
-	return osRemoveAllFunc(filepath.Join(fsys.Dir, path))
+	u := &url.URL{Scheme: "file", Path: path}
+	return u.String(), nil
 }
@@ -1,16 +1,23 @@
 package osfs
 
 import (
+	"crypto/md5"
 	"errors"
+	"fmt"
 	"io/fs"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
+	"sort"
+	"strings"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/jarxorg/wfs"
+	"github.com/jarxorg/wfs/memfs"
 	"github.com/jarxorg/wfs/wfstest"
 )
 
@@ -34,6 +41,251 @@ func TestWriteFileFS(t *testing.T) {
 	}
 }
 
+func TestConditionalWriteFS(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(filepath.Dir(tmpDir))
+	if err := wfstest.TestConditionalWriteFS(fsys, filepath.Base(tmpDir)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGlobFS(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(filepath.Dir(tmpDir))
+	if err := wfstest.TestGlobFS(fsys, filepath.Base(tmpDir)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOrdering(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(filepath.Dir(tmpDir))
+	if err := wfstest.TestOrdering(fsys, filepath.Base(tmpDir)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteFileIf_SameContent(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(tmpDir)
+	v1, err := fsys.WriteFileIf("a.txt", []byte("a"), fs.ModePerm, wfs.Condition{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := fsys.WriteFileIf("a.txt", []byte("b"), fs.ModePerm, wfs.Condition{IfMatch: v1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v3, err := fsys.WriteFileIf("a.txt", []byte("a"), fs.ModePerm, wfs.Condition{IfMatch: v2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v3 == v1 {
+		t.Errorf("unexpected %s; want a new version", v3)
+	}
+	if _, err := fsys.WriteFileIf("a.txt", []byte("c"), fs.ModePerm, wfs.Condition{IfMatch: v1}); !errors.Is(err, wfs.ErrPreconditionFailed) {
+		t.Errorf("unexpected %v; want %v", err, wfs.ErrPreconditionFailed)
+	}
+	if got, err := fsys.FileVersion("a.txt"); err != nil {
+		t.Fatal(err)
+	} else if got != v3 {
+		t.Errorf("unexpected %s; want %s", got, v3)
+	}
+}
+
+func TestPathErrorOps(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(filepath.Dir(tmpDir))
+	if err := wfstest.TestPathErrorOps(fsys, filepath.Base(tmpDir)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoveFileFS(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(filepath.Dir(tmpDir))
+	if err := wfstest.TestRemoveFileFS(fsys, filepath.Base(tmpDir)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubFS(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(filepath.Dir(tmpDir))
+	if err := wfstest.TestSub(fsys, filepath.Base(tmpDir)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadDirFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(filepath.Dir(tmpDir))
+	if err := wfstest.TestReadDirFile(fsys, filepath.Base(tmpDir)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadDirPage(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(tmpDir)
+	for i := 0; i < 5; i++ {
+		if _, err := fsys.WriteFile(fmt.Sprintf("dir/%d.txt", i), []byte{}, fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	pages := 0
+	err = wfs.ReadDirPage(fsys, "dir", 2, func(entries []fs.DirEntry) error {
+		if len(entries) > 2 {
+			t.Errorf("unexpected %d entries; want <= 2", len(entries))
+		}
+		pages++
+		for _, e := range entries {
+			got = append(got, e.Name())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	want := []string{"0.txt", "1.txt", "2.txt", "3.txt", "4.txt"}
+	if !reflect.DeepEqual(got, want) || pages != 3 {
+		t.Errorf("unexpected %v in %d pages; want %v in 3 pages", got, pages, want)
+	}
+
+	if err := fsys.ReadDirPage("not-found", 2, func([]fs.DirEntry) error { return nil }); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	if err := fsys.ReadDirPage("../invalid", 2, func([]fs.DirEntry) error { return nil }); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(tmpDir)
+	for _, name := range []string{"a.txt", "dir/b.txt", "empty/"} {
+		if strings.HasSuffix(name, "/") {
+			if err := fsys.MkdirAll(name[:len(name)-1], fs.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if _, err := fsys.WriteFile(name, []byte(name), fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mfs, m, err := Snapshot(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wfstest.AssertFSMatchesDir(t, mfs, tmpDir)
+	if len(m.Files) != 2 {
+		t.Errorf("unexpected %v; want 2 files", m.Files)
+	}
+
+	names, err := wfs.VerifyManifest(fsys, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 0 {
+		t.Errorf("unexpected %v; want no names", names)
+	}
+	if _, err := fsys.WriteFile("dir/b.txt", []byte("changed"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	names, err = wfs.VerifyManifest(fsys, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"dir/b.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("unexpected %v; want %v", names, want)
+	}
+
+	if _, _, err := Snapshot(filepath.Join(tmpDir, "not-found")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestRandomOps(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		tmpDir, err := ioutil.TempDir("", "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		fsys := New(filepath.Dir(tmpDir))
+		if err := wfstest.TestRandomOps(fsys, memfs.New(), filepath.Base(tmpDir), seed, 200); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFS(b *testing.B) {
+	wfstest.BenchmarkFS(b, func(b *testing.B) fs.FS {
+		tmpDir, err := ioutil.TempDir("", "bench")
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(func() { os.RemoveAll(tmpDir) })
+		return New(tmpDir)
+	})
+}
+
 func TestMkdirAll(t *testing.T) {
 	tmpDir, err := ioutil.TempDir("", "test")
 	if err != nil {
@@ -68,30 +320,194 @@ func TestCreateFile(t *testing.T) {
 	defer got.Close()
 }
 
-func TestCreateFile_MkdirAllError(t *testing.T) {
+func TestCreateFile_StrictCreate(t *testing.T) {
 	tmpDir, err := ioutil.TempDir("", "test")
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer os.RemoveAll(tmpDir)
 
-	orgMkdirAllFunc := osMkdirAllFunc
-	defer func() { osMkdirAllFunc = orgMkdirAllFunc }()
+	fsys := New(tmpDir, WithStrictCreate())
+	if _, err := fsys.CreateFile("dir/a.txt", fs.ModePerm); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	cond := wfs.Condition{IfNotExists: true}
+	if _, err := fsys.WriteFileIf("dir/a.txt", []byte{}, fs.ModePerm, cond); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
 
-	wantErr := errors.New("test")
-	osMkdirAllFunc = func(dir string, perm os.FileMode) error {
-		return wantErr
+	if err := fsys.MkdirAll("dir", fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	sub, err := fsys.Sub("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wfs.WriteFile(sub, "a.txt", []byte{}, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wfs.WriteFile(sub, "sub/a.txt", []byte{}, fs.ModePerm); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
 	}
+}
 
-	fsys := DirFS(tmpDir)
+func TestWithDirMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not supported on windows")
+	}
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(tmpDir, WithDirMode(0700))
+	if err := fsys.MkdirAll("a/b", fs.ModeDir|fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("c/d.txt", []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"a", "a/b", "c"} {
+		info, err := os.Stat(filepath.Join(tmpDir, dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != 0700 {
+			t.Errorf("%s: unexpected %v; want %v", dir, got, fs.FileMode(0700))
+		}
+	}
+}
+
+func TestWithFileModeMask(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not supported on windows")
+	}
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	testCases := []struct {
+		mask fs.FileMode
+		mode fs.FileMode
+		want fs.FileMode
+	}{
+		{mask: 0022, mode: fs.ModePerm, want: 0755},
+		{mask: 0, mode: 0600, want: 0600},
+		{mask: 0077, mode: 0666, want: 0600},
+	}
+	for i, tc := range testCases {
+		fsys := New(tmpDir, WithFileModeMask(tc.mask))
+		name := fmt.Sprintf("%d.txt", i)
+		f, err := fsys.CreateFile(name, tc.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+		cond := wfs.Condition{IfNotExists: true}
+		if _, err := fsys.WriteFileIf("if-"+name, []byte{}, tc.mode, cond); err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range []string{name, "if-" + name} {
+			info, err := os.Stat(filepath.Join(tmpDir, n))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.Mode().Perm(); got != tc.want {
+				t.Errorf("%s: unexpected %v; want %v", n, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestCreateFile_MkdirAllError(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	wantErr := errors.New("test")
+	fsys := New(tmpDir, WithMkdirAllFunc(func(dir string, perm os.FileMode) error {
+		return wantErr
+	}))
 	var gotErr error
 	_, gotErr = wfs.CreateFile(fsys, "name.txt", fs.ModePerm)
 
-	if gotErr.Error() != wantErr.Error() {
+	if !errors.Is(gotErr, wantErr) {
 		t.Errorf("unexpected %v; want %v", gotErr, wantErr)
 	}
 }
 
+func TestOptions_Hooks(t *testing.T) {
+	wantErr := errors.New("test")
+	testCases := map[string]struct {
+		opt Option
+		fn  func(fsys fs.FS) error
+	}{
+		"create": {
+			opt: WithCreateFunc(func(name string) (*os.File, error) { return nil, wantErr }),
+			fn: func(fsys fs.FS) error {
+				_, err := wfs.WriteFile(fsys, "a.txt", []byte("a"), fs.ModePerm)
+				return err
+			},
+		},
+		"openFile": {
+			opt: WithOpenFileFunc(func(name string, flag int, perm os.FileMode) (*os.File, error) { return nil, wantErr }),
+			fn: func(fsys fs.FS) error {
+				_, err := wfs.WriteFileIf(fsys, "a.txt", []byte("a"), fs.ModePerm, wfs.Condition{IfNotExists: true})
+				return err
+			},
+		},
+		"remove": {
+			opt: WithRemoveFunc(func(name string) error { return wantErr }),
+			fn: func(fsys fs.FS) error {
+				return wfs.RemoveFile(fsys, "a.txt")
+			},
+		},
+		"removeAll": {
+			opt: WithRemoveAllFunc(func(path string) error { return wantErr }),
+			fn: func(fsys fs.FS) error {
+				return wfs.RemoveAll(fsys, "a.txt")
+			},
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir, err := ioutil.TempDir("", "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmpDir)
+			if err := os.Mkdir(filepath.Join(tmpDir, "sub"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			fsys := New(tmpDir, tc.opt)
+			if err := tc.fn(fsys); !errors.Is(err, wantErr) {
+				t.Errorf("unexpected %v; want %v", err, wantErr)
+			}
+			sub, err := fsys.Sub("sub")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.fn(sub); !errors.Is(err, wantErr) {
+				t.Errorf("sub: unexpected %v; want %v", err, wantErr)
+			}
+			if err := tc.fn(New(tmpDir)); errors.Is(err, wantErr) {
+				t.Errorf("unexpected hook of another instance")
+			}
+		})
+	}
+}
+
 func TestWriteFile(t *testing.T) {
 	tmpDir, err := ioutil.TempDir("", "test")
 	if err != nil {
@@ -120,6 +536,34 @@ func TestWriteFile(t *testing.T) {
 	}
 }
 
+func TestCopyFile_SameName(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	name := "b.txt"
+	want := []byte("test")
+	fsys := DirFS(tmpDir)
+	if _, err := wfs.WriteFile(fsys, name, want, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.CopyFile(fsys, name, name); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.MoveFile(fsys, name, name); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(tmpDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %s; want %s", got, want)
+	}
+}
+
 func TestWriteFile_InvalidError(t *testing.T) {
 	tmpDir, err := ioutil.TempDir("", "test")
 	if err != nil {
@@ -154,6 +598,9 @@ func TestContainsDenyWin(t *testing.T) {
 		}, {
 			name: `path\to\deny.txt`,
 			want: true,
+		}, {
+			name: "path/to/CON.txt",
+			want: true,
 		},
 	}
 	for i, testCase := range testCases {
@@ -176,6 +623,12 @@ func TestSub_WriteFile(t *testing.T) {
 	name := "test.txt"
 	want := []byte("test")
 
+	if _, err := fs.Sub(DirFS(tmpDir), dir); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	if err := os.Mkdir(filepath.Join(tmpDir, dir), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
 	fsys, err := fs.Sub(DirFS(tmpDir), dir)
 	if err != nil {
 		t.Fatal(err)
@@ -268,3 +721,512 @@ func TestRemoveAll_InvalidError(t *testing.T) {
 		t.Fatal("no error")
 	}
 }
+
+func TestURL(t *testing.T) {
+	dir, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := New("testdata")
+
+	got, err := wfs.URL(fsys, "dir0/file01.txt", "GET", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "file://" + filepath.ToSlash(dir) + "/dir0/file01.txt"
+	if got != want {
+		t.Errorf("unexpected %s; want %s", got, want)
+	}
+
+	_, err = fsys.URL("../invalid", "GET", time.Minute)
+	if !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(tmpDir)
+	name := "dir/test.txt"
+	want := wfs.Metadata{wfs.MetaContentType: "text/plain"}
+
+	if _, err := wfs.WriteFileWithMeta(fsys, name, []byte("test"), fs.ModePerm, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := wfs.StatMeta(fsys, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+
+	sub, err := fsys.Sub("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = wfs.StatMeta(sub, "test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+
+	if err := fsys.RemoveAll("dir"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wfs.StatMeta(fsys, name); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	if got := fsys.meta.get(filepath.Join(tmpDir, name)); got != nil {
+		t.Errorf("unexpected %v", got)
+	}
+}
+
+func TestStat_Hash(t *testing.T) {
+	fsys := New("testdata")
+	name := "dir0/file01.txt"
+
+	info, err := fsys.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, ok := info.(wfs.HashedInfo)
+	if !ok {
+		t.Fatalf("unexpected %T", info)
+	}
+	p, err := fsys.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := md5.Sum(p)
+	if algo, sum := h.Hash(); algo != wfs.HashMD5 || !reflect.DeepEqual(sum, want[:]) {
+		t.Errorf("unexpected %s %x; want %s %x", algo, sum, wfs.HashMD5, want)
+	}
+
+	info, err = fsys.Stat("dir0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if algo, sum := info.(wfs.HashedInfo).Hash(); algo != "" || sum != nil {
+		t.Errorf("unexpected %s %x", algo, sum)
+	}
+}
+
+func TestStat_HashCache(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(tmpDir)
+	hash := func() []byte {
+		info, err := fsys.Stat("a.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, sum := info.(wfs.HashedInfo).Hash()
+		return sum
+	}
+	for _, data := range []string{"a", "b"} {
+		if _, err := fsys.WriteFile("a.txt", []byte(data), fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		want := md5.Sum([]byte(data))
+		for i := 0; i < 2; i++ {
+			if got := hash(); !reflect.DeepEqual(got, want[:]) {
+				t.Errorf("unexpected %x; want %x", got, want)
+			}
+		}
+	}
+	if got := len(fsys.hashes.values); got != 1 {
+		t.Errorf("unexpected %d cached hashes; want 1", got)
+	}
+	if err := fsys.RemoveFile("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(fsys.hashes.values); got != 0 {
+		t.Errorf("unexpected %d cached hashes; want 0", got)
+	}
+}
+
+func TestHashCache_Size(t *testing.T) {
+	c := newHashCache()
+	c.size = 2
+	c.put("a", "1", []byte("a"))
+	c.put("b", "1", []byte("b"))
+	if _, ok := c.get("a", "1"); !ok {
+		t.Fatal("a is not cached")
+	}
+	c.put("c", "1", []byte("c"))
+	if got := len(c.values); got != 2 {
+		t.Errorf("unexpected %d cached hashes; want 2", got)
+	}
+	if _, ok := c.get("b", "1"); ok {
+		t.Errorf("least recently used b is cached")
+	}
+	for _, path := range []string{"a", "c"} {
+		if _, ok := c.get(path, "1"); !ok {
+			t.Errorf("%s is not cached", path)
+		}
+	}
+}
+
+func TestStat_BirthTime(t *testing.T) {
+	fsys := New("testdata")
+	info, err := fsys.Stat("dir0/file01.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	birth, ok := wfs.BirthTime(info)
+	switch runtime.GOOS {
+	case "darwin", "freebsd", "netbsd", "windows":
+		if !ok || birth.IsZero() {
+			t.Errorf("unexpected %v %v; want non-zero true", birth, ok)
+		}
+	default:
+		if ok {
+			t.Errorf("unexpected %v %v; want false", birth, ok)
+		}
+	}
+}
+
+func TestOpenRange(t *testing.T) {
+	fsys := New("testdata")
+	name := "dir0/file01.txt"
+	p, err := fsys.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		off, length int64
+		want        string
+	}{
+		{off: 0, length: -1, want: string(p)},
+		{off: 2, length: 3, want: string(p[2:5])},
+		{off: 2, length: 100, want: string(p[2:])},
+		{off: 100, length: 3, want: ""},
+	}
+	for i, tc := range testCases {
+		r, err := fsys.OpenRange(name, tc.off, tc.length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("tests[%d] unexpected %q; want %q", i, got, tc.want)
+		}
+	}
+
+	if _, err := fsys.OpenRange(name, -1, 1); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+	_, err = fsys.OpenRange("not-found.txt", 0, 1)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	want := &fs.PathError{Op: string(wfs.OpOpenRange), Path: "not-found.txt", Err: errors.Unwrap(err)}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("unexpected %v; want %v", err, want)
+	}
+}
+
+func TestCreateFile_WriteAt(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(tmpDir)
+	f, err := fsys.CreateFile("test.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wfs.WriteAt(f, []byte("bbb"), 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wfs.WriteAt(f, []byte("aaa"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := fsys.ReadFile("test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "aaabbb" {
+		t.Errorf("unexpected %s; want aaabbb", got)
+	}
+}
+
+func TestCreateFile_SparseFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(tmpDir)
+	f, err := fsys.CreateFile("test.img", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("abcdefgh")); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.Truncate(f, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.PunchHole(f, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.PunchHole(f, -1, 3); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := fsys.ReadFile("test.img")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte("ab\x00\x00\x00fgh\x00\x00")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %q; want %q", got, want)
+	}
+}
+
+func TestCreateFile_Abort(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(tmpDir)
+	f, err := fsys.CreateFile("test.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+	if err := wfs.Abort(fsys, "test.txt", f); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("test.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestCreateFile_StagedAbortKeepsExisting(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(tmpDir, WithStagedCreate())
+	meta := wfs.Metadata{"k": "original"}
+	if _, err := fsys.WriteFileWithMeta("test.txt", []byte("original"), fs.ModePerm, meta); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fsys.CreateFileWithMeta("test.txt", fs.ModePerm, wfs.Metadata{"k": "partial"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := f.Stat(); err != nil {
+		t.Fatal(err)
+	} else if info.Name() != "test.txt" {
+		t.Errorf("unexpected %s; want %s", info.Name(), "test.txt")
+	}
+
+	want := []string{"test.txt"}
+	entries, err := fsys.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := entryNames(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected ReadDir %v; want %v", got, want)
+	}
+	if got, err := fsys.Glob("*"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected Glob %v; want %v", got, want)
+	}
+	var walked []string
+	err = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			walked = append(walked, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(walked, want) {
+		t.Errorf("unexpected WalkDir %v; want %v", walked, want)
+	}
+	var paged []string
+	err = fsys.ReadDirPage(".", 1, func(entries []fs.DirEntry) error {
+		paged = append(paged, entryNames(entries)...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(paged, want) {
+		t.Errorf("unexpected ReadDirPage %v; want %v", paged, want)
+	}
+
+	if err := wfs.Abort(fsys, "test.txt", f); err != nil {
+		t.Fatal(err)
+	}
+	got, err := fs.ReadFile(fsys, "test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "original" {
+		t.Errorf("unexpected %q; want %q", got, "original")
+	}
+	if got, err := fsys.StatMeta("test.txt"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got, meta) {
+		t.Errorf("unexpected %v; want %v", got, meta)
+	}
+
+	f, err = fsys.CreateFile("test.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("replaced")); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := fs.ReadFile(fsys, "test.txt"); string(got) != "original" {
+		t.Errorf("unexpected %q before Close; want %q", got, "original")
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := fs.ReadFile(fsys, "test.txt"); string(got) != "replaced" {
+		t.Errorf("unexpected %q; want %q", got, "replaced")
+	}
+	if got, _ := fsys.StatMeta("test.txt"); got != nil {
+		t.Errorf("unexpected %v; want nil", got)
+	}
+}
+
+func entryNames(entries []fs.DirEntry) []string {
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestCreateFile_ErrorName(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithStagedCreate()}} {
+		tmpDir, err := ioutil.TempDir("", "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		fsys := New(tmpDir, opts...)
+		f, err := fsys.CreateFile("dir/test.txt", fs.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+		_, err = f.Write([]byte("closed"))
+		var pe *fs.PathError
+		if !errors.As(err, &pe) {
+			t.Fatalf("unexpected %v; want *fs.PathError", err)
+		}
+		if pe.Path != "dir/test.txt" {
+			t.Errorf("unexpected %s; want %s", pe.Path, "dir/test.txt")
+		}
+	}
+}
+
+func TestCreateFile_AbortMeta(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := New(tmpDir)
+	f, err := fsys.CreateFileWithMeta("test.txt", fs.ModePerm, wfs.Metadata{"k": "v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := fsys.StatMeta("test.txt"); err != nil {
+		t.Fatal(err)
+	} else if got != nil {
+		t.Errorf("unexpected %v before Close; want nil", got)
+	}
+	if err := wfs.Abort(fsys, "test.txt", f); err != nil {
+		t.Fatal(err)
+	}
+	if got := fsys.meta.get(filepath.Join(tmpDir, "test.txt")); got != nil {
+		t.Errorf("unexpected %v; want nil", got)
+	}
+}
+
+func TestOSError(t *testing.T) {
+	type testCase struct {
+		err   error
+		errno error
+		want  error
+	}
+	var testCases []testCase
+	for _, errno := range readOnlyErrors {
+		testCases = append(testCases, testCase{err: &fs.PathError{Op: "open", Path: "a", Err: errno}, errno: errno, want: wfs.ErrReadOnly})
+	}
+	for _, errno := range quotaErrors {
+		testCases = append(testCases,
+			testCase{err: &fs.PathError{Op: "write", Path: "a", Err: errno}, errno: errno, want: wfs.ErrQuotaExceeded},
+			testCase{err: errno, errno: errno, want: wfs.ErrQuotaExceeded})
+	}
+	for _, tc := range testCases {
+		got := osError(tc.err)
+		if !errors.Is(got, tc.want) {
+			t.Errorf("unexpected %v; want %v", got, tc.want)
+		}
+		if !errors.Is(got, tc.errno) {
+			t.Errorf("unexpected %v; want %v", got, tc.errno)
+		}
+		if got.Error() != tc.err.Error() {
+			t.Errorf("unexpected %q; want %q", got.Error(), tc.err.Error())
+		}
+	}
+	err := &fs.PathError{Op: "open", Path: "a", Err: fs.ErrNotExist}
+	if got := osError(err); got != err {
+		t.Errorf("unexpected %v; want %v", got, err)
+	}
+	if osError(nil) != nil {
+		t.Errorf("unexpected not nil")
+	}
+}
@@ -0,0 +1,27 @@
+package osfs
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"github.com/jarxorg/wfs"
+)
+
+const (
+	fallocFlKeepSize  = 0x01
+	fallocFlPunchHole = 0x02
+)
+
+func punchHole(f *os.File, off, length int64) error {
+	err := syscall.Fallocate(int(f.Fd()), fallocFlPunchHole|fallocFlKeepSize, off, length)
+	if errors.Is(err, syscall.EOPNOTSUPP) {
+		// NOTE: *os.File does not implement wfs.SparseFile, so wfs.PunchHole
+		// writes zeros.
+		return wfs.PunchHole(f, off, length)
+	}
+	if err != nil {
+		return &os.PathError{Op: "PunchHole", Path: f.Name(), Err: err}
+	}
+	return nil
+}
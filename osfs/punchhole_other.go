@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package osfs
+
+import (
+	"os"
+
+	"github.com/jarxorg/wfs"
+)
+
+// punchHole writes zeros by wfs.PunchHole, because *os.File does not
+// implement wfs.SparseFile.
+func punchHole(f *os.File, off, length int64) error {
+	return wfs.PunchHole(f, off, length)
+}
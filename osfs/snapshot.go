@@ -0,0 +1,51 @@
+package osfs
+
+import (
+	"io/fs"
+
+	"github.com/jarxorg/wfs"
+	"github.com/jarxorg/wfs/memfs"
+)
+
+// Snapshot loads the regular files of the tree of the directory dir into a
+// new MemFS and returns it with the manifest of the loaded files, so a server
+// can serve the files from memory. The manifest is made from the loaded data,
+// so it can be used to detect that the directory has drifted:
+//
+//	names, err := wfs.VerifyManifest(osfs.New(dir), m)
+//
+// The returned MemFS is not synchronized with the directory and should be
+// treated as read-only.
+func Snapshot(dir string) (*memfs.MemFS, *wfs.Manifest, error) {
+	src := New(dir)
+	dest := memfs.New()
+	err := fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return dest.MkdirAll(name, fs.ModePerm)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		p, err := src.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		_, err = dest.WriteFile(name, p, info.Mode().Perm())
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := wfs.NewManifest(dest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dest, m, nil
+}
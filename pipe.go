@@ -0,0 +1,142 @@
+package wfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// PipeOption is an option for Pipe.
+type PipeOption func(o *pipeOptions)
+
+type pipeOptions struct {
+	retries       int
+	retryInterval time.Duration
+	onError       func(name string, err error)
+}
+
+// WithPipeRetries sets the number of retries of copying a file that fails.
+// The default is 3. The interval between the attempts increases linearly.
+func WithPipeRetries(n int) PipeOption {
+	return func(o *pipeOptions) {
+		o.retries = n
+	}
+}
+
+// WithPipeErrorHandler makes Pipe continue when copying a file fails after
+// the retries and call fn with the name and the error instead of stopping.
+// fn may be called concurrently.
+func WithPipeErrorHandler(fn func(name string, err error)) PipeOption {
+	return func(o *pipeOptions) {
+		o.onError = fn
+	}
+}
+
+// Pipe receives names of files from paths, such as the names of WatchEvents
+// or a queue, and copies the files from src to dest using workers goroutines
+// until paths is closed or ctx is done. At most workers files are copied at
+// once and the next name is not received until a worker is free, so a
+// producer is blocked on sending while dest is slow. A directory is created by
+// MkdirAll and a name that does not exist in src is removed from dest, so
+// removals are replicated as well.
+//
+// Copying a file that fails is retried. By default Pipe stops receiving at
+// the first file that fails after the retries and returns the error after the
+// running copies finish; WithPipeErrorHandler makes Pipe report the error and
+// continue. Pipe returns ctx.Err() if ctx is done.
+func Pipe(ctx context.Context, dest WriteFileFS, src fs.FS, paths <-chan string, workers int, opts ...PipeOption) error {
+	o := &pipeOptions{retries: 3, retryInterval: 100 * time.Millisecond}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, workers)
+	fail := func(name string, err error) {
+		if o.onError != nil {
+			o.onError(name, err)
+			return
+		}
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+loop:
+	for {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+		var name string
+		var ok bool
+		select {
+		case name, ok = <-paths:
+		case <-ctx.Done():
+		}
+		if !ok {
+			<-sem
+			break loop
+		}
+		wg.Add(1)
+		go func(name string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := pipeFile(ctx, dest, src, name, o); err != nil && ctx.Err() == nil {
+				fail(name, err)
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// pipeFile copies the named file with retries.
+func pipeFile(ctx context.Context, dest WriteFileFS, src fs.FS, name string, o *pipeOptions) error {
+	var err error
+	for i := 0; ; i++ {
+		if err = pipeFileOnce(dest, src, name); err == nil || i >= o.retries {
+			return err
+		}
+		t := time.NewTimer(o.retryInterval * time.Duration(i+1))
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+func pipeFileOnce(dest WriteFileFS, src fs.FS, name string) error {
+	info, err := fs.Stat(src, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return RemoveAll(dest, name, WithProtectRoot())
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return dest.MkdirAll(name, fs.ModePerm)
+	}
+	return copyFileTo(dest, name, src, name, info.Mode().Perm())
+}
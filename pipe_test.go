@@ -0,0 +1,219 @@
+package wfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// pipeDestFS is a WriteFileFS of a map that is safe for concurrent use.
+type pipeDestFS struct {
+	*FSDelegator
+	mutex sync.Mutex
+	files map[string][]byte
+}
+
+func newPipeDestFS(write func(name string) error) *pipeDestFS {
+	d := &pipeDestFS{FSDelegator: &FSDelegator{}, files: map[string][]byte{}}
+	d.MkdirAllFunc = func(dir string, mode fs.FileMode) error {
+		return nil
+	}
+	d.CreateFileFunc = func(name string, mode fs.FileMode) (WriterFile, error) {
+		var buf bytes.Buffer
+		return &FileDelegator{
+			WriteFunc: buf.Write,
+			CloseFunc: func() error {
+				if write != nil {
+					if err := write(name); err != nil {
+						return err
+					}
+				}
+				d.mutex.Lock()
+				defer d.mutex.Unlock()
+				d.files[name] = buf.Bytes()
+				return nil
+			},
+		}, nil
+	}
+	d.RemoveFileFunc = func(name string) error {
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		delete(d.files, name)
+		return nil
+	}
+	d.RemoveAllFunc = d.RemoveFileFunc
+	return d
+}
+
+func (d *pipeDestFS) names() []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var names []string
+	for name := range d.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sendPaths(names ...string) <-chan string {
+	paths := make(chan string, len(names))
+	for _, name := range names {
+		paths <- name
+	}
+	close(paths)
+	return paths
+}
+
+func withoutPipeRetryInterval(o *pipeOptions) {
+	o.retryInterval = 0
+}
+
+func TestPipe(t *testing.T) {
+	src := fstest.MapFS{
+		"a.txt":     {Data: []byte("a")},
+		"dir/b.txt": {Data: []byte("b")},
+		"dir/c.txt": {Data: []byte("c")},
+	}
+	dest := newPipeDestFS(nil)
+	dest.files["removed.txt"] = []byte("removed")
+
+	err := Pipe(context.Background(), dest, src, sendPaths("a.txt", "dir", "dir/b.txt", "dir/c.txt", "removed.txt"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a.txt", "dir/b.txt", "dir/c.txt"}
+	if got := dest.names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+	if got := string(dest.files["dir/b.txt"]); got != "b" {
+		t.Errorf("unexpected %q; want %q", got, "b")
+	}
+}
+
+func TestPipe_Backpressure(t *testing.T) {
+	src := fstest.MapFS{}
+	var names []string
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		src[name] = &fstest.MapFile{Data: []byte(name)}
+		names = append(names, name)
+	}
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	started := make(chan struct{}, len(names))
+	release := make(chan struct{})
+	dest := newPipeDestFS(func(name string) error {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+		started <- struct{}{}
+		<-release
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+		return nil
+	})
+
+	paths := make(chan string)
+	done := make(chan error)
+	go func() {
+		done <- Pipe(context.Background(), dest, src, paths, 2)
+	}()
+	paths <- names[0]
+	paths <- names[1]
+	<-started
+	<-started
+	select {
+	case paths <- names[2]:
+		t.Fatal("unexpected send while workers are busy")
+	default:
+	}
+	close(release)
+	for _, name := range names[2:] {
+		paths <- name
+	}
+	close(paths)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if maxRunning != 2 {
+		t.Errorf("unexpected %d; want %d", maxRunning, 2)
+	}
+	if got := dest.names(); !reflect.DeepEqual(got, names) {
+		t.Errorf("unexpected %v; want %v", got, names)
+	}
+}
+
+func TestPipe_Retry(t *testing.T) {
+	src := fstest.MapFS{"a.txt": {Data: []byte("a")}}
+	attempts := 0
+	dest := newPipeDestFS(func(name string) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+	if err := Pipe(context.Background(), dest, src, sendPaths("a.txt"), 1, withoutPipeRetryInterval); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Errorf("unexpected %d; want %d", attempts, 3)
+	}
+}
+
+func TestPipe_Error(t *testing.T) {
+	src := fstest.MapFS{
+		"a.txt": {Data: []byte("a")},
+		"b.txt": {Data: []byte("b")},
+	}
+	errWrite := errors.New("write")
+	write := func(name string) error {
+		if name == "a.txt" {
+			return errWrite
+		}
+		return nil
+	}
+
+	dest := newPipeDestFS(write)
+	err := Pipe(context.Background(), dest, src, sendPaths("a.txt", "b.txt"), 1,
+		WithPipeRetries(1), withoutPipeRetryInterval)
+	if !errors.Is(err, errWrite) {
+		t.Errorf("unexpected %v; want %v", err, errWrite)
+	}
+
+	dest = newPipeDestFS(write)
+	var failed []string
+	err = Pipe(context.Background(), dest, src, sendPaths("a.txt", "b.txt"), 1,
+		WithPipeRetries(0), WithPipeErrorHandler(func(name string, err error) {
+			failed = append(failed, name)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(failed, []string{"a.txt"}) {
+		t.Errorf("unexpected %v", failed)
+	}
+	if got := dest.names(); !reflect.DeepEqual(got, []string{"b.txt"}) {
+		t.Errorf("unexpected %v", got)
+	}
+}
+
+func TestPipe_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Pipe(ctx, newPipeDestFS(nil), fstest.MapFS{}, make(chan string), 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected %v; want %v", err, context.Canceled)
+	}
+}
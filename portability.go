@@ -0,0 +1,128 @@
+package wfs
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"syscall"
+	"unicode/utf8"
+)
+
+// PortabilityProfile describes the names that are valid on target platforms.
+type PortabilityProfile struct {
+	// Windows rejects the names that are invalid on Windows and SMB shares
+	// such as reserved device names, reserved characters and names ending
+	// with a dot or a space.
+	Windows bool
+	// UTF8 rejects names that are not valid UTF-8.
+	UTF8 bool
+	// MaxNameLen is the maximum length in bytes of each path element if it
+	// is positive.
+	MaxNameLen int
+	// MaxPathLen is the maximum length in bytes of a path if it is positive.
+	MaxPathLen int
+}
+
+var (
+	// PortabilityWindows is the profile of Windows and SMB shares.
+	PortabilityWindows = PortabilityProfile{Windows: true, UTF8: true, MaxNameLen: 255}
+	// PortabilityPOSIX is the profile of common POSIX filesystems.
+	PortabilityPOSIX = PortabilityProfile{MaxNameLen: 255, MaxPathLen: 4095}
+	// PortabilityS3 is the profile of object keys of Amazon S3.
+	PortabilityS3 = PortabilityProfile{UTF8: true, MaxPathLen: 1024}
+	// PortabilityAll is the intersection of the other profiles.
+	PortabilityAll = PortabilityProfile{Windows: true, UTF8: true, MaxNameLen: 255, MaxPathLen: 1024}
+)
+
+// Check returns an error of fs.ErrInvalid if the name is invalid by the
+// profile or syscall.ENAMETOOLONG if the name is too long.
+func (p PortabilityProfile) Check(name string) error {
+	if p.UTF8 && !utf8.ValidString(name) {
+		return fs.ErrInvalid
+	}
+	if p.MaxPathLen > 0 && len(name) > p.MaxPathLen {
+		return syscall.ENAMETOOLONG
+	}
+	for _, elem := range strings.Split(name, "/") {
+		if p.MaxNameLen > 0 && len(elem) > p.MaxNameLen {
+			return syscall.ENAMETOOLONG
+		}
+		if p.Windows && !validPortableElem(elem) {
+			return fs.ErrInvalid
+		}
+	}
+	return nil
+}
+
+// PortabilityFS is a filesystem that rejects names that are not portable by
+// a profile on MkdirAll, CreateFile and WriteFile, so a tree written on one
+// platform can be copied to the target platforms later. Reading is not
+// restricted.
+type PortabilityFS struct {
+	*FSDelegator
+	fsys    fs.FS
+	profile PortabilityProfile
+	dir     string
+}
+
+var (
+	_ WriteFileFS = (*PortabilityFS)(nil)
+	_ fs.SubFS    = (*PortabilityFS)(nil)
+)
+
+// NewPortabilityFS returns a PortabilityFS that checks names by profile.
+func NewPortabilityFS(fsys fs.FS, profile PortabilityProfile) *PortabilityFS {
+	return &PortabilityFS{
+		FSDelegator: DelegateFS(fsys),
+		fsys:        fsys,
+		profile:     profile,
+		dir:         ".",
+	}
+}
+
+func (fsys *PortabilityFS) check(op Op, name string) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
+	}
+	if err := fsys.profile.Check(path.Join(fsys.dir, name)); err != nil {
+		return &fs.PathError{Op: string(op), Path: name, Err: err}
+	}
+	return nil
+}
+
+// Sub returns a PortabilityFS corresponding to the subtree rooted at dir.
+// The maximum path length is checked for the path from the root of fsys.
+func (fsys *PortabilityFS) Sub(dir string) (fs.FS, error) {
+	sub, err := fs.Sub(fsys.fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	subFS := NewPortabilityFS(sub, fsys.profile)
+	subFS.dir = path.Join(fsys.dir, dir)
+	return subFS, nil
+}
+
+// MkdirAll creates the named directory if the name is portable.
+func (fsys *PortabilityFS) MkdirAll(dir string, mode fs.FileMode) error {
+	if err := fsys.check(OpMkdirAll, dir); err != nil {
+		return err
+	}
+	return MkdirAll(fsys.fsys, dir, mode)
+}
+
+// CreateFile creates the named file if the name is portable.
+func (fsys *PortabilityFS) CreateFile(name string, mode fs.FileMode) (WriterFile, error) {
+	if err := fsys.check(OpCreateFile, name); err != nil {
+		return nil, err
+	}
+	return CreateFile(fsys.fsys, name, mode)
+}
+
+// WriteFile writes the specified bytes to the named file if the name is
+// portable.
+func (fsys *PortabilityFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	if err := fsys.check(OpWriteFile, name); err != nil {
+		return 0, err
+	}
+	return WriteFile(fsys.fsys, name, p, mode)
+}
@@ -0,0 +1,75 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"syscall"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPortabilityProfile_Check(t *testing.T) {
+	long := strings.Repeat("a", 256)
+	testCases := []struct {
+		profile PortabilityProfile
+		name    string
+		want    error
+	}{
+		{profile: PortabilityAll, name: "dir/a.txt"},
+		{profile: PortabilityAll, name: "dir/CON.txt", want: fs.ErrInvalid},
+		{profile: PortabilityAll, name: "trailing./a.txt", want: fs.ErrInvalid},
+		{profile: PortabilityAll, name: "a\xff.txt", want: fs.ErrInvalid},
+		{profile: PortabilityAll, name: long, want: syscall.ENAMETOOLONG},
+		{profile: PortabilityAll, name: strings.Repeat("a/", 600), want: syscall.ENAMETOOLONG},
+		{profile: PortabilityPOSIX, name: "dir/CON.txt"},
+		{profile: PortabilityPOSIX, name: "a:b?"},
+		{profile: PortabilityPOSIX, name: long, want: syscall.ENAMETOOLONG},
+		{profile: PortabilityS3, name: long},
+		{profile: PortabilityS3, name: "a\xff", want: fs.ErrInvalid},
+		{profile: PortabilityWindows, name: "a:b", want: fs.ErrInvalid},
+	}
+	for _, tc := range testCases {
+		if err := tc.profile.Check(tc.name); !errors.Is(err, tc.want) {
+			t.Errorf("%.20q: unexpected %v; want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestPortabilityFS(t *testing.T) {
+	m := fstest.MapFS{}
+	fsys := NewPortabilityFS(newMapWriteFS(m), PortabilityAll)
+
+	if _, err := fsys.WriteFile("dir/a.txt", []byte("a"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("dir/aux", []byte("a"), fs.ModePerm); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+	if _, err := fsys.CreateFile("dir/a ", fs.ModePerm); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+	if err := fsys.MkdirAll("dir/a|b", fs.ModePerm); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+	if _, ok := m["dir/aux"]; ok {
+		t.Errorf("unexpected dir/aux")
+	}
+	if got, err := fs.ReadFile(fsys, "dir/a.txt"); err != nil || string(got) != "a" {
+		t.Errorf("unexpected %s, %v", got, err)
+	}
+
+	sub, err := fs.Sub(fsys, strings.Repeat("d", 200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if sub, err = fs.Sub(sub, strings.Repeat("d", 200)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = WriteFile(sub, strings.Repeat("a", 100), []byte{}, fs.ModePerm)
+	if !errors.Is(err, syscall.ENAMETOOLONG) {
+		t.Errorf("unexpected %v; want %v", err, syscall.ENAMETOOLONG)
+	}
+}
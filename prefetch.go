@@ -0,0 +1,32 @@
+package wfs
+
+import (
+	"io/fs"
+)
+
+// PrefetchFS is the interface implemented by a filesystem that can fetch files
+// ahead of reads, such as by warming a cache or issuing parallel requests to a
+// remote backend.
+type PrefetchFS interface {
+	fs.FS
+	// Prefetch starts fetching the named files. It may return before the files
+	// are fetched.
+	Prefetch(names []string) error
+}
+
+// Prefetch hints that the named files will be read soon, such as the assets
+// referenced by an HTML page that is being served. If the filesystem
+// implements PrefetchFS calls fsys.Prefetch, otherwise does nothing since
+// reading the files ahead would not make the later reads faster. Prefetch
+// returns a PathError of fs.ErrInvalid if a name is not valid.
+func Prefetch(fsys fs.FS, names []string) error {
+	for _, name := range names {
+		if !fs.ValidPath(name) {
+			return &fs.PathError{Op: string(OpPrefetch), Path: name, Err: fs.ErrInvalid}
+		}
+	}
+	if fsys, ok := fsys.(PrefetchFS); ok {
+		return fsys.Prefetch(names)
+	}
+	return nil
+}
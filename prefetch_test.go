@@ -0,0 +1,37 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+type prefetchMapFS struct {
+	fstest.MapFS
+	names []string
+}
+
+func (fsys *prefetchMapFS) Prefetch(names []string) error {
+	fsys.names = append(fsys.names, names...)
+	return nil
+}
+
+func TestPrefetch(t *testing.T) {
+	fsys := &prefetchMapFS{MapFS: fstest.MapFS{}}
+	want := []string{"index.html", "css/style.css"}
+	if err := Prefetch(fsys, want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fsys.names, want) {
+		t.Errorf("unexpected %v; want %v", fsys.names, want)
+	}
+
+	if err := Prefetch(fstest.MapFS{}, want); err != nil {
+		t.Errorf("unexpected %v", err)
+	}
+	if err := Prefetch(fsys, []string{"../index.html"}); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+}
@@ -0,0 +1,19 @@
+package wfs
+
+import "context"
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx that carries the id of the principal
+// performing operations, so middlewares such as ACLFS can attribute the
+// operations to the principal.
+func WithPrincipal(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, principalKey{}, id)
+}
+
+// PrincipalFrom returns the id of the principal carried by ctx. The second
+// value reports whether ctx carries a principal.
+func PrincipalFrom(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(principalKey{}).(string)
+	return id, ok
+}
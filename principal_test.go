@@ -0,0 +1,17 @@
+package wfs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPrincipal(t *testing.T) {
+	if _, ok := PrincipalFrom(context.Background()); ok {
+		t.Errorf("unexpected principal")
+	}
+	ctx := WithPrincipal(context.Background(), "alice")
+	got, ok := PrincipalFrom(ctx)
+	if !ok || got != "alice" {
+		t.Errorf("unexpected %s %v; want alice", got, ok)
+	}
+}
@@ -0,0 +1,100 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// PruneEmptyDirs removes the directories under root that are empty or contain
+// only empty directories. The root itself is not removed. The directories are
+// removed bottom-up by RemoveAll after checking that they are empty.
+func PruneEmptyDirs(fsys fs.FS, root string) error {
+	var dirs []string
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && name != root {
+			dirs = append(dirs, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	removeAll := func(dir string) error {
+		return RemoveAll(fsys, dir)
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if _, err := removeEmptyDir(fsys, removeAll, dirs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeEmptyDir removes the named directory by removeAll if it is empty and
+// reports whether the directory is removed. A directory that does not exist
+// such as an implicit directory of an object storage is reported as removed.
+func removeEmptyDir(fsys fs.FS, removeAll func(dir string) error, dir string) (bool, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil || len(entries) > 0 {
+		return false, err
+	}
+	if err := removeAll(dir); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// pruneParents removes the empty parent directories of name under root.
+func pruneParents(fsys fs.FS, removeAll func(dir string) error, name, root string) error {
+	for dir := path.Dir(name); dir != root && dir != "."; dir = path.Dir(dir) {
+		if root != "." && !strings.HasPrefix(dir, root+"/") {
+			return nil
+		}
+		removed, err := removeEmptyDir(fsys, removeAll, dir)
+		if err != nil || !removed {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithPruneEmptyDirs makes RemoveFile and RemoveAll of the wrapped filesystem
+// remove the parent directories under root that become empty, so trees on
+// object storages do not accumulate empty directory markers. The directories
+// are removed by the original RemoveAll, or RemoveFile if RemoveAll is not
+// available.
+func WithPruneEmptyDirs(root string) WrapOption {
+	return func(d *FSDelegator) {
+		removeFile, removeAll := d.RemoveFileFunc, d.RemoveAllFunc
+		// NOTE: RemoveFiles removes the files by RemoveFileFunc to prune.
+		d.RemoveFilesFunc = nil
+		removeDir := removeAll
+		if removeDir == nil {
+			removeDir = removeFile
+		}
+		if removeFile != nil {
+			d.RemoveFileFunc = func(name string) error {
+				if err := removeFile(name); err != nil {
+					return err
+				}
+				return pruneParents(d, removeDir, name, root)
+			}
+		}
+		if removeAll != nil {
+			d.RemoveAllFunc = func(name string) error {
+				if err := removeAll(name); err != nil {
+					return err
+				}
+				return pruneParents(d, removeDir, name, root)
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package wfs
+
+import (
+	"io/fs"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newPruneTestFS() (fstest.MapFS, *FSDelegator) {
+	m := fstest.MapFS{
+		"a/b/c":      {Mode: fs.ModeDir | 0755},
+		"a/b/f.txt":  {Data: []byte("f")},
+		"x/y":        {Mode: fs.ModeDir | 0755},
+		"keep/k.txt": {Data: []byte("k")},
+		"empty":      {Mode: fs.ModeDir | 0755},
+	}
+	fsys := newMapWriteFS(m)
+	fsys.RemoveAllFunc = func(dir string) error {
+		for name := range m {
+			if name == dir || strings.HasPrefix(name, dir+"/") {
+				delete(m, name)
+			}
+		}
+		return nil
+	}
+	return m, fsys
+}
+
+func mapNames(m fstest.MapFS) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestPruneEmptyDirs(t *testing.T) {
+	m, fsys := newPruneTestFS()
+	if err := PruneEmptyDirs(fsys, "."); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := mapNames(m), []string{"a/b/f.txt", "keep/k.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+
+	m, fsys = newPruneTestFS()
+	if err := PruneEmptyDirs(fsys, "a"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a/b/f.txt", "empty", "keep/k.txt", "x/y"}
+	if got := mapNames(m); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+}
+
+func TestWithPruneEmptyDirs(t *testing.T) {
+	m, d := newPruneTestFS()
+	m["keep/sub/s.txt"] = &fstest.MapFile{Data: []byte("s")}
+	m["keep/sub"] = &fstest.MapFile{Mode: fs.ModeDir | 0755}
+	fsys := Wrap(d, WithPruneEmptyDirs("keep"))
+
+	if err := RemoveFile(fsys, "keep/sub/s.txt"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a/b/c", "a/b/f.txt", "empty", "keep/k.txt", "x/y"}
+	if got := mapNames(m); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+	if err := RemoveFile(fsys, "a/b/f.txt"); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"a/b/c", "empty", "keep/k.txt", "x/y"}
+	if got := mapNames(m); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+
+	fsys = Wrap(d, WithPruneEmptyDirs("."))
+	if err := RemoveAll(fsys, "a/b/c"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveFile(fsys, "keep/k.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := mapNames(m), []string{"empty", "x/y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+}
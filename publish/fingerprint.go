@@ -0,0 +1,294 @@
+package publish
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jarxorg/wfs"
+)
+
+// ManifestName is the default name of the manifest written by Fingerprint.
+const ManifestName = "manifest.json"
+
+// fingerprintLen is the number of hex digits of a fingerprint.
+const fingerprintLen = 8
+
+// referenceRegexp matches the tokens in HTML and CSS that may reference an
+// asset such as the values of src and href attributes and url() functions.
+var referenceRegexp = regexp.MustCompile(`[^\s"'()<>,=]+`)
+
+// FingerprintOption is an option for Fingerprint.
+type FingerprintOption func(o *fingerprintOptions)
+
+type fingerprintOptions struct {
+	manifest string
+	rewrites []string
+}
+
+// WithManifest changes the name of the manifest from ManifestName. An empty
+// name disables writing the manifest.
+func WithManifest(name string) FingerprintOption {
+	return func(o *fingerprintOptions) {
+		o.manifest = name
+	}
+}
+
+// WithRewrite makes Fingerprint rewrite the references to the fingerprinted
+// assets in the files matching the patterns such as "*.html" and
+// "assets/*.css". Absolute references are resolved from the root of the
+// filesystem and relative references from the directory of the file.
+func WithRewrite(patterns ...string) FingerprintOption {
+	return func(o *fingerprintOptions) {
+		o.rewrites = append(o.rewrites, patterns...)
+	}
+}
+
+// Fingerprint renames the files matching the glob pattern to names that
+// contain the MD5 hash of the contents such as "app.3f2a9c1d.js" and writes
+// a JSON manifest that maps the original names to the fingerprinted names.
+// Matching files that are also rewritten by WithRewrite are fingerprinted
+// after rewriting their references to the other assets, and after the other
+// rewritten assets that they reference. Rewritten assets that reference each
+// other in a cycle are fingerprinted in order of name, so the references in
+// the cycle are rewritten after hashing. Hashed assets detected by
+// IsHashedAsset are not renamed again. Fingerprint returns the map written
+// to the manifest.
+func Fingerprint(fsys wfs.WriteFileFS, pattern string, opts ...FingerprintOption) (map[string]string, error) {
+	o := &fingerprintOptions{manifest: ManifestName}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	matches, err := wfs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := globAll(fsys, o.rewrites)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assets that reference other assets are fingerprinted after rewriting
+	// so that their names change when the referenced assets change.
+	names := map[string]string{}
+	var deferred []string
+	for _, name := range matches {
+		if IsHashedAsset(name) || name == o.manifest {
+			continue
+		}
+		if targets[name] {
+			deferred = append(deferred, name)
+			continue
+		}
+		if err := fingerprint(fsys, name, names); err != nil {
+			return nil, err
+		}
+	}
+	if err := rewriteFiles(fsys, targets, names); err != nil {
+		return nil, err
+	}
+	if len(deferred) > 0 {
+		if err := fingerprintDeferred(fsys, deferred, names); err != nil {
+			return nil, err
+		}
+		if targets, err = globAll(fsys, o.rewrites); err != nil {
+			return nil, err
+		}
+		if err := rewriteFiles(fsys, targets, names); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.manifest != "" {
+		data, err := json.MarshalIndent(names, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := wfs.WriteFileWithOptions(fsys, o.manifest, data, 0644); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// fingerprint renames the named file to the fingerprinted name and adds the
+// name to names. Directories are ignored.
+func fingerprint(fsys wfs.WriteFileFS, name string, names map[string]string) error {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return nil
+	}
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(data)
+	ext := path.Ext(name)
+	fingerprinted := strings.TrimSuffix(name, ext) + "." +
+		hex.EncodeToString(sum[:])[:fingerprintLen] + ext
+	if _, err := wfs.WriteFileWithOptions(fsys, fingerprinted, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := wfs.RemoveFile(fsys, name); err != nil {
+		return err
+	}
+	names[name] = fingerprinted
+	return nil
+}
+
+// fingerprintDeferred fingerprints the deferred assets after rewriting their
+// references. An asset is fingerprinted after the deferred assets that it
+// references unless they reference each other in a cycle.
+func fingerprintDeferred(fsys wfs.WriteFileFS, deferred []string, names map[string]string) error {
+	pending := map[string]bool{}
+	for _, name := range deferred {
+		pending[name] = true
+	}
+	for len(pending) > 0 {
+		next := ""
+		for _, name := range deferred {
+			if !pending[name] {
+				continue
+			}
+			if next == "" {
+				// NOTE: The first pending asset is used if all of them are in cycles.
+				next = name
+			}
+			ok, err := referencesAny(fsys, name, pending)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				next = name
+				break
+			}
+		}
+		if err := rewriteFiles(fsys, map[string]bool{next: true}, names); err != nil {
+			return err
+		}
+		if err := fingerprint(fsys, next, names); err != nil {
+			return err
+		}
+		delete(pending, next)
+	}
+	return nil
+}
+
+// referencesAny reports whether the named file references any of the other
+// names. Directories reference nothing.
+func referencesAny(fsys fs.FS, name string, names map[string]bool) (bool, error) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return false, err
+	}
+	if info.IsDir() {
+		return false, nil
+	}
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return false, err
+	}
+	for _, ref := range referenceRegexp.FindAll(data, -1) {
+		if ref, ok := resolveReference(string(ref), path.Dir(name)); ok && ref != name && names[ref] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// globAll returns the set of names matching the patterns.
+func globAll(fsys fs.FS, patterns []string) (map[string]bool, error) {
+	matches := map[string]bool{}
+	for _, pattern := range patterns {
+		m, err := wfs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range m {
+			matches[name] = true
+		}
+	}
+	return matches, nil
+}
+
+// rewriteFiles rewrites the references in the target files.
+func rewriteFiles(fsys wfs.WriteFileFS, targets map[string]bool, names map[string]string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	var sorted []string
+	for name := range targets {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		rewritten := rewriteReferences(data, path.Dir(name), names)
+		if string(rewritten) == string(data) {
+			continue
+		}
+		if _, err := wfs.WriteFileWithOptions(fsys, name, rewritten, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteReferences replaces the references to the original names in data
+// with the fingerprinted names keeping queries and fragments.
+func rewriteReferences(data []byte, dir string, names map[string]string) []byte {
+	return referenceRegexp.ReplaceAllFunc(data, func(ref []byte) []byte {
+		name, ok := resolveReference(string(ref), dir)
+		if !ok {
+			return ref
+		}
+		fingerprinted, ok := names[name]
+		if !ok {
+			return ref
+		}
+		s, suffix := splitReference(string(ref))
+		return []byte(s[:len(s)-len(path.Base(s))] + path.Base(fingerprinted) + suffix)
+	})
+}
+
+// splitReference splits ref into the path and the query or fragment.
+func splitReference(ref string) (string, string) {
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		return ref[:i], ref[i:]
+	}
+	return ref, ""
+}
+
+// resolveReference returns the name of the file referenced by ref in a file of
+// dir. Absolute references are resolved from the root of the filesystem.
+// resolveReference returns false if ref is not a path such as a URL.
+func resolveReference(ref, dir string) (string, bool) {
+	s, _ := splitReference(ref)
+	if s == "" || strings.Contains(s, "://") {
+		return "", false
+	}
+	if strings.HasPrefix(s, "/") {
+		return strings.TrimPrefix(path.Clean(s), "/"), true
+	}
+	return path.Join(dir, s), true
+}
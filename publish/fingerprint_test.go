@@ -0,0 +1,120 @@
+package publish
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jarxorg/wfs/memfs"
+)
+
+func TestFingerprint(t *testing.T) {
+	fsys := memfs.New()
+	if err := fsys.MkdirAll("assets", fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"index.html":             `<link href="/assets/app.css"><script src="assets/app.js?v=1"></script>`,
+		"assets/app.css":         `body { background: url(logo.png); }`,
+		"assets/app.js":          `app`,
+		"assets/logo.png":        `logo`,
+		"assets/lib.0123abcd.js": `lib`,
+	}
+	for name, data := range files {
+		if _, err := fsys.WriteFile(name, []byte(data), fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := Fingerprint(fsys, "assets/*", WithRewrite("*.html", "assets/*.css"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{}
+	for _, name := range []string{"assets/app.css", "assets/app.js", "assets/logo.png"} {
+		if got := names[name]; !IsHashedAsset(got) {
+			t.Errorf("%s: unexpected %s", name, got)
+		}
+		want[name] = names[name]
+		if _, err := fsys.Stat(name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+		}
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("unexpected %v; want %v", names, want)
+	}
+
+	data, err := fsys.ReadFile(ManifestName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest map[string]string
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(manifest, want) {
+		t.Errorf("unexpected %v; want %v", manifest, want)
+	}
+
+	rewrites := map[string]string{
+		"index.html": `<link href="/` + want["assets/app.css"] + `"><script src="` +
+			want["assets/app.js"] + `?v=1"></script>`,
+		want["assets/app.css"]: `body { background: url(` + want["assets/logo.png"][len("assets/"):] + `); }`,
+	}
+	for name, wantData := range rewrites {
+		data, err := fsys.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(data); got != wantData {
+			t.Errorf("%s: unexpected %s; want %s", name, got, wantData)
+		}
+	}
+
+	data, err = fsys.ReadFile(want["assets/app.css"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := md5.Sum(data)
+	if hash := hex.EncodeToString(sum[:])[:fingerprintLen]; !strings.Contains(want["assets/app.css"], hash) {
+		t.Errorf("unexpected %s; want the hash of the rewritten content %s", want["assets/app.css"], hash)
+	}
+}
+
+func TestFingerprint_DeferredReferences(t *testing.T) {
+	fsys := memfs.New()
+	if err := fsys.MkdirAll("assets", fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"assets/a.css":    `@import url(b.css);`,
+		"assets/b.css":    `body { background: url(logo.png); }`,
+		"assets/logo.png": `logo`,
+	}
+	for name, data := range files {
+		if _, err := fsys.WriteFile(name, []byte(data), fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := Fingerprint(fsys, "assets/*", WithRewrite("assets/*.css"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := fsys.ReadFile(names["assets/a.css"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `@import url(` + names["assets/b.css"][len("assets/"):] + `);`; string(data) != want {
+		t.Errorf("unexpected %s; want %s", data, want)
+	}
+	sum := md5.Sum(data)
+	if hash := hex.EncodeToString(sum[:])[:fingerprintLen]; !strings.Contains(names["assets/a.css"], hash) {
+		t.Errorf("unexpected %s; want the hash of the rewritten content %s", names["assets/a.css"], hash)
+	}
+}
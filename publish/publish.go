@@ -0,0 +1,214 @@
+// Package publish provides a helper to publish a static site to a filesystem
+// such as a bucket of a cloud storage. Content-Type and Cache-Control are
+// assigned to files by rules, hashed assets are cached immutably and files
+// that do not exist in the source are deleted.
+package publish
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"path"
+	"regexp"
+
+	"github.com/jarxorg/wfs"
+)
+
+// ImmutableCacheControl is the Cache-Control of hashed assets.
+const ImmutableCacheControl = "public, max-age=31536000, immutable"
+
+// hashedAssetRegexp matches names that contain a content hash of 8 or more hex
+// digits before the extension such as "app.3f2a9c1d.js" or "app-3f2a9c1d.css".
+var hashedAssetRegexp = regexp.MustCompile(`[.-][0-9a-fA-F]{8,}\.[^./]+$`)
+
+// IsHashedAsset reports whether the named file is a hashed asset whose name
+// changes when its content changes.
+func IsHashedAsset(name string) bool {
+	return hashedAssetRegexp.MatchString(path.Base(name))
+}
+
+// Rule assigns Content-Type and Cache-Control to files matching Pattern. A
+// pattern without "/" matches the base name of a file, otherwise the pattern
+// matches the whole path. Empty fields are not assigned.
+type Rule struct {
+	Pattern      string
+	ContentType  string
+	CacheControl string
+}
+
+// Option is an option for Publish.
+type Option func(o *options)
+
+type options struct {
+	noDelete    bool
+	hashedAsset func(name string) bool
+}
+
+// WithoutDelete makes Publish keep files on dest that do not exist in src.
+func WithoutDelete() Option {
+	return func(o *options) {
+		o.noDelete = true
+	}
+}
+
+// WithHashedAsset replaces IsHashedAsset to detect hashed assets.
+func WithHashedAsset(fn func(name string) bool) Option {
+	return func(o *options) {
+		o.hashedAsset = fn
+	}
+}
+
+// Result is the result of Publish.
+type Result struct {
+	// Uploaded is the names of files written to dest.
+	Uploaded []string
+	// Skipped is the names of files that are unchanged on dest.
+	Skipped []string
+	// Deleted is the names of files removed from dest.
+	Deleted []string
+}
+
+// Metadata returns the metadata of the named file by the rules. The first
+// rule that matches the name and has a non-empty field assigns the field.
+// Content-Type defaults to the type of the extension of the name and
+// Cache-Control of a hashed asset is ImmutableCacheControl regardless of the
+// rules.
+func Metadata(name string, rules []Rule, hashedAsset func(name string) bool) wfs.Metadata {
+	meta := wfs.Metadata{}
+	for _, r := range rules {
+		if !wfs.MatchFile(r.Pattern, name) {
+			continue
+		}
+		if _, ok := meta[wfs.MetaContentType]; !ok && r.ContentType != "" {
+			meta[wfs.MetaContentType] = r.ContentType
+		}
+		if _, ok := meta[wfs.MetaCacheControl]; !ok && r.CacheControl != "" {
+			meta[wfs.MetaCacheControl] = r.CacheControl
+		}
+	}
+	if _, ok := meta[wfs.MetaContentType]; !ok {
+		if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+			meta[wfs.MetaContentType] = contentType
+		}
+	}
+	if hashedAsset == nil {
+		hashedAsset = IsHashedAsset
+	}
+	if hashedAsset(name) {
+		meta[wfs.MetaCacheControl] = ImmutableCacheControl
+	}
+	return meta
+}
+
+// Publish copies the files of src to dest with the metadata assigned by the
+// rules and deletes files on dest that do not exist in src. Content-Type of a
+// file that is not assigned by the rules nor the extension is detected by
+// wfs.DetectContentType. Files that have the same content and metadata on
+// dest are skipped. The metadata is stored only if dest implements
+// wfs.MetadataFS.
+func Publish(dest wfs.WriteFileFS, src fs.FS, rules []Rule, opts ...Option) (*Result, error) {
+	o := &options{hashedAsset: IsHashedAsset}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	result := &Result{}
+	names := map[string]bool{}
+	err := fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		names[name] = true
+		meta := Metadata(name, rules, o.hashedAsset)
+		if _, ok := meta[wfs.MetaContentType]; !ok {
+			contentType, err := wfs.DetectContentType(src, name)
+			if err != nil {
+				return err
+			}
+			meta[wfs.MetaContentType] = contentType
+		}
+		unchanged, err := isUnchanged(dest, src, name, meta)
+		if err != nil {
+			return err
+		}
+		if unchanged {
+			result.Skipped = append(result.Skipped, name)
+			return nil
+		}
+		if err := upload(dest, src, name, meta); err != nil {
+			return err
+		}
+		result.Uploaded = append(result.Uploaded, name)
+		return nil
+	})
+	if err != nil || o.noDelete {
+		return result, err
+	}
+
+	err = fs.WalkDir(dest, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || names[name] {
+			return err
+		}
+		result.Deleted = append(result.Deleted, name)
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, wfs.RemoveFiles(dest, result.Deleted)
+}
+
+// isUnchanged reports whether the named file on dest has the same content
+// and metadata as the file on src.
+func isUnchanged(dest wfs.WriteFileFS, src fs.FS, name string, meta wfs.Metadata) (bool, error) {
+	equal, err := wfs.EqualFile(dest, name, src, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !equal {
+		return false, nil
+	}
+	if _, ok := dest.(wfs.MetadataFS); !ok {
+		return true, nil
+	}
+	destMeta, err := wfs.StatMeta(dest, name)
+	if err != nil {
+		return false, err
+	}
+	for _, key := range []string{wfs.MetaContentType, wfs.MetaCacheControl} {
+		if destMeta[key] != meta[key] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// upload copies the named file from src to dest with the metadata.
+func upload(dest wfs.WriteFileFS, src fs.FS, name string, meta wfs.Metadata) error {
+	srcFile, err := src.Open(name)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	var opts []wfs.WriteOption
+	for key, value := range meta {
+		opts = append(opts, wfs.WithMeta(key, value))
+	}
+	if err := dest.MkdirAll(path.Dir(name), fs.ModePerm); err != nil {
+		return err
+	}
+	f, err := wfs.CreateFileWithOptions(dest, name, 0644, opts...)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, srcFile); err != nil {
+		wfs.Abort(dest, name, f)
+		return err
+	}
+	return f.Close()
+}
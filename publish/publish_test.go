@@ -0,0 +1,129 @@
+package publish
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jarxorg/wfs"
+	"github.com/jarxorg/wfs/memfs"
+)
+
+func TestIsHashedAsset(t *testing.T) {
+	testCases := map[string]bool{
+		"assets/app.3f2a9c1d.js":  true,
+		"assets/app-3f2a9c1d.css": true,
+		"assets/app.js":           false,
+		"index.html":              false,
+		"assets/overview.css":     false,
+		"3f2a9c1d.js":             false,
+	}
+	for name, want := range testCases {
+		if got := IsHashedAsset(name); got != want {
+			t.Errorf("%s: unexpected %v; want %v", name, got, want)
+		}
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*.html", CacheControl: "no-cache"},
+		{Pattern: "feeds/*", ContentType: "application/rss+xml"},
+		{Pattern: "*", CacheControl: "public, max-age=3600"},
+	}
+	testCases := []struct {
+		name string
+		want wfs.Metadata
+	}{
+		{
+			name: "index.html",
+			want: wfs.Metadata{
+				wfs.MetaContentType:  "text/html; charset=utf-8",
+				wfs.MetaCacheControl: "no-cache",
+			},
+		}, {
+			name: "feeds/blog",
+			want: wfs.Metadata{
+				wfs.MetaContentType:  "application/rss+xml",
+				wfs.MetaCacheControl: "public, max-age=3600",
+			},
+		}, {
+			name: "assets/app.3f2a9c1d.css",
+			want: wfs.Metadata{
+				wfs.MetaContentType:  "text/css; charset=utf-8",
+				wfs.MetaCacheControl: ImmutableCacheControl,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		if got := Metadata(tc.name, rules, nil); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: unexpected %v; want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	src := fstest.MapFS{
+		"index.html":             {Data: []byte("<html>")},
+		"assets/app.3f2a9c1d.js": {Data: []byte("app")},
+		"favicon":                {Data: []byte("\x89PNG\x0D\x0A\x1A\x0A")},
+	}
+	dest := memfs.New()
+	if _, err := dest.WriteFile("old.html", []byte("old"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	rules := []Rule{{Pattern: "*.html", CacheControl: "no-cache"}}
+
+	result, err := Publish(dest, src, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Result{
+		Uploaded: []string{"assets/app.3f2a9c1d.js", "favicon", "index.html"},
+		Deleted:  []string{"old.html"},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("unexpected %+v; want %+v", result, want)
+	}
+	if _, err := dest.Stat("old.html"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	meta, err := dest.StatMeta("assets/app.3f2a9c1d.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := meta[wfs.MetaCacheControl]; got != ImmutableCacheControl {
+		t.Errorf("unexpected %s; want %s", got, ImmutableCacheControl)
+	}
+	meta, err = dest.StatMeta("favicon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := meta[wfs.MetaContentType]; got != "image/png" {
+		t.Errorf("unexpected %s; want image/png", got)
+	}
+
+	src["index.html"] = &fstest.MapFile{Data: []byte("<html>new")}
+	result, err = Publish(dest, src, rules, WithoutDelete())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = &Result{
+		Uploaded: []string{"index.html"},
+		Skipped:  []string{"assets/app.3f2a9c1d.js", "favicon"},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("unexpected %+v; want %+v", result, want)
+	}
+
+	rules[0].CacheControl = "no-store"
+	result, err = Publish(dest, src, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"index.html"}; !reflect.DeepEqual(result.Uploaded, want) {
+		t.Errorf("unexpected %v; want %v", result.Uploaded, want)
+	}
+}
@@ -0,0 +1,68 @@
+package wfs
+
+import (
+	"io"
+	"io/fs"
+	"io/ioutil"
+)
+
+// RangeReaderFS is the interface implemented by a filesystem that provides an
+// optimized implementation of OpenRange such as a HTTP Range request.
+type RangeReaderFS interface {
+	fs.FS
+	OpenRange(name string, off, length int64) (io.ReadCloser, error)
+}
+
+// OpenRange opens the named file and returns a reader that reads length bytes
+// from the offset off. If length is negative the reader reads until EOF.
+// If the filesystem implements RangeReaderFS calls fsys.OpenRange otherwise
+// opens the file and skips to the offset using io.ReaderAt, io.Seeker or
+// reading.
+func OpenRange(fsys fs.FS, name string, off, length int64) (io.ReadCloser, error) {
+	if off < 0 {
+		return nil, &fs.PathError{Op: string(OpOpenRange), Path: name, Err: fs.ErrInvalid}
+	}
+	if fsys, ok := fsys.(RangeReaderFS); ok {
+		return fsys.OpenRange(name, off, length)
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := rangeReader(f, off, length)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &readCloser{Reader: r, Closer: f}, nil
+}
+
+func rangeReader(f fs.File, off, length int64) (io.Reader, error) {
+	if ra, ok := f.(io.ReaderAt); ok {
+		if length < 0 {
+			info, err := f.Stat()
+			if err != nil {
+				return nil, err
+			}
+			length = info.Size() - off
+		}
+		return io.NewSectionReader(ra, off, length), nil
+	}
+	var r io.Reader = f
+	if s, ok := f.(io.Seeker); ok {
+		if _, err := s.Seek(off, io.SeekStart); err != nil {
+			return nil, err
+		}
+	} else if _, err := io.CopyN(ioutil.Discard, f, off); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if length >= 0 {
+		r = io.LimitReader(r, length)
+	}
+	return r, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
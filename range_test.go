@@ -0,0 +1,91 @@
+package wfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestOpenRange(t *testing.T) {
+	dirFS := os.DirFS("osfs/testdata")
+	name := "dir0/file01.txt"
+	p, err := fs.ReadFile(dirFS, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: FileDelegator hides io.ReaderAt and io.Seeker of os.File.
+	delegated := DelegateFS(dirFS)
+	delegated.OpenFunc = func(name string) (fs.File, error) {
+		f, err := dirFS.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		return DelegateFile(f), nil
+	}
+
+	testCases := []struct {
+		fsys        fs.FS
+		off, length int64
+		want        string
+	}{
+		{fsys: dirFS, off: 2, length: 3, want: string(p[2:5])},
+		{fsys: dirFS, off: 2, length: -1, want: string(p[2:])},
+		{fsys: delegated, off: 2, length: 3, want: string(p[2:5])},
+		{fsys: delegated, off: 2, length: -1, want: string(p[2:])},
+		{fsys: delegated, off: 100, length: 3, want: ""},
+	}
+	for i, tc := range testCases {
+		r, err := OpenRange(tc.fsys, name, tc.off, tc.length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("tests[%d] unexpected %q; want %q", i, got, tc.want)
+		}
+	}
+}
+
+type rangeReaderFS struct {
+	*FSDelegator
+}
+
+func (fsys *rangeReaderFS) OpenRange(name string, off, length int64) (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader(name)), nil
+}
+
+func TestOpenRange_RangeReaderFS(t *testing.T) {
+	r, err := OpenRange(&rangeReaderFS{FSDelegator: &FSDelegator{}}, "test.txt", 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "test.txt" {
+		t.Errorf("unexpected %s", got)
+	}
+}
+
+func TestOpenRange_Errors(t *testing.T) {
+	fsys := os.DirFS("osfs/testdata")
+
+	if _, err := OpenRange(fsys, "dir0/file01.txt", -1, 1); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+	if _, err := OpenRange(fsys, "not-found.txt", 0, 1); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+}
@@ -0,0 +1,54 @@
+package wfs
+
+import "io/fs"
+
+// WithReadOnly makes the writes of the wrapped filesystem, including the
+// filesystems returned by Sub, return a fs.PathError of ErrReadOnly without
+// calling the wrapped filesystem. The wrapped filesystem implements
+// WriteFileFS and RemoveFileFS so that callers can tell a read-only
+// filesystem from a filesystem that does not implement writes.
+func WithReadOnly() WrapOption {
+	return func(d *FSDelegator) {
+		if sub := d.SubFunc; sub != nil {
+			d.SubFunc = func(dir string) (fs.FS, error) {
+				subFS, err := sub(dir)
+				if err != nil {
+					return nil, err
+				}
+				return Wrap(subFS, WithReadOnly()), nil
+			}
+		}
+		d.MkdirAllFunc = func(dir string, mode fs.FileMode) error {
+			return &fs.PathError{Op: string(OpMkdirAll), Path: dir, Err: ErrReadOnly}
+		}
+		d.CreateFileFunc = func(name string, mode fs.FileMode) (WriterFile, error) {
+			return nil, &fs.PathError{Op: string(OpCreateFile), Path: name, Err: ErrReadOnly}
+		}
+		d.WriteFileFunc = func(name string, p []byte, mode fs.FileMode) (int, error) {
+			return 0, &fs.PathError{Op: string(OpWriteFile), Path: name, Err: ErrReadOnly}
+		}
+		d.RemoveFileFunc = func(name string) error {
+			return &fs.PathError{Op: string(OpRemoveFile), Path: name, Err: ErrReadOnly}
+		}
+		d.RemoveAllFunc = func(path string) error {
+			return &fs.PathError{Op: string(OpRemoveAll), Path: path, Err: ErrReadOnly}
+		}
+		d.CopyFileFunc = func(src, dst string) error {
+			return &fs.PathError{Op: string(OpCopyFile), Path: dst, Err: ErrReadOnly}
+		}
+		d.RemoveFilesFunc = nil
+		if d.CreateFileWithMetaFunc != nil || d.WriteFileWithMetaFunc != nil {
+			d.CreateFileWithMetaFunc = func(name string, mode fs.FileMode, meta Metadata) (WriterFile, error) {
+				return nil, &fs.PathError{Op: string(OpCreateFileWithMeta), Path: name, Err: ErrReadOnly}
+			}
+			d.WriteFileWithMetaFunc = func(name string, p []byte, mode fs.FileMode, meta Metadata) (int, error) {
+				return 0, &fs.PathError{Op: string(OpWriteFileWithMeta), Path: name, Err: ErrReadOnly}
+			}
+		}
+		if d.WriteFileIfFunc != nil {
+			d.WriteFileIfFunc = func(name string, p []byte, mode fs.FileMode, cond Condition) (string, error) {
+				return "", &fs.PathError{Op: string(OpWriteFileIf), Path: name, Err: ErrReadOnly}
+			}
+		}
+	}
+}
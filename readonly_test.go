@@ -0,0 +1,42 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestWithReadOnly(t *testing.T) {
+	m := fstest.MapFS{
+		"dir/a.txt": {Data: []byte("a")},
+	}
+	fsys := Wrap(newMapWriteFS(m), WithReadOnly())
+
+	if err := fstest.TestFS(fsys, "dir/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	sub, err := fs.Sub(fsys, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fsys := range []fs.FS{fsys, sub} {
+		tests := map[string]error{}
+		_, tests["CreateFile"] = CreateFile(fsys, "b.txt", fs.ModePerm)
+		_, tests["WriteFile"] = WriteFile(fsys, "b.txt", []byte("b"), fs.ModePerm)
+		tests["MkdirAll"] = MkdirAll(fsys, "b", fs.ModePerm)
+		tests["RemoveFile"] = RemoveFile(fsys, "a.txt")
+		tests["RemoveAll"] = RemoveAll(fsys, "a.txt")
+		for op, err := range tests {
+			if !errors.Is(err, ErrReadOnly) {
+				t.Errorf("%s: unexpected %v; want %v", op, err, ErrReadOnly)
+			}
+		}
+	}
+	if _, ok := m["dir/a.txt"]; !ok {
+		t.Errorf("unexpected removal of dir/a.txt")
+	}
+	if _, ok := m["b.txt"]; ok {
+		t.Errorf("unexpected b.txt")
+	}
+}
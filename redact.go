@@ -0,0 +1,63 @@
+package wfs
+
+import (
+	"io/fs"
+	"path"
+	"regexp"
+)
+
+// RedactRule is a rule of RedactFS. A Pattern that contains no "/" is matched
+// by path.Match against the base name of a file, otherwise against the path
+// of a file. Redact returns the contents to read from the contents stored.
+type RedactRule struct {
+	Pattern string
+	Redact  func(p []byte) []byte
+}
+
+// RedactRegexp returns a function for RedactRule.Redact that replaces matches
+// of re with repl as regexp.ReplaceAll does.
+func RedactRegexp(re *regexp.Regexp, repl string) func(p []byte) []byte {
+	return func(p []byte) []byte {
+		return re.ReplaceAll(p, []byte(repl))
+	}
+}
+
+// RedactFS is a filesystem that transforms the contents of files matching
+// rules on read, for example to mask secrets in .env files. The first
+// matching rule of a file is used. Writes are delegated to the wrapped
+// filesystem untouched, so the stored contents are not redacted. Stat and
+// ReadDir report the sizes of the redacted contents.
+type RedactFS struct {
+	*transformFS
+}
+
+var (
+	_ fs.ReadDirFS  = (*RedactFS)(nil)
+	_ fs.ReadFileFS = (*RedactFS)(nil)
+	_ fs.StatFS     = (*RedactFS)(nil)
+	_ fs.SubFS      = (*RedactFS)(nil)
+	_ WriteFileFS   = (*RedactFS)(nil)
+	_ RemoveFileFS  = (*RedactFS)(nil)
+)
+
+// NewRedactFS returns a RedactFS.
+func NewRedactFS(fsys fs.FS, rules []RedactRule) (*RedactFS, error) {
+	for _, r := range rules {
+		if _, err := path.Match(r.Pattern, ""); err != nil {
+			return nil, err
+		}
+	}
+	return &RedactFS{
+		transformFS: newTransformFS(fsys, func(name string) transformFunc {
+			for _, r := range rules {
+				if MatchFile(r.Pattern, name) {
+					redact := r.Redact
+					return func(p []byte) ([]byte, error) {
+						return redact(p), nil
+					}
+				}
+			}
+			return nil
+		}),
+	}, nil
+}
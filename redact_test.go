@@ -0,0 +1,69 @@
+package wfs
+
+import (
+	"io/fs"
+	"regexp"
+	"testing"
+	"testing/fstest"
+)
+
+func newRedactFSTest(t *testing.T, m fstest.MapFS) *RedactFS {
+	fsys, err := NewRedactFS(newMapWriteFS(m), []RedactRule{
+		{Pattern: "*.env", Redact: RedactRegexp(regexp.MustCompile(`(?m)=.*$`), "=***")},
+		{Pattern: "dir/secret.txt", Redact: func(p []byte) []byte { return nil }},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fsys
+}
+
+func TestRedactFS(t *testing.T) {
+	m := fstest.MapFS{
+		"app.env":        {Data: []byte("USER=alice\nPASSWORD=secret\n")},
+		"dir/prod.env":   {Data: []byte("TOKEN=abcdef\n")},
+		"dir/secret.txt": {Data: []byte("secret")},
+		"dir/plain.txt":  {Data: []byte("plain")},
+	}
+	fsys := newRedactFSTest(t, m)
+
+	if err := fstest.TestFS(fsys, "app.env", "dir/prod.env", "dir/secret.txt", "dir/plain.txt"); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"app.env":        "USER=***\nPASSWORD=***\n",
+		"dir/prod.env":   "TOKEN=***\n",
+		"dir/secret.txt": "",
+		"dir/plain.txt":  "plain",
+	}
+	for name, want := range tests {
+		got, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: unexpected %q; want %q", name, got, want)
+		}
+	}
+
+	sub, err := fsys.Sub("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := fs.ReadFile(sub, "secret.txt"); len(got) != 0 {
+		t.Errorf("unexpected %q", got)
+	}
+
+	if _, err := fsys.WriteFile("new.env", []byte("KEY=value"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(m["new.env"].Data); got != "KEY=value" {
+		t.Errorf("unexpected stored %q", got)
+	}
+}
+
+func TestNewRedactFS_ErrBadPattern(t *testing.T) {
+	if _, err := NewRedactFS(fstest.MapFS{}, []RedactRule{{Pattern: "["}}); err == nil {
+		t.Errorf("no error")
+	}
+}
@@ -0,0 +1,136 @@
+package wfs
+
+import (
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// RefreshingFS is a read-only filesystem that serves a snapshot returned by a
+// loader and replaces the snapshot by a new one on a timer or on demand. Each
+// operation is served by a single snapshot, so a file opened before a refresh
+// keeps reading the old snapshot. If loading fails the current snapshot is
+// kept and the error is reported by Err.
+type RefreshingFS struct {
+	loader func() (fs.FS, error)
+	mutex  sync.RWMutex
+	fsys   fs.FS
+	err    error
+	done   chan struct{}
+	once   sync.Once
+}
+
+var (
+	_ fs.GlobFS     = (*RefreshingFS)(nil)
+	_ fs.ReadDirFS  = (*RefreshingFS)(nil)
+	_ fs.ReadFileFS = (*RefreshingFS)(nil)
+	_ fs.StatFS     = (*RefreshingFS)(nil)
+	_ fs.SubFS      = (*RefreshingFS)(nil)
+)
+
+// NewRefreshingFS returns a RefreshingFS that loads the first snapshot by
+// loader and refreshes the snapshot every interval if interval is positive.
+// The loader should return an in-memory filesystem such as a memfs or the
+// snapshot returned by osfs.Snapshot so operations do not access the backend.
+// Close stops refreshing.
+func NewRefreshingFS(loader func() (fs.FS, error), interval time.Duration) (*RefreshingFS, error) {
+	fsys, err := loader()
+	if err != nil {
+		return nil, err
+	}
+	r := &RefreshingFS{
+		loader: loader,
+		fsys:   fsys,
+		done:   make(chan struct{}),
+	}
+	if interval > 0 {
+		go r.run(interval)
+	}
+	return r, nil
+}
+
+func (r *RefreshingFS) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.Refresh()
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// Refresh loads a new snapshot and replaces the current snapshot. If loading
+// fails the current snapshot is kept and the error is returned.
+func (r *RefreshingFS) Refresh() error {
+	fsys, err := r.loader()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.err = err
+	if err == nil {
+		r.fsys = fsys
+	}
+	return err
+}
+
+// Err returns the error of the last refresh or nil if it succeeded.
+func (r *RefreshingFS) Err() error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.err
+}
+
+// Snapshot returns the current snapshot.
+func (r *RefreshingFS) Snapshot() fs.FS {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.fsys
+}
+
+// Close stops refreshing on the timer. The current snapshot is still served.
+func (r *RefreshingFS) Close() error {
+	r.once.Do(func() {
+		close(r.done)
+	})
+	return nil
+}
+
+// Open opens the named file of the current snapshot.
+func (r *RefreshingFS) Open(name string) (fs.File, error) {
+	return r.Snapshot().Open(name)
+}
+
+// ReadDir reads the named directory of the current snapshot.
+func (r *RefreshingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(r.Snapshot(), name)
+}
+
+// ReadFile reads the named file of the current snapshot.
+func (r *RefreshingFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(r.Snapshot(), name)
+}
+
+// Glob returns the names of the files of the current snapshot matching
+// pattern.
+func (r *RefreshingFS) Glob(pattern string) ([]string, error) {
+	return Glob(r.Snapshot(), pattern)
+}
+
+// Stat returns a fs.FileInfo describing the named file of the current
+// snapshot.
+func (r *RefreshingFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(r.Snapshot(), name)
+}
+
+// Sub returns the subtree rooted at dir of the current snapshot. The
+// returned filesystem is not refreshed.
+func (r *RefreshingFS) Sub(dir string) (fs.FS, error) {
+	return fs.Sub(r.Snapshot(), dir)
+}
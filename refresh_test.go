@@ -0,0 +1,104 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestRefreshingFS(t *testing.T) {
+	var mutex sync.Mutex
+	version := "v1"
+	errLoad := errors.New("load")
+	var loadErr error
+	loader := func() (fs.FS, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		return fstest.MapFS{"version.txt": {Data: []byte(version)}}, nil
+	}
+	r, err := NewRefreshingFS(loader, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if err := fstest.TestFS(r, "version.txt"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := r.Open("version.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	mutex.Lock()
+	version = "v2"
+	mutex.Unlock()
+	if err := r.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := fs.ReadFile(r, "version.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "v2" {
+		t.Errorf("unexpected %s; want v2", got)
+	}
+	buf := make([]byte, 2)
+	if _, err := f.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "v1" {
+		t.Errorf("unexpected %s; want v1", buf)
+	}
+
+	mutex.Lock()
+	loadErr = errLoad
+	mutex.Unlock()
+	if err := r.Refresh(); !errors.Is(err, errLoad) {
+		t.Errorf("unexpected %v; want %v", err, errLoad)
+	}
+	if err := r.Err(); !errors.Is(err, errLoad) {
+		t.Errorf("unexpected %v; want %v", err, errLoad)
+	}
+	if _, err := r.Stat("version.txt"); err != nil {
+		t.Errorf("unexpected %v", err)
+	}
+
+	if _, err := NewRefreshingFS(loader, 0); !errors.Is(err, errLoad) {
+		t.Errorf("unexpected %v; want %v", err, errLoad)
+	}
+}
+
+func TestRefreshingFS_Interval(t *testing.T) {
+	loads := make(chan struct{}, 10)
+	r, err := NewRefreshingFS(func() (fs.FS, error) {
+		select {
+		case loads <- struct{}{}:
+		default:
+		}
+		return fstest.MapFS{}, nil
+	}, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-loads:
+		case <-time.After(time.Second):
+			t.Fatalf("timeout")
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("unexpected %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// sequenceRetries is the number of attempts of NextSequence on conflicts.
+	sequenceRetries = 20
+	// sequenceRetryInterval is the base interval between the attempts of
+	// NextSequence that increases linearly.
+	sequenceRetryInterval = time.Millisecond
+	// sequenceLockSuffix is the suffix of the lock file of NextSequence.
+	sequenceLockSuffix = ".lock"
+)
+
+// NextSequence increments the decimal number stored in the named file and
+// returns the incremented number. A missing file is treated as 0, so the
+// first call returns 1.
+//
+// If the filesystem implements ConditionalWriteFS, the number is written by
+// WriteFileIf with the version that the number was read at, and the increment
+// is retried if another writer has updated the file. Otherwise NextSequence
+// holds a file created by CreateFile named name+".lock" while reading and
+// writing the number and retries while CreateFile returns ErrLocked, that is
+// atomic only if the filesystem serializes writers of a file such as memfs
+// with write leases. NextSequence returns an error wrapping
+// ErrPreconditionFailed or ErrLocked if the retries are exhausted.
+func NextSequence(fsys fs.FS, name string) (int64, error) {
+	if cfsys, ok := fsys.(ConditionalWriteFS); ok {
+		return nextSequenceIf(cfsys, name)
+	}
+	return nextSequenceLocked(fsys, name)
+}
+
+func nextSequenceIf(fsys ConditionalWriteFS, name string) (int64, error) {
+	var err error
+	for i := 0; i < sequenceRetries; i++ {
+		cond := Condition{}
+		cond.IfMatch, err = fsys.FileVersion(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			cond = Condition{IfNotExists: true}
+		} else if err != nil {
+			return 0, err
+		}
+		var n int64
+		n, err = readSequence(fsys, name)
+		if err != nil {
+			return 0, err
+		}
+		n++
+		_, err = fsys.WriteFileIf(name, formatSequence(n), 0644, cond)
+		if err == nil {
+			return n, nil
+		}
+		if !errors.Is(err, ErrPreconditionFailed) {
+			return 0, err
+		}
+		time.Sleep(time.Duration(i+1) * sequenceRetryInterval)
+	}
+	return 0, err
+}
+
+func nextSequenceLocked(fsys fs.FS, name string) (int64, error) {
+	lockName := name + sequenceLockSuffix
+	var lock WriterFile
+	var err error
+	for i := 0; i < sequenceRetries; i++ {
+		lock, err = CreateFile(fsys, lockName, 0644)
+		if !errors.Is(err, ErrLocked) {
+			break
+		}
+		time.Sleep(time.Duration(i+1) * sequenceRetryInterval)
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer Abort(fsys, lockName, lock)
+
+	n, err := readSequence(fsys, name)
+	if err != nil {
+		return 0, err
+	}
+	n++
+	if _, err := WriteFile(fsys, name, formatSequence(n), 0644); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// readSequence reads the number of the named file. A missing file is 0.
+func readSequence(fsys fs.FS, name string) (int64, error) {
+	p, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(string(p)), 10, 64)
+	if err != nil {
+		return 0, &fs.PathError{Op: "NextSequence", Path: name, Err: err}
+	}
+	return n, nil
+}
+
+func formatSequence(n int64) []byte {
+	return []byte(strconv.FormatInt(n, 10))
+}
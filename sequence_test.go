@@ -0,0 +1,114 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"strconv"
+	"testing"
+	"testing/fstest"
+)
+
+type sequenceFS struct {
+	*FSDelegator
+	m         fstest.MapFS
+	conflicts int
+}
+
+func (fsys *sequenceFS) WriteFileIf(name string, p []byte, mode fs.FileMode, cond Condition) (string, error) {
+	if fsys.conflicts > 0 {
+		fsys.conflicts--
+		n, _ := strconv.Atoi(string(fsys.m[name].Data))
+		fsys.m[name] = &fstest.MapFile{Data: []byte(strconv.Itoa(n + 100))}
+	}
+	version, _ := fsys.FileVersion(name)
+	if cond.IfNotExists && version != "" || cond.IfMatch != version {
+		return "", &fs.PathError{Op: "WriteFileIf", Path: name, Err: ErrPreconditionFailed}
+	}
+	fsys.m[name] = &fstest.MapFile{Data: p, Mode: mode}
+	return string(p), nil
+}
+
+func (fsys *sequenceFS) FileVersion(name string) (string, error) {
+	f, ok := fsys.m[name]
+	if !ok {
+		return "", &fs.PathError{Op: "FileVersion", Path: name, Err: fs.ErrNotExist}
+	}
+	return string(f.Data), nil
+}
+
+func TestNextSequence(t *testing.T) {
+	m := fstest.MapFS{}
+	fsys := newMapWriteFS(m)
+	for i := int64(1); i <= 3; i++ {
+		got, err := NextSequence(fsys, "seq")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != i {
+			t.Errorf("unexpected %d; want %d", got, i)
+		}
+	}
+	if got := string(m["seq"].Data); got != "3" {
+		t.Errorf("unexpected %s; want 3", got)
+	}
+	if _, ok := m["seq.lock"]; ok {
+		t.Errorf("unexpected seq.lock")
+	}
+
+	m["invalid"] = &fstest.MapFile{Data: []byte("x")}
+	if _, err := NextSequence(fsys, "invalid"); !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("unexpected %v; want %v", err, strconv.ErrSyntax)
+	}
+}
+
+func TestNextSequence_Locked(t *testing.T) {
+	m := fstest.MapFS{}
+	fsys := newMapWriteFS(m)
+	createFile := fsys.CreateFileFunc
+	locked := 2
+	fsys.CreateFileFunc = func(name string, mode fs.FileMode) (WriterFile, error) {
+		if locked > 0 {
+			locked--
+			return nil, &fs.PathError{Op: "CreateFile", Path: name, Err: ErrLocked}
+		}
+		return createFile(name, mode)
+	}
+	got, err := NextSequence(fsys, "seq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 1 {
+		t.Errorf("unexpected %d; want 1", got)
+	}
+
+	locked = sequenceRetries
+	if _, err := NextSequence(fsys, "seq"); !errors.Is(err, ErrLocked) {
+		t.Errorf("unexpected %v; want %v", err, ErrLocked)
+	}
+}
+
+func TestNextSequence_Conditional(t *testing.T) {
+	m := fstest.MapFS{}
+	fsys := &sequenceFS{FSDelegator: DelegateFS(m), m: m}
+	got, err := NextSequence(fsys, "seq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 1 {
+		t.Errorf("unexpected %d; want 1", got)
+	}
+
+	fsys.conflicts = 1
+	got, err = NextSequence(fsys, "seq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 102 {
+		t.Errorf("unexpected %d; want 102", got)
+	}
+
+	fsys.conflicts = sequenceRetries
+	if _, err := NextSequence(fsys, "seq"); !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("unexpected %v; want %v", err, ErrPreconditionFailed)
+	}
+}
@@ -0,0 +1,270 @@
+package wfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// ShardOption is an option for NewShardFS.
+type ShardOption func(fsys *ShardFS)
+
+// WithShardFunc sets the function that returns the directory of the named file
+// on the wrapped filesystem, such as the first characters of names that are
+// already digests. The default is HashShard(2, 2).
+func WithShardFunc(shard func(name string) string) ShardOption {
+	return func(fsys *ShardFS) {
+		fsys.shard = shard
+	}
+}
+
+// HashShard returns a shard function that makes levels directories named by
+// width hex characters of the SHA-256 of a name, such as "ab/cd" for levels 2
+// and width 2.
+func HashShard(levels, width int) func(name string) string {
+	return func(name string) string {
+		sum := sha256.Sum256([]byte(name))
+		h := hex.EncodeToString(sum[:])
+		dirs := make([]string, 0, levels)
+		for i := 0; i < levels && (i+1)*width <= len(h); i++ {
+			dirs = append(dirs, h[i*width:(i+1)*width])
+		}
+		return path.Join(dirs...)
+	}
+}
+
+// ShardFS is a filesystem that stores the files of a flat namespace in fan-out
+// directories of the wrapped filesystem, such as "a.txt" in "ab/cd/a.txt", so
+// that no directory of osfs or prefix of an object storage holds millions of
+// files. Callers see only the flat namespace: the names of files must not
+// contain "/", and ReadDir and Glob of "." list the files of all of the
+// shards by walking the wrapped filesystem. Shard directories left empty by
+// RemoveFile are not removed.
+type ShardFS struct {
+	fsys  fs.FS
+	shard func(name string) string
+}
+
+var (
+	_ fs.GlobFS     = (*ShardFS)(nil)
+	_ fs.ReadDirFS  = (*ShardFS)(nil)
+	_ fs.ReadFileFS = (*ShardFS)(nil)
+	_ fs.StatFS     = (*ShardFS)(nil)
+	_ fs.SubFS      = (*ShardFS)(nil)
+	_ WriteFileFS   = (*ShardFS)(nil)
+	_ RemoveFileFS  = (*ShardFS)(nil)
+)
+
+// NewShardFS returns a ShardFS that stores the files in the shards of fsys.
+func NewShardFS(fsys fs.FS, opts ...ShardOption) *ShardFS {
+	s := &ShardFS{
+		fsys:  fsys,
+		shard: HashShard(2, 2),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// physical returns the name of the named file on the wrapped filesystem.
+func (fsys *ShardFS) physical(op Op, name string) (string, error) {
+	if !fs.ValidPath(name) || name == "." || strings.Contains(name, "/") {
+		return "", &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
+	}
+	return path.Join(fsys.shard(name), name), nil
+}
+
+// entries returns the files of all of the shards sorted by filename.
+func (fsys *ShardFS) entries() ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	err := fs.WalkDir(fsys.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			entries = append(entries, d)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// Open opens the named file or "." that lists the files of all of the shards.
+func (fsys *ShardFS) Open(name string) (fs.File, error) {
+	if name == "." {
+		info, err := fs.Stat(fsys.fsys, ".")
+		if err != nil {
+			return nil, err
+		}
+		entries, err := fsys.entries()
+		if err != nil {
+			return nil, err
+		}
+		return NewDirFile(info, entries), nil
+	}
+	pname, err := fsys.physical(OpOpen, name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := fsys.fsys.Open(pname)
+	if err != nil {
+		return nil, PathError(OpOpen, name, err)
+	}
+	return f, nil
+}
+
+// ReadDir reads "." and returns the files of all of the shards sorted by
+// filename.
+func (fsys *ShardFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	if dir != "." {
+		if _, err := fsys.Stat(dir); err != nil {
+			return nil, PathError(OpReadDir, dir, err)
+		}
+		return nil, &fs.PathError{Op: string(OpReadDir), Path: dir, Err: syscall.ENOTDIR}
+	}
+	return fsys.entries()
+}
+
+// ReadFile reads the named file and returns its contents.
+func (fsys *ShardFS) ReadFile(name string) ([]byte, error) {
+	pname, err := fsys.physical(OpReadFile, name)
+	if err != nil {
+		return nil, err
+	}
+	p, err := fs.ReadFile(fsys.fsys, pname)
+	if err != nil {
+		return nil, PathError(OpReadFile, name, err)
+	}
+	return p, nil
+}
+
+// Glob returns the names of the files matching pattern. A pattern that
+// contains "/" matches no files.
+func (fsys *ShardFS) Glob(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	if pattern == "." {
+		return []string{"."}, nil
+	}
+	entries, err := fsys.entries()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if ok, _ := path.Match(pattern, e.Name()); ok {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+// Stat returns a FileInfo describing the named file.
+func (fsys *ShardFS) Stat(name string) (fs.FileInfo, error) {
+	if name == "." {
+		return fs.Stat(fsys.fsys, ".")
+	}
+	pname, err := fsys.physical(OpStat, name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fs.Stat(fsys.fsys, pname)
+	if err != nil {
+		return nil, PathError(OpStat, name, err)
+	}
+	return info, nil
+}
+
+// Sub returns fsys for "." because the namespace has no other directories.
+func (fsys *ShardFS) Sub(dir string) (fs.FS, error) {
+	if dir == "." {
+		return fsys, nil
+	}
+	return nil, &fs.PathError{Op: string(OpSub), Path: dir, Err: fs.ErrInvalid}
+}
+
+// MkdirAll creates the root of the wrapped filesystem for ".". Other
+// directories cannot be created in the flat namespace.
+func (fsys *ShardFS) MkdirAll(dir string, mode fs.FileMode) error {
+	if dir == "." {
+		return MkdirAll(fsys.fsys, ".", mode)
+	}
+	return &fs.PathError{Op: string(OpMkdirAll), Path: dir, Err: fs.ErrInvalid}
+}
+
+// mkdirShard creates the shard directory of the named file.
+func (fsys *ShardFS) mkdirShard(op Op, pname, name string) error {
+	return PathError(op, name, MkdirAll(fsys.fsys, path.Dir(pname), fs.ModePerm))
+}
+
+// CreateFile creates the named file in its shard.
+func (fsys *ShardFS) CreateFile(name string, mode fs.FileMode) (WriterFile, error) {
+	pname, err := fsys.physical(OpCreateFile, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := fsys.mkdirShard(OpCreateFile, pname, name); err != nil {
+		return nil, err
+	}
+	f, err := CreateFile(fsys.fsys, pname, mode)
+	if err != nil {
+		return nil, PathError(OpCreateFile, name, err)
+	}
+	return f, nil
+}
+
+// WriteFile writes the specified bytes to the named file in its shard.
+func (fsys *ShardFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	pname, err := fsys.physical(OpWriteFile, name)
+	if err != nil {
+		return 0, err
+	}
+	if err := fsys.mkdirShard(OpWriteFile, pname, name); err != nil {
+		return 0, err
+	}
+	n, err := WriteFile(fsys.fsys, pname, p, mode)
+	return n, PathError(OpWriteFile, name, err)
+}
+
+// RemoveFile removes the named file from its shard.
+func (fsys *ShardFS) RemoveFile(name string) error {
+	pname, err := fsys.physical(OpRemoveFile, name)
+	if err != nil {
+		return err
+	}
+	return PathError(OpRemoveFile, name, RemoveFile(fsys.fsys, pname))
+}
+
+// RemoveAll removes the named file from its shard, or all of the files and
+// the shards for ".".
+func (fsys *ShardFS) RemoveAll(name string) error {
+	if name == "." {
+		entries, err := fs.ReadDir(fsys.fsys, ".")
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := RemoveAll(fsys.fsys, e.Name()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	pname, err := fsys.physical(OpRemoveAll, name)
+	if err != nil {
+		return err
+	}
+	return PathError(OpRemoveAll, name, RemoveAll(fsys.fsys, pname))
+}
@@ -0,0 +1,81 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestShardFS(t *testing.T) {
+	m := fstest.MapFS{}
+	fsys := NewShardFS(newMapWriteFS(m))
+
+	for _, name := range []string{"a.txt", "b.txt", "c.json"} {
+		if _, err := fsys.WriteFile(name, []byte(name), fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	shard := HashShard(2, 2)("a.txt")
+	if len(shard) != len("ab/cd") {
+		t.Fatalf("unexpected shard %s", shard)
+	}
+	if _, ok := m[shard+"/a.txt"]; !ok {
+		t.Errorf("unexpected a.txt is not stored in %s", shard)
+	}
+	if err := fstest.TestFS(fsys, "a.txt", "b.txt", "c.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := fsys.Glob("*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.txt", "b.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+
+	if err := fsys.RemoveFile("b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := fsys.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"a.txt", "c.json"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("unexpected %v; want %v", names, want)
+	}
+
+	var pe *fs.PathError
+	if _, err := fsys.ReadFile("b.txt"); !errors.As(err, &pe) || pe.Path != "b.txt" || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v of b.txt", err, fs.ErrNotExist)
+	}
+	if _, err := fsys.WriteFile("dir/d.txt", []byte("d"), fs.ModePerm); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+}
+
+func TestShardFS_ShardFunc(t *testing.T) {
+	m := fstest.MapFS{}
+	fsys := NewShardFS(newMapWriteFS(m), WithShardFunc(func(name string) string {
+		return name[0:2] + "/" + name[2:4]
+	}))
+	if _, err := fsys.WriteFile("abcdef", []byte("blob"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["ab/cd/abcdef"]; !ok {
+		t.Errorf("unexpected abcdef is not stored in ab/cd")
+	}
+	got, err := fsys.ReadFile("abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "blob" {
+		t.Errorf("unexpected %q; want %q", got, "blob")
+	}
+}
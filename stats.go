@@ -0,0 +1,211 @@
+package wfs
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultLargestFiles is the default number of files in Usage.Largest.
+const defaultLargestFiles = 10
+
+// FileSize is the name and size of a file.
+type FileSize struct {
+	Name string
+	Size int64
+}
+
+// ExtUsage is the usage of files that have the same extension.
+type ExtUsage struct {
+	Files int64
+	Bytes int64
+}
+
+// Usage is the usage of a directory tree returned by DirStats.
+type Usage struct {
+	// Bytes is the total size of the files.
+	Bytes int64
+	// Files is the number of files.
+	Files int64
+	// Dirs is the number of directories including the root.
+	Dirs int64
+	// Largest is the largest files in descending order of size.
+	Largest []FileSize
+	// Exts is the usage per lower-cased extension such as ".go". Files without
+	// an extension are aggregated by "".
+	Exts map[string]*ExtUsage
+}
+
+// DirStatsOption is an option for DirStats.
+type DirStatsOption func(o *dirStatsOptions)
+
+type dirStatsOptions struct {
+	largest  int
+	parallel int
+}
+
+// WithLargestFiles sets the number of files in Usage.Largest. The default is
+// 10.
+func WithLargestFiles(n int) DirStatsOption {
+	return func(o *dirStatsOptions) {
+		o.largest = n
+	}
+}
+
+// WithParallel makes DirStats read up to n directories of each level of the
+// tree concurrently. With this option the files are passed to the callback of
+// DirStatsFunc level by level in breadth-first order instead of the lexical
+// depth-first order of fs.WalkDir, and the files of a directory are passed in
+// the order returned by ReadDirPage.
+func WithParallel(n int) DirStatsOption {
+	return func(o *dirStatsOptions) {
+		o.parallel = n
+	}
+}
+
+// DirStats returns the usage of the tree rooted at root by a single pass of
+// the tree.
+func DirStats(fsys fs.FS, root string, opts ...DirStatsOption) (*Usage, error) {
+	return DirStatsFunc(fsys, root, nil, opts...)
+}
+
+// DirStatsFunc is DirStats that calls fn for each file as the file is
+// counted, so progress of a huge tree can be streamed. If fn returns an error
+// DirStatsFunc stops and returns the error. The fn is not called concurrently.
+func DirStatsFunc(fsys fs.FS, root string, fn func(name string, info fs.FileInfo) error, opts ...DirStatsOption) (*Usage, error) {
+	o := &dirStatsOptions{largest: defaultLargestFiles}
+	for _, opt := range opts {
+		opt(o)
+	}
+	u := &Usage{Exts: map[string]*ExtUsage{}}
+	add := func(name string, info fs.FileInfo) error {
+		if info.IsDir() {
+			u.Dirs++
+			return nil
+		}
+		u.add(name, info.Size(), o.largest)
+		if fn != nil {
+			return fn(name, info)
+		}
+		return nil
+	}
+	if o.parallel > 1 {
+		return u, walkStatsParallel(fsys, root, o.parallel, add)
+	}
+	return u, fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return add(name, info)
+	})
+}
+
+// add counts the named file.
+func (u *Usage) add(name string, size int64, largest int) {
+	u.Files++
+	u.Bytes += size
+
+	ext := strings.ToLower(path.Ext(name))
+	e, ok := u.Exts[ext]
+	if !ok {
+		e = &ExtUsage{}
+		u.Exts[ext] = e
+	}
+	e.Files++
+	e.Bytes += size
+
+	if largest <= 0 {
+		return
+	}
+	i := sort.Search(len(u.Largest), func(i int) bool {
+		l := u.Largest[i]
+		return l.Size < size || (l.Size == size && l.Name > name)
+	})
+	if i >= largest {
+		return
+	}
+	u.Largest = append(u.Largest, FileSize{})
+	copy(u.Largest[i+1:], u.Largest[i:])
+	u.Largest[i] = FileSize{Name: name, Size: size}
+	if len(u.Largest) > largest {
+		u.Largest = u.Largest[:largest]
+	}
+}
+
+// statsEntry is an entry read by walkStatsParallel.
+type statsEntry struct {
+	name string
+	info fs.FileInfo
+}
+
+// walkStatsParallel reads the directories of each level of the tree
+// concurrently and calls fn for each entry serially.
+func walkStatsParallel(fsys fs.FS, root string, parallel int, fn func(name string, info fs.FileInfo) error) error {
+	info, err := fs.Stat(fsys, root)
+	if err != nil {
+		return err
+	}
+	if err := fn(root, info); err != nil || !info.IsDir() {
+		return err
+	}
+
+	dirs := []string{root}
+	for len(dirs) > 0 {
+		results := make([][]statsEntry, len(dirs))
+		errs := make([]error, len(dirs))
+		sem := make(chan struct{}, parallel)
+		var wg sync.WaitGroup
+		for i, dir := range dirs {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, dir string) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				results[i], errs[i] = readStatsDir(fsys, dir)
+			}(i, dir)
+		}
+		wg.Wait()
+
+		var next []string
+		for i, entries := range results {
+			if errs[i] != nil {
+				return errs[i]
+			}
+			for _, e := range entries {
+				if err := fn(e.name, e.info); err != nil {
+					return err
+				}
+				if e.info.IsDir() {
+					next = append(next, e.name)
+				}
+			}
+		}
+		dirs = next
+	}
+	return nil
+}
+
+// readStatsDir reads the entries of the directory with their infos using
+// ReadDirPage so that the entries are not loaded and sorted at once.
+func readStatsDir(fsys fs.FS, dir string) ([]statsEntry, error) {
+	var stats []statsEntry
+	err := ReadDirPage(fsys, dir, 0, func(entries []fs.DirEntry) error {
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil {
+				return err
+			}
+			stats = append(stats, statsEntry{name: path.Join(dir, e.Name()), info: info})
+		}
+		return nil
+	})
+	return stats, err
+}
@@ -0,0 +1,71 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDirStats(t *testing.T) {
+	fsys := fstest.MapFS{
+		"root/a.go":       {Data: []byte("aaaa")},
+		"root/b.GO":       {Data: []byte("bb")},
+		"root/dir/c.txt":  {Data: []byte("ccc")},
+		"root/dir/README": {Data: []byte("r")},
+		"other.txt":       {Data: []byte("other")},
+	}
+	want := &Usage{
+		Bytes: 10,
+		Files: 4,
+		Dirs:  2,
+		Largest: []FileSize{
+			{Name: "root/a.go", Size: 4},
+			{Name: "root/dir/c.txt", Size: 3},
+		},
+		Exts: map[string]*ExtUsage{
+			".go":  {Files: 2, Bytes: 6},
+			".txt": {Files: 1, Bytes: 3},
+			"":     {Files: 1, Bytes: 1},
+		},
+	}
+	for _, opts := range [][]DirStatsOption{
+		{WithLargestFiles(2)},
+		{WithLargestFiles(2), WithParallel(4)},
+	} {
+		got, err := DirStats(fsys, "root", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("unexpected %+v; want %+v", got, want)
+		}
+	}
+}
+
+func TestDirStatsFunc(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt": {Data: []byte("a")},
+		"b.txt": {Data: []byte("b")},
+	}
+	var names []string
+	_, err := DirStatsFunc(fsys, ".", func(name string, _ fs.FileInfo) error {
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.txt", "b.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("unexpected %v; want %v", names, want)
+	}
+
+	wantErr := errors.New("stop")
+	_, err = DirStatsFunc(fsys, ".", func(string, fs.FileInfo) error {
+		return wantErr
+	}, WithParallel(2))
+	if !errors.Is(err, wantErr) {
+		t.Errorf("unexpected %v; want %v", err, wantErr)
+	}
+}
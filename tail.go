@@ -0,0 +1,146 @@
+package wfs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// TailPollInterval is the interval of polling the file of Tail on a
+// filesystem that does not implement WatchFS.
+var TailPollInterval = time.Second
+
+// TailLine is a line read by Tail. Err is set in the last value sent before
+// the channel is closed if reading fails.
+type TailLine struct {
+	Text string
+	Err  error
+}
+
+// Tail reads the lines of the named file from the beginning and sends them
+// to the returned channel without the line endings. If follow is false the
+// channel is closed at the end of the file. If follow is true Tail keeps
+// reading lines appended to the file until ctx is done, waiting for changes
+// using Watch if the filesystem implements WatchFS or polling every
+// TailPollInterval otherwise. A file that becomes shorter is read again from
+// the beginning and a removed file is waited for to be created again, so
+// rotated log files can be followed.
+func Tail(ctx context.Context, fsys fs.FS, name string, follow bool) (<-chan TailLine, error) {
+	if _, err := fs.Stat(fsys, name); err != nil {
+		return nil, err
+	}
+	var events <-chan WatchEvent
+	if follow {
+		if ch, err := Watch(ctx, fsys, path.Dir(name)); err == nil {
+			events = ch
+		}
+	}
+	t := &tailer{ctx: ctx, fsys: fsys, name: name, lines: make(chan TailLine)}
+	go t.run(follow, events)
+	return t.lines, nil
+}
+
+type tailer struct {
+	ctx     context.Context
+	fsys    fs.FS
+	name    string
+	lines   chan TailLine
+	off     int64
+	partial string
+}
+
+func (t *tailer) run(follow bool, events <-chan WatchEvent) {
+	defer close(t.lines)
+
+	if err := t.read(); err != nil {
+		t.send(TailLine{Err: err})
+		return
+	}
+	if !follow {
+		if t.partial != "" {
+			t.send(TailLine{Text: t.partial})
+		}
+		return
+	}
+
+	var tick <-chan time.Time
+	if events == nil {
+		ticker := time.NewTicker(TailPollInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			if e.Name != t.name {
+				continue
+			}
+		case <-tick:
+		}
+		if err := t.read(); err != nil {
+			t.send(TailLine{Err: err})
+			return
+		}
+	}
+}
+
+// read sends the complete lines written after the offset.
+func (t *tailer) read() error {
+	info, err := fs.Stat(t.fsys, t.name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			t.off, t.partial = 0, ""
+			return nil
+		}
+		return err
+	}
+	if info.Size() < t.off {
+		t.off, t.partial = 0, ""
+	}
+	if info.Size() == t.off {
+		return nil
+	}
+	r, err := OpenRange(t.fsys, t.name, t.off, -1)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	br := bufio.NewReader(r)
+	for {
+		s, err := br.ReadString('\n')
+		t.off += int64(len(s))
+		if err == io.EOF {
+			t.partial += s
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line := strings.TrimSuffix(strings.TrimSuffix(t.partial+s, "\n"), "\r")
+		t.partial = ""
+		if !t.send(TailLine{Text: line}) {
+			return nil
+		}
+	}
+}
+
+// send sends the line and reports whether it was sent before ctx is done.
+func (t *tailer) send(line TailLine) bool {
+	select {
+	case t.lines <- line:
+		return true
+	case <-t.ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,121 @@
+package wfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type tailFS struct {
+	mutex  sync.Mutex
+	data   []byte
+	events chan WatchEvent
+}
+
+func (fsys *tailFS) Open(name string) (fs.File, error) {
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	m := fstest.MapFS{}
+	if fsys.data != nil {
+		m["logs/app.log"] = &fstest.MapFile{Data: append([]byte{}, fsys.data...)}
+	}
+	return m.Open(name)
+}
+
+func (fsys *tailFS) write(data string) {
+	fsys.mutex.Lock()
+	fsys.data = []byte(data)
+	fsys.mutex.Unlock()
+	if fsys.events != nil {
+		fsys.events <- WatchEvent{Op: WatchWrite, Name: "logs/app.log"}
+	}
+}
+
+type tailWatchFS struct {
+	*tailFS
+}
+
+func (fsys *tailWatchFS) Watch(ctx context.Context, dir string) (<-chan WatchEvent, error) {
+	return fsys.events, nil
+}
+
+func receiveLines(t *testing.T, ch <-chan TailLine, n int) []string {
+	var lines []string
+	for len(lines) < n {
+		select {
+		case line, ok := <-ch:
+			if !ok {
+				return lines
+			}
+			if line.Err != nil {
+				t.Fatal(line.Err)
+			}
+			lines = append(lines, line.Text)
+		case <-time.After(time.Second):
+			t.Fatalf("timeout: %v", lines)
+		}
+	}
+	return lines
+}
+
+func TestTail(t *testing.T) {
+	fsys := &tailFS{data: []byte("a\r\nb\nc")}
+	ch, err := Tail(context.Background(), fsys, "logs/app.log", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := receiveLines(t, ch, 4), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+
+	if _, err := Tail(context.Background(), fsys, "missing.log", false); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestTail_Follow(t *testing.T) {
+	interval := TailPollInterval
+	TailPollInterval = time.Millisecond
+	defer func() { TailPollInterval = interval }()
+
+	testCases := map[string]fs.FS{
+		"poll":  &tailFS{data: []byte("a\nb")},
+		"watch": &tailWatchFS{tailFS: &tailFS{data: []byte("a\nb"), events: make(chan WatchEvent)}},
+	}
+	for caseName, fsys := range testCases {
+		t.Run(caseName, func(t *testing.T) {
+			tfs, ok := fsys.(*tailFS)
+			if !ok {
+				tfs = fsys.(*tailWatchFS).tailFS
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ch, err := Tail(ctx, fsys, "logs/app.log", true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := receiveLines(t, ch, 1), []string{"a"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("unexpected %v; want %v", got, want)
+			}
+			tfs.write("a\nbc\nd\n")
+			if got, want := receiveLines(t, ch, 2), []string{"bc", "d"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("unexpected %v; want %v", got, want)
+			}
+			tfs.write("r\n")
+			if got, want := receiveLines(t, ch, 1), []string{"r"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("unexpected %v; want %v", got, want)
+			}
+
+			cancel()
+			for range ch {
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package wfs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"strings"
+)
+
+// tempDirRetries is the number of attempts of TempDir to find an unused name.
+const tempDirRetries = 100
+
+// TempDir creates a new directory named by pattern whose last "*" is replaced
+// by a random string, or a random string is appended if pattern has no "*",
+// such as "tmp/test-*" to "tmp/test-3f2a9c1d5e6b7a80". TempDir returns the
+// name of the directory and a cleanup function that removes the directory
+// and its contents by RemoveAll. Callers should call cleanup when the
+// directory is no longer needed.
+func TempDir(fsys WriteFileFS, pattern string) (dir string, cleanup func() error, err error) {
+	prefix, suffix := pattern, ""
+	if i := strings.LastIndex(pattern, "*"); i >= 0 {
+		prefix, suffix = pattern[:i], pattern[i+1:]
+	}
+	for i := 0; i < tempDirRetries; i++ {
+		random, err := tempDirRandom()
+		if err != nil {
+			return "", nil, err
+		}
+		dir := prefix + random + suffix
+		if !fs.ValidPath(dir) {
+			return "", nil, &fs.PathError{Op: "TempDir", Path: pattern, Err: fs.ErrInvalid}
+		}
+		_, err = fs.Stat(fsys, dir)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", nil, err
+		}
+		if err := fsys.MkdirAll(dir, 0700); err != nil {
+			return "", nil, err
+		}
+		return dir, func() error { return RemoveAll(fsys, dir) }, nil
+	}
+	return "", nil, &fs.PathError{Op: "TempDir", Path: pattern, Err: fs.ErrExist}
+}
+
+func tempDirRandom() (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,60 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"regexp"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTempDir(t *testing.T) {
+	m := fstest.MapFS{}
+	fsys := newMapWriteFS(m)
+	fsys.RemoveAllFunc = func(dir string) error {
+		for name := range m {
+			if name == dir || len(name) > len(dir) && name[:len(dir)+1] == dir+"/" {
+				delete(m, name)
+			}
+		}
+		return nil
+	}
+
+	dir, cleanup, err := TempDir(fsys, "tmp/test-*.d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^tmp/test-[0-9a-f]{16}\.d$`).MatchString(dir) {
+		t.Errorf("unexpected %s", dir)
+	}
+	dir2, _, err := TempDir(fsys, "tmp/test-*.d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir2 == dir {
+		t.Errorf("unexpected same %s", dir2)
+	}
+	if _, err := fsys.WriteFile(dir+"/a.txt", []byte("a"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := cleanup(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.Stat(fsys, dir); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	if _, err := fs.Stat(fsys, dir2); err != nil {
+		t.Errorf("unexpected %v", err)
+	}
+
+	dir, _, err = TempDir(fsys, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^test[0-9a-f]{16}$`).MatchString(dir) {
+		t.Errorf("unexpected %s", dir)
+	}
+	if _, _, err := TempDir(fsys, "/tmp/*"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrInvalid)
+	}
+}
@@ -0,0 +1,61 @@
+package wfs
+
+import (
+	"bytes"
+	"io/fs"
+	"path"
+	"text/template"
+)
+
+// TemplateFS is a filesystem that renders files matching patterns as
+// text/template with data on read. A pattern that contains no "/" is matched
+// by path.Match against the base name of a file, otherwise against the path
+// of a file. Writes are delegated to the wrapped filesystem untouched. A
+// template that fails to parse or execute, including a reference to a missing
+// key of data, returns a fs.PathError on read.
+type TemplateFS struct {
+	*transformFS
+}
+
+var (
+	_ fs.ReadDirFS  = (*TemplateFS)(nil)
+	_ fs.ReadFileFS = (*TemplateFS)(nil)
+	_ fs.StatFS     = (*TemplateFS)(nil)
+	_ fs.SubFS      = (*TemplateFS)(nil)
+	_ WriteFileFS   = (*TemplateFS)(nil)
+	_ RemoveFileFS  = (*TemplateFS)(nil)
+)
+
+// NewTemplateFS returns a TemplateFS that renders files matching patterns
+// with data.
+func NewTemplateFS(fsys fs.FS, patterns []string, data map[string]interface{}) (*TemplateFS, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, err
+		}
+	}
+	return &TemplateFS{
+		transformFS: newTransformFS(fsys, func(name string) transformFunc {
+			for _, pattern := range patterns {
+				if MatchFile(pattern, name) {
+					return func(p []byte) ([]byte, error) {
+						return renderTemplate(name, p, data)
+					}
+				}
+			}
+			return nil
+		}),
+	}, nil
+}
+
+func renderTemplate(name string, p []byte, data map[string]interface{}) ([]byte, error) {
+	t, err := template.New(path.Base(name)).Option("missingkey=error").Parse(string(p))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
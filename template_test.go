@@ -0,0 +1,69 @@
+package wfs
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTemplateFS(t *testing.T) {
+	m := fstest.MapFS{
+		"index.html.tmpl":    {Data: []byte("<h1>{{.Title}}</h1>")},
+		"conf/app.yaml.tmpl": {Data: []byte("env: {{.Env}}")},
+		"static.txt":         {Data: []byte("{{.Title}}")},
+	}
+	fsys, err := NewTemplateFS(newMapWriteFS(m), []string{"*.tmpl"}, map[string]interface{}{
+		"Title": "Hello",
+		"Env":   "prod",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(fsys, "index.html.tmpl", "conf/app.yaml.tmpl", "static.txt"); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"index.html.tmpl":    "<h1>Hello</h1>",
+		"conf/app.yaml.tmpl": "env: prod",
+		"static.txt":         "{{.Title}}",
+	}
+	for name, want := range tests {
+		got, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: unexpected %q; want %q", name, got, want)
+		}
+	}
+
+	dest := fstest.MapFS{}
+	if err := CopyFS(newMapWriteFS(dest), fsys, "."); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(dest["conf/app.yaml.tmpl"].Data); got != "env: prod" {
+		t.Errorf("unexpected copied %q", got)
+	}
+}
+
+func TestTemplateFS_Errors(t *testing.T) {
+	m := fstest.MapFS{
+		"missing.tmpl": {Data: []byte("{{.Missing}}")},
+		"invalid.tmpl": {Data: []byte("{{")},
+	}
+	fsys, err := NewTemplateFS(m, []string{"*.tmpl"}, map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name := range m {
+		if _, err := fsys.ReadFile(name); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+		if _, err := fsys.Open(name); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+	if _, err := NewTemplateFS(m, []string{"["}, nil); err == nil {
+		t.Errorf("no error")
+	}
+}
@@ -0,0 +1,108 @@
+package wfs
+
+import (
+	"context"
+	"io/fs"
+	"strings"
+	"sync"
+)
+
+// TenantOption is an option for NewTenantFS.
+type TenantOption func(fsys *TenantFS)
+
+// WithTenantBackend makes TenantFS use the filesystem returned by backend as
+// the filesystem of a tenant instead of the subtree of the base named by the
+// tenant id. The filesystem of each tenant is created once and reused.
+func WithTenantBackend(backend func(tenantID string) (fs.FS, error)) TenantOption {
+	return func(fsys *TenantFS) {
+		fsys.backend = backend
+	}
+}
+
+// WithTenantWrap wraps the filesystem of a tenant by wrap on each ForContext,
+// such as an ACLFS authorizing the principal of ctx or a quota wrapper.
+// Multiple wraps are applied in order.
+func WithTenantWrap(wrap func(ctx context.Context, tenantID string, fsys fs.FS) (fs.FS, error)) TenantOption {
+	return func(fsys *TenantFS) {
+		fsys.wraps = append(fsys.wraps, wrap)
+	}
+}
+
+// TenantFS routes the operations of each tenant to the filesystem of the
+// tenant. The tenant of the operations is resolved from a context by
+// ForContext, so handlers call ForContext with the context of a request and
+// use the returned filesystem.
+type TenantFS struct {
+	base     fs.FS
+	resolver func(ctx context.Context) string
+	backend  func(tenantID string) (fs.FS, error)
+	wraps    []func(ctx context.Context, tenantID string, fsys fs.FS) (fs.FS, error)
+
+	mutex   sync.Mutex
+	tenants map[string]fs.FS
+}
+
+// NewTenantFS returns a TenantFS that resolves the tenant id from a context
+// by resolver. By default the filesystem of a tenant is the subtree of base
+// named by the tenant id that is created if base implements WriteFileFS.
+func NewTenantFS(base fs.FS, resolver func(ctx context.Context) string, opts ...TenantOption) *TenantFS {
+	fsys := &TenantFS{
+		base:     base,
+		resolver: resolver,
+		tenants:  map[string]fs.FS{},
+	}
+	for _, opt := range opts {
+		opt(fsys)
+	}
+	return fsys
+}
+
+// ForContext returns the filesystem of the tenant resolved from ctx. An empty
+// tenant id or an id that is not a valid portable name returns a PathError of
+// fs.ErrPermission.
+func (fsys *TenantFS) ForContext(ctx context.Context) (fs.FS, error) {
+	id := fsys.resolver(ctx)
+	if id == "" || id == "." || strings.Contains(id, "/") || !validPortableElem(id) {
+		return nil, &fs.PathError{Op: "Tenant", Path: id, Err: fs.ErrPermission}
+	}
+	tenant, err := fsys.tenant(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, wrap := range fsys.wraps {
+		if tenant, err = wrap(ctx, id, tenant); err != nil {
+			return nil, err
+		}
+	}
+	return tenant, nil
+}
+
+func (fsys *TenantFS) tenant(id string) (fs.FS, error) {
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	if tenant, ok := fsys.tenants[id]; ok {
+		return tenant, nil
+	}
+	var tenant fs.FS
+	var err error
+	if fsys.backend != nil {
+		tenant, err = fsys.backend(id)
+	} else {
+		tenant, err = fsys.subTenant(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fsys.tenants[id] = tenant
+	return tenant, nil
+}
+
+func (fsys *TenantFS) subTenant(id string) (fs.FS, error) {
+	if wfsys, ok := fsys.base.(WriteFileFS); ok {
+		if err := wfsys.MkdirAll(id, fs.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+	return fs.Sub(fsys.base, id)
+}
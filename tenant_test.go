@@ -0,0 +1,96 @@
+package wfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func tenantFromPrincipal(ctx context.Context) string {
+	id, _ := PrincipalFrom(ctx)
+	return id
+}
+
+func TestTenantFS(t *testing.T) {
+	m := fstest.MapFS{
+		"acme/a.txt":   {Data: []byte("acme")},
+		"globex/a.txt": {Data: []byte("globex")},
+	}
+	tfs := NewTenantFS(newMapWriteFS(m), tenantFromPrincipal)
+
+	for _, id := range []string{"acme", "globex"} {
+		fsys, err := tfs.ForContext(WithPrincipal(context.Background(), id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := fs.ReadFile(fsys, "a.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != id {
+			t.Errorf("unexpected %s; want %s", got, id)
+		}
+	}
+
+	fsys, err := tfs.ForContext(WithPrincipal(context.Background(), "initech"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["initech"]; !ok {
+		t.Errorf("unexpected no initech directory")
+	}
+	if _, err := fs.ReadFile(fsys, "a.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+
+	for _, id := range []string{"", ".", "..", "a/b", "CON"} {
+		_, err := tfs.ForContext(WithPrincipal(context.Background(), id))
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("%q: unexpected %v; want %v", id, err, fs.ErrPermission)
+		}
+	}
+}
+
+func TestTenantFS_Options(t *testing.T) {
+	backends := map[string]fs.FS{
+		"acme": fstest.MapFS{
+			"public/a.txt":  {Data: []byte("a")},
+			"private/b.txt": {Data: []byte("b")},
+		},
+	}
+	calls := 0
+	tfs := NewTenantFS(nil, tenantFromPrincipal,
+		WithTenantBackend(func(id string) (fs.FS, error) {
+			calls++
+			if b, ok := backends[id]; ok {
+				return b, nil
+			}
+			return nil, &fs.PathError{Op: "Tenant", Path: id, Err: fs.ErrNotExist}
+		}),
+		WithTenantWrap(func(ctx context.Context, id string, fsys fs.FS) (fs.FS, error) {
+			return NewACLFS(fsys, []ACLRule{{Pattern: "public", Access: AccessRead}})
+		}),
+	)
+
+	ctx := WithPrincipal(context.Background(), "acme")
+	for i := 0; i < 2; i++ {
+		fsys, err := tfs.ForContext(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fs.ReadFile(fsys, "public/a.txt"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fs.ReadFile(fsys, "private/b.txt"); !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("unexpected %v; want %v", err, fs.ErrPermission)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("unexpected %d backend calls; want 1", calls)
+	}
+	if _, err := tfs.ForContext(WithPrincipal(context.Background(), "other")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+}
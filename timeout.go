@@ -0,0 +1,199 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// TimeoutFS is a filesystem that enforces a deadline per operation of the
+// wrapped filesystem. An operation that exceeds its deadline returns a
+// fs.PathError of os.ErrDeadlineExceeded. The operation keeps running in the
+// background and a file opened or created after the deadline is closed.
+type TimeoutFS struct {
+	fsys     fs.FS
+	timeouts map[Op]time.Duration
+}
+
+var (
+	_ fs.GlobFS     = (*TimeoutFS)(nil)
+	_ fs.ReadDirFS  = (*TimeoutFS)(nil)
+	_ fs.ReadFileFS = (*TimeoutFS)(nil)
+	_ fs.StatFS     = (*TimeoutFS)(nil)
+	_ fs.SubFS      = (*TimeoutFS)(nil)
+	_ WriteFileFS   = (*TimeoutFS)(nil)
+	_ RemoveFileFS  = (*TimeoutFS)(nil)
+)
+
+// NewTimeoutFS returns a TimeoutFS. Operations that are not in timeouts or
+// have a non-positive timeout are not limited.
+func NewTimeoutFS(fsys fs.FS, timeouts map[Op]time.Duration) *TimeoutFS {
+	return &TimeoutFS{fsys: fsys, timeouts: timeouts}
+}
+
+// FS returns fsys as a filesystem that implements WriteFileFS and
+// RemoveFileFS only if the wrapped filesystem implements them.
+func (fsys *TimeoutFS) FS() fs.FS {
+	return narrowFS(fsys, fsys.fsys)
+}
+
+// call calls fn and waits for it until the timeout of op. If fn completes
+// without error after the timeout, cleanup is called.
+func (fsys *TimeoutFS) call(op Op, name string, fn func() error, cleanup func()) error {
+	d := fsys.timeouts[op]
+	if d <= 0 {
+		return fn()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		if cleanup != nil {
+			go func() {
+				if err := <-done; err == nil {
+					cleanup()
+				}
+			}()
+		}
+		return &fs.PathError{Op: string(op), Path: name, Err: os.ErrDeadlineExceeded}
+	}
+}
+
+// Open opens the named file.
+func (fsys *TimeoutFS) Open(name string) (fs.File, error) {
+	var f fs.File
+	err := fsys.call(OpOpen, name, func() (err error) {
+		f, err = fsys.fsys.Open(name)
+		return
+	}, func() {
+		f.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ReadDir reads the named directory.
+func (fsys *TimeoutFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	err := fsys.call(OpReadDir, dir, func() (err error) {
+		entries, err = fs.ReadDir(fsys.fsys, dir)
+		return
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ReadFile reads the named file and returns its contents.
+func (fsys *TimeoutFS) ReadFile(name string) ([]byte, error) {
+	var p []byte
+	err := fsys.call(OpReadFile, name, func() (err error) {
+		p, err = fs.ReadFile(fsys.fsys, name)
+		return
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Glob returns the names of all files matching pattern.
+func (fsys *TimeoutFS) Glob(pattern string) ([]string, error) {
+	var names []string
+	err := fsys.call(OpGlob, pattern, func() (err error) {
+		names, err = Glob(fsys.fsys, pattern)
+		return
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// Stat returns a FileInfo describing the named file.
+func (fsys *TimeoutFS) Stat(name string) (fs.FileInfo, error) {
+	var info fs.FileInfo
+	err := fsys.call(OpStat, name, func() (err error) {
+		info, err = fs.Stat(fsys.fsys, name)
+		return
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Sub returns a TimeoutFS corresponding to the subtree rooted at dir.
+func (fsys *TimeoutFS) Sub(dir string) (fs.FS, error) {
+	var sub fs.FS
+	err := fsys.call(OpSub, dir, func() (err error) {
+		sub, err = fs.Sub(fsys.fsys, dir)
+		return
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewTimeoutFS(sub, fsys.timeouts), nil
+}
+
+// MkdirAll creates the named directory.
+func (fsys *TimeoutFS) MkdirAll(dir string, mode fs.FileMode) error {
+	return fsys.call(OpMkdirAll, dir, func() error {
+		return MkdirAll(fsys.fsys, dir, mode)
+	}, nil)
+}
+
+// CreateFile creates the named file.
+func (fsys *TimeoutFS) CreateFile(name string, mode fs.FileMode) (WriterFile, error) {
+	var f WriterFile
+	err := fsys.call(OpCreateFile, name, func() (err error) {
+		f, err = CreateFile(fsys.fsys, name, mode)
+		return
+	}, func() {
+		f.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// WriteFile writes the specified bytes to the named file.
+func (fsys *TimeoutFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	var n int
+	err := fsys.call(OpWriteFile, name, func() (err error) {
+		n, err = WriteFile(fsys.fsys, name, p, mode)
+		return
+	}, nil)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		// NOTE: n may be still written by the timed out operation.
+		return 0, err
+	}
+	return n, err
+}
+
+// RemoveFile removes the named file.
+func (fsys *TimeoutFS) RemoveFile(name string) error {
+	return fsys.call(OpRemoveFile, name, func() error {
+		return RemoveFile(fsys.fsys, name)
+	}, nil)
+}
+
+// RemoveAll removes path and any children it contains.
+func (fsys *TimeoutFS) RemoveAll(path string) error {
+	return fsys.call(OpRemoveAll, path, func() error {
+		return RemoveAll(fsys.fsys, path)
+	}, nil)
+}
@@ -0,0 +1,69 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestTimeoutFS(t *testing.T) {
+	m := fstest.MapFS{"test.txt": {Data: []byte("test")}}
+	block := make(chan struct{})
+	defer close(block)
+
+	d := newMapWriteFS(m)
+	d.StatFunc = func(name string) (fs.FileInfo, error) {
+		<-block
+		return nil, fs.ErrNotExist
+	}
+	fsys := NewTimeoutFS(d, map[Op]time.Duration{
+		OpStat:     10 * time.Millisecond,
+		OpReadFile: time.Second,
+	})
+
+	if _, err := fsys.Stat("test.txt"); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("unexpected %v; want %v", err, os.ErrDeadlineExceeded)
+	}
+	p, err := fsys.ReadFile("test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p) != "test" {
+		t.Errorf("unexpected %s", p)
+	}
+	if _, err := fsys.WriteFile("new.txt", []byte("new"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["new.txt"]; !ok {
+		t.Errorf("new.txt is not written")
+	}
+}
+
+func TestTimeoutFS_Open(t *testing.T) {
+	closed := make(chan struct{})
+	block := make(chan struct{})
+	fsys := NewTimeoutFS(&OpenFSDelegator{
+		OpenFunc: func(name string) (fs.File, error) {
+			<-block
+			return &FileDelegator{
+				CloseFunc: func() error {
+					close(closed)
+					return nil
+				},
+			}, nil
+		},
+	}, map[Op]time.Duration{OpOpen: 10 * time.Millisecond})
+
+	if _, err := fsys.Open("test.txt"); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("unexpected %v; want %v", err, os.ErrDeadlineExceeded)
+	}
+	close(block)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Errorf("file opened after the deadline is not closed")
+	}
+}
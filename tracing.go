@@ -0,0 +1,245 @@
+package wfs
+
+import (
+	"io"
+	"io/fs"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// TraceAttrPath is the attribute key of the path of an operation.
+	TraceAttrPath = "wfs.path"
+	// TraceAttrBytes is the attribute key of the number of bytes read or
+	// written by an operation.
+	TraceAttrBytes = "wfs.bytes"
+)
+
+// Tracer is the interface to start a span of an operation. An implementation
+// typically wraps go.opentelemetry.io/otel/trace.Tracer.
+type Tracer interface {
+	Start(op Op) Span
+}
+
+// Span is the interface of a span started by Tracer.
+type Span interface {
+	// SetAttribute sets an attribute of the span.
+	SetAttribute(key string, value interface{})
+	// End ends the span with the error of the operation that may be nil.
+	End(err error)
+}
+
+// TracingFS is a filesystem that starts a span per operation of the wrapped
+// filesystem with the path, byte-count and error of the operation. The spans
+// of Open and CreateFile end when the file is closed, so they count the bytes
+// read from or written to the file and end with the error of Close.
+type TracingFS struct {
+	fsys   fs.FS
+	tracer Tracer
+}
+
+var (
+	_ fs.GlobFS     = (*TracingFS)(nil)
+	_ fs.ReadDirFS  = (*TracingFS)(nil)
+	_ fs.ReadFileFS = (*TracingFS)(nil)
+	_ fs.StatFS     = (*TracingFS)(nil)
+	_ fs.SubFS      = (*TracingFS)(nil)
+	_ WriteFileFS   = (*TracingFS)(nil)
+	_ RemoveFileFS  = (*TracingFS)(nil)
+)
+
+// NewTracingFS returns a TracingFS.
+func NewTracingFS(fsys fs.FS, tracer Tracer) *TracingFS {
+	return &TracingFS{fsys: fsys, tracer: tracer}
+}
+
+// FS returns fsys as a filesystem that implements WriteFileFS and
+// RemoveFileFS only if the traced filesystem implements them.
+func (fsys *TracingFS) FS() fs.FS {
+	return narrowFS(fsys, fsys.fsys)
+}
+
+// trace calls fn in a span of op. fn returns the number of bytes or -1 if the
+// operation does not read or write bytes.
+func (fsys *TracingFS) trace(op Op, name string, fn func() (int64, error)) error {
+	span := fsys.start(op, name)
+	n, err := fn()
+	endSpan(span, n, err)
+	return err
+}
+
+func (fsys *TracingFS) start(op Op, name string) Span {
+	span := fsys.tracer.Start(op)
+	span.SetAttribute(TraceAttrPath, name)
+	return span
+}
+
+func endSpan(span Span, n int64, err error) {
+	if n >= 0 {
+		span.SetAttribute(TraceAttrBytes, n)
+	}
+	span.End(err)
+}
+
+// traceFile returns f that ends span with the number of bytes read or written
+// when f is closed or aborted. The returned file implements the optional
+// interfaces of f such as io.ReaderAt and AbortWriterFile.
+func traceFile(f fs.File, span Span) fs.File {
+	var n int64
+	var once sync.Once
+	end := func(err error) {
+		once.Do(func() {
+			endSpan(span, atomic.LoadInt64(&n), err)
+		})
+	}
+	count := func(m int, err error) (int, error) {
+		atomic.AddInt64(&n, int64(m))
+		return m, err
+	}
+	d := DelegateFile(f)
+	d.ReadFunc = func(p []byte) (int, error) {
+		return count(f.Read(p))
+	}
+	if write := d.WriteFunc; write != nil {
+		d.WriteFunc = func(p []byte) (int, error) {
+			return count(write(p))
+		}
+	}
+	if readAt := d.ReadAtFunc; readAt != nil {
+		d.ReadAtFunc = func(p []byte, off int64) (int, error) {
+			return count(readAt(p, off))
+		}
+	}
+	if writeAt := d.WriteAtFunc; writeAt != nil {
+		d.WriteAtFunc = func(p []byte, off int64) (int, error) {
+			return count(writeAt(p, off))
+		}
+	}
+	if readFrom := d.ReadFromFunc; readFrom != nil {
+		d.ReadFromFunc = func(r io.Reader) (int64, error) {
+			m, err := readFrom(r)
+			atomic.AddInt64(&n, m)
+			return m, err
+		}
+	}
+	d.CloseFunc = func() error {
+		err := f.Close()
+		end(err)
+		return err
+	}
+	if abort := d.AbortFunc; abort != nil {
+		d.AbortFunc = func() error {
+			err := abort()
+			end(err)
+			return err
+		}
+	}
+	return d.File()
+}
+
+// Open opens the named file. The span ends when the file is closed.
+func (fsys *TracingFS) Open(name string) (fs.File, error) {
+	span := fsys.start(OpOpen, name)
+	f, err := fsys.fsys.Open(name)
+	if err != nil {
+		span.End(err)
+		return nil, err
+	}
+	return traceFile(f, span), nil
+}
+
+// ReadDir reads the named directory.
+func (fsys *TracingFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	err := fsys.trace(OpReadDir, dir, func() (n int64, err error) {
+		entries, err = fs.ReadDir(fsys.fsys, dir)
+		return -1, err
+	})
+	return entries, err
+}
+
+// ReadFile reads the named file and returns its contents.
+func (fsys *TracingFS) ReadFile(name string) ([]byte, error) {
+	var p []byte
+	err := fsys.trace(OpReadFile, name, func() (n int64, err error) {
+		p, err = fs.ReadFile(fsys.fsys, name)
+		return int64(len(p)), err
+	})
+	return p, err
+}
+
+// Glob returns the names of all files matching pattern.
+func (fsys *TracingFS) Glob(pattern string) ([]string, error) {
+	var names []string
+	err := fsys.trace(OpGlob, pattern, func() (n int64, err error) {
+		names, err = Glob(fsys.fsys, pattern)
+		return -1, err
+	})
+	return names, err
+}
+
+// Stat returns a FileInfo describing the named file.
+func (fsys *TracingFS) Stat(name string) (fs.FileInfo, error) {
+	var info fs.FileInfo
+	err := fsys.trace(OpStat, name, func() (n int64, err error) {
+		info, err = fs.Stat(fsys.fsys, name)
+		return -1, err
+	})
+	return info, err
+}
+
+// Sub returns a TracingFS corresponding to the subtree rooted at dir.
+func (fsys *TracingFS) Sub(dir string) (fs.FS, error) {
+	var sub fs.FS
+	err := fsys.trace(OpSub, dir, func() (n int64, err error) {
+		sub, err = fs.Sub(fsys.fsys, dir)
+		return -1, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewTracingFS(sub, fsys.tracer), nil
+}
+
+// MkdirAll creates the named directory.
+func (fsys *TracingFS) MkdirAll(dir string, mode fs.FileMode) error {
+	return fsys.trace(OpMkdirAll, dir, func() (int64, error) {
+		return -1, MkdirAll(fsys.fsys, dir, mode)
+	})
+}
+
+// CreateFile creates the named file. The span ends when the file is closed.
+func (fsys *TracingFS) CreateFile(name string, mode fs.FileMode) (WriterFile, error) {
+	span := fsys.start(OpCreateFile, name)
+	f, err := CreateFile(fsys.fsys, name, mode)
+	if err != nil {
+		span.End(err)
+		return nil, err
+	}
+	return traceFile(f, span).(WriterFile), nil
+}
+
+// WriteFile writes the specified bytes to the named file.
+func (fsys *TracingFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	var n int
+	err := fsys.trace(OpWriteFile, name, func() (int64, error) {
+		var err error
+		n, err = WriteFile(fsys.fsys, name, p, mode)
+		return int64(n), err
+	})
+	return n, err
+}
+
+// RemoveFile removes the named file.
+func (fsys *TracingFS) RemoveFile(name string) error {
+	return fsys.trace(OpRemoveFile, name, func() (int64, error) {
+		return -1, RemoveFile(fsys.fsys, name)
+	})
+}
+
+// RemoveAll removes path and any children it contains.
+func (fsys *TracingFS) RemoveAll(path string) error {
+	return fsys.trace(OpRemoveAll, path, func() (int64, error) {
+		return -1, RemoveAll(fsys.fsys, path)
+	})
+}
@@ -0,0 +1,160 @@
+package wfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+type testSpan struct {
+	op    Op
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) {
+	s.attrs[key] = value
+}
+
+func (s *testSpan) End(err error) {
+	s.err = err
+	s.ended = true
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (tr *testTracer) Start(op Op) Span {
+	s := &testSpan{op: op, attrs: map[string]interface{}{}}
+	tr.spans = append(tr.spans, s)
+	return s
+}
+
+func TestTracingFS(t *testing.T) {
+	m := fstest.MapFS{"test.txt": {Data: []byte("test")}}
+	tr := &testTracer{}
+	fsys := NewTracingFS(newMapWriteFS(m), tr)
+
+	if _, err := fsys.ReadFile("test.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("new.txt", []byte("new"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	_, wantErr := fsys.Stat("not-found.txt")
+	if !errors.Is(wantErr, fs.ErrNotExist) {
+		t.Fatalf("unexpected %v; want %v", wantErr, fs.ErrNotExist)
+	}
+
+	want := []*testSpan{
+		{op: OpReadFile, attrs: map[string]interface{}{TraceAttrPath: "test.txt", TraceAttrBytes: int64(4)}, ended: true},
+		{op: OpWriteFile, attrs: map[string]interface{}{TraceAttrPath: "new.txt", TraceAttrBytes: int64(3)}, ended: true},
+		{op: OpStat, attrs: map[string]interface{}{TraceAttrPath: "not-found.txt"}, err: wantErr, ended: true},
+	}
+	if !reflect.DeepEqual(tr.spans, want) {
+		t.Errorf("unexpected %v; want %v", tr.spans, want)
+	}
+}
+
+func TestTracingFS_Files(t *testing.T) {
+	m := fstest.MapFS{"test.txt": {Data: []byte("test")}}
+	tr := &testTracer{}
+	fsys := NewTracingFS(newMapWriteFS(m), tr)
+
+	f, err := fsys.Open("test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(f); err != nil {
+		t.Fatal(err)
+	}
+	if tr.spans[0].ended {
+		t.Errorf("unexpected span of Open is ended before Close")
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	w, err := fsys.CreateFile("new.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_, wantErr := fsys.Open("not-found.txt")
+	if !errors.Is(wantErr, fs.ErrNotExist) {
+		t.Fatalf("unexpected %v; want %v", wantErr, fs.ErrNotExist)
+	}
+
+	want := []*testSpan{
+		{op: OpOpen, attrs: map[string]interface{}{TraceAttrPath: "test.txt", TraceAttrBytes: int64(4)}, ended: true},
+		{op: OpCreateFile, attrs: map[string]interface{}{TraceAttrPath: "new.txt", TraceAttrBytes: int64(3)}, ended: true},
+		{op: OpOpen, attrs: map[string]interface{}{TraceAttrPath: "not-found.txt"}, err: wantErr, ended: true},
+	}
+	if !reflect.DeepEqual(tr.spans, want) {
+		t.Errorf("unexpected %v; want %v", tr.spans, want)
+	}
+}
+
+func TestTracingFS_FileInterfaces(t *testing.T) {
+	var aborted bool
+	d := DelegateFS(fstest.MapFS{"test.txt": {Data: []byte("test")}})
+	d.CreateFileFunc = func(name string, mode fs.FileMode) (WriterFile, error) {
+		f := &FileDelegator{
+			WriteAtFunc: func(p []byte, off int64) (int, error) {
+				return len(p), nil
+			},
+			AbortFunc: func() error {
+				aborted = true
+				return nil
+			},
+		}
+		return f.File().(WriterFile), nil
+	}
+	tr := &testTracer{}
+	fsys := NewTracingFS(d, tr)
+
+	f, err := fsys.Open("test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := make([]byte, 3)
+	if _, err := f.(io.ReaderAt).ReadAt(p, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	w, err := fsys.CreateFile("new.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := w.(io.Seeker); ok {
+		t.Errorf("unexpected io.Seeker that the traced file does not implement")
+	}
+	if _, err := WriteAt(w, []byte("new"), 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := Abort(fsys, "new.txt", w); err != nil {
+		t.Fatal(err)
+	}
+	if !aborted {
+		t.Errorf("unexpected Abort is not forwarded")
+	}
+
+	want := []*testSpan{
+		{op: OpOpen, attrs: map[string]interface{}{TraceAttrPath: "test.txt", TraceAttrBytes: int64(3)}, ended: true},
+		{op: OpCreateFile, attrs: map[string]interface{}{TraceAttrPath: "new.txt", TraceAttrBytes: int64(3)}, ended: true},
+	}
+	if !reflect.DeepEqual(tr.spans, want) {
+		t.Errorf("unexpected %v; want %v", tr.spans, want)
+	}
+}
@@ -0,0 +1,294 @@
+package wfs
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+)
+
+// transformFunc transforms the contents of a file on read.
+type transformFunc func(p []byte) ([]byte, error)
+
+// transformFS is a filesystem that transforms the contents of files on read
+// and delegates the other operations to the wrapped filesystem untouched.
+type transformFS struct {
+	*FSDelegator
+	fsys fs.FS
+	// root is the wrapped filesystem of the root that stores the metadata of
+	// files, because the filesystem returned by Sub may not implement
+	// MetadataFS.
+	root fs.FS
+	dir  string
+	// transformer returns the transformFunc of the named file that is a path
+	// from the root of the filesystem or nil.
+	transformer func(name string) transformFunc
+	// encoder returns the transformFunc that encodes the contents of the named
+	// file on write or nil. Writes are delegated untouched if encoder is nil.
+	encoder func(name string) transformFunc
+}
+
+func newTransformFS(fsys fs.FS, transformer func(name string) transformFunc) *transformFS {
+	return &transformFS{
+		FSDelegator: DelegateFS(fsys),
+		fsys:        fsys,
+		root:        fsys,
+		dir:         ".",
+		transformer: transformer,
+	}
+}
+
+// FS returns fsys as a filesystem that transforms the contents of files and
+// implements WriteFileFS and RemoveFileFS only if the wrapped filesystem
+// implements them.
+func (fsys *transformFS) FS() fs.FS {
+	return narrowFS(fsys, fsys.fsys)
+}
+
+// withEncoder sets the encoder and wraps the write functions of fsys to encode
+// the contents of files. If the wrapped filesystem implements MetadataFS the
+// size of the contents before encoding is stored as MetaDecodedSize.
+func (fsys *transformFS) withEncoder(encoder func(name string) transformFunc) *transformFS {
+	fsys.encoder = encoder
+	writeFileFunc := fsys.WriteFileFunc
+	if writeFileFunc == nil {
+		return fsys
+	}
+	fsys.WriteFileFunc = func(name string, p []byte, mode fs.FileMode) (int, error) {
+		fn := fsys.encode(name)
+		if fn == nil {
+			return writeFileFunc(name, p, mode)
+		}
+		encoded, err := fn(p)
+		if err != nil {
+			return 0, &fs.PathError{Op: string(OpWriteFile), Path: name, Err: err}
+		}
+		if _, ok := fsys.root.(MetadataFS); ok {
+			meta := Metadata{MetaDecodedSize: strconv.Itoa(len(p))}
+			_, err = WriteFileWithMeta(fsys.root, path.Join(fsys.dir, name), encoded, mode, meta)
+		} else {
+			_, err = writeFileFunc(name, encoded, mode)
+		}
+		if err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if createFileFunc := fsys.CreateFileFunc; createFileFunc != nil {
+		fsys.CreateFileFunc = func(name string, mode fs.FileMode) (WriterFile, error) {
+			if fsys.encode(name) == nil {
+				return createFileFunc(name, mode)
+			}
+			return fsys.encodingFile(name, mode), nil
+		}
+	}
+	return fsys
+}
+
+// encodingFile returns a WriterFile that buffers the written contents and
+// writes them by WriteFile on Close, because the whole contents are encoded at
+// once.
+func (fsys *transformFS) encodingFile(name string, mode fs.FileMode) WriterFile {
+	var buf []byte
+	return &FileDelegator{
+		StatFunc: func() (fs.FileInfo, error) {
+			return &FileInfoDelegator{
+				Values: FileInfoValues{
+					Name: path.Base(name),
+					Size: int64(len(buf)),
+					Mode: mode,
+				},
+			}, nil
+		},
+		WriteFunc: func(p []byte) (int, error) {
+			buf = append(buf, p...)
+			return len(p), nil
+		},
+		CloseFunc: func() error {
+			_, err := fsys.WriteFileFunc(name, buf, mode)
+			return err
+		},
+	}
+}
+
+func (fsys *transformFS) encode(name string) transformFunc {
+	if fsys.encoder == nil {
+		return nil
+	}
+	return fsys.encoder(path.Join(fsys.dir, name))
+}
+
+// UnknownSize is the Size of a fs.FileInfo of a file whose size is not known
+// without reading the file, such as a file stored encoded by TransformFS
+// without MetaDecodedSize. Callers must not use the Size as a length of the
+// contents if it is UnknownSize.
+const UnknownSize int64 = -1
+
+// EncodedInfo is a fs.FileInfo of a file whose contents are transformed on
+// read. Size returns the size of the transformed contents that Open and
+// ReadFile return or UnknownSize and EncodedSize returns the size of the
+// contents stored in the wrapped filesystem.
+type EncodedInfo interface {
+	fs.FileInfo
+	EncodedSize() int64
+}
+
+type encodedInfo struct {
+	*FileInfoDelegator
+	encodedSize int64
+}
+
+var _ EncodedInfo = (*encodedInfo)(nil)
+
+// newEncodedInfo returns an EncodedInfo of the stored info whose Size is size.
+func newEncodedInfo(info fs.FileInfo, size int64) *encodedInfo {
+	d := DelegateFileInfo(info)
+	d.Values.Size = size
+	return &encodedInfo{FileInfoDelegator: d, encodedSize: info.Size()}
+}
+
+func (info *encodedInfo) EncodedSize() int64 {
+	return info.encodedSize
+}
+
+func (fsys *transformFS) transform(name string) transformFunc {
+	return fsys.transformer(path.Join(fsys.dir, name))
+}
+
+// Open opens the named file. The contents of a file to transform are read and
+// transformed at Open, so the Stat of the opened file reports the exact size
+// of the transformed contents.
+func (fsys *transformFS) Open(name string) (fs.File, error) {
+	f, err := fsys.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		d := DelegateFile(f)
+		if rd, ok := f.(fs.ReadDirFile); ok {
+			d.ReadDirFunc = func(n int) ([]fs.DirEntry, error) {
+				entries, err := rd.ReadDir(n)
+				return fsys.transformEntries(name, entries), err
+			}
+		}
+		return d, nil
+	}
+	fn := fsys.transform(name)
+	if fn == nil {
+		return f, nil
+	}
+	defer f.Close()
+
+	p, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if p, err = fn(p); err != nil {
+		return nil, &fs.PathError{Op: string(OpOpen), Path: name, Err: err}
+	}
+	return NewBytesFile(newEncodedInfo(info, int64(len(p))), p), nil
+}
+
+// ReadDir reads the named directory. The sizes of files to transform are the
+// sizes that Stat reports.
+func (fsys *transformFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(fsys.fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return fsys.transformEntries(dir, entries), nil
+}
+
+// transformEntries replaces Info of the entries to transform with Stat of
+// fsys.
+func (fsys *transformFS) transformEntries(dir string, entries []fs.DirEntry) []fs.DirEntry {
+	for i, e := range entries {
+		name := path.Join(dir, e.Name())
+		if e.IsDir() || fsys.transform(name) == nil {
+			continue
+		}
+		d := DelegateDirEntry(e)
+		d.InfoFunc = func() (fs.FileInfo, error) {
+			return fsys.Stat(name)
+		}
+		entries[i] = d
+	}
+	return entries
+}
+
+// ReadFile reads the named file and returns its transformed contents.
+func (fsys *transformFS) ReadFile(name string) ([]byte, error) {
+	p, err := fs.ReadFile(fsys.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	fn := fsys.transform(name)
+	if fn == nil {
+		return p, nil
+	}
+	if p, err = fn(p); err != nil {
+		return nil, &fs.PathError{Op: string(OpReadFile), Path: name, Err: err}
+	}
+	return p, nil
+}
+
+// Stat returns a FileInfo describing the named file. The size of a file to
+// transform is the size of the transformed contents. If fsys has an encoder
+// the size is read from MetaDecodedSize without reading the file and is
+// UnknownSize if the metadata is not available.
+func (fsys *transformFS) Stat(name string) (fs.FileInfo, error) {
+	if fsys.transform(name) == nil {
+		return fs.Stat(fsys.fsys, name)
+	}
+	if fsys.encoder != nil {
+		return fsys.statDecodedSize(name)
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return f.Stat()
+}
+
+// statDecodedSize returns a FileInfo whose size is MetaDecodedSize of the
+// named file or UnknownSize.
+func (fsys *transformFS) statDecodedSize(name string) (fs.FileInfo, error) {
+	info, err := fs.Stat(fsys.fsys, name)
+	if err != nil || info.IsDir() {
+		return info, err
+	}
+	size := UnknownSize
+	if meta, err := StatMeta(fsys.root, path.Join(fsys.dir, name)); err == nil {
+		if n, err := strconv.ParseInt(meta[MetaDecodedSize], 10, 64); err == nil && n >= 0 {
+			size = n
+		}
+	}
+	return newEncodedInfo(info, size), nil
+}
+
+// Sub returns a filesystem corresponding to the subtree rooted at dir that
+// transforms files in the same way as fsys.
+func (fsys *transformFS) Sub(dir string) (fs.FS, error) {
+	sub, err := fs.Sub(fsys.fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	t := &transformFS{
+		FSDelegator: DelegateFS(sub),
+		fsys:        sub,
+		root:        fsys.root,
+		dir:         path.Join(fsys.dir, dir),
+		transformer: fsys.transformer,
+	}
+	if fsys.encoder != nil {
+		t.withEncoder(fsys.encoder)
+	}
+	return t, nil
+}
@@ -0,0 +1,81 @@
+package wfs
+
+import (
+	"crypto"
+	// NOTE: Register SHA-256 for TreeHash in addition to MD5 of hash.go.
+	_ "crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+)
+
+const (
+	treeTypeFile byte = 0
+	treeTypeDir  byte = 1
+)
+
+// TreeHash returns a Merkle-style digest of the tree rooted at root and of
+// each directory in the tree using algo. The returned map is keyed by the
+// paths of the directories including root. The digest of a file is the hash
+// of its contents and the digest of a directory is the hash of a canonical
+// encoding of the names, types and digests of its entries in lexical order,
+// so two trees have the same digest if and only if they have the same paths
+// and contents. Modes and modification times are not included.
+func TreeHash(fsys fs.FS, root string, algo crypto.Hash) (map[string][]byte, error) {
+	if !algo.Available() {
+		return nil, fmt.Errorf("TreeHash: hash function %d is not available", algo)
+	}
+	sums := map[string][]byte{}
+	if _, err := treeHashDir(fsys, root, algo, sums); err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+func treeHashDir(fsys fs.FS, dir string, algo crypto.Hash, sums map[string][]byte) ([]byte, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	h := algo.New()
+	h.Write([]byte{treeTypeDir})
+	for _, e := range entries {
+		name := path.Join(dir, e.Name())
+		typ := treeTypeFile
+		var sum []byte
+		if e.IsDir() {
+			typ = treeTypeDir
+			sum, err = treeHashDir(fsys, name, algo, sums)
+		} else {
+			sum, err = treeHashFile(fsys, name, algo)
+		}
+		if err != nil {
+			return nil, err
+		}
+		var n [binary.MaxVarintLen64]byte
+		h.Write([]byte{typ})
+		h.Write(n[:binary.PutUvarint(n[:], uint64(len(e.Name())))])
+		h.Write([]byte(e.Name()))
+		h.Write(sum)
+	}
+	sum := h.Sum(nil)
+	sums[dir] = sum
+	return sum, nil
+}
+
+func treeHashFile(fsys fs.FS, name string, algo crypto.Hash) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := algo.New()
+	h.Write([]byte{treeTypeFile})
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
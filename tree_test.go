@@ -0,0 +1,77 @@
+package wfs
+
+import (
+	"bytes"
+	"crypto"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func newTreeFSTest() fstest.MapFS {
+	return fstest.MapFS{
+		"a.txt":         {Data: []byte("a")},
+		"dir/b.txt":     {Data: []byte("b")},
+		"dir/sub/c.txt": {Data: []byte("c")},
+		"other/d.txt":   {Data: []byte("d")},
+	}
+}
+
+func TestTreeHash(t *testing.T) {
+	sums1, err := TreeHash(newTreeFSTest(), ".", crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{".", "dir", "dir/sub", "other"} {
+		if len(sums1[dir]) != crypto.SHA256.Size() {
+			t.Errorf("%s: unexpected %x", dir, sums1[dir])
+		}
+	}
+
+	// NOTE: Modes and modification times are not included.
+	fsys := newTreeFSTest()
+	fsys["a.txt"].Mode = 0600
+	fsys["a.txt"].ModTime = time.Now()
+	sums2, err := TreeHash(fsys, ".", crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sums1["."], sums2["."]) {
+		t.Errorf("unexpected %x; want %x", sums2["."], sums1["."])
+	}
+
+	fsys["dir/sub/c.txt"].Data = []byte("changed")
+	sums3, err := TreeHash(fsys, ".", crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{".", "dir", "dir/sub"} {
+		if bytes.Equal(sums1[dir], sums3[dir]) {
+			t.Errorf("%s: unexpected same digest", dir)
+		}
+	}
+	if !bytes.Equal(sums1["other"], sums3["other"]) {
+		t.Errorf("other: unexpected %x; want %x", sums3["other"], sums1["other"])
+	}
+
+	// NOTE: Moving a file changes the digest.
+	fsys = newTreeFSTest()
+	fsys["dir/a.txt"] = fsys["a.txt"]
+	delete(fsys, "a.txt")
+	sums4, err := TreeHash(fsys, ".", crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(sums1["."], sums4["."]) {
+		t.Errorf("unexpected same digest")
+	}
+}
+
+func TestTreeHash_Errors(t *testing.T) {
+	if _, err := TreeHash(newTreeFSTest(), "not-found", crypto.SHA256); err == nil {
+		t.Errorf("no error")
+	}
+	if _, err := TreeHash(newTreeFSTest(), ".", crypto.BLAKE2b_256); err == nil {
+		t.Errorf("no error")
+	}
+}
@@ -0,0 +1,179 @@
+package wfs
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+)
+
+var (
+	// ErrTampered "tampered"
+	ErrTampered = errors.New("tampered")
+	// ErrInvalidSignature "invalid signature"
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// SignedManifest is a list of the SHA-256 hashes of files signed by Ed25519.
+type SignedManifest struct {
+	// Files maps the names of files to the hex encoded SHA-256 hashes.
+	Files map[string]string `json:"files"`
+	// Signature is the Ed25519 signature of the files.
+	Signature []byte `json:"signature"`
+}
+
+// NewSignedManifest returns a SignedManifest of all files of fsys signed by
+// the private key.
+func NewSignedManifest(fsys fs.FS, key ed25519.PrivateKey) (*SignedManifest, error) {
+	m := &SignedManifest{Files: map[string]string{}}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		p, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(p)
+		m.Files[name] = hex.EncodeToString(sum[:])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.Signature = ed25519.Sign(key, m.payload())
+	return m, nil
+}
+
+// payload returns the signed bytes that are the lines of "<hash>  <name>" in
+// order of the names like the output of sha256sum.
+func (m *SignedManifest) payload() []byte {
+	names := make([]string, 0, len(m.Files))
+	for name := range m.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b bytes.Buffer
+	for _, name := range names {
+		b.WriteString(m.Files[name])
+		b.WriteString("  ")
+		b.WriteString(name)
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// Verify returns ErrInvalidSignature if the manifest is not signed by the
+// private key of the public key.
+func (m *SignedManifest) Verify(key ed25519.PublicKey) error {
+	if len(key) != ed25519.PublicKeySize || !ed25519.Verify(key, m.payload(), m.Signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// VerifyingFS is a read-only filesystem that serves only the files whose
+// SHA-256 hashes match a signed manifest. Open and ReadFile read the whole
+// file and verify it before returning the verified bytes, so a file replaced
+// after the verification is never served. A file that does not match the
+// manifest returns a PathError of ErrTampered and a file that is not in the
+// manifest returns a PathError of fs.ErrPermission. Directories and file
+// infos are not verified.
+type VerifyingFS struct {
+	fsys     fs.FS
+	manifest *SignedManifest
+	dir      string
+}
+
+var (
+	_ fs.ReadDirFS  = (*VerifyingFS)(nil)
+	_ fs.ReadFileFS = (*VerifyingFS)(nil)
+	_ fs.StatFS     = (*VerifyingFS)(nil)
+	_ fs.SubFS      = (*VerifyingFS)(nil)
+)
+
+// NewVerifyingFS returns a VerifyingFS of fsys if the manifest is signed by
+// the private key of the public key, otherwise returns ErrInvalidSignature.
+func NewVerifyingFS(fsys fs.FS, manifest *SignedManifest, key ed25519.PublicKey) (*VerifyingFS, error) {
+	if err := manifest.Verify(key); err != nil {
+		return nil, err
+	}
+	return &VerifyingFS{fsys: fsys, manifest: manifest, dir: "."}, nil
+}
+
+func (fsys *VerifyingFS) verify(op Op, name string) ([]byte, fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
+	}
+	f, err := fsys.fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return nil, info, err
+	}
+	want, ok := fsys.manifest.Files[path.Join(fsys.dir, name)]
+	if !ok {
+		return nil, nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrPermission}
+	}
+	var b bytes.Buffer
+	if _, err := b.ReadFrom(f); err != nil {
+		return nil, nil, err
+	}
+	sum := sha256.Sum256(b.Bytes())
+	if hex.EncodeToString(sum[:]) != want {
+		return nil, nil, &fs.PathError{Op: string(op), Path: name, Err: ErrTampered}
+	}
+	return b.Bytes(), info, nil
+}
+
+// Open opens the named file after verifying it. Directories are opened
+// without verification.
+func (fsys *VerifyingFS) Open(name string) (fs.File, error) {
+	p, info, err := fsys.verify(OpOpen, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return fsys.fsys.Open(name)
+	}
+	return NewBytesFile(info, p), nil
+}
+
+// ReadFile reads the named file after verifying it.
+func (fsys *VerifyingFS) ReadFile(name string) ([]byte, error) {
+	p, info, err := fsys.verify(OpReadFile, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, &fs.PathError{Op: string(OpReadFile), Path: name, Err: fs.ErrInvalid}
+	}
+	return p, nil
+}
+
+// ReadDir reads the named directory.
+func (fsys *VerifyingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(fsys.fsys, name)
+}
+
+// Stat returns a fs.FileInfo describing the named file.
+func (fsys *VerifyingFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(fsys.fsys, name)
+}
+
+// Sub returns a VerifyingFS corresponding to the subtree rooted at dir.
+func (fsys *VerifyingFS) Sub(dir string) (fs.FS, error) {
+	sub, err := fs.Sub(fsys.fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &VerifyingFS{fsys: sub, manifest: fsys.manifest, dir: path.Join(fsys.dir, dir)}, nil
+}
@@ -0,0 +1,98 @@
+package wfs
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestVerifyingFS(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"plugins/a.so": {Data: []byte("a")},
+		"plugins/b.so": {Data: []byte("b")},
+		"config.json":  {Data: []byte(`{}`)},
+	}
+	m, err := NewSignedManifest(fsys, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys["plugins/b.so"] = &fstest.MapFile{Data: []byte("evil")}
+	fsys["plugins/c.so"] = &fstest.MapFile{Data: []byte("c")}
+
+	vfs, err := NewVerifyingFS(fsys, m, pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := fs.ReadFile(vfs, "plugins/a.so")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p) != "a" {
+		t.Errorf("unexpected %q; want %q", p, "a")
+	}
+	f, err := vfs.Open("config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p) != "{}" {
+		t.Errorf("unexpected %q; want %q", p, "{}")
+	}
+	if _, err := vfs.Open("plugins/b.so"); !errors.Is(err, ErrTampered) {
+		t.Errorf("unexpected %v; want %v", err, ErrTampered)
+	}
+	if _, err := vfs.ReadFile("plugins/b.so"); !errors.Is(err, ErrTampered) {
+		t.Errorf("unexpected %v; want %v", err, ErrTampered)
+	}
+	if _, err := vfs.ReadFile("plugins/c.so"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrPermission)
+	}
+	if _, err := vfs.ReadFile("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+
+	sub, err := fs.Sub(vfs, "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.ReadFile(sub, "a.so"); err != nil {
+		t.Errorf("unexpected %v", err)
+	}
+	if _, err := fs.ReadFile(sub, "b.so"); !errors.Is(err, ErrTampered) {
+		t.Errorf("unexpected %v; want %v", err, ErrTampered)
+	}
+}
+
+func TestNewVerifyingFS_invalidSignature(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{"a": {Data: []byte("a")}}
+	m, err := NewSignedManifest(fsys, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewVerifyingFS(fsys, m, other); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("unexpected %v; want %v", err, ErrInvalidSignature)
+	}
+	m.Files["b"] = m.Files["a"]
+	if err := m.Verify(priv.Public().(ed25519.PublicKey)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("unexpected %v; want %v", err, ErrInvalidSignature)
+	}
+}
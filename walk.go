@@ -0,0 +1,139 @@
+package wfs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// maxWalkErrorsSummary is the number of errors listed by WalkErrors.Error.
+const maxWalkErrorsSummary = 3
+
+// WalkErrors is the errors of the directories that WalkDirCollect could not
+// read in the order they occurred.
+type WalkErrors []error
+
+// Error returns a summary of the errors.
+func (e WalkErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	msgs := make([]string, 0, maxWalkErrorsSummary+1)
+	for i, err := range e {
+		if i == maxWalkErrorsSummary {
+			msgs = append(msgs, fmt.Sprintf("and %d more", len(e)-i))
+			break
+		}
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d errors: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Is reports whether any of the errors matches target.
+func (e WalkErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error that matches target.
+func (e WalkErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// WalkDirCollect walks the tree rooted at root like fs.WalkDir but continues
+// when a directory cannot be read, such as by fs.ErrPermission or a transient
+// failure of the backend, and returns the errors as WalkErrors after the walk,
+// so audit and statistics tools can process the rest of an imperfect tree.
+//
+// WalkDirCollect calls fn for each file and directory including root. If fn
+// returns fs.SkipDir for a directory the directory is not read, and for a
+// file the remaining entries of its directory are skipped. Any other error of
+// fn stops the walk and is returned as is.
+//
+// Directories are read in pages by ReadDirPage and the walk is not recursive,
+// so huge directories and deep trees are walked with small memory. The
+// entries are visited in the order of the filesystem and a directory is read
+// after all of the entries of its parent are visited.
+func WalkDirCollect(fsys fs.FS, root string, fn func(name string, d fs.DirEntry) error) error {
+	info, err := fs.Stat(fsys, root)
+	if err != nil {
+		return WalkErrors{err}
+	}
+	if err := fn(root, infoDirEntry(info)); err != nil {
+		if err == fs.SkipDir {
+			return nil
+		}
+		return err
+	}
+	if !info.IsDir() {
+		return nil
+	}
+
+	var (
+		errs  WalkErrors
+		fnErr error
+		stack = []string{root}
+	)
+	errSkip := errors.New("skip")
+	for len(stack) > 0 {
+		dir := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		var subdirs []string
+		err := ReadDirPage(fsys, dir, 0, func(entries []fs.DirEntry) error {
+			for _, e := range entries {
+				name := path.Join(dir, e.Name())
+				if err := fn(name, e); err != nil {
+					if err != fs.SkipDir {
+						fnErr = err
+						return err
+					}
+					if e.IsDir() {
+						continue
+					}
+					return errSkip
+				}
+				if e.IsDir() {
+					subdirs = append(subdirs, name)
+				}
+			}
+			return nil
+		})
+		if fnErr != nil {
+			return fnErr
+		}
+		if err != nil && err != errSkip {
+			errs = append(errs, err)
+		}
+		for i := len(subdirs) - 1; i >= 0; i-- {
+			stack = append(stack, subdirs[i])
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// infoDirEntry returns a fs.DirEntry of info.
+func infoDirEntry(info fs.FileInfo) fs.DirEntry {
+	return &DirEntryDelegator{
+		Values: DirEntryValues{
+			Name:  info.Name(),
+			IsDir: info.IsDir(),
+			Type:  info.Mode().Type(),
+			Info:  info,
+		},
+	}
+}
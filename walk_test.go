@@ -0,0 +1,79 @@
+package wfs
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestWalkDirCollect(t *testing.T) {
+	m := fstest.MapFS{
+		"a.txt":          {Data: []byte("a")},
+		"dir/b.txt":      {Data: []byte("b")},
+		"dir/deep/c.txt": {Data: []byte("c")},
+		"secret/d.txt":   {Data: []byte("d")},
+		"skip/e.txt":     {Data: []byte("e")},
+	}
+	d := DelegateFS(m)
+	d.OpenFunc = func(name string) (fs.File, error) {
+		if name == "secret" {
+			return nil, &fs.PathError{Op: "Open", Path: name, Err: fs.ErrPermission}
+		}
+		return m.Open(name)
+	}
+
+	var got []string
+	err := WalkDirCollect(d, ".", func(name string, d fs.DirEntry) error {
+		got = append(got, name)
+		if name == "skip" {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrPermission)
+	}
+	var pe *fs.PathError
+	if !errors.As(err, &pe) || pe.Path != "secret" {
+		t.Errorf("unexpected %v; want PathError of secret", err)
+	}
+	sort.Strings(got)
+	want := []string{".", "a.txt", "dir", "dir/b.txt", "dir/deep", "dir/deep/c.txt", "secret", "skip"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+
+	wantErr := errors.New("stop")
+	err = WalkDirCollect(d, ".", func(name string, d fs.DirEntry) error {
+		if name == "dir/b.txt" {
+			return wantErr
+		}
+		return nil
+	})
+	if err != wantErr {
+		t.Errorf("unexpected %v; want %v", err, wantErr)
+	}
+
+	err = WalkDirCollect(d, "missing", func(name string, d fs.DirEntry) error {
+		return nil
+	})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestWalkErrors_Error(t *testing.T) {
+	errs := WalkErrors{
+		errors.New("e1"), errors.New("e2"), errors.New("e3"), errors.New("e4"), errors.New("e5"),
+	}
+	if got, want := errs.Error(), "5 errors: e1; e2; e3; and 2 more"; got != want {
+		t.Errorf("unexpected %q; want %q", got, want)
+	}
+	if got := errs[:1].Error(); !strings.Contains(got, "e1") || strings.Contains(got, "errors") {
+		t.Errorf("unexpected %q", got)
+	}
+}
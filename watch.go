@@ -0,0 +1,50 @@
+package wfs
+
+import (
+	"context"
+	"io/fs"
+)
+
+// WatchOp describes an operation of a WatchEvent.
+type WatchOp int
+
+const (
+	// WatchWrite is an operation that creates or updates a file.
+	WatchWrite WatchOp = iota + 1
+	// WatchRemove is an operation that removes a file.
+	WatchRemove
+)
+
+// String returns the name of the operation.
+func (op WatchOp) String() string {
+	switch op {
+	case WatchWrite:
+		return "WRITE"
+	case WatchRemove:
+		return "REMOVE"
+	}
+	return "UNKNOWN"
+}
+
+// WatchEvent represents a change of a file.
+type WatchEvent struct {
+	Op   WatchOp
+	Name string
+}
+
+// WatchFS is the interface implemented by a filesystem that can notify changes
+// of files under a directory. The returned channel is closed when the context
+// is done.
+type WatchFS interface {
+	fs.FS
+	Watch(ctx context.Context, dir string) (<-chan WatchEvent, error)
+}
+
+// Watch watches changes of files under the named directory. If the filesystem
+// implements WatchFS calls fsys.Watch otherwise returns a PathError.
+func Watch(ctx context.Context, fsys fs.FS, dir string) (<-chan WatchEvent, error) {
+	if fsys, ok := fsys.(WatchFS); ok {
+		return fsys.Watch(ctx, dir)
+	}
+	return nil, &fs.PathError{Op: string(OpWatch), Path: dir, Err: ErrNotImplemented}
+}
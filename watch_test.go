@@ -0,0 +1,45 @@
+package wfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type watchFS struct {
+	*FSDelegator
+}
+
+func (fsys *watchFS) Watch(ctx context.Context, dir string) (<-chan WatchEvent, error) {
+	ch := make(chan WatchEvent, 1)
+	ch <- WatchEvent{Op: WatchWrite, Name: dir + "/test.txt"}
+	close(ch)
+	return ch, nil
+}
+
+func TestWatch(t *testing.T) {
+	ch, err := Watch(context.Background(), &watchFS{FSDelegator: &FSDelegator{}}, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := <-ch
+	want := WatchEvent{Op: WatchWrite, Name: "dir/test.txt"}
+	if got != want {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+}
+
+func TestWatch_ErrNotImplemented(t *testing.T) {
+	_, err := Watch(context.Background(), &OpenFSDelegator{}, "dir")
+	if !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotImplemented)
+	}
+}
+
+func TestWatchOp_String(t *testing.T) {
+	for op, want := range map[WatchOp]string{WatchWrite: "WRITE", WatchRemove: "REMOVE", 0: "UNKNOWN"} {
+		if got := op.String(); got != want {
+			t.Errorf("unexpected %s; want %s", got, want)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// Package wfslog provides an io.Writer that writes logs to files on a
+// wfs.WriteFileFS with size and time based rotation, retention and gzip of
+// rotated files.
+//
+// A filesystem such as an object storage may not support appending to a file,
+// so each file is written once from creation to rotation and is named with
+// the time of creation such as "logs/app-20240102T150405.000000000.log".
+package wfslog
+
+import (
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jarxorg/wfs"
+)
+
+// timeFormat is the format of the time in the names of log files that sorts
+// in order of creation.
+const timeFormat = "20060102T150405.000000000"
+
+// Option is an option for New.
+type Option func(w *Writer)
+
+// WithMaxSize rotates the file before writing exceeds the size in bytes. A
+// single write that exceeds the size is written to a new file as it is.
+func WithMaxSize(size int64) Option {
+	return func(w *Writer) {
+		w.maxSize = size
+	}
+}
+
+// WithInterval rotates the file when the interval has passed since the file
+// was created.
+func WithInterval(interval time.Duration) Option {
+	return func(w *Writer) {
+		w.interval = interval
+	}
+}
+
+// WithMaxFiles removes the oldest rotated files to keep at most n rotated
+// files. The file being written is not counted.
+func WithMaxFiles(n int) Option {
+	return func(w *Writer) {
+		w.maxFiles = n
+	}
+}
+
+// WithGzip compresses rotated files to files with the extension ".gz".
+func WithGzip() Option {
+	return func(w *Writer) {
+		w.gzip = true
+	}
+}
+
+// Writer is an io.WriteCloser that writes logs to rotated files. Writer is
+// safe for concurrent use.
+type Writer struct {
+	fsys     wfs.WriteFileFS
+	dir      string
+	prefix   string
+	ext      string
+	maxSize  int64
+	interval time.Duration
+	maxFiles int
+	gzip     bool
+	now      func() time.Time
+
+	mutex   sync.Mutex
+	f       wfs.WriterFile
+	name    string
+	size    int64
+	created time.Time
+}
+
+// New returns a Writer that writes to the files named by name such as
+// "logs/app.log". The files are named "logs/app-<time>.log". The first file
+// is created by the first Write.
+func New(fsys wfs.WriteFileFS, name string, opts ...Option) *Writer {
+	ext := path.Ext(name)
+	w := &Writer{
+		fsys:   fsys,
+		dir:    path.Dir(name),
+		prefix: strings.TrimSuffix(path.Base(name), ext) + "-",
+		ext:    ext,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Name returns the name of the file being written or an empty string if no
+// file is open.
+func (w *Writer) Name() string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.name
+}
+
+// Write writes p to the current file, rotating the file if required.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.f != nil && w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	if w.f == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file. The next Write creates a new file.
+func (w *Writer) Rotate() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.rotate()
+}
+
+// Close closes the current file like Rotate.
+func (w *Writer) Close() error {
+	return w.Rotate()
+}
+
+func (w *Writer) shouldRotate(n int64) bool {
+	if w.maxSize > 0 && w.size > 0 && w.size+n > w.maxSize {
+		return true
+	}
+	return w.interval > 0 && w.now().Sub(w.created) >= w.interval
+}
+
+func (w *Writer) open() error {
+	if err := w.fsys.MkdirAll(w.dir, fs.ModePerm); err != nil {
+		return err
+	}
+	created := w.now()
+	name := path.Join(w.dir, w.prefix+created.UTC().Format(timeFormat)+w.ext)
+	f, err := w.fsys.CreateFile(name, 0644)
+	if err != nil {
+		return err
+	}
+	w.f, w.name, w.size, w.created = f, name, 0, created
+	return nil
+}
+
+func (w *Writer) rotate() error {
+	if w.f == nil {
+		return nil
+	}
+	f, name := w.f, w.name
+	w.f, w.name, w.size = nil, "", 0
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if w.gzip {
+		if err := w.compress(name); err != nil {
+			return err
+		}
+	}
+	return w.removeOld()
+}
+
+// compress compresses the named file to name+".gz" and removes the file.
+func (w *Writer) compress(name string) error {
+	src, err := w.fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	gzName := name + ".gz"
+	f, err := w.fsys.CreateFile(gzName, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(f)
+	if _, err := io.Copy(zw, src); err != nil {
+		wfs.Abort(w.fsys, gzName, f)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		wfs.Abort(w.fsys, gzName, f)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return wfs.RemoveFile(w.fsys, name)
+}
+
+// Files returns the names of the rotated files in order of creation.
+func (w *Writer) Files() ([]string, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.files()
+}
+
+func (w *Writer) files() ([]string, error) {
+	entries, err := fs.ReadDir(w.fsys, w.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, w.prefix) {
+			continue
+		}
+		if !strings.HasSuffix(name, w.ext) && !strings.HasSuffix(name, w.ext+".gz") {
+			continue
+		}
+		if name := path.Join(w.dir, name); name != w.name {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (w *Writer) removeOld() error {
+	if w.maxFiles <= 0 {
+		return nil
+	}
+	names, err := w.files()
+	if err != nil || len(names) <= w.maxFiles {
+		return err
+	}
+	return wfs.RemoveFiles(w.fsys, names[:len(names)-w.maxFiles])
+}
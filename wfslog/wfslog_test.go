@@ -0,0 +1,112 @@
+package wfslog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jarxorg/wfs/memfs"
+)
+
+func newTestWriter(t *testing.T, opts ...Option) (*Writer, *memfs.MemFS, *time.Time) {
+	fsys := memfs.New()
+	w := New(fsys, "logs/app.log", opts...)
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	w.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	t.Cleanup(func() { w.Close() })
+	return w, fsys, &now
+}
+
+func writeLines(t *testing.T, w io.Writer, lines ...string) {
+	for _, line := range lines {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWriter_MaxSize(t *testing.T) {
+	w, fsys, _ := newTestWriter(t, WithMaxSize(8))
+	writeLines(t, w, "aaaa\n", "bb\n", "cccc\n", "0123456789\n")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := w.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"logs/app-20240102T150406.000000000.log",
+		"logs/app-20240102T150407.000000000.log",
+		"logs/app-20240102T150408.000000000.log",
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("unexpected %v; want %v", files, want)
+	}
+	for i, data := range []string{"aaaa\nbb\n", "cccc\n", "0123456789\n"} {
+		got, err := fsys.ReadFile(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != data {
+			t.Errorf("unexpected %q; want %q", got, data)
+		}
+	}
+}
+
+func TestWriter_Interval(t *testing.T) {
+	w, _, now := newTestWriter(t, WithInterval(time.Minute))
+	writeLines(t, w, "a\n", "b\n")
+	first := w.Name()
+	*now = now.Add(time.Minute)
+	writeLines(t, w, "c\n")
+	if w.Name() == first {
+		t.Errorf("unexpected not rotated %s", first)
+	}
+	files, err := w.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{first}; !reflect.DeepEqual(files, want) {
+		t.Errorf("unexpected %v; want %v", files, want)
+	}
+}
+
+func TestWriter_MaxFilesGzip(t *testing.T) {
+	w, fsys, _ := newTestWriter(t, WithMaxSize(1), WithMaxFiles(2), WithGzip())
+	writeLines(t, w, "a", "b", "c", "d")
+
+	files, err := w.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"logs/app-20240102T150407.000000000.log.gz",
+		"logs/app-20240102T150408.000000000.log.gz",
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("unexpected %v; want %v", files, want)
+	}
+	data, err := fsys.ReadFile(files[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "c" {
+		t.Errorf("unexpected %q; want c", got)
+	}
+}
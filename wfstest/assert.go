@@ -0,0 +1,137 @@
+package wfstest
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// maxDiffLines is the maximum number of lines reported by AssertFSEqual.
+const maxDiffLines = 50
+
+// AssertFSEqual reports an error to t if the trees of got and want differ in
+// their paths, file sizes or contents. Each difference is reported as a line
+// prefixed by "-" for a path missing in got, "+" for an unexpected path in got
+// and "~" for a file whose size or content differs.
+func AssertFSEqual(t testing.TB, got, want fs.FS) {
+	t.Helper()
+	diffs, err := diffFS(got, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) == 0 {
+		return
+	}
+	if len(diffs) > maxDiffLines {
+		diffs = append(diffs[:maxDiffLines], fmt.Sprintf("... and %d more", len(diffs)-maxDiffLines))
+	}
+	t.Errorf("filesystems differ (-want +got):\n%s", strings.Join(diffs, "\n"))
+}
+
+// AssertFSMatchesDir reports an error to t if the tree of got differs from the
+// golden directory dir on disk.
+func AssertFSMatchesDir(t testing.TB, got fs.FS, dir string) {
+	t.Helper()
+	AssertFSEqual(t, got, os.DirFS(dir))
+}
+
+// treeEntry is a walked entry of diffFS.
+type treeEntry struct {
+	isDir bool
+	data  []byte
+}
+
+// walkTree returns the entries of fsys keyed by the path.
+func walkTree(fsys fs.FS) (map[string]treeEntry, error) {
+	entries := map[string]treeEntry{}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			return nil
+		}
+		if d.IsDir() {
+			entries[name] = treeEntry{isDir: true}
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		entries[name] = treeEntry{data: data}
+		return nil
+	})
+	return entries, err
+}
+
+// diffFS returns lines describing the differences of the trees of got and
+// want sorted by the path.
+func diffFS(got, want fs.FS) ([]string, error) {
+	gotEntries, err := walkTree(got)
+	if err != nil {
+		return nil, fmt.Errorf("got: %w", err)
+	}
+	wantEntries, err := walkTree(want)
+	if err != nil {
+		return nil, fmt.Errorf("want: %w", err)
+	}
+
+	var names []string
+	for name := range wantEntries {
+		names = append(names, name)
+	}
+	for name := range gotEntries {
+		if _, ok := wantEntries[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diffs []string
+	for _, name := range names {
+		g, gok := gotEntries[name]
+		w, wok := wantEntries[name]
+		switch {
+		case !gok:
+			diffs = append(diffs, "- "+name)
+		case !wok:
+			diffs = append(diffs, "+ "+name)
+		case g.isDir != w.isDir:
+			diffs = append(diffs, fmt.Sprintf("~ %s: dir %v; want %v", name, g.isDir, w.isDir))
+		case len(g.data) != len(w.data):
+			diffs = append(diffs, fmt.Sprintf("~ %s: size %d; want %d", name, len(g.data), len(w.data)))
+		case !bytes.Equal(g.data, w.data):
+			off := diffOffset(g.data, w.data)
+			diffs = append(diffs, fmt.Sprintf("~ %s: content differs at %d: %q; want %q",
+				name, off, snippet(g.data, off), snippet(w.data, off)))
+		}
+	}
+	return diffs, nil
+}
+
+// diffOffset returns the offset of the first differing byte of a and b.
+func diffOffset(a, b []byte) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	if len(a) < len(b) {
+		return len(a)
+	}
+	return len(b)
+}
+
+// snippet returns up to 16 bytes of p starting at off.
+func snippet(p []byte, off int) []byte {
+	end := off + 16
+	if end > len(p) {
+		end = len(p)
+	}
+	return p[off:end]
+}
@@ -0,0 +1,43 @@
+package wfstest
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAssertFSEqual(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dir/a.txt": {Data: []byte("a")},
+		"b.txt":     {Data: []byte("b")},
+	}
+	AssertFSEqual(t, fsys, fsys)
+}
+
+func TestDiffFS(t *testing.T) {
+	got := fstest.MapFS{
+		"dir/same.txt":  {Data: []byte("same")},
+		"dir/size.txt":  {Data: []byte("abc")},
+		"dir/data.txt":  {Data: []byte("abcx")},
+		"unexpected.go": {Data: []byte("")},
+	}
+	want := fstest.MapFS{
+		"dir/same.txt": {Data: []byte("same")},
+		"dir/size.txt": {Data: []byte("abcde")},
+		"dir/data.txt": {Data: []byte("abcd")},
+		"missing.txt":  {Data: []byte("")},
+	}
+	diffs, err := diffFS(got, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDiffs := []string{
+		`~ dir/data.txt: content differs at 3: "x"; want "d"`,
+		`~ dir/size.txt: size 3; want 5`,
+		`- missing.txt`,
+		`+ unexpected.go`,
+	}
+	if !reflect.DeepEqual(diffs, wantDiffs) {
+		t.Errorf("unexpected %q; want %q", diffs, wantDiffs)
+	}
+}
@@ -0,0 +1,126 @@
+package wfstest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/jarxorg/wfs"
+)
+
+const (
+	benchSmallFiles    = 100
+	benchSmallFileSize = 1 << 10
+	benchLargeFileSize = 16 << 20
+	benchTreeDepth     = 6
+	benchTreeFanout    = 3
+)
+
+// BenchmarkFS runs the standard workloads against filesystems created by
+// factory. The factory is called for each workload and should return an
+// empty writable filesystem and register any cleanup with b.Cleanup.
+//
+// Typical usage inside a test is:
+//
+//	func BenchmarkFS(b *testing.B) {
+//	  wfstest.BenchmarkFS(b, func(b *testing.B) fs.FS {
+//	    return memfs.New()
+//	  })
+//	}
+func BenchmarkFS(b *testing.B, factory func(b *testing.B) fs.FS) {
+	b.Run("SmallWrites", func(b *testing.B) {
+		benchmarkSmallWrites(b, factory(b))
+	})
+	b.Run("LargeWrite", func(b *testing.B) {
+		benchmarkLargeWrite(b, factory(b))
+	})
+	b.Run("WalkDir", func(b *testing.B) {
+		benchmarkWalkDir(b, factory(b))
+	})
+	b.Run("Glob", func(b *testing.B) {
+		benchmarkGlob(b, factory(b))
+	})
+}
+
+// benchmarkSmallWrites writes many small files using WriteFile.
+func benchmarkSmallWrites(b *testing.B, fsys fs.FS) {
+	p := bytes.Repeat([]byte("a"), benchSmallFileSize)
+	if err := wfs.MkdirAll(fsys, "small", fs.ModePerm); err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(benchSmallFiles * benchSmallFileSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < benchSmallFiles; j++ {
+			name := fmt.Sprintf("small/%03d.txt", j)
+			if _, err := wfs.WriteFile(fsys, name, p, fs.ModePerm); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// benchmarkLargeWrite streams one large file using CreateFile.
+func benchmarkLargeWrite(b *testing.B, fsys fs.FS) {
+	p := bytes.Repeat([]byte("a"), benchLargeFileSize)
+	b.SetBytes(benchLargeFileSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f, err := wfs.CreateFile(fsys, "large.bin", fs.ModePerm)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := io.Copy(f, bytes.NewReader(p)); err != nil {
+			f.Close()
+			b.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// writeBenchTree writes a tree of benchTreeDepth levels with benchTreeFanout
+// directories and files in each directory.
+func writeBenchTree(b *testing.B, fsys fs.FS, dir string, depth int) {
+	for i := 0; i < benchTreeFanout; i++ {
+		name := fmt.Sprintf("%s/file%d.txt", dir, i)
+		if _, err := wfs.WriteFile(fsys, name, []byte(name), fs.ModePerm); err != nil {
+			b.Fatal(err)
+		}
+		if depth > 1 {
+			writeBenchTree(b, fsys, fmt.Sprintf("%s/dir%d", dir, i), depth-1)
+		}
+	}
+}
+
+// benchmarkWalkDir walks a deep tree.
+func benchmarkWalkDir(b *testing.B, fsys fs.FS) {
+	writeBenchTree(b, fsys, "tree", benchTreeDepth)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := fs.WalkDir(fsys, "tree", func(_ string, _ fs.DirEntry, err error) error {
+			return err
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkGlob globs files in a deep tree.
+func benchmarkGlob(b *testing.B, fsys fs.FS) {
+	writeBenchTree(b, fsys, "tree", benchTreeDepth)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matches, err := wfs.Glob(fsys, "tree/dir*/dir*/dir1/file*.txt")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(matches) == 0 {
+			b.Fatal("no matches")
+		}
+	}
+}
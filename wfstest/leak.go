@@ -0,0 +1,188 @@
+package wfstest
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jarxorg/wfs"
+)
+
+// leakTracker tracks the files opened and not closed yet.
+type leakTracker struct {
+	mutex sync.Mutex
+	seq   int
+	open  map[*leakFile]string
+}
+
+func (tr *leakTracker) add(f *leakFile) {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+
+	tr.seq++
+	f.seq = tr.seq
+	tr.open[f] = string(debug.Stack())
+}
+
+func (tr *leakTracker) remove(f *leakFile) {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+
+	delete(tr.open, f)
+}
+
+// LeakCheckFS is a filesystem that tracks the files returned by Open and
+// CreateFile of the wrapped filesystem, including the files of the
+// filesystems returned by Sub, and reports the files that are not closed with
+// the stack traces of the openers.
+//
+// The files returned by LeakCheckFS implement fs.ReadDirFile or
+// wfs.WriterFile if the files of the wrapped filesystem implement them but
+// hide the other optional interfaces.
+type LeakCheckFS struct {
+	*wfs.FSDelegator
+	tracker *leakTracker
+}
+
+// NewLeakCheckFS returns a LeakCheckFS that wraps fsys.
+func NewLeakCheckFS(fsys fs.FS) *LeakCheckFS {
+	return newLeakCheckFS(fsys, &leakTracker{open: map[*leakFile]string{}})
+}
+
+func newLeakCheckFS(fsys fs.FS, tracker *leakTracker) *LeakCheckFS {
+	d := wfs.DelegateFS(fsys)
+	lfs := &LeakCheckFS{FSDelegator: d, tracker: tracker}
+
+	openFunc := d.OpenFunc
+	d.OpenFunc = func(name string) (fs.File, error) {
+		f, err := openFunc(name)
+		if err != nil {
+			return nil, err
+		}
+		return lfs.track(name, f), nil
+	}
+	if createFileFunc := d.CreateFileFunc; createFileFunc != nil {
+		d.CreateFileFunc = func(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+			f, err := createFileFunc(name, mode)
+			if err != nil {
+				return nil, err
+			}
+			return lfs.track(name, f).(wfs.WriterFile), nil
+		}
+	}
+	subFunc := d.SubFunc
+	d.SubFunc = func(dir string) (fs.FS, error) {
+		sub, err := subFunc(dir)
+		if err != nil {
+			return nil, err
+		}
+		return newLeakCheckFS(sub, tracker), nil
+	}
+	return lfs
+}
+
+// FS returns fsys as a filesystem that implements wfs.WriteFileFS and
+// wfs.RemoveFileFS only if the wrapped filesystem implements them. The files
+// of the returned filesystem are tracked by fsys.
+func (fsys *LeakCheckFS) FS() fs.FS {
+	d := *fsys.FSDelegator
+	subFunc := d.SubFunc
+	d.SubFunc = func(dir string) (fs.FS, error) {
+		sub, err := subFunc(dir)
+		if err != nil {
+			return nil, err
+		}
+		return sub.(*LeakCheckFS).FS(), nil
+	}
+	return d.FS()
+}
+
+func (fsys *LeakCheckFS) track(name string, f fs.File) fs.File {
+	lf := &leakFile{File: f, name: name, tracker: fsys.tracker}
+	fsys.tracker.add(lf)
+	if _, ok := f.(wfs.WriterFile); ok {
+		return &leakWriterFile{leakFile: lf}
+	}
+	if _, ok := f.(fs.ReadDirFile); ok {
+		return &leakReadDirFile{leakFile: lf}
+	}
+	return lf
+}
+
+// Check returns an error that describes the files that are not closed with
+// the stack traces of the openers in the order of opening. Check returns nil
+// if there are no leaks.
+func (fsys *LeakCheckFS) Check() error {
+	fsys.tracker.mutex.Lock()
+	defer fsys.tracker.mutex.Unlock()
+
+	if len(fsys.tracker.open) == 0 {
+		return nil
+	}
+	files := make([]*leakFile, 0, len(fsys.tracker.open))
+	for f := range fsys.tracker.open {
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].seq < files[j].seq
+	})
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d files are not closed:", len(files))
+	for _, f := range files {
+		fmt.Fprintf(&b, "\n%s opened at:\n\t%s", f.name,
+			strings.ReplaceAll(strings.TrimSpace(fsys.tracker.open[f]), "\n", "\n\t"))
+	}
+	return fmt.Errorf("%s", b.String())
+}
+
+// LeakCheck returns a LeakCheckFS that wraps fsys and reports the files that
+// are not closed to t at the end of the test.
+func LeakCheck(t testing.TB, fsys fs.FS) *LeakCheckFS {
+	lfs := NewLeakCheckFS(fsys)
+	t.Cleanup(func() {
+		if err := lfs.Check(); err != nil {
+			t.Error(err)
+		}
+	})
+	return lfs
+}
+
+type leakFile struct {
+	fs.File
+	name    string
+	seq     int
+	tracker *leakTracker
+}
+
+func (f *leakFile) Close() error {
+	f.tracker.remove(f)
+	return f.File.Close()
+}
+
+type leakReadDirFile struct {
+	*leakFile
+}
+
+func (f *leakReadDirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	return f.File.(fs.ReadDirFile).ReadDir(n)
+}
+
+type leakWriterFile struct {
+	*leakFile
+}
+
+func (f *leakWriterFile) Write(p []byte) (int, error) {
+	return f.File.(io.Writer).Write(p)
+}
+
+func (f *leakWriterFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d, ok := f.File.(fs.ReadDirFile); ok {
+		return d.ReadDir(n)
+	}
+	return nil, &fs.PathError{Op: string(wfs.OpReadDir), Path: f.name, Err: wfs.ErrNotImplemented}
+}
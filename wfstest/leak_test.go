@@ -0,0 +1,55 @@
+package wfstest
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLeakCheckFS(t *testing.T) {
+	lfs := NewLeakCheckFS(fstest.MapFS{
+		"dir/a.txt": {Data: []byte("a")},
+		"dir/b.txt": {Data: []byte("b")},
+	})
+	if err := fstest.TestFS(lfs, "dir/a.txt", "dir/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := lfs.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := lfs.Open("dir/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := fs.Sub(lfs, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := sub.Open(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := d.(fs.ReadDirFile); !ok {
+		t.Errorf("unexpected %T; want fs.ReadDirFile", d)
+	}
+
+	err = lfs.Check()
+	if err == nil {
+		t.Fatal("no leaks")
+	}
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "2 files are not closed:\ndir/a.txt opened at:") {
+		t.Errorf("unexpected %q", msg)
+	}
+	if !strings.Contains(msg, "\n. opened at:") || !strings.Contains(msg, "TestLeakCheckFS") {
+		t.Errorf("unexpected %q", msg)
+	}
+
+	f.Close()
+	d.Close()
+	if err := lfs.Check(); err != nil {
+		t.Errorf("unexpected %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package wfstest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/jarxorg/wfs"
+)
+
+type opCheck struct {
+	op  wfs.Op
+	err func() error
+}
+
+// TestPathErrorOps tests that the errors returned by a file system are
+// *fs.PathError values with the standard wfs.Op names, so that callers can
+// rely on the same error strings across backends. Read operations are tested
+// with a missing name and only when the file system implements the
+// corresponding interface. Write operations are tested with an invalid name
+// under tmpDir.
+func TestPathErrorOps(fsys fs.FS, tmpDir string) error {
+	missing := tmpDir + "/missing.txt"
+	invalid := tmpDir + "/file.txt/."
+
+	checks := []opCheck{
+		{op: wfs.OpOpen, err: func() error {
+			f, err := fsys.Open(missing)
+			if err == nil {
+				f.Close()
+			}
+			return err
+		}},
+	}
+	if _, ok := fsys.(fs.StatFS); ok {
+		checks = append(checks, opCheck{op: wfs.OpStat, err: func() error {
+			_, err := fs.Stat(fsys, missing)
+			return err
+		}})
+	}
+	if _, ok := fsys.(fs.ReadFileFS); ok {
+		checks = append(checks, opCheck{op: wfs.OpReadFile, err: func() error {
+			_, err := fs.ReadFile(fsys, missing)
+			return err
+		}})
+	}
+	if _, ok := fsys.(fs.ReadDirFS); ok {
+		checks = append(checks, opCheck{op: wfs.OpReadDir, err: func() error {
+			_, err := fs.ReadDir(fsys, missing)
+			return err
+		}})
+	}
+	checks = append(checks, []opCheck{
+		{op: wfs.OpMkdirAll, err: func() error {
+			return wfs.MkdirAll(fsys, invalid, fs.ModePerm)
+		}},
+		{op: wfs.OpCreateFile, err: func() error {
+			f, err := wfs.CreateFile(fsys, invalid, fs.ModePerm)
+			if err == nil {
+				f.Close()
+			}
+			return err
+		}},
+		{op: wfs.OpWriteFile, err: func() error {
+			_, err := wfs.WriteFile(fsys, invalid, []byte{}, fs.ModePerm)
+			return err
+		}},
+		{op: wfs.OpRemoveFile, err: func() error {
+			return wfs.RemoveFile(fsys, invalid)
+		}},
+		{op: wfs.OpRemoveAll, err: func() error {
+			return wfs.RemoveAll(fsys, invalid)
+		}},
+	}...)
+
+	for _, c := range checks {
+		err := c.err()
+		if err == nil {
+			return fmt.Errorf("%s: returns no error", c.op)
+		}
+		var pe *fs.PathError
+		if !errors.As(err, &pe) {
+			return fmt.Errorf("%s: returns %T %v; want *fs.PathError", c.op, err, err)
+		}
+		if pe.Op != string(c.op) {
+			return fmt.Errorf("%s: returns Op %q; want %q", c.op, pe.Op, c.op)
+		}
+	}
+	return nil
+}
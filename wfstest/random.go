@@ -0,0 +1,128 @@
+package wfstest
+
+import (
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"strings"
+
+	"github.com/jarxorg/wfs"
+)
+
+// randomNames are the path components of TestRandomOps. The set is small so
+// that operations often conflict, for example writing a file where a
+// directory exists.
+var randomNames = []string{"a", "b", "c"}
+
+// randomOp is an operation applied by TestRandomOps.
+type randomOp struct {
+	desc  string
+	apply func(fsys fs.FS) error
+}
+
+// randomPath returns a path of 1 to 3 components under dir.
+func randomPath(r *rand.Rand, dir string) string {
+	elems := []string{dir}
+	for i := r.Intn(3); i >= 0; i-- {
+		elems = append(elems, randomNames[r.Intn(len(randomNames))])
+	}
+	return strings.Join(elems, "/")
+}
+
+// newRandomOp returns a random operation on paths under dir.
+func newRandomOp(r *rand.Rand, dir string) randomOp {
+	name := randomPath(r, dir)
+	switch r.Intn(5) {
+	case 0:
+		return randomOp{
+			desc: fmt.Sprintf("MkdirAll(%q)", name),
+			apply: func(fsys fs.FS) error {
+				return wfs.MkdirAll(fsys, name, fs.ModePerm)
+			},
+		}
+	case 1:
+		p := []byte(fmt.Sprintf("%d", r.Int63()))
+		return randomOp{
+			desc: fmt.Sprintf("WriteFile(%q, %q)", name, p),
+			apply: func(fsys fs.FS) error {
+				_, err := wfs.WriteFile(fsys, name, p, fs.ModePerm)
+				return err
+			},
+		}
+	case 2:
+		dst := randomPath(r, dir)
+		return randomOp{
+			desc: fmt.Sprintf("MoveFile(%q, %q)", name, dst),
+			apply: func(fsys fs.FS) error {
+				return wfs.MoveFile(fsys, name, dst)
+			},
+		}
+	case 3:
+		return randomOp{
+			desc: fmt.Sprintf("RemoveFile(%q)", name),
+			apply: func(fsys fs.FS) error {
+				return wfs.RemoveFile(fsys, name)
+			},
+		}
+	default:
+		return randomOp{
+			desc: fmt.Sprintf("RemoveAll(%q)", name),
+			apply: func(fsys fs.FS) error {
+				return wfs.RemoveAll(fsys, name)
+			},
+		}
+	}
+}
+
+// TestRandomOps applies the same random sequence of MkdirAll, WriteFile,
+// MoveFile, RemoveFile and RemoveAll under tmpDir to fsys and a reference
+// model such as memfs.New(). After each operation TestRandomOps checks that
+// both operations succeed or both fail and that the trees under tmpDir are
+// equal. The sequence is reproducible by the seed.
+//
+// Typical usage inside a test is:
+//
+//	tmpDir, err := ioutil.TempDir("", "test")
+//	if err != nil {
+//	  t.Fatal(err)
+//	}
+//	defer os.RemoveAll(tmpDir)
+//
+//	fsys := osfs.New(filepath.Dir(tmpDir))
+//	model := memfs.New()
+//	if err := wfstest.TestRandomOps(fsys, model, filepath.Base(tmpDir), 1, 1000); err != nil {
+//	  t.Fatal(err)
+//	}
+func TestRandomOps(fsys, model fs.FS, tmpDir string, seed int64, steps int) error {
+	if err := wfs.MkdirAll(model, tmpDir, fs.ModePerm); err != nil {
+		return fmt.Errorf("model: %w", err)
+	}
+	r := rand.New(rand.NewSource(seed))
+	for i := 0; i < steps; i++ {
+		op := newRandomOp(r, tmpDir)
+		err := op.apply(fsys)
+		modelErr := op.apply(model)
+		if (err == nil) != (modelErr == nil) {
+			return fmt.Errorf("seed %d step %d: %s returns %v; model returns %v",
+				seed, i, op.desc, err, modelErr)
+		}
+
+		got, err := fs.Sub(fsys, tmpDir)
+		if err != nil {
+			return err
+		}
+		want, err := fs.Sub(model, tmpDir)
+		if err != nil {
+			return err
+		}
+		diffs, err := diffFS(got, want)
+		if err != nil {
+			return fmt.Errorf("seed %d step %d: %s: %w", seed, i, op.desc, err)
+		}
+		if len(diffs) > 0 {
+			return fmt.Errorf("seed %d step %d: %s: trees differ (-model +fsys):\n%s",
+				seed, i, op.desc, strings.Join(diffs, "\n"))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package wfstest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"reflect"
+	"sort"
+
+	"github.com/jarxorg/wfs"
+)
+
+// TestReadDirFile tests the ReadDir of fs.ReadDirFile returned by Open of
+// a directory. TestReadDirFile checks that ReadDir(n) with n > 0 returns
+// each entry once and then io.EOF with an empty slice, and that ReadDir(n)
+// with n <= 0 returns the remaining entries and then an empty slice with a
+// nil error like os.File. The order of entries is not checked because it is
+// not defined by os.File. TestReadDirFile creates files in tmpDir and
+// removes them at the end.
+func TestReadDirFile(fsys fs.FS, tmpDir string) error {
+	dir := tmpDir + "/readdir"
+	want := []string{"a.txt", "b.txt", "c.txt", "sub"}
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "sub/d.txt"} {
+		if _, err := wfs.WriteFile(fsys, dir+"/"+name, []byte(name), fs.ModePerm); err != nil {
+			return fmt.Errorf("%s: WriteFile: %v", name, err)
+		}
+	}
+	defer wfs.RemoveAll(fsys, dir)
+
+	// NOTE: ReadDir(1) until io.EOF.
+	var got []string
+	err := readDirFile(fsys, dir, func(d fs.ReadDirFile) error {
+		for {
+			entries, err := d.ReadDir(1)
+			if errors.Is(err, io.EOF) {
+				if len(entries) != 0 {
+					return fmt.Errorf("ReadDir(1) returns %d entries with io.EOF", len(entries))
+				}
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("ReadDir(1): %v", err)
+			}
+			if len(entries) != 1 {
+				return fmt.Errorf("ReadDir(1) returns %d entries; want 1", len(entries))
+			}
+			got = append(got, entries[0].Name())
+		}
+		if _, err := d.ReadDir(1); !errors.Is(err, io.EOF) {
+			return fmt.Errorf("ReadDir(1) after io.EOF returns %v; want %v", err, io.EOF)
+		}
+		entries, err := d.ReadDir(-1)
+		if err != nil || len(entries) != 0 {
+			return fmt.Errorf("ReadDir(-1) at end returns %d entries and %v; want 0 entries and nil", len(entries), err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %v", dir, err)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("%s: ReadDir(1) returns %v; want %v", dir, got, want)
+	}
+
+	// NOTE: ReadDir(2) and then ReadDir(-1).
+	got = nil
+	err = readDirFile(fsys, dir, func(d fs.ReadDirFile) error {
+		for _, n := range []int{2, -1} {
+			entries, err := d.ReadDir(n)
+			if err != nil {
+				return fmt.Errorf("ReadDir(%d): %v", n, err)
+			}
+			for _, e := range entries {
+				got = append(got, e.Name())
+			}
+		}
+		entries, err := d.ReadDir(0)
+		if err != nil || len(entries) != 0 {
+			return fmt.Errorf("ReadDir(0) at end returns %d entries and %v; want 0 entries and nil", len(entries), err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %v", dir, err)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("%s: ReadDir(2), ReadDir(-1) returns %v; want %v", dir, got, want)
+	}
+	return nil
+}
+
+func readDirFile(fsys fs.FS, dir string, fn func(d fs.ReadDirFile) error) error {
+	f, err := fsys.Open(dir)
+	if err != nil {
+		return fmt.Errorf("Open: %v", err)
+	}
+	defer f.Close()
+
+	d, ok := f.(fs.ReadDirFile)
+	if !ok {
+		return fmt.Errorf("Open returns %T; want fs.ReadDirFile", f)
+	}
+	return fn(d)
+}
@@ -0,0 +1,552 @@
+package wfstest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"sync"
+	"time"
+
+	"github.com/jarxorg/wfs"
+)
+
+// ErrNotRecorded is returned by ReplayFS for an operation that has not been
+// recorded.
+var ErrNotRecorded = errors.New("not recorded")
+
+// recordErrKinds are the sentinel errors that are preserved through a fixture.
+var recordErrKinds = map[string]error{
+	"invalid":       fs.ErrInvalid,
+	"permission":    fs.ErrPermission,
+	"exist":         fs.ErrExist,
+	"notexist":      fs.ErrNotExist,
+	"closed":        fs.ErrClosed,
+	"notimpl":       wfs.ErrNotImplemented,
+	"precondition":  wfs.ErrPreconditionFailed,
+	"protectedroot": wfs.ErrProtectedRoot,
+}
+
+// recordErr is a serialized error.
+type recordErr struct {
+	Kind string `json:"kind,omitempty"`
+	Msg  string `json:"msg"`
+}
+
+func newRecordErr(err error) *recordErr {
+	if err == nil {
+		return nil
+	}
+	e := &recordErr{Msg: err.Error()}
+	for kind, target := range recordErrKinds {
+		if errors.Is(err, target) {
+			e.Kind = kind
+			break
+		}
+	}
+	return e
+}
+
+// replayError is an error restored from a fixture that has the same message
+// as the recorded error and matches the same sentinel error by errors.Is.
+type replayError struct {
+	msg    string
+	target error
+}
+
+func (e *replayError) Error() string {
+	return e.msg
+}
+
+func (e *replayError) Is(target error) bool {
+	return e.target != nil && e.target == target
+}
+
+func (e *recordErr) error() error {
+	if e == nil {
+		return nil
+	}
+	return &replayError{msg: e.Msg, target: recordErrKinds[e.Kind]}
+}
+
+// recordInfo is a serialized fs.FileInfo.
+type recordInfo struct {
+	Name    string      `json:"name"`
+	Size    int64       `json:"size"`
+	Mode    fs.FileMode `json:"mode"`
+	ModTime time.Time   `json:"modTime"`
+}
+
+func newRecordInfo(info fs.FileInfo) *recordInfo {
+	return &recordInfo{
+		Name:    info.Name(),
+		Size:    info.Size(),
+		Mode:    info.Mode(),
+		ModTime: info.ModTime(),
+	}
+}
+
+// replayInfo implements fs.FileInfo and fs.DirEntry of a recordInfo.
+type replayInfo struct {
+	info *recordInfo
+}
+
+var (
+	_ fs.FileInfo = replayInfo{}
+	_ fs.DirEntry = replayInfo{}
+)
+
+func (i replayInfo) Name() string               { return i.info.Name }
+func (i replayInfo) Size() int64                { return i.info.Size }
+func (i replayInfo) Mode() fs.FileMode          { return i.info.Mode }
+func (i replayInfo) ModTime() time.Time         { return i.info.ModTime }
+func (i replayInfo) IsDir() bool                { return i.info.Mode.IsDir() }
+func (i replayInfo) Sys() interface{}           { return nil }
+func (i replayInfo) Type() fs.FileMode          { return i.info.Mode.Type() }
+func (i replayInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+// record is a recorded operation and its response.
+type record struct {
+	Op       wfs.Op        `json:"op"`
+	Path     string        `json:"path"`
+	Data     []byte        `json:"data,omitempty"`
+	N        int           `json:"n,omitempty"`
+	Info     *recordInfo   `json:"info,omitempty"`
+	Entries  []*recordInfo `json:"entries,omitempty"`
+	Matches  []string      `json:"matches,omitempty"`
+	Err      *recordErr    `json:"err,omitempty"`
+	CloseErr *recordErr    `json:"closeErr,omitempty"`
+}
+
+func (r *record) entries() []fs.DirEntry {
+	entries := make([]fs.DirEntry, len(r.Entries))
+	for i, info := range r.Entries {
+		entries[i] = replayInfo{info: info}
+	}
+	return entries
+}
+
+func newRecordEntries(entries []fs.DirEntry) ([]*recordInfo, error) {
+	infos := make([]*recordInfo, len(entries))
+	for i, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos[i] = newRecordInfo(info)
+	}
+	return infos, nil
+}
+
+// replayFile is a file served from a record of Open.
+type replayFile struct {
+	r        *bytes.Reader
+	info     fs.FileInfo
+	entries  []fs.DirEntry
+	dirIndex int
+}
+
+func newReplayFile(r *record) *replayFile {
+	return &replayFile{
+		r:       bytes.NewReader(r.Data),
+		info:    replayInfo{info: r.Info},
+		entries: r.entries(),
+	}
+}
+
+func (f *replayFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *replayFile) Read(p []byte) (int, error) {
+	return f.r.Read(p)
+}
+
+func (f *replayFile) Close() error {
+	return nil
+}
+
+func (f *replayFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := f.entries[f.dirIndex:]
+	if n <= 0 {
+		f.dirIndex = len(f.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	f.dirIndex += n
+	return rest[:n], nil
+}
+
+// recordWriterFile records the written data of CreateFile at Close.
+type recordWriterFile struct {
+	wfs.WriterFile
+	fsys *RecordFS
+	rec  *record
+	buf  bytes.Buffer
+}
+
+func (f *recordWriterFile) Write(p []byte) (int, error) {
+	n, err := f.WriterFile.Write(p)
+	f.buf.Write(p[:n])
+	return n, err
+}
+
+func (f *recordWriterFile) Close() error {
+	err := f.WriterFile.Close()
+	f.rec.Data = f.buf.Bytes()
+	f.rec.N = f.buf.Len()
+	f.rec.CloseErr = newRecordErr(err)
+	f.fsys.add(f.rec)
+	return err
+}
+
+// RecordFS is a filesystem that records the operations and responses of the
+// wrapped filesystem. The records are written as a fixture by Save and served
+// by ReplayFS so that integration tests of a backend such as a cloud storage
+// can run without credentials or network.
+//
+// Files opened by RecordFS are read entirely at Open.
+type RecordFS struct {
+	fsys    fs.FS
+	mutex   sync.Mutex
+	records []*record
+}
+
+var (
+	_ fs.GlobFS        = (*RecordFS)(nil)
+	_ fs.ReadDirFS     = (*RecordFS)(nil)
+	_ fs.ReadFileFS    = (*RecordFS)(nil)
+	_ fs.StatFS        = (*RecordFS)(nil)
+	_ wfs.WriteFileFS  = (*RecordFS)(nil)
+	_ wfs.RemoveFileFS = (*RecordFS)(nil)
+)
+
+// NewRecordFS returns a RecordFS that records the operations of fsys.
+func NewRecordFS(fsys fs.FS) *RecordFS {
+	return &RecordFS{fsys: fsys}
+}
+
+func (fsys *RecordFS) add(r *record) {
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	fsys.records = append(fsys.records, r)
+}
+
+// Save writes the records as a fixture of JSON lines to w.
+func (fsys *RecordFS) Save(w io.Writer) error {
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	enc := json.NewEncoder(w)
+	for _, r := range fsys.records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// open reads the named file or directory entirely to a record.
+func (fsys *RecordFS) open(name string) (*record, error) {
+	r := &record{Op: wfs.OpOpen, Path: name}
+	f, err := fsys.fsys.Open(name)
+	if err != nil {
+		return r, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return r, err
+	}
+	r.Info = newRecordInfo(info)
+	if info.IsDir() {
+		d, ok := f.(fs.ReadDirFile)
+		if !ok {
+			return r, &fs.PathError{Op: string(wfs.OpReadDir), Path: name, Err: wfs.ErrNotImplemented}
+		}
+		entries, err := d.ReadDir(-1)
+		if err != nil {
+			return r, err
+		}
+		r.Entries, err = newRecordEntries(entries)
+		return r, err
+	}
+	r.Data, err = io.ReadAll(f)
+	return r, err
+}
+
+// Open opens the named file.
+func (fsys *RecordFS) Open(name string) (fs.File, error) {
+	r, err := fsys.open(name)
+	r.Err = newRecordErr(err)
+	fsys.add(r)
+	if err != nil {
+		return nil, err
+	}
+	return newReplayFile(r), nil
+}
+
+// Glob returns the names of all files matching pattern.
+func (fsys *RecordFS) Glob(pattern string) ([]string, error) {
+	matches, err := wfs.Glob(fsys.fsys, pattern)
+	fsys.add(&record{Op: wfs.OpGlob, Path: pattern, Matches: append([]string{}, matches...), Err: newRecordErr(err)})
+	return matches, err
+}
+
+// ReadDir reads the named directory.
+func (fsys *RecordFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	r := &record{Op: wfs.OpReadDir, Path: dir}
+	entries, err := fs.ReadDir(fsys.fsys, dir)
+	if err == nil {
+		r.Entries, err = newRecordEntries(entries)
+	}
+	r.Err = newRecordErr(err)
+	fsys.add(r)
+	if err != nil {
+		return nil, err
+	}
+	return r.entries(), nil
+}
+
+// ReadFile reads the named file.
+func (fsys *RecordFS) ReadFile(name string) ([]byte, error) {
+	p, err := fs.ReadFile(fsys.fsys, name)
+	fsys.add(&record{Op: wfs.OpReadFile, Path: name, Data: append([]byte{}, p...), Err: newRecordErr(err)})
+	return p, err
+}
+
+// Stat returns a FileInfo describing the file.
+func (fsys *RecordFS) Stat(name string) (fs.FileInfo, error) {
+	r := &record{Op: wfs.OpStat, Path: name}
+	info, err := fs.Stat(fsys.fsys, name)
+	if err == nil {
+		r.Info = newRecordInfo(info)
+	}
+	r.Err = newRecordErr(err)
+	fsys.add(r)
+	return info, err
+}
+
+// MkdirAll creates a directory named path.
+func (fsys *RecordFS) MkdirAll(dir string, mode fs.FileMode) error {
+	err := wfs.MkdirAll(fsys.fsys, dir, mode)
+	fsys.add(&record{Op: wfs.OpMkdirAll, Path: dir, Err: newRecordErr(err)})
+	return err
+}
+
+// CreateFile creates the named file. The written data is recorded at Close.
+func (fsys *RecordFS) CreateFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+	r := &record{Op: wfs.OpCreateFile, Path: name}
+	f, err := wfs.CreateFile(fsys.fsys, name, mode)
+	if err != nil {
+		r.Err = newRecordErr(err)
+		fsys.add(r)
+		return nil, err
+	}
+	return &recordWriterFile{WriterFile: f, fsys: fsys, rec: r}, nil
+}
+
+// WriteFile writes the specified bytes to the named file.
+func (fsys *RecordFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	n, err := wfs.WriteFile(fsys.fsys, name, p, mode)
+	fsys.add(&record{Op: wfs.OpWriteFile, Path: name, N: n, Err: newRecordErr(err)})
+	return n, err
+}
+
+// RemoveFile removes the specified named file.
+func (fsys *RecordFS) RemoveFile(name string) error {
+	err := wfs.RemoveFile(fsys.fsys, name)
+	fsys.add(&record{Op: wfs.OpRemoveFile, Path: name, Err: newRecordErr(err)})
+	return err
+}
+
+// RemoveAll removes path and any children it contains.
+func (fsys *RecordFS) RemoveAll(path string) error {
+	err := wfs.RemoveAll(fsys.fsys, path)
+	fsys.add(&record{Op: wfs.OpRemoveAll, Path: path, Err: newRecordErr(err)})
+	return err
+}
+
+// replayKey is the key of the recorded responses of ReplayFS.
+type replayKey struct {
+	op   wfs.Op
+	path string
+}
+
+// ReplayFS is a filesystem that serves the responses recorded by RecordFS.
+// The responses of the same operation and path are served in the recorded
+// order and the last response is repeated. An operation that has not been
+// recorded returns ErrNotRecorded.
+type ReplayFS struct {
+	mutex   sync.Mutex
+	records map[replayKey][]*record
+}
+
+var (
+	_ fs.GlobFS        = (*ReplayFS)(nil)
+	_ fs.ReadDirFS     = (*ReplayFS)(nil)
+	_ fs.ReadFileFS    = (*ReplayFS)(nil)
+	_ fs.StatFS        = (*ReplayFS)(nil)
+	_ wfs.WriteFileFS  = (*ReplayFS)(nil)
+	_ wfs.RemoveFileFS = (*ReplayFS)(nil)
+)
+
+// NewReplayFS returns a ReplayFS that serves the fixture read from r.
+func NewReplayFS(r io.Reader) (*ReplayFS, error) {
+	fsys := &ReplayFS{records: map[replayKey][]*record{}}
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 64<<20)
+	for s.Scan() {
+		if len(bytes.TrimSpace(s.Bytes())) == 0 {
+			continue
+		}
+		rec := &record{}
+		if err := json.Unmarshal(s.Bytes(), rec); err != nil {
+			return nil, err
+		}
+		key := replayKey{op: rec.Op, path: rec.Path}
+		fsys.records[key] = append(fsys.records[key], rec)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return fsys, nil
+}
+
+// next returns the next recorded response of the operation.
+func (fsys *ReplayFS) next(op wfs.Op, name string) (*record, error) {
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	key := replayKey{op: op, path: name}
+	recs := fsys.records[key]
+	if len(recs) == 0 {
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: ErrNotRecorded}
+	}
+	if len(recs) > 1 {
+		fsys.records[key] = recs[1:]
+	}
+	return recs[0], nil
+}
+
+// Open opens the named file.
+func (fsys *ReplayFS) Open(name string) (fs.File, error) {
+	r, err := fsys.next(wfs.OpOpen, name)
+	if err != nil {
+		return nil, err
+	}
+	if r.Err != nil {
+		return nil, r.Err.error()
+	}
+	return newReplayFile(r), nil
+}
+
+// Glob returns the names of all files matching pattern.
+func (fsys *ReplayFS) Glob(pattern string) ([]string, error) {
+	r, err := fsys.next(wfs.OpGlob, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return r.Matches, r.Err.error()
+}
+
+// ReadDir reads the named directory.
+func (fsys *ReplayFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	r, err := fsys.next(wfs.OpReadDir, dir)
+	if err != nil {
+		return nil, err
+	}
+	if r.Err != nil {
+		return nil, r.Err.error()
+	}
+	return r.entries(), nil
+}
+
+// ReadFile reads the named file.
+func (fsys *ReplayFS) ReadFile(name string) ([]byte, error) {
+	r, err := fsys.next(wfs.OpReadFile, name)
+	if err != nil {
+		return nil, err
+	}
+	if r.Err != nil {
+		return nil, r.Err.error()
+	}
+	return append([]byte{}, r.Data...), nil
+}
+
+// Stat returns a FileInfo describing the file.
+func (fsys *ReplayFS) Stat(name string) (fs.FileInfo, error) {
+	r, err := fsys.next(wfs.OpStat, name)
+	if err != nil {
+		return nil, err
+	}
+	if r.Err != nil {
+		return nil, r.Err.error()
+	}
+	return replayInfo{info: r.Info}, nil
+}
+
+// MkdirAll creates a directory named path.
+func (fsys *ReplayFS) MkdirAll(dir string, mode fs.FileMode) error {
+	r, err := fsys.next(wfs.OpMkdirAll, dir)
+	if err != nil {
+		return err
+	}
+	return r.Err.error()
+}
+
+// CreateFile creates the named file. The returned file discards the written
+// data and Close returns the recorded error.
+func (fsys *ReplayFS) CreateFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+	r, err := fsys.next(wfs.OpCreateFile, name)
+	if err != nil {
+		return nil, err
+	}
+	if r.Err != nil {
+		return nil, r.Err.error()
+	}
+	return &wfs.FileDelegator{
+		WriteFunc: func(p []byte) (int, error) {
+			return len(p), nil
+		},
+		CloseFunc: func() error {
+			return r.CloseErr.error()
+		},
+	}, nil
+}
+
+// WriteFile writes the specified bytes to the named file.
+func (fsys *ReplayFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	r, err := fsys.next(wfs.OpWriteFile, name)
+	if err != nil {
+		return 0, err
+	}
+	return r.N, r.Err.error()
+}
+
+// RemoveFile removes the specified named file.
+func (fsys *ReplayFS) RemoveFile(name string) error {
+	r, err := fsys.next(wfs.OpRemoveFile, name)
+	if err != nil {
+		return err
+	}
+	return r.Err.error()
+}
+
+// RemoveAll removes path and any children it contains.
+func (fsys *ReplayFS) RemoveAll(path string) error {
+	r, err := fsys.next(wfs.OpRemoveAll, path)
+	if err != nil {
+		return err
+	}
+	return r.Err.error()
+}
@@ -0,0 +1,79 @@
+package wfstest
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jarxorg/wfs"
+)
+
+func TestRecordFS(t *testing.T) {
+	src := fstest.MapFS{
+		"dir/a.txt": {Data: []byte("a")},
+		"dir/b.txt": {Data: []byte("bb")},
+	}
+	rec := NewRecordFS(src)
+	if err := fstest.TestFS(rec, "dir/a.txt", "dir/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec.Stat("missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	AssertFSEqual(t, rec, src)
+
+	var buf bytes.Buffer
+	if err := rec.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	replay, err := NewReplayFS(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(replay, "dir/a.txt", "dir/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := replay.Stat("missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	if _, err := replay.Stat("other.txt"); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotRecorded)
+	}
+	AssertFSEqual(t, replay, src)
+}
+
+func TestRecordFS_Write(t *testing.T) {
+	m := fstest.MapFS{}
+	d := wfs.DelegateFS(m)
+	d.WriteFileFunc = func(name string, p []byte, mode fs.FileMode) (int, error) {
+		if _, ok := m[name]; ok {
+			return 0, &fs.PathError{Op: "WriteFile", Path: name, Err: fs.ErrExist}
+		}
+		m[name] = &fstest.MapFile{Data: p, Mode: mode}
+		return len(p), nil
+	}
+	rec := NewRecordFS(d)
+
+	ops := func(fsys fs.FS) []interface{} {
+		n1, err1 := wfs.WriteFile(fsys, "a.txt", []byte("abc"), fs.ModePerm)
+		n2, err2 := wfs.WriteFile(fsys, "a.txt", []byte("abc"), fs.ModePerm)
+		p, err3 := fs.ReadFile(fsys, "a.txt")
+		return []interface{}{n1, err1 == nil, n2, errors.Is(err2, fs.ErrExist), string(p), err3 == nil}
+	}
+	want := ops(rec)
+
+	var buf bytes.Buffer
+	if err := rec.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	replay, err := NewReplayFS(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ops(replay); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+}
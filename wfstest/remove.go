@@ -0,0 +1,44 @@
+package wfstest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/jarxorg/wfs"
+)
+
+// TestRemoveFileFS tests that a wfs.RemoveFileFS implementation handles
+// missing files like os.Remove and os.RemoveAll: RemoveFile returns an error
+// wrapping fs.ErrNotExist and RemoveAll returns nil. TestRemoveFileFS creates
+// files in tmpDir and removes them at the end.
+func TestRemoveFileFS(fsys fs.FS, tmpDir string) error {
+	missing := tmpDir + "/remove/missing.txt"
+	if err := wfs.RemoveFile(fsys, missing); !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s: RemoveFile of missing file returns %v; want %v", missing, err, fs.ErrNotExist)
+	}
+	if err := wfs.RemoveAll(fsys, missing); err != nil {
+		return fmt.Errorf("%s: RemoveAll of missing path returns %v; want nil", missing, err)
+	}
+	if err := wfs.RemoveIfExists(fsys, missing); err != nil {
+		return fmt.Errorf("%s: RemoveIfExists of missing file returns %v; want nil", missing, err)
+	}
+
+	name := tmpDir + "/remove/file.txt"
+	if _, err := wfs.WriteFile(fsys, name, []byte("remove"), fs.ModePerm); err != nil {
+		return fmt.Errorf("%s: WriteFile: %v", name, err)
+	}
+	if err := wfs.RemoveFile(fsys, name); err != nil {
+		return fmt.Errorf("%s: RemoveFile: %v", name, err)
+	}
+	if _, err := fs.Stat(fsys, name); !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s: Stat after RemoveFile returns %v; want %v", name, err, fs.ErrNotExist)
+	}
+	if err := wfs.RemoveFile(fsys, name); !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s: second RemoveFile returns %v; want %v", name, err, fs.ErrNotExist)
+	}
+	if err := wfs.RemoveAll(fsys, tmpDir+"/remove"); err != nil {
+		return fmt.Errorf("%s: RemoveAll: %v", tmpDir+"/remove", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package wfstest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing/fstest"
+
+	"github.com/jarxorg/wfs"
+)
+
+// TestSub tests Sub of a filesystem that implements fs.SubFS. TestSub checks
+// that Sub returns a view of an existing directory that reads and writes the
+// files under the directory, that Sub of a missing directory returns
+// fs.ErrNotExist and Sub of an invalid path or a file returns an error, and
+// that the names of the view cannot escape the directory by "..". TestSub
+// creates files in tmpDir and removes them at the end.
+func TestSub(fsys fs.FS, tmpDir string) error {
+	subFS, ok := fsys.(fs.SubFS)
+	if !ok {
+		return fmt.Errorf("%T does not implement fs.SubFS", fsys)
+	}
+	dir := tmpDir + "/sub"
+	files := map[string][]byte{
+		"outside.txt":   []byte("outside"),
+		"sub/a.txt":     []byte("a"),
+		"sub/dir/b.txt": []byte("b"),
+	}
+	for name, p := range files {
+		if _, err := wfs.WriteFile(fsys, tmpDir+"/"+name, p, fs.ModePerm); err != nil {
+			return fmt.Errorf("%s: WriteFile: %v", name, err)
+		}
+	}
+	defer wfs.RemoveAll(fsys, dir)
+	defer wfs.RemoveFile(fsys, tmpDir+"/outside.txt")
+
+	sub, err := subFS.Sub(dir)
+	if err != nil {
+		return fmt.Errorf("Sub(%s): %v", dir, err)
+	}
+	if err := fstest.TestFS(sub, "a.txt", "dir/b.txt"); err != nil {
+		return fmt.Errorf("Sub(%s): %v", dir, err)
+	}
+	subsub, err := fs.Sub(sub, "dir")
+	if err != nil {
+		return fmt.Errorf("Sub(%s) Sub(dir): %v", dir, err)
+	}
+	if p, err := fs.ReadFile(subsub, "b.txt"); err != nil || !bytes.Equal(p, files["sub/dir/b.txt"]) {
+		return fmt.Errorf("Sub(%s) Sub(dir) ReadFile(b.txt): %q, %v", dir, p, err)
+	}
+
+	for _, name := range []string{"../outside.txt", "dir/../../outside.txt", "/outside.txt"} {
+		if _, err := sub.Open(name); !errors.Is(err, fs.ErrInvalid) {
+			return fmt.Errorf("Sub(%s) Open(%s) returns %v; want %v", dir, name, err, fs.ErrInvalid)
+		}
+		if _, ok := sub.(wfs.WriteFileFS); ok {
+			if _, err := wfs.WriteFile(sub, name, []byte("x"), fs.ModePerm); !errors.Is(err, fs.ErrInvalid) {
+				return fmt.Errorf("Sub(%s) WriteFile(%s) returns %v; want %v", dir, name, err, fs.ErrInvalid)
+			}
+		}
+	}
+	if p, err := fs.ReadFile(fsys, tmpDir+"/outside.txt"); err != nil || !bytes.Equal(p, files["outside.txt"]) {
+		return fmt.Errorf("ReadFile(outside.txt) after writes via Sub: %q, %v", p, err)
+	}
+
+	if _, ok := sub.(wfs.WriteFileFS); ok {
+		if _, err := wfs.WriteFile(sub, "c.txt", []byte("c"), fs.ModePerm); err != nil {
+			return fmt.Errorf("Sub(%s) WriteFile(c.txt): %v", dir, err)
+		}
+		if p, err := fs.ReadFile(fsys, dir+"/c.txt"); err != nil || string(p) != "c" {
+			return fmt.Errorf("ReadFile(%s/c.txt) written via Sub: %q, %v", dir, p, err)
+		}
+	}
+
+	if _, err := subFS.Sub(tmpDir + "/missing"); !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("Sub(%s/missing) returns %v; want %v", tmpDir, err, fs.ErrNotExist)
+	}
+	if _, err := subFS.Sub(dir + "/a.txt"); err == nil {
+		return fmt.Errorf("Sub(%s/a.txt) of a file returns no error", dir)
+	}
+	for _, name := range []string{"../" + tmpDir, tmpDir + "/../..", "/" + tmpDir} {
+		if _, err := subFS.Sub(name); !errors.Is(err, fs.ErrInvalid) {
+			return fmt.Errorf("Sub(%s) returns %v; want %v", name, err, fs.ErrInvalid)
+		}
+	}
+	return nil
+}
@@ -2,8 +2,10 @@
 package wfstest
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
+	"sort"
 	"strings"
 	"testing/iotest"
 
@@ -106,3 +108,162 @@ func checkFileWrite(fsys fs.FS, f wfs.WriterFile, name string) error {
 	}
 	return nil
 }
+
+// TestConditionalWriteFS tests a wfs.ConditionalWriteFS implementation.
+func TestConditionalWriteFS(fsys fs.FS, tmpDir string) error {
+	name := tmpDir + "/conditional.txt"
+	cond := wfs.Condition{IfNotExists: true}
+
+	v1, err := wfs.WriteFileIf(fsys, name, []byte("v1"), fs.ModePerm, cond)
+	if err != nil {
+		return fmt.Errorf("%s: WriteFileIf IfNotExists: %v", name, err)
+	}
+	if _, err := wfs.WriteFileIf(fsys, name, []byte("v1"), fs.ModePerm, cond); !errors.Is(err, wfs.ErrPreconditionFailed) {
+		return fmt.Errorf("%s: WriteFileIf IfNotExists on exists returns %v; want %v", name, err, wfs.ErrPreconditionFailed)
+	}
+	got, err := wfs.FileVersion(fsys, name)
+	if err != nil {
+		return fmt.Errorf("%s: FileVersion: %v", name, err)
+	}
+	if got != v1 {
+		return fmt.Errorf("%s: FileVersion returns %s; want %s", name, got, v1)
+	}
+
+	v2, err := wfs.WriteFileIf(fsys, name, []byte("v2"), fs.ModePerm, wfs.Condition{IfMatch: v1})
+	if err != nil {
+		return fmt.Errorf("%s: WriteFileIf IfMatch: %v", name, err)
+	}
+	if v2 == v1 {
+		return fmt.Errorf("%s: WriteFileIf returns same version %s", name, v2)
+	}
+	if _, err := wfs.WriteFileIf(fsys, name, []byte("v3"), fs.ModePerm, wfs.Condition{IfMatch: v1}); !errors.Is(err, wfs.ErrPreconditionFailed) {
+		return fmt.Errorf("%s: WriteFileIf IfMatch with old version returns %v; want %v", name, err, wfs.ErrPreconditionFailed)
+	}
+	p, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return fmt.Errorf("%s: ReadFile: %v", name, err)
+	}
+	if string(p) != "v2" {
+		return fmt.Errorf("%s: ReadFile returns %s; want v2", name, p)
+	}
+	if err := wfs.RemoveFile(fsys, name); err != nil {
+		return fmt.Errorf("%s: RemoveFile: %v", name, err)
+	}
+	return nil
+}
+
+// TestGlobFS tests that the Glob of a fs.GlobFS implementation returns the
+// same names as fs.Glob over ReadDir sorted lexically. TestGlobFS creates files in tmpDir and
+// removes them at the end.
+func TestGlobFS(fsys fs.FS, tmpDir string) error {
+	dir := tmpDir + "/glob"
+	names := []string{
+		"a.txt",
+		"b.gz",
+		"dir-a/c.gz",
+		"dir/d.gz",
+		"dir/e.txt",
+		"dir/sub/f.gz",
+	}
+	for _, name := range names {
+		if _, err := wfs.WriteFile(fsys, dir+"/"+name, []byte(name), fs.ModePerm); err != nil {
+			return fmt.Errorf("%s: WriteFile: %v", name, err)
+		}
+	}
+	defer wfs.RemoveAll(fsys, dir)
+
+	patterns := []string{
+		"glob",
+		"glob/*",
+		"glob/*.gz",
+		"glob/*/*.gz",
+		"glob/dir*/*",
+		"glob/[a-b].*",
+		"glob/dir/sub/f.gz",
+		"glob/missing/*",
+	}
+	for _, pattern := range patterns {
+		if err := checkGlob(fsys, tmpDir+"/"+pattern); err != nil {
+			return err
+		}
+	}
+
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("%s: Sub: %v", dir, err)
+	}
+	for _, pattern := range []string{".", "*", "dir/*", "*/*.gz"} {
+		if err := checkGlob(sub, pattern); err != nil {
+			return fmt.Errorf("%s: Sub: %v", dir, err)
+		}
+	}
+	return nil
+}
+
+func checkGlob(fsys fs.FS, pattern string) error {
+	got, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return fmt.Errorf("%s: Glob: %v", pattern, err)
+	}
+	// NOTE: OpenFSDelegator hides fsys.Glob so fs.Glob uses ReadDir.
+	want, err := fs.Glob(wfs.DelegateOpenFS(fsys), pattern)
+	if err != nil {
+		return fmt.Errorf("%s: fs.Glob: %v", pattern, err)
+	}
+	sort.Strings(want)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		return fmt.Errorf("%s: Glob returns %v; want %v", pattern, got, want)
+	}
+	return nil
+}
+
+// TestOrdering tests that ReadDir returns the entries sorted by filename and
+// that Glob and wfs.Glob return the names sorted lexically as whole paths, so
+// the results do not depend on the backend. TestOrdering creates files whose
+// names are sorted differently directory by directory in tmpDir and removes
+// them at the end.
+func TestOrdering(fsys fs.FS, tmpDir string) error {
+	dir := tmpDir + "/ordering"
+	names := []string{
+		"C.txt",
+		"a-b/x.txt",
+		"a.txt",
+		"a/x.txt",
+		"a0.txt",
+		"b.txt",
+	}
+	// NOTE: Write in reverse order so that insertion order is not sorted.
+	for i := len(names) - 1; i >= 0; i-- {
+		name := names[i]
+		if _, err := wfs.WriteFile(fsys, dir+"/"+name, []byte(name), fs.ModePerm); err != nil {
+			return fmt.Errorf("%s: WriteFile: %v", name, err)
+		}
+	}
+	defer wfs.RemoveAll(fsys, dir)
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("%s: ReadDir: %v", dir, err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	want := []string{"C.txt", "a", "a-b", "a.txt", "a0.txt", "b.txt"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		return fmt.Errorf("%s: ReadDir returns %v; want %v", dir, got, want)
+	}
+
+	for _, pattern := range []string{dir + "/*", dir + "/*/*", dir + "/a*/x.txt"} {
+		for _, glob := range []func(fsys fs.FS, pattern string) ([]string, error){fs.Glob, wfs.Glob} {
+			got, err := glob(fsys, pattern)
+			if err != nil {
+				return fmt.Errorf("%s: Glob: %v", pattern, err)
+			}
+			if len(got) == 0 || !sort.StringsAreSorted(got) {
+				return fmt.Errorf("%s: Glob returns %v; want sorted names", pattern, got)
+			}
+		}
+	}
+	return nil
+}
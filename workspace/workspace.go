@@ -0,0 +1,392 @@
+// Package workspace provides a staging area over a filesystem for build
+// pipelines. Build steps write to a Workspace that reads through to the base
+// filesystem, and the results are published to the base by Commit or dropped
+// by Discard.
+package workspace
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/jarxorg/wfs"
+	"github.com/jarxorg/wfs/memfs"
+)
+
+var (
+	_ wfs.WriteFileFS  = (*Workspace)(nil)
+	_ wfs.RemoveFileFS = (*Workspace)(nil)
+	_ fs.ReadDirFS     = (*Workspace)(nil)
+	_ fs.StatFS        = (*Workspace)(nil)
+)
+
+// opCommit is the operation of Workspace.Commit.
+const opCommit wfs.Op = "Commit"
+
+// Workspace is a filesystem that stages writes and removals in memory over a
+// base filesystem. Files that are not written or removed in the workspace are
+// read from the base. Nothing is written to the base until Commit.
+type Workspace struct {
+	base    wfs.WriteFileFS
+	mutex   sync.Mutex
+	stage   *memfs.MemFS
+	removed map[string]bool
+	done    bool
+}
+
+// New returns a new Workspace over base.
+func New(base wfs.WriteFileFS) *Workspace {
+	return &Workspace{
+		base:    base,
+		stage:   memfs.New(),
+		removed: map[string]bool{},
+	}
+}
+
+// hidden reports whether the named file on the base is removed in the
+// workspace. w.mutex must be held.
+func (w *Workspace) hidden(name string) bool {
+	for {
+		if w.removed[name] {
+			return true
+		}
+		if name == "." {
+			return false
+		}
+		name = path.Dir(name)
+	}
+}
+
+// staged reports whether the named file exists on the stage.
+func (w *Workspace) staged(name string) bool {
+	_, err := w.stage.Stat(name)
+	return err == nil
+}
+
+func (w *Workspace) checkDone(op wfs.Op, name string) error {
+	if w.done {
+		return &fs.PathError{Op: string(op), Path: name, Err: fs.ErrClosed}
+	}
+	return nil
+}
+
+// Open opens the named file.
+func (w *Workspace) Open(name string) (fs.File, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	info, err := w.stat(wfs.OpOpen, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		entries, err := w.readDir(wfs.OpOpen, name)
+		if err != nil {
+			return nil, err
+		}
+		return wfs.NewDirFile(info, entries), nil
+	}
+	if w.staged(name) {
+		return w.stage.Open(name)
+	}
+	return w.base.Open(name)
+}
+
+// Stat returns a fs.FileInfo describing the named file.
+func (w *Workspace) Stat(name string) (fs.FileInfo, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.stat(wfs.OpStat, name)
+}
+
+func (w *Workspace) stat(op wfs.Op, name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
+	}
+	if info, err := w.stage.Stat(name); err == nil {
+		return info, nil
+	}
+	if w.hidden(name) {
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrNotExist}
+	}
+	info, err := fs.Stat(w.base, name)
+	return info, wfs.PathError(op, name, err)
+}
+
+// ReadDir reads the named directory merging the entries of the stage and the
+// base.
+func (w *Workspace) ReadDir(name string) ([]fs.DirEntry, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.readDir(wfs.OpReadDir, name)
+}
+
+func (w *Workspace) readDir(op wfs.Op, name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrInvalid}
+	}
+	found := false
+	entries := map[string]fs.DirEntry{}
+	if !w.hidden(name) {
+		baseEntries, err := fs.ReadDir(w.base, name)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, wfs.PathError(op, name, err)
+		}
+		found = err == nil
+		for _, e := range baseEntries {
+			if !w.removed[path.Join(name, e.Name())] {
+				entries[e.Name()] = e
+			}
+		}
+	}
+	stageEntries, err := w.stage.ReadDir(name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, wfs.PathError(op, name, err)
+	}
+	found = found || err == nil
+	for _, e := range stageEntries {
+		entries[e.Name()] = e
+	}
+	if !found {
+		return nil, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrNotExist}
+	}
+
+	sorted := make([]fs.DirEntry, 0, len(entries))
+	for _, e := range entries {
+		sorted = append(sorted, e)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name() < sorted[j].Name()
+	})
+	return sorted, nil
+}
+
+// MkdirAll creates the named directory in the workspace.
+func (w *Workspace) MkdirAll(dir string, mode fs.FileMode) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if err := w.checkDone(wfs.OpMkdirAll, dir); err != nil {
+		return err
+	}
+	return w.stage.MkdirAll(dir, mode)
+}
+
+// CreateFile creates the named file in the workspace.
+func (w *Workspace) CreateFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if err := w.checkDone(wfs.OpCreateFile, name); err != nil {
+		return nil, err
+	}
+	if err := w.stage.MkdirAll(path.Dir(name), fs.ModePerm); err != nil {
+		return nil, wfs.PathError(wfs.OpCreateFile, name, err)
+	}
+	return w.stage.CreateFile(name, mode)
+}
+
+// WriteFile writes the specified bytes to the named file in the workspace.
+func (w *Workspace) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if err := w.checkDone(wfs.OpWriteFile, name); err != nil {
+		return 0, err
+	}
+	if err := w.stage.MkdirAll(path.Dir(name), fs.ModePerm); err != nil {
+		return 0, wfs.PathError(wfs.OpWriteFile, name, err)
+	}
+	return w.stage.WriteFile(name, p, mode)
+}
+
+// RemoveFile removes the named file in the workspace. A file on the base is
+// removed from the base by Commit.
+func (w *Workspace) RemoveFile(name string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if err := w.checkDone(wfs.OpRemoveFile, name); err != nil {
+		return err
+	}
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: string(wfs.OpRemoveFile), Path: name, Err: fs.ErrInvalid}
+	}
+	staged := w.staged(name)
+	if staged {
+		if err := w.stage.RemoveFile(name); err != nil {
+			return err
+		}
+	}
+	if !w.hidden(name) {
+		if _, err := fs.Stat(w.base, name); err == nil {
+			w.removed[name] = true
+			return nil
+		}
+	}
+	if !staged {
+		return &fs.PathError{Op: string(wfs.OpRemoveFile), Path: name, Err: fs.ErrNotExist}
+	}
+	return nil
+}
+
+// RemoveAll removes the named directory and its contents in the workspace.
+// The directory on the base is removed from the base by Commit.
+func (w *Workspace) RemoveAll(dir string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if err := w.checkDone(wfs.OpRemoveAll, dir); err != nil {
+		return err
+	}
+	if err := w.stage.RemoveAll(dir); err != nil {
+		return err
+	}
+	w.removed[path.Clean(dir)] = true
+	return nil
+}
+
+// Commit publishes the workspace to the base. Commit copies the staged files
+// to the base and then removes the files removed in the workspace from the
+// base, so a failed Commit does not lose the files of the base that are
+// replaced by staged files. The base is not modified before Commit, but
+// Commit itself is not atomic unless the base is; if Commit fails it may be
+// retried. The workspace cannot be written after a successful Commit.
+func (w *Workspace) Commit() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if err := w.checkDone(opCommit, "."); err != nil {
+		return err
+	}
+	err := fs.WalkDir(w.stage, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || name == "." {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		// NOTE: A removed file of the base that is replaced by a staged
+		// directory and vice versa must be removed before the copy.
+		if w.hidden(name) {
+			if baseInfo, err := fs.Stat(w.base, name); err == nil && baseInfo.IsDir() != d.IsDir() {
+				if err := removeBase(w.base, name); err != nil {
+					return err
+				}
+			}
+		}
+		if d.IsDir() {
+			return w.base.MkdirAll(name, info.Mode().Perm())
+		}
+		return w.commitFile(name, info.Mode().Perm())
+	})
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(w.removed))
+	for name := range w.removed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := w.removeUnstaged(name); err != nil {
+			return err
+		}
+	}
+	w.discard()
+	return nil
+}
+
+// removeUnstaged removes the named file or directory from the base except the
+// files that are staged. w.mutex must be held.
+func (w *Workspace) removeUnstaged(name string) error {
+	if !w.staged(name) {
+		return removeBase(w.base, name)
+	}
+	err := fs.WalkDir(w.base, name, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if w.staged(p) {
+			return nil
+		}
+		if err := removeBase(w.base, p); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// removeBase removes the named file or directory from the base.
+func removeBase(base wfs.WriteFileFS, name string) error {
+	info, err := fs.Stat(base, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if info.IsDir() {
+		return wfs.RemoveAll(base, name)
+	}
+	return wfs.RemoveIfExists(base, name)
+}
+
+// commitFile copies the named staged file with the metadata to the base.
+func (w *Workspace) commitFile(name string, mode fs.FileMode) error {
+	src, err := w.stage.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	meta, err := w.stage.StatMeta(name)
+	if err != nil {
+		return err
+	}
+	var f wfs.WriterFile
+	if _, ok := w.base.(wfs.MetadataFS); ok && len(meta) > 0 {
+		f, err = wfs.CreateFileWithMeta(w.base, name, mode, meta)
+	} else {
+		f, err = w.base.CreateFile(name, mode)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		wfs.Abort(w.base, name, f)
+		return err
+	}
+	return f.Close()
+}
+
+// Discard drops the staged files and removals. The workspace cannot be
+// written after Discard.
+func (w *Workspace) Discard() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.discard()
+}
+
+func (w *Workspace) discard() {
+	w.stage = memfs.New()
+	w.removed = map[string]bool{}
+	w.done = true
+}
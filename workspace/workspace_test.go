@@ -0,0 +1,188 @@
+package workspace
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jarxorg/wfs"
+	"github.com/jarxorg/wfs/memfs"
+	"github.com/jarxorg/wfs/wfstest"
+)
+
+func newBase(t *testing.T) *memfs.MemFS {
+	base := memfs.New()
+	if err := base.MkdirAll("dir", fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "keep.txt", "dir/b.txt", "dir/c.txt"} {
+		if _, err := base.WriteFile(name, []byte(name), fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return base
+}
+
+func TestWorkspace(t *testing.T) {
+	base := newBase(t)
+	w := New(base)
+	if _, err := w.WriteFile("a.txt", []byte("new a"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteFile("out/new.txt", []byte("new"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.RemoveFile("dir/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.RemoveFile("dir/b.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`Error unexpected %v; want %v`, err, fs.ErrNotExist)
+	}
+
+	if err := fstest.TestFS(w, "a.txt", "keep.txt", "dir/c.txt", "out/new.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Stat("dir/b.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf(`Error unexpected %v; want %v`, err, fs.ErrNotExist)
+	}
+	wfstest.AssertFSEqual(t, base, newBase(t))
+
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	want := memfs.New()
+	for name, data := range map[string]string{
+		"a.txt":       "new a",
+		"keep.txt":    "keep.txt",
+		"dir/c.txt":   "dir/c.txt",
+		"out/new.txt": "new",
+	} {
+		if err := want.MkdirAll("out", fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := want.MkdirAll("dir", fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := want.WriteFile(name, []byte(data), fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wfstest.AssertFSEqual(t, base, want)
+
+	if _, err := w.WriteFile("a.txt", nil, fs.ModePerm); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf(`Error unexpected %v; want %v`, err, fs.ErrClosed)
+	}
+	if err := w.Commit(); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf(`Error unexpected %v; want %v`, err, fs.ErrClosed)
+	}
+}
+
+func TestWorkspace_RemoveAll(t *testing.T) {
+	base := newBase(t)
+	w := New(base)
+	if err := w.RemoveAll("dir"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteFile("dir/d.txt", []byte("d"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := w.ReadDir("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "d.txt" {
+		t.Errorf(`Error unexpected %v`, entries)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = base.ReadDir("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "d.txt" {
+		t.Errorf(`Error unexpected %v`, entries)
+	}
+}
+
+func TestWorkspace_CommitReplaceType(t *testing.T) {
+	base := newBase(t)
+	w := New(base)
+	if err := w.RemoveFile("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteFile("a.txt/x.txt", []byte("x"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.RemoveAll("dir"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteFile("dir", []byte("dir"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := fs.ReadFile(base, "a.txt/x.txt"); err != nil || string(p) != "x" {
+		t.Errorf(`Error unexpected %s, %v; want x`, p, err)
+	}
+	if p, err := fs.ReadFile(base, "dir"); err != nil || string(p) != "dir" {
+		t.Errorf(`Error unexpected %s, %v; want dir`, p, err)
+	}
+}
+
+func TestWorkspace_CommitFailure(t *testing.T) {
+	base := newBase(t)
+	errCreate := errors.New("create")
+	d := wfs.DelegateFS(base)
+	d.CreateFileFunc = func(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+		if name == "fail.txt" {
+			return nil, errCreate
+		}
+		return base.CreateFile(name, mode)
+	}
+	w := New(d)
+	if err := w.RemoveFile("keep.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteFile("fail.txt", []byte("fail"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(); !errors.Is(err, errCreate) {
+		t.Errorf(`Error unexpected %v; want %v`, err, errCreate)
+	}
+	if _, err := base.Stat("keep.txt"); err != nil {
+		t.Errorf(`Error removed file is lost by a failed Commit: %v`, err)
+	}
+}
+
+func TestWorkspace_Discard(t *testing.T) {
+	base := newBase(t)
+	w := New(base)
+	if _, err := w.WriteFile("a.txt", []byte("new a"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.RemoveFile("keep.txt"); err != nil {
+		t.Fatal(err)
+	}
+	w.Discard()
+	wfstest.AssertFSEqual(t, base, newBase(t))
+	if err := w.RemoveFile("a.txt"); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf(`Error unexpected %v; want %v`, err, fs.ErrClosed)
+	}
+}
+
+func TestWriteFileFS(t *testing.T) {
+	w := New(newBase(t))
+	if err := wfstest.TestWriteFileFS(w, "dir"); err != nil {
+		t.Errorf(`Error wfs/wfstest: %+v`, err)
+	}
+}
+
+func TestRemoveFileFS(t *testing.T) {
+	w := New(newBase(t))
+	if err := wfstest.TestRemoveFileFS(w, "dir"); err != nil {
+		t.Errorf(`Error wfs/wfstest: %+v`, err)
+	}
+}
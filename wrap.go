@@ -0,0 +1,222 @@
+package wfs
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// WrapOption is an option for Wrap that customizes the functions of the
+// FSDelegator used by the wrapped filesystem.
+type WrapOption func(d *FSDelegator)
+
+// Wrap returns a filesystem that delegates the functions of fsys like
+// DelegateFS(fsys).FS(), so the returned filesystem implements WriteFileFS
+// and RemoveFileFS only if fsys implements them or opts set their functions,
+// and forwards the optional interfaces of fsys such as MetadataFS and
+// ConditionalWriteFS.
+func Wrap(fsys fs.FS, opts ...WrapOption) fs.FS {
+	d := DelegateFS(fsys)
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d.FS()
+}
+
+// narrowFS returns a filesystem that calls the methods of fsys and implements
+// WriteFileFS, RemoveFileFS, RangeReaderFS, URLFS and WatchFS only if inner
+// that is wrapped by fsys implements them, for a wrapper that always
+// implements them. Filesystems returned by Sub are narrowed in the same way if
+// they provide FS.
+func narrowFS(fsys, inner fs.FS) fs.FS {
+	d := DelegateFS(fsys)
+	sub := d.SubFunc
+	d.SubFunc = func(dir string) (fs.FS, error) {
+		subFS, err := sub(dir)
+		if err != nil {
+			return nil, err
+		}
+		if n, ok := subFS.(interface{ FS() fs.FS }); ok {
+			return n.FS(), nil
+		}
+		return subFS, nil
+	}
+	if _, ok := inner.(WriteFileFS); !ok {
+		d.MkdirAllFunc, d.CreateFileFunc, d.WriteFileFunc = nil, nil, nil
+	}
+	if _, ok := inner.(RemoveFileFS); !ok {
+		d.RemoveFileFunc, d.RemoveAllFunc = nil, nil
+	}
+	if _, ok := inner.(RangeReaderFS); !ok {
+		d.OpenRangeFunc = nil
+	}
+	if _, ok := inner.(URLFS); !ok {
+		d.URLFunc = nil
+	}
+	if _, ok := inner.(WatchFS); !ok {
+		d.WatchFunc = nil
+	}
+	return d.FS()
+}
+
+// readFS exposes the read functions of FSDelegator. FSDelegator.FS embeds it
+// with the parts of the optional interfaces that are set.
+type readFS struct {
+	d *FSDelegator
+}
+
+var (
+	_ fs.GlobFS     = (*readFS)(nil)
+	_ fs.ReadDirFS  = (*readFS)(nil)
+	_ fs.ReadFileFS = (*readFS)(nil)
+	_ fs.StatFS     = (*readFS)(nil)
+	_ fs.SubFS      = (*readFS)(nil)
+)
+
+func (fsys *readFS) Open(name string) (fs.File, error) {
+	return fsys.d.Open(name)
+}
+
+func (fsys *readFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fsys.d.ReadDir(name)
+}
+
+func (fsys *readFS) ReadFile(name string) ([]byte, error) {
+	return fsys.d.ReadFile(name)
+}
+
+func (fsys *readFS) Glob(pattern string) ([]string, error) {
+	return fsys.d.Glob(pattern)
+}
+
+func (fsys *readFS) Stat(name string) (fs.FileInfo, error) {
+	return fsys.d.Stat(name)
+}
+
+func (fsys *readFS) Sub(dir string) (fs.FS, error) {
+	return fsys.d.Sub(dir)
+}
+
+// delegateRangeFS exposes RangeReaderFS of FSDelegator.
+type delegateRangeFS struct {
+	d *FSDelegator
+}
+
+func (fsys delegateRangeFS) OpenRange(name string, off, length int64) (io.ReadCloser, error) {
+	return fsys.d.OpenRangeFunc(name, off, length)
+}
+
+// delegateURLFS exposes URLFS of FSDelegator.
+type delegateURLFS struct {
+	d *FSDelegator
+}
+
+func (fsys delegateURLFS) URL(name string, method string, expiry time.Duration) (string, error) {
+	return fsys.d.URLFunc(name, method, expiry)
+}
+
+// delegateWatchFS exposes WatchFS of FSDelegator.
+type delegateWatchFS struct {
+	d *FSDelegator
+}
+
+func (fsys delegateWatchFS) Watch(ctx context.Context, dir string) (<-chan WatchEvent, error) {
+	return fsys.d.WatchFunc(ctx, dir)
+}
+
+// writeFS exposes WriteFileFS and CopyFileWithinFS of FSDelegator.
+type writeFS struct {
+	d *FSDelegator
+}
+
+func (fsys writeFS) MkdirAll(dir string, mode fs.FileMode) error {
+	return fsys.d.MkdirAll(dir, mode)
+}
+
+func (fsys writeFS) CreateFile(name string, mode fs.FileMode) (WriterFile, error) {
+	return fsys.d.CreateFile(name, mode)
+}
+
+func (fsys writeFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	return fsys.d.WriteFile(name, p, mode)
+}
+
+func (fsys writeFS) CopyFile(src, dst string) error {
+	if fsys.d.CopyFileFunc == nil {
+		return CopyFile(fsys.d, src, dst)
+	}
+	return fsys.d.CopyFileFunc(src, dst)
+}
+
+// removeFS exposes RemoveFileFS and RemoveFilesFS of FSDelegator.
+type removeFS struct {
+	d *FSDelegator
+}
+
+func (fsys removeFS) RemoveFile(name string) error {
+	return fsys.d.RemoveFile(name)
+}
+
+func (fsys removeFS) RemoveAll(path string) error {
+	return fsys.d.RemoveAll(path)
+}
+
+func (fsys removeFS) RemoveFiles(names []string) error {
+	return removeFiles(fsys.d, names)
+}
+
+// removeFiles calls RemoveFilesFunc of d or RemoveFiles with d.
+func removeFiles(d *FSDelegator, names []string) error {
+	if d.RemoveFilesFunc == nil {
+		return RemoveFiles(d, names)
+	}
+	return d.RemoveFilesFunc(names)
+}
+
+// metaFS exposes MetadataFS of FSDelegator. It is embedded with writeFS by
+// FSDelegator.FS.
+type metaFS struct {
+	d *FSDelegator
+}
+
+func (fsys metaFS) CreateFileWithMeta(name string, mode fs.FileMode, meta Metadata) (WriterFile, error) {
+	if fsys.d.CreateFileWithMetaFunc == nil {
+		return CreateFileWithMeta(fsys.d, name, mode, meta)
+	}
+	return fsys.d.CreateFileWithMetaFunc(name, mode, meta)
+}
+
+func (fsys metaFS) WriteFileWithMeta(name string, p []byte, mode fs.FileMode, meta Metadata) (int, error) {
+	if fsys.d.WriteFileWithMetaFunc == nil {
+		return WriteFileWithMeta(fsys.d, name, p, mode, meta)
+	}
+	return fsys.d.WriteFileWithMetaFunc(name, p, mode, meta)
+}
+
+func (fsys metaFS) StatMeta(name string) (Metadata, error) {
+	if fsys.d.StatMetaFunc == nil {
+		return StatMeta(fsys.d, name)
+	}
+	return fsys.d.StatMetaFunc(name)
+}
+
+// condFS exposes ConditionalWriteFS of FSDelegator. It is embedded with
+// writeFS by FSDelegator.FS.
+type condFS struct {
+	d *FSDelegator
+}
+
+func (fsys condFS) WriteFileIf(name string, p []byte, mode fs.FileMode, cond Condition) (string, error) {
+	if fsys.d.WriteFileIfFunc == nil {
+		return WriteFileIf(fsys.d, name, p, mode, cond)
+	}
+	return fsys.d.WriteFileIfFunc(name, p, mode, cond)
+}
+
+func (fsys condFS) FileVersion(name string) (string, error) {
+	if fsys.d.FileVersionFunc == nil {
+		return FileVersion(fsys.d, name)
+	}
+	return fsys.d.FileVersionFunc(name)
+}
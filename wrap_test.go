@@ -0,0 +1,232 @@
+package wfs
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestWrap(t *testing.T) {
+	m := fstest.MapFS{"test.txt": {Data: []byte("test")}}
+
+	fsys := Wrap(m)
+	if _, ok := fsys.(WriteFileFS); ok {
+		t.Errorf("unexpected %T implements WriteFileFS", fsys)
+	}
+	if _, ok := fsys.(RemoveFileFS); ok {
+		t.Errorf("unexpected %T implements RemoveFileFS", fsys)
+	}
+	p, err := fs.ReadFile(fsys, "test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p) != "test" {
+		t.Errorf("unexpected %s", p)
+	}
+
+	fsys = Wrap(newMapWriteFS(m), func(d *FSDelegator) {
+		d.ReadFileFunc = func(name string) ([]byte, error) {
+			return []byte("wrapped"), nil
+		}
+	})
+	if _, ok := fsys.(WriteFileFS); !ok {
+		t.Errorf("unexpected %T does not implement WriteFileFS", fsys)
+	}
+	if _, ok := fsys.(RemoveFileFS); !ok {
+		t.Errorf("unexpected %T does not implement RemoveFileFS", fsys)
+	}
+	if p, _ := fs.ReadFile(fsys, "test.txt"); string(p) != "wrapped" {
+		t.Errorf("unexpected %s", p)
+	}
+	if _, err := WriteFile(fsys, "new.txt", []byte("new"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveFile(fsys, "new.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["new.txt"]; ok {
+		t.Errorf("unexpected new.txt exists")
+	}
+}
+
+func TestWrap_WriteOnly(t *testing.T) {
+	w := DelegateFS(fstest.MapFS{})
+	w.WriteFileFunc = func(name string, p []byte, mode fs.FileMode) (int, error) {
+		return len(p), nil
+	}
+	fsys := Wrap(w.FS())
+	if _, ok := fsys.(WriteFileFS); !ok {
+		t.Errorf("unexpected %T does not implement WriteFileFS", fsys)
+	}
+	if err := RemoveFile(fsys, "test.txt"); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotImplemented)
+	}
+
+	r := DelegateFS(fstest.MapFS{})
+	r.RemoveFileFunc = func(name string) error {
+		return nil
+	}
+	fsys = Wrap(r.FS())
+	if _, ok := fsys.(RemoveFileFS); !ok {
+		t.Errorf("unexpected %T does not implement RemoveFileFS", fsys)
+	}
+	if _, err := WriteFile(fsys, "test.txt", nil, fs.ModePerm); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("unexpected %v; want %v", err, ErrNotImplemented)
+	}
+}
+
+func TestWrap_ReadInterfaces(t *testing.T) {
+	m := fstest.MapFS{"test.txt": {Data: []byte("test")}}
+
+	fsys := Wrap(m)
+	if _, ok := fsys.(RangeReaderFS); ok {
+		t.Errorf("unexpected %T implements RangeReaderFS", fsys)
+	}
+	if _, ok := fsys.(URLFS); ok {
+		t.Errorf("unexpected %T implements URLFS", fsys)
+	}
+	if _, ok := fsys.(WatchFS); ok {
+		t.Errorf("unexpected %T implements WatchFS", fsys)
+	}
+	r, err := OpenRange(fsys, "test.txt", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if p, _ := io.ReadAll(r); string(p) != "es" {
+		t.Errorf("unexpected %q; want %q", p, "es")
+	}
+
+	d := DelegateFS(m)
+	d.URLFunc = func(name string, method string, expiry time.Duration) (string, error) {
+		return "https://example.com/" + name, nil
+	}
+	fsys = Wrap(d.FS())
+	if _, ok := fsys.(URLFS); !ok {
+		t.Errorf("unexpected %T does not implement URLFS", fsys)
+	}
+	if _, ok := fsys.(RangeReaderFS); ok {
+		t.Errorf("unexpected %T implements RangeReaderFS", fsys)
+	}
+	if got, err := URL(fsys, "test.txt", "GET", time.Minute); err != nil {
+		t.Fatal(err)
+	} else if got != "https://example.com/test.txt" {
+		t.Errorf("unexpected %s", got)
+	}
+}
+
+func TestWrappers_FS(t *testing.T) {
+	m := fstest.MapFS{"dir/a.txt": {Data: []byte("a")}}
+	index, err := NewIndexFS(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acl, err := NewACLFS(m, []ACLRule{{Pattern: ".", Access: AccessRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	redact, err := NewRedactFS(m, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrappers := map[string]interface{ FS() fs.FS }{
+		"TimeoutFS":  NewTimeoutFS(m, nil),
+		"BreakerFS":  NewBreakerFS(m, 1, time.Second),
+		"TracingFS":  NewTracingFS(m, &testTracer{}),
+		"ACLFS":      acl,
+		"IndexFS":    index,
+		"HashingFS":  NewHashingFS(m, sha256.New),
+		"BufferedFS": NewBufferedFS(m, 1),
+		"RedactFS":   redact,
+	}
+	for name, w := range wrappers {
+		fsys := w.FS()
+		if _, ok := fsys.(WriteFileFS); ok {
+			t.Errorf("unexpected %s implements WriteFileFS", name)
+		}
+		if _, ok := fsys.(RemoveFileFS); ok {
+			t.Errorf("unexpected %s implements RemoveFileFS", name)
+		}
+		sub, err := fs.Sub(fsys, "dir")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := sub.(WriteFileFS); ok {
+			t.Errorf("unexpected Sub of %s implements WriteFileFS", name)
+		}
+		if got, err := fs.ReadFile(sub, "a.txt"); err != nil {
+			t.Errorf("unexpected %s: %v", name, err)
+		} else if string(got) != "a" {
+			t.Errorf("unexpected %s: %q; want %q", name, got, "a")
+		}
+	}
+
+	fsys := NewTimeoutFS(newMapWriteFS(fstest.MapFS{}), nil).FS()
+	if _, ok := fsys.(WriteFileFS); !ok {
+		t.Errorf("unexpected %T does not implement WriteFileFS", fsys)
+	}
+}
+
+func TestWrap_OptionalInterfaces(t *testing.T) {
+	m := fstest.MapFS{"test.txt": {Data: []byte("test")}}
+
+	fsys := Wrap(newMapWriteFS(m))
+	if _, ok := fsys.(MetadataFS); ok {
+		t.Errorf("unexpected %T implements MetadataFS", fsys)
+	}
+	if _, ok := fsys.(ConditionalWriteFS); ok {
+		t.Errorf("unexpected %T implements ConditionalWriteFS", fsys)
+	}
+	if err := CopyFile(fsys, "test.txt", "copy.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(m["copy.txt"].Data); got != "test" {
+		t.Errorf("unexpected %s; want test", got)
+	}
+
+	var copied, statted, written bool
+	inner := newMapWriteFS(m)
+	inner.CopyFileFunc = func(src, dst string) error {
+		copied = true
+		return nil
+	}
+	inner.StatMetaFunc = func(name string) (Metadata, error) {
+		statted = true
+		return Metadata{}, nil
+	}
+	inner.WriteFileIfFunc = func(name string, p []byte, mode fs.FileMode, cond Condition) (string, error) {
+		written = true
+		return "1", nil
+	}
+	fsys = Wrap(inner.FS())
+	if _, ok := fsys.(MetadataFS); !ok {
+		t.Errorf("unexpected %T does not implement MetadataFS", fsys)
+	}
+	if _, ok := fsys.(ConditionalWriteFS); !ok {
+		t.Errorf("unexpected %T does not implement ConditionalWriteFS", fsys)
+	}
+	if err := CopyFile(fsys, "test.txt", "copy.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := StatMeta(fsys, "test.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteFileIf(fsys, "test.txt", nil, fs.ModePerm, Condition{}); err != nil {
+		t.Fatal(err)
+	}
+	if !copied || !statted || !written {
+		t.Errorf("unexpected copied %v, statted %v, written %v; want all true", copied, statted, written)
+	}
+
+	fsys = Wrap(inner.FS(), WithReadOnly())
+	if err := CopyFile(fsys, "test.txt", "copy.txt"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("unexpected %v; want %v", err, ErrReadOnly)
+	}
+	if _, err := WriteFileIf(fsys, "test.txt", nil, fs.ModePerm, Condition{}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("unexpected %v; want %v", err, ErrReadOnly)
+	}
+}
@@ -0,0 +1,417 @@
+package wfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WriteBehindOption is an option for NewWriteBehindFS.
+type WriteBehindOption func(o *writeBehindOptions)
+
+type writeBehindOptions struct {
+	queueSize int
+	attempts  int
+	backoff   time.Duration
+}
+
+// WithWriteBehindQueue sets the number of files that can wait to be flushed.
+// Writes block while the queue is full. The default is 64.
+func WithWriteBehindQueue(n int) WriteBehindOption {
+	return func(o *writeBehindOptions) {
+		o.queueSize = n
+	}
+}
+
+// WithWriteBehindRetry sets the number of attempts to flush a file and the
+// delay before the first retry that doubles on each retry. The default is 3
+// attempts with 100 milliseconds.
+func WithWriteBehindRetry(attempts int, backoff time.Duration) WriteBehindOption {
+	return func(o *writeBehindOptions) {
+		o.attempts = attempts
+		o.backoff = backoff
+	}
+}
+
+// WriteBehindFS is a filesystem that writes files to a fast layer such as a
+// memfs or a local disk and flushes them to a slow backend such as an object
+// storage in the background, so writes do not wait for the backend.
+//
+// Files are flushed in order by a single goroutine and a file written again
+// before it is flushed is flushed once with the latest contents. A file that
+// is flushed and not written again is removed from the fast layer, so the
+// fast layer holds only the files waiting to be flushed. A flush that fails
+// is retried and the error of a flush that fails all of the attempts is
+// returned by Drain; the file stays in the fast layer until it is written
+// and flushed again. Directories are not removed from the fast layer.
+//
+// Reads are served by the fast layer if the file exists there or is waiting
+// to be flushed, otherwise by the backend. ReadDir merges the listings of
+// both of the layers, so a directory lists the files waiting to be flushed
+// and the files already evicted to the backend.
+type WriteBehindFS struct {
+	fast  WriteFileFS
+	slow  WriteFileFS
+	o     *writeBehindOptions
+	queue chan string
+	done  chan struct{}
+
+	// writing is held for reading by a write from the write to the fast
+	// layer until the file is marked dirty, and for writing by the eviction
+	// of a flushed file so a new write is not evicted.
+	writing sync.RWMutex
+	mutex   sync.Mutex
+	queued  map[string]bool
+	dirty   map[string]int
+	pending int
+	drained chan struct{}
+	err     error
+	closed  bool
+}
+
+var (
+	_ fs.ReadDirFS  = (*WriteBehindFS)(nil)
+	_ fs.ReadFileFS = (*WriteBehindFS)(nil)
+	_ fs.StatFS     = (*WriteBehindFS)(nil)
+	_ WriteFileFS   = (*WriteBehindFS)(nil)
+	_ RemoveFileFS  = (*WriteBehindFS)(nil)
+)
+
+// NewWriteBehindFS returns a WriteBehindFS that writes files to fast and
+// flushes them to slow. Close stops flushing.
+func NewWriteBehindFS(fast, slow WriteFileFS, opts ...WriteBehindOption) *WriteBehindFS {
+	o := &writeBehindOptions{
+		queueSize: 64,
+		attempts:  3,
+		backoff:   100 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.queueSize < 0 {
+		o.queueSize = 0
+	}
+	if o.attempts < 1 {
+		o.attempts = 1
+	}
+	drained := make(chan struct{})
+	close(drained)
+	fsys := &WriteBehindFS{
+		fast:    fast,
+		slow:    slow,
+		o:       o,
+		queue:   make(chan string, o.queueSize),
+		done:    make(chan struct{}),
+		queued:  map[string]bool{},
+		dirty:   map[string]int{},
+		drained: drained,
+	}
+	go fsys.run()
+	return fsys
+}
+
+func (fsys *WriteBehindFS) run() {
+	for {
+		select {
+		case name := <-fsys.queue:
+			fsys.flush(name)
+		case <-fsys.done:
+			return
+		}
+	}
+}
+
+// enqueue calls write that writes the named file to the fast layer and queues
+// the file to be flushed unless it is already queued.
+func (fsys *WriteBehindFS) enqueue(op Op, name string, write func() error) error {
+	fsys.writing.RLock()
+	if err := write(); err != nil {
+		fsys.writing.RUnlock()
+		return err
+	}
+	queue, err := fsys.markDirty(op, name)
+	// NOTE: Release writing before blocking on the queue that is consumed by
+	// the flush that acquires writing.
+	fsys.writing.RUnlock()
+	if queue {
+		fsys.queue <- name
+	}
+	return err
+}
+
+// markDirty marks the named file as waiting to be flushed and reports whether
+// the file must be sent to the queue.
+func (fsys *WriteBehindFS) markDirty(op Op, name string) (bool, error) {
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	if fsys.closed {
+		return false, &fs.PathError{Op: string(op), Path: name, Err: fs.ErrClosed}
+	}
+	if fsys.queued[name] {
+		return false, nil
+	}
+	fsys.queued[name] = true
+	fsys.dirty[name]++
+	if fsys.pending == 0 {
+		fsys.drained = make(chan struct{})
+	}
+	fsys.pending++
+	return true, nil
+}
+
+// flush flushes the named file with retries.
+func (fsys *WriteBehindFS) flush(name string) {
+	fsys.mutex.Lock()
+	delete(fsys.queued, name)
+	fsys.mutex.Unlock()
+
+	err := fsys.sync(name)
+	for i := 1; err != nil && i < fsys.o.attempts; i++ {
+		time.Sleep(fsys.o.backoff << (i - 1))
+		err = fsys.sync(name)
+	}
+
+	fsys.writing.Lock()
+	defer fsys.writing.Unlock()
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	if err != nil && fsys.err == nil {
+		fsys.err = err
+	}
+	if fsys.dirty[name]--; fsys.dirty[name] == 0 {
+		delete(fsys.dirty, name)
+		if err == nil {
+			fsys.evict(name)
+		}
+	}
+	if fsys.pending--; fsys.pending == 0 {
+		close(fsys.drained)
+	}
+}
+
+// evict removes the named file that is flushed from the fast layer. An error
+// of the eviction is ignored because the file is already on the backend.
+// fsys.writing must be held for writing.
+func (fsys *WriteBehindFS) evict(name string) {
+	if info, err := fs.Stat(fsys.fast, name); err == nil && !info.IsDir() {
+		RemoveIfExists(fsys.fast, name)
+	}
+}
+
+// sync makes the named file on the backend the same as on the fast layer.
+func (fsys *WriteBehindFS) sync(name string) error {
+	info, err := fs.Stat(fsys.fast, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return RemoveIfExists(fsys.slow, name)
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fsys.slow.MkdirAll(name, fs.ModePerm)
+	}
+	return copyFileTo(fsys.slow, name, fsys.fast, name, info.Mode().Perm())
+}
+
+// wait waits until all of the queued files are flushed.
+func (fsys *WriteBehindFS) wait(ctx context.Context) error {
+	fsys.mutex.Lock()
+	drained := fsys.drained
+	fsys.mutex.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Drain waits until all of the queued files are flushed and returns the first
+// error of the flushes that failed since the last Drain.
+func (fsys *WriteBehindFS) Drain(ctx context.Context) error {
+	if err := fsys.wait(ctx); err != nil {
+		return err
+	}
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	err := fsys.err
+	fsys.err = nil
+	return err
+}
+
+// Close drains the queued files and stops flushing. Writes after Close return
+// fs.ErrClosed.
+func (fsys *WriteBehindFS) Close() error {
+	fsys.mutex.Lock()
+	if fsys.closed {
+		fsys.mutex.Unlock()
+		return nil
+	}
+	fsys.closed = true
+	fsys.mutex.Unlock()
+
+	err := fsys.Drain(context.Background())
+	close(fsys.done)
+	return err
+}
+
+func (fsys *WriteBehindFS) isDirty(name string) bool {
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+
+	return fsys.dirty[name] > 0
+}
+
+// layer returns the layer that serves the named file.
+func (fsys *WriteBehindFS) layer(name string) fs.FS {
+	if fsys.isDirty(name) {
+		return fsys.fast
+	}
+	if _, err := fs.Stat(fsys.fast, name); err == nil {
+		return fsys.fast
+	}
+	return fsys.slow
+}
+
+// read calls fn with the layer that serves the named file. If the file is
+// evicted from the fast layer after the layer is selected fn is called again
+// with the backend.
+func (fsys *WriteBehindFS) read(name string, fn func(layer fs.FS) error) error {
+	layer := fsys.layer(name)
+	err := fn(layer)
+	if layer == fs.FS(fsys.fast) && errors.Is(err, fs.ErrNotExist) && !fsys.isDirty(name) {
+		return fn(fsys.slow)
+	}
+	return err
+}
+
+// Open opens the named file.
+func (fsys *WriteBehindFS) Open(name string) (f fs.File, err error) {
+	err = fsys.read(name, func(layer fs.FS) error {
+		f, err = layer.Open(name)
+		return err
+	})
+	return f, err
+}
+
+// ReadDir reads the named directory and returns a list of directory entries
+// sorted by filename. The entries of the fast layer take precedence over the
+// entries of the backend with the same names.
+func (fsys *WriteBehindFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	// NOTE: Read the fast layer first so a file evicted between the reads is
+	// found on the backend.
+	fastEntries, fastErr := fs.ReadDir(fsys.fast, name)
+	if fastErr != nil && !errors.Is(fastErr, fs.ErrNotExist) {
+		return nil, fastErr
+	}
+	slowEntries, slowErr := fs.ReadDir(fsys.slow, name)
+	if slowErr != nil {
+		if fastErr == nil && errors.Is(slowErr, fs.ErrNotExist) {
+			return fastEntries, nil
+		}
+		return nil, slowErr
+	}
+	seen := map[string]bool{}
+	entries := make([]fs.DirEntry, 0, len(fastEntries)+len(slowEntries))
+	for _, e := range fastEntries {
+		seen[e.Name()] = true
+		entries = append(entries, e)
+	}
+	for _, e := range slowEntries {
+		// NOTE: A dirty file that is not on the fast layer is waiting to be
+		// removed from the backend.
+		if seen[e.Name()] || fsys.isDirty(path.Join(name, e.Name())) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// ReadFile reads the named file and returns its contents.
+func (fsys *WriteBehindFS) ReadFile(name string) (p []byte, err error) {
+	err = fsys.read(name, func(layer fs.FS) error {
+		p, err = fs.ReadFile(layer, name)
+		return err
+	})
+	return p, err
+}
+
+// Stat returns a FileInfo describing the file.
+func (fsys *WriteBehindFS) Stat(name string) (info fs.FileInfo, err error) {
+	err = fsys.read(name, func(layer fs.FS) error {
+		info, err = fs.Stat(layer, name)
+		return err
+	})
+	return info, err
+}
+
+// MkdirAll creates the named directory on both of the layers.
+func (fsys *WriteBehindFS) MkdirAll(dir string, mode fs.FileMode) error {
+	if err := fsys.fast.MkdirAll(dir, mode); err != nil {
+		return err
+	}
+	return fsys.slow.MkdirAll(dir, mode)
+}
+
+// CreateFile creates the named file on the fast layer. The file is queued to
+// be flushed when it is closed. The returned file implements the optional
+// interfaces of the file of the fast layer and Abort discards the file on the
+// fast layer without queuing it.
+func (fsys *WriteBehindFS) CreateFile(name string, mode fs.FileMode) (WriterFile, error) {
+	f, err := fsys.fast.CreateFile(name, mode)
+	if err != nil {
+		return nil, err
+	}
+	d := DelegateFile(f)
+	d.CloseFunc = func() error {
+		return fsys.enqueue(OpCreateFile, name, f.Close)
+	}
+	return d.File().(WriterFile), nil
+}
+
+// WriteFile writes the specified bytes to the named file on the fast layer and
+// queues the file to be flushed.
+func (fsys *WriteBehindFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	var n int
+	err := fsys.enqueue(OpWriteFile, name, func() (err error) {
+		n, err = fsys.fast.WriteFile(name, p, mode)
+		return err
+	})
+	return n, err
+}
+
+// RemoveFile removes the named file from the fast layer and queues the file to
+// be removed from the backend. It returns an error wrapping fs.ErrNotExist if
+// neither of the layers has the file.
+func (fsys *WriteBehindFS) RemoveFile(name string) error {
+	if _, err := fsys.Stat(name); err != nil {
+		return PathError(OpRemoveFile, name, err)
+	}
+	return fsys.enqueue(OpRemoveFile, name, func() error {
+		return RemoveIfExists(fsys.fast, name)
+	})
+}
+
+// RemoveAll removes path and any children from both of the layers after the
+// queued files are flushed.
+func (fsys *WriteBehindFS) RemoveAll(path string) error {
+	if err := fsys.wait(context.Background()); err != nil {
+		return err
+	}
+	if err := RemoveAll(fsys.fast, path); err != nil {
+		return err
+	}
+	return RemoveAll(fsys.slow, path)
+}
@@ -0,0 +1,203 @@
+package wfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestWriteBehindFS(t *testing.T) {
+	fast := fstest.MapFS{}
+	slow := fstest.MapFS{"old.txt": {Data: []byte("old")}}
+	gate := make(chan struct{})
+	failures := 1
+	slowFS := newMapWriteFS(slow)
+	mkdirAll := slowFS.MkdirAllFunc
+	slowFS.MkdirAllFunc = func(dir string, mode fs.FileMode) error {
+		<-gate
+		if failures > 0 {
+			failures--
+			return errors.New("unavailable")
+		}
+		return mkdirAll(dir, mode)
+	}
+	fsys := NewWriteBehindFS(newMapWriteFS(fast), slowFS, WithWriteBehindRetry(3, time.Millisecond))
+	defer fsys.Close()
+
+	if _, err := fsys.WriteFile("dir/a.txt", []byte("a"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	got, err := fsys.ReadFile("dir/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "a" {
+		t.Errorf("unexpected %q; want %q", got, "a")
+	}
+	if _, ok := slow["dir/a.txt"]; ok {
+		t.Errorf("unexpected flushed before the backend is available")
+	}
+	got, err = fsys.ReadFile("old.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "old" {
+		t.Errorf("unexpected %q; want %q", got, "old")
+	}
+
+	close(gate)
+	if err := fsys.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := slow["dir/a.txt"]; !ok || string(f.Data) != "a" {
+		t.Errorf("unexpected dir/a.txt is not flushed")
+	}
+
+	if err := fsys.RemoveFile("old.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("old.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrNotExist)
+	}
+	if err := fsys.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := slow["old.txt"]; ok {
+		t.Errorf("unexpected old.txt is not removed")
+	}
+
+	if err := fsys.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("b.txt", []byte("b"), fs.ModePerm); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("unexpected %v; want %v", err, fs.ErrClosed)
+	}
+}
+
+func TestWriteBehindFS_Drain(t *testing.T) {
+	slowFS := newMapWriteFS(fstest.MapFS{})
+	wantErr := errors.New("unavailable")
+	slowFS.CreateFileFunc = func(name string, mode fs.FileMode) (WriterFile, error) {
+		return nil, wantErr
+	}
+	fsys := NewWriteBehindFS(newMapWriteFS(fstest.MapFS{}), slowFS, WithWriteBehindRetry(2, time.Millisecond))
+	defer fsys.Close()
+
+	if _, err := fsys.WriteFile("a.txt", []byte("a"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Drain(context.Background()); !errors.Is(err, wantErr) {
+		t.Errorf("unexpected %v; want %v", err, wantErr)
+	}
+	if err := fsys.Drain(context.Background()); err != nil {
+		t.Errorf("unexpected %v", err)
+	}
+	got, err := fsys.ReadFile("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "a" {
+		t.Errorf("unexpected %q; want %q", got, "a")
+	}
+}
+
+func TestWriteBehindFS_Evict(t *testing.T) {
+	fast := fstest.MapFS{}
+	slow := fstest.MapFS{}
+	fsys := NewWriteBehindFS(newMapWriteFS(fast), newMapWriteFS(slow))
+	defer fsys.Close()
+
+	if _, err := fsys.WriteFile("a.txt", []byte("a"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fast["a.txt"]; ok {
+		t.Errorf("unexpected a.txt is not evicted from the fast layer")
+	}
+	got, err := fsys.ReadFile("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "a" {
+		t.Errorf("unexpected %q; want %q", got, "a")
+	}
+}
+
+func TestWriteBehindFS_ReadDirEvicted(t *testing.T) {
+	fast := fstest.MapFS{}
+	slow := fstest.MapFS{}
+	fsys := NewWriteBehindFS(newMapWriteFS(fast), newMapWriteFS(slow))
+	defer fsys.Close()
+
+	if _, err := fsys.WriteFile("dir/a.txt", []byte("a"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	fast["dir"] = &fstest.MapFile{Mode: fs.ModeDir | fs.ModePerm}
+	if _, err := fsys.WriteFile("dir/b.txt", []byte("b"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := fsys.ReadDir("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	want := []string{"a.txt", "b.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected %v; want %v", got, want)
+	}
+}
+
+func TestWriteBehindFS_RemoveFileNotExist(t *testing.T) {
+	fsys := NewWriteBehindFS(newMapWriteFS(fstest.MapFS{}), newMapWriteFS(fstest.MapFS{}))
+	defer fsys.Close()
+
+	err := fsys.RemoveFile("missing.txt")
+	var pe *fs.PathError
+	if !errors.As(err, &pe) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("unexpected %v; want a PathError of fs.ErrNotExist", err)
+	}
+	if pe.Op != string(OpRemoveFile) {
+		t.Errorf("unexpected op %q; want %q", pe.Op, OpRemoveFile)
+	}
+}
+
+func TestWriteBehindFS_CreateFileAbort(t *testing.T) {
+	fast := fstest.MapFS{}
+	slow := fstest.MapFS{"a.txt": {Data: []byte("original")}}
+	fsys := NewWriteBehindFS(newAbortMapWriteFS(fast), newMapWriteFS(slow))
+	defer fsys.Close()
+
+	w, err := fsys.CreateFile("a.txt", fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Abort(fsys, "a.txt", w); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := fsys.ReadFile("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "original" {
+		t.Errorf("unexpected %q; want %q", got, "original")
+	}
+}